@@ -0,0 +1,122 @@
+package admin
+
+import "net/http"
+
+// Action 是管理接口中的操作类型，授权函数根据它决定是否允许请求
+// Action is the type of operation in the admin API, the authorize function decides whether to allow the request based on it
+type Action string
+
+// 定义管理接口支持的操作
+// Define the operations supported by the admin API
+const (
+	// ActionList 表示列出所有任务
+	// ActionList represents listing all tasks
+	ActionList Action = "list"
+
+	// ActionCreate 表示使用注册的处理函数创建一个任务
+	// ActionCreate represents creating one task with a registered handling function
+	ActionCreate Action = "create"
+
+	// ActionGet 表示获取一个任务
+	// ActionGet represents getting one task
+	ActionGet Action = "get"
+
+	// ActionCancel 表示取消一个任务
+	// ActionCancel represents canceling one task
+	ActionCancel Action = "cancel"
+
+	// ActionEarlyReturn 表示让一个任务提前执行
+	// ActionEarlyReturn represents making one task execute early
+	ActionEarlyReturn Action = "early-return"
+
+	// ActionReschedule 表示重新调度一个任务
+	// ActionReschedule represents rescheduling one task
+	ActionReschedule Action = "reschedule"
+
+	// ActionPause 表示暂停一个任务
+	// ActionPause represents pausing one task
+	ActionPause Action = "pause"
+
+	// ActionResume 表示恢复一个任务
+	// ActionResume represents resuming one task
+	ActionResume Action = "resume"
+
+	// ActionStats 表示获取调度器的统计信息
+	// ActionStats represents getting the statistics of the scheduler
+	ActionStats Action = "stats"
+
+	// ActionHistory 表示查询执行历史
+	// ActionHistory represents querying the execution history
+	ActionHistory Action = "history"
+
+	// ActionEvents 表示订阅事件流
+	// ActionEvents represents subscribing to the event stream
+	ActionEvents Action = "events"
+
+	// ActionHandlers 表示列出注册的处理函数
+	// ActionHandlers represents listing the registered handling functions
+	ActionHandlers Action = "handlers"
+
+	// ActionAggregates 表示按名称聚合任务和执行历史
+	// ActionAggregates represents aggregating tasks and the execution history by name
+	ActionAggregates Action = "aggregates"
+
+	// ActionDashboard 表示访问内嵌的仪表盘页面
+	// ActionDashboard represents accessing the embedded dashboard pages
+	ActionDashboard Action = "dashboard"
+)
+
+// AuthorizeFunc 是授权函数，返回非 nil 的错误表示拒绝请求
+// AuthorizeFunc is the authorize function, a non-nil error means the request is rejected
+type AuthorizeFunc = func(r *http.Request, action Action) error
+
+// DefaultAuthorizeFunc 是默认的授权函数，它允许所有的请求
+// DefaultAuthorizeFunc is the default authorize function, it allows all requests
+var DefaultAuthorizeFunc AuthorizeFunc = func(r *http.Request, action Action) error { return nil }
+
+// Config 结构体定义了管理接口的配置
+// The Config struct defines the configuration of the admin API
+type Config struct {
+	// authorize 是授权函数
+	// authorize is the authorize function
+	authorize AuthorizeFunc
+}
+
+// NewConfig 函数用于创建一个新的 Config 实例
+// The NewConfig function is used to create a new instance of Config
+func NewConfig() *Config {
+	return &Config{
+		authorize: DefaultAuthorizeFunc,
+	}
+}
+
+// DefaultConfig 函数用于获取默认的 Config 实例
+// The DefaultConfig function is used to get the default instance of Config
+func DefaultConfig() *Config {
+	return NewConfig()
+}
+
+// WithAuthorizeFunc 方法用于设置授权函数
+// The WithAuthorizeFunc method is used to set the authorize function
+func (c *Config) WithAuthorizeFunc(fn AuthorizeFunc) *Config {
+	c.authorize = fn
+	return c
+}
+
+// isConfigValid 函数用于检查 Config 实例是否有效
+// The isConfigValid function is used to check if the instance of Config is valid
+func isConfigValid(conf *Config) *Config {
+	// 如果 conf 为 nil，使用默认配置
+	// If conf is nil, use the default configuration
+	if conf == nil {
+		return DefaultConfig()
+	}
+
+	// 如果授权函数为 nil，使用默认的授权函数
+	// If the authorize function is nil, use the default authorize function
+	if conf.authorize == nil {
+		conf.authorize = DefaultAuthorizeFunc
+	}
+
+	return conf
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+)
+
+// assets 是内嵌的仪表盘页面，不依赖任何外部资源，可以完全离线使用
+// assets are the embedded dashboard pages, they do not depend on any external resource and work fully offline
+//
+//go:embed ui
+var assets embed.FS
+
+// NameAggregate 结构体是同名任务的聚合统计，等待中的任务来自调度器，执行结果来自执行历史
+// The NameAggregate struct is the aggregated statistics of tasks with the same name, pending tasks come from the scheduler and execution results come from the execution history
+type NameAggregate struct {
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Pending 是等待执行的任务数量
+	// Pending is the number of tasks waiting to execute
+	Pending int `json:"pending"`
+
+	// Running 是正在执行的任务数量
+	// Running is the number of tasks being executed
+	Running int `json:"running"`
+
+	// Paused 是暂停的任务数量
+	// Paused is the number of paused tasks
+	Paused int `json:"paused"`
+
+	// Executed 是执行历史中成功执行的任务数量
+	// Executed is the number of tasks executed successfully in the execution history
+	Executed int `json:"executed"`
+
+	// Canceled 是执行历史中被取消的任务数量
+	// Canceled is the number of canceled tasks in the execution history
+	Canceled int `json:"canceled"`
+
+	// Skipped 是执行历史中到期了但是处理函数被跳过的任务数量，例如错过执行时间、锁被其他实例持有或者被其他节点认领
+	// Skipped is the number of expired tasks whose handling function was skipped in the execution history, for example misfired, locked by another instance or claimed by another node
+	Skipped int `json:"skipped"`
+
+	// Failed 是执行历史中处理函数返回错误的任务数量
+	// Failed is the number of tasks whose handling function returned an error in the execution history
+	Failed int `json:"failed"`
+
+	// AvgDuration 是处理函数的平均执行时间，单位为毫秒
+	// AvgDuration is the average execution time of the handling function in milliseconds
+	AvgDuration float64 `json:"avg_duration_ms"`
+
+	// MaxDuration 是处理函数的最长执行时间，单位为毫秒
+	// MaxDuration is the longest execution time of the handling function in milliseconds
+	MaxDuration float64 `json:"max_duration_ms"`
+
+	// LastFinishedAt 是最近一次结束的时间
+	// LastFinishedAt is the time of the most recent finish
+	LastFinishedAt time.Time `json:"last_finished_at,omitempty"`
+}
+
+// dashboard 函数返回仪表盘静态文件的处理器，路径中的 /ui 前缀会被去掉
+// The dashboard function returns the handler of the static files of the dashboard, the /ui prefix in the path is removed
+func dashboard() http.Handler {
+	sub, err := fs.Sub(assets, "ui")
+	if err != nil {
+		// 内嵌的目录一定存在
+		// The embedded directory always exists
+		panic(err)
+	}
+	return http.StripPrefix("/ui", http.FileServer(http.FS(sub)))
+}
+
+// skipped 函数判断执行历史中的原因是否表示处理函数被跳过，这些原因都包装了 ErrorTaskSkipped
+// The skipped function reports whether the reason in the execution history means the handling function was skipped, these reasons all wrap ErrorTaskSkipped
+func skipped(reason string) bool {
+	return reason == ks.ErrorTaskSkipped.Error() || strings.HasPrefix(reason, ks.ErrorTaskSkipped.Error()+": ")
+}
+
+// aggregate 方法按名称聚合调度器中的任务和执行历史
+// The aggregate method aggregates the tasks in the scheduler and the execution history by name
+func (h *Handler) aggregate() []*NameAggregate {
+	aggregates := make(map[string]*NameAggregate)
+	get := func(name string) *NameAggregate {
+		agg, ok := aggregates[name]
+		if !ok {
+			agg = &NameAggregate{Name: name}
+			aggregates[name] = agg
+		}
+		return agg
+	}
+
+	// 统计调度器中的任务
+	// Count the tasks in the scheduler
+	for _, info := range h.sched.List() {
+		agg := get(info.Name)
+		switch info.State {
+		case ks.TaskStateRunning:
+			agg.Running++
+		case ks.TaskStatePaused:
+			agg.Paused++
+		default:
+			agg.Pending++
+		}
+	}
+
+	// 统计执行历史，没有配置执行历史时跳过
+	// Count the execution history, skipped if no execution history is configured
+	if history := h.sched.History(); history != nil {
+		durations := make(map[string]int)
+		for _, rec := range history.Query(nil) {
+			agg := get(rec.Name)
+			switch {
+			case rec.Reason == ks.ErrorTaskCanceled.Error() || rec.Reason == ks.ErrorTaskContextCanceled.Error():
+				agg.Canceled++
+			case skipped(rec.Reason):
+				agg.Skipped++
+			case rec.Error != "":
+				agg.Failed++
+			default:
+				agg.Executed++
+			}
+			if rec.FinishedAt.After(agg.LastFinishedAt) {
+				agg.LastFinishedAt = rec.FinishedAt
+			}
+
+			// 只有开始执行的任务才有执行时间
+			// Only tasks that started executing have an execution time
+			if !rec.StartedAt.IsZero() {
+				duration := float64(rec.FinishedAt.Sub(rec.StartedAt)) / float64(time.Millisecond)
+				agg.AvgDuration += duration
+				if duration > agg.MaxDuration {
+					agg.MaxDuration = duration
+				}
+				durations[rec.Name]++
+			}
+		}
+		for name, count := range durations {
+			aggregates[name].AvgDuration /= float64(count)
+		}
+	}
+
+	// 按名称排序
+	// Sort by name
+	result := make([]*NameAggregate, 0, len(aggregates))
+	for _, agg := range aggregates {
+		result = append(result, agg)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
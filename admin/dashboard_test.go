@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Dashboard(t *testing.T) {
+	_, server := newTestServer(t, nil)
+
+	// The root path redirects to the dashboard
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/admin/", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/admin/ui/", resp.Request.URL.Path)
+	assert.Contains(t, string(body), "<title>Kairos Dashboard</title>")
+
+	// The dashboard path without a trailing slash redirects too
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/ui", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "/admin/ui/", resp.Request.URL.Path)
+
+	// The static resources are served
+	for _, name := range []string{"app.js", "style.css"} {
+		resp, body = doRequest(t, http.MethodGet, server.URL+"/admin/ui/"+name, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, name)
+		assert.NotEmpty(t, body, name)
+	}
+
+	// Unknown resources are not found
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/ui/missing.js", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The dashboard is read only
+	resp, _ = doRequest(t, http.MethodPost, server.URL+"/admin/ui/", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHandler_DashboardAuthorize(t *testing.T) {
+	_, server := newTestServer(t, NewConfig().WithAuthorizeFunc(func(r *http.Request, action Action) error {
+		if action == ActionDashboard {
+			return errors.New("dashboard disabled")
+		}
+		return nil
+	}))
+
+	resp, _ := doRequest(t, http.MethodGet, server.URL+"/admin/ui/", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestHandler_Aggregates(t *testing.T) {
+	scheduler, server := newTestServer(t, nil)
+
+	// One task executes successfully, one fails, one is canceled and one is still pending
+	_, _ = scheduler.Set("report", func(_ <-chan struct{}) (any, error) { return "ok", nil }, time.Millisecond*10)
+	_, _ = scheduler.Set("report", func(_ <-chan struct{}) (any, error) { return nil, errors.New("boom") }, time.Millisecond*10)
+	id, _ := scheduler.Set("cleanup", nil, time.Hour)
+	scheduler.Delete(id)
+	_, _ = scheduler.Set("cleanup", nil, time.Hour)
+
+	// A misfired task is skipped without running its handling function
+	_, _ = scheduler.SetAtWithOptions("report", nil, time.Now().Add(-time.Hour), ks.NewTaskOptions().WithMisfire(time.Millisecond, ks.MisfireSkip))
+	time.Sleep(time.Millisecond * 100)
+
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/admin/aggregates", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var aggregates []*NameAggregate
+	assert.Nil(t, json.Unmarshal(body, &aggregates))
+	assert.Equal(t, 2, len(aggregates))
+
+	// The aggregates are sorted by name
+	assert.Equal(t, "cleanup", aggregates[0].Name)
+	assert.Equal(t, 1, aggregates[0].Pending)
+	assert.Equal(t, 1, aggregates[0].Canceled)
+	assert.Equal(t, "report", aggregates[1].Name)
+	assert.Equal(t, 1, aggregates[1].Executed)
+	assert.Equal(t, 1, aggregates[1].Failed)
+	assert.Equal(t, 1, aggregates[1].Skipped)
+	assert.False(t, aggregates[1].LastFinishedAt.IsZero())
+}
@@ -0,0 +1,503 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+)
+
+// 定义管理接口的错误
+// Define the errors of the admin API
+var (
+	// ErrorMethodNotAllowed 表示请求的方法不被允许
+	// ErrorMethodNotAllowed indicates the method of the request is not allowed
+	ErrorMethodNotAllowed = errors.New("method not allowed")
+
+	// ErrorNotFound 表示请求的路径不存在
+	// ErrorNotFound indicates the path of the request does not exist
+	ErrorNotFound = errors.New("not found")
+
+	// ErrorHistoryDisabled 表示调度器没有配置执行历史
+	// ErrorHistoryDisabled indicates the scheduler has no execution history configured
+	ErrorHistoryDisabled = errors.New("history disabled")
+
+	// ErrorExecAtRequired 表示请求中没有指定执行时间或者延迟
+	// ErrorExecAtRequired indicates neither the execution time nor the delay is specified in the request
+	ErrorExecAtRequired = errors.New("exec_at or delay is required")
+)
+
+// eventBufferSize 是每个事件流订阅者的缓冲区大小
+// eventBufferSize is the buffer size of each event stream subscriber
+const eventBufferSize = 256
+
+// RescheduleRequest 结构体是重新调度任务的请求体，ExecAt 和 Delay 二选一
+// The RescheduleRequest struct is the request body to reschedule a task, either ExecAt or Delay is used
+type RescheduleRequest struct {
+	// ExecAt 是任务新的执行时间
+	// ExecAt is the new execution time of the task
+	ExecAt time.Time `json:"exec_at,omitempty"`
+
+	// Delay 是从现在开始的延迟，使用 time.ParseDuration 的格式，例如 "30s"
+	// Delay is the delay from now, in the format of time.ParseDuration, e.g. "30s"
+	Delay string `json:"delay,omitempty"`
+}
+
+// CreateRequest 结构体是创建任务的请求体，处理函数通过注册表中的名称引用，ExecAt 和 Delay 二选一
+// The CreateRequest struct is the request body to create a task, the handling function is referenced by its name in the registry, either ExecAt or Delay is used
+type CreateRequest struct {
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Handler 是注册表中处理函数的名称
+	// Handler is the name of the handling function in the registry
+	Handler string `json:"handler"`
+
+	// ExecAt 是任务的执行时间
+	// ExecAt is the execution time of the task
+	ExecAt time.Time `json:"exec_at,omitempty"`
+
+	// Delay 是从现在开始的延迟，使用 time.ParseDuration 的格式，例如 "30s"
+	// Delay is the delay from now, in the format of time.ParseDuration, e.g. "30s"
+	Delay string `json:"delay,omitempty"`
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels ks.Labels `json:"labels,omitempty"`
+
+	// Priority 是任务的优先级
+	// Priority is the priority of the task
+	Priority int `json:"priority,omitempty"`
+}
+
+// errorResponse 结构体是错误响应的格式
+// The errorResponse struct is the format of an error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler 结构体实现了 http.Handler，提供管理调度器的 JSON 接口，可以挂载在任意路径下
+// The Handler struct implements http.Handler, it provides JSON endpoints to manage the scheduler and can be mounted under any path
+//
+//	GET    /tasks                   列出所有任务，可以使用 selector 参数按标签过滤 / list all tasks, the selector parameter filters by labels
+//	POST   /tasks                   使用注册的处理函数创建任务 / create a task with a registered handling function
+//	GET    /tasks/{id}              获取一个任务 / get one task
+//	DELETE /tasks/{id}              取消一个任务 / cancel one task
+//	POST   /tasks/{id}/early-return 让任务提前执行 / make the task execute early
+//	POST   /tasks/{id}/reschedule   重新调度任务 / reschedule the task
+//	POST   /tasks/{id}/pause        暂停任务 / pause the task
+//	POST   /tasks/{id}/resume       恢复任务 / resume the task
+//	GET    /stats                   调度器的统计信息 / statistics of the scheduler
+//	GET    /history                 执行历史 / execution history
+//	GET    /events                  事件流，每行一个 JSON 事件 / event stream, one JSON event per line
+//	GET    /handlers                注册的处理函数 / registered handling functions
+//	GET    /aggregates              按名称聚合的统计信息 / statistics aggregated by name
+//	GET    /ui/                     内嵌的仪表盘页面 / embedded dashboard pages
+type Handler struct {
+	// sched 是被管理的调度器
+	// sched is the managed scheduler
+	sched *ks.Scheduler
+
+	// cfg 是管理接口的配置
+	// cfg is the configuration of the admin API
+	cfg *Config
+
+	// assets 是仪表盘静态文件的处理器
+	// assets is the handler of the static files of the dashboard
+	assets http.Handler
+}
+
+// NewHandler 函数用于创建一个新的 Handler 实例。挂载在子路径下时，需要使用 http.StripPrefix 去掉前缀
+// The NewHandler function is used to create a new instance of Handler. When mounted under a sub path, use http.StripPrefix to remove the prefix
+func NewHandler(sched *ks.Scheduler, conf *Config) *Handler {
+	return &Handler{
+		sched:  sched,
+		cfg:    isConfigValid(conf),
+		assets: dashboard(),
+	}
+}
+
+// ServeHTTP 方法根据请求的路径和方法分发请求
+// The ServeHTTP method dispatches the request according to its path and method
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 将转义的路径拆分为多个部分之后再逐个反转义，任务 ID 中的 / 等字符不会改变路由
+	// Split the escaped path into parts before unescaping each of them, characters such as / in a task ID do not change the route
+	parts := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, part := range parts {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			writeError(w, http.StatusNotFound, ErrorNotFound)
+			return
+		}
+		parts[i] = unescaped
+	}
+
+	switch {
+	case len(parts) == 1 && parts[0] == "tasks":
+		switch r.Method {
+		case http.MethodPost:
+			h.route(w, r, http.MethodPost, ActionCreate, h.createTask)
+		default:
+			h.route(w, r, http.MethodGet, ActionList, h.listTasks)
+		}
+
+	case len(parts) == 2 && parts[0] == "tasks":
+		switch r.Method {
+		case http.MethodDelete:
+			h.route(w, r, http.MethodDelete, ActionCancel, func(w http.ResponseWriter, r *http.Request) { h.cancelTask(w, parts[1]) })
+		default:
+			h.route(w, r, http.MethodGet, ActionGet, func(w http.ResponseWriter, r *http.Request) { h.getTask(w, parts[1]) })
+		}
+
+	case len(parts) == 3 && parts[0] == "tasks":
+		id := parts[1]
+		switch Action(parts[2]) {
+		case ActionEarlyReturn:
+			h.route(w, r, http.MethodPost, ActionEarlyReturn, func(w http.ResponseWriter, r *http.Request) { h.writeResult(w, id, h.sched.EarlyReturn(id)) })
+		case ActionReschedule:
+			h.route(w, r, http.MethodPost, ActionReschedule, func(w http.ResponseWriter, r *http.Request) { h.rescheduleTask(w, r, id) })
+		case ActionPause:
+			h.route(w, r, http.MethodPost, ActionPause, func(w http.ResponseWriter, r *http.Request) { h.writeResult(w, id, h.sched.Pause(id)) })
+		case ActionResume:
+			h.route(w, r, http.MethodPost, ActionResume, func(w http.ResponseWriter, r *http.Request) { h.writeResult(w, id, h.sched.Resume(id)) })
+		default:
+			writeError(w, http.StatusNotFound, ErrorNotFound)
+		}
+
+	case len(parts) == 1 && parts[0] == "stats":
+		h.route(w, r, http.MethodGet, ActionStats, func(w http.ResponseWriter, r *http.Request) { writeJSON(w, http.StatusOK, h.sched.Stats()) })
+
+	case len(parts) == 1 && parts[0] == "history":
+		h.route(w, r, http.MethodGet, ActionHistory, h.queryHistory)
+
+	case len(parts) == 1 && parts[0] == "events":
+		h.route(w, r, http.MethodGet, ActionEvents, h.streamEvents)
+
+	case len(parts) == 1 && parts[0] == "handlers":
+		h.route(w, r, http.MethodGet, ActionHandlers, func(w http.ResponseWriter, r *http.Request) { writeJSON(w, http.StatusOK, h.sched.Registry().Names()) })
+
+	case len(parts) == 1 && parts[0] == "aggregates":
+		h.route(w, r, http.MethodGet, ActionAggregates, func(w http.ResponseWriter, r *http.Request) { writeJSON(w, http.StatusOK, h.aggregate()) })
+
+	case len(parts) == 1 && parts[0] == "":
+		// 根路径重定向到仪表盘，使用相对路径以支持任意挂载路径
+		// The root path redirects to the dashboard, a relative path is used to support any mount path
+		h.route(w, r, http.MethodGet, ActionDashboard, func(w http.ResponseWriter, r *http.Request) { redirect(w, "ui/") })
+
+	case len(parts) == 1 && parts[0] == "ui" && !strings.HasSuffix(r.URL.Path, "/"):
+		// 页面中的资源使用相对路径，仪表盘的路径必须以 / 结尾
+		// The resources in the pages use relative paths, the path of the dashboard must end with /
+		h.route(w, r, http.MethodGet, ActionDashboard, func(w http.ResponseWriter, r *http.Request) { redirect(w, "ui/") })
+
+	case parts[0] == "ui":
+		h.route(w, r, http.MethodGet, ActionDashboard, h.assets.ServeHTTP)
+
+	default:
+		writeError(w, http.StatusNotFound, ErrorNotFound)
+	}
+}
+
+// route 方法检查请求的方法和授权，然后调用处理函数
+// The route method checks the method and the authorization of the request, then calls the handling function
+func (h *Handler) route(w http.ResponseWriter, r *http.Request, method string, action Action, fn http.HandlerFunc) {
+	// 检查请求的方法
+	// Check the method of the request
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, ErrorMethodNotAllowed)
+		return
+	}
+
+	// 检查请求的授权
+	// Check the authorization of the request
+	if err := h.cfg.authorize(r, action); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	// 调用处理函数
+	// Call the handling function
+	fn(w, r)
+}
+
+// listTasks 方法返回所有任务的快照，查询参数 selector 用于按标签过滤任务
+// The listTasks method returns the snapshots of all tasks, the selector query parameter is used to filter tasks by labels
+func (h *Handler) listTasks(w http.ResponseWriter, r *http.Request) {
+	// 解析标签选择器
+	// Parse the label selector
+	selector, err := ks.ParseSelector(r.URL.Query().Get("selector"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 过滤出标签匹配选择器的任务
+	// Filter the tasks whose labels match the selector
+	infos := []*ks.TaskInfo{}
+	for _, info := range h.sched.List() {
+		if selector.Matches(info.Labels) {
+			infos = append(infos, info)
+		}
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// createTask 方法根据请求体使用注册的处理函数创建一个任务
+// The createTask method creates a task with a registered handling function according to the request body
+func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
+	// 解析请求体
+	// Parse the request body
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 计算执行时间
+	// Calculate the execution time
+	execAt, err := resolveExecAt(req.ExecAt, req.Delay)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 创建任务
+	// Create the task
+	id, err := h.sched.SetAtRegisteredWithOptions(req.Name, req.Handler, execAt, ks.NewTaskOptions().WithLabels(req.Labels).WithPriority(req.Priority))
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	// 返回任务的快照。很快到期的任务可能已经执行完成并被移除，此时只返回请求中的定义，状态为空
+	// Return the snapshot of the task. A task expiring soon may already be executed and removed, only the definition in the request is returned with an empty state then
+	info, err := h.sched.GetInfo(id)
+	if err != nil {
+		info = &ks.TaskInfo{ID: id, Name: req.Name, Handler: req.Handler, ExecAt: execAt, Labels: req.Labels, Priority: req.Priority}
+	}
+	writeJSON(w, http.StatusCreated, info)
+}
+
+// getTask 方法返回一个任务的快照
+// The getTask method returns the snapshot of one task
+func (h *Handler) getTask(w http.ResponseWriter, id string) {
+	info, err := h.sched.GetInfo(id)
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// cancelTask 方法取消一个任务
+// The cancelTask method cancels one task
+func (h *Handler) cancelTask(w http.ResponseWriter, id string) {
+	// 先检查任务是否存在，Delete 方法不会返回错误
+	// Check whether the task exists first, the Delete method does not return an error
+	if _, err := h.sched.GetInfo(id); err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+
+	h.sched.Delete(id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rescheduleTask 方法根据请求体重新调度一个任务
+// The rescheduleTask method reschedules one task according to the request body
+func (h *Handler) rescheduleTask(w http.ResponseWriter, r *http.Request, id string) {
+	// 解析请求体
+	// Parse the request body
+	var req RescheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 计算新的执行时间
+	// Calculate the new execution time
+	execAt, err := resolveExecAt(req.ExecAt, req.Delay)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeResult(w, id, h.sched.Reschedule(id, execAt))
+}
+
+// queryHistory 方法根据查询参数返回执行历史
+// The queryHistory method returns the execution history according to the query parameters
+func (h *Handler) queryHistory(w http.ResponseWriter, r *http.Request) {
+	// 如果调度器没有配置执行历史，返回错误
+	// If the scheduler has no execution history configured, return an error
+	history := h.sched.History()
+	if history == nil {
+		writeError(w, http.StatusNotFound, ErrorHistoryDisabled)
+		return
+	}
+
+	// 解析查询参数
+	// Parse the query parameters
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history.Query(query))
+}
+
+// streamEvents 方法以 JSON Lines 格式持续输出调度器的事件，直到客户端断开或者调度器停止
+// The streamEvents method keeps writing the events of the scheduler in JSON Lines format until the client disconnects or the scheduler stops
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	// 订阅调度器的事件
+	// Subscribe to the events of the scheduler
+	events, unsubscribe := h.sched.Subscribe(eventBufferSize)
+	defer unsubscribe()
+
+	// 发送响应头，让客户端尽快开始读取
+	// Send the response header so the client can start reading as soon as possible
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		// 客户端断开
+		// The client disconnects
+		case <-r.Context().Done():
+			return
+
+		// 收到一个事件
+		// An event is received
+		case event, ok := <-events:
+			// 调度器停止
+			// The scheduler stops
+			if !ok {
+				return
+			}
+
+			// 输出事件，写入失败说明客户端已经断开
+			// Write the event, a write failure means the client has disconnected
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeResult 方法在操作成功时返回任务的快照，否则返回错误
+// The writeResult method returns the snapshot of the task if the operation succeeded, otherwise it returns the error
+func (h *Handler) writeResult(w http.ResponseWriter, id string, err error) {
+	if err != nil {
+		writeError(w, statusOf(err), err)
+		return
+	}
+	h.getTask(w, id)
+}
+
+// resolveExecAt 函数根据执行时间或者延迟计算执行时间，延迟优先
+// The resolveExecAt function calculates the execution time from the execution time or the delay, the delay takes precedence
+func resolveExecAt(execAt time.Time, delay string) (time.Time, error) {
+	// 如果指定了延迟，使用当前时间加上延迟
+	// If the delay is specified, use the current time plus the delay
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Now().Add(d), nil
+	}
+
+	// 如果没有指定执行时间，返回错误
+	// If no execution time is specified, return an error
+	if execAt.IsZero() {
+		return time.Time{}, ErrorExecAtRequired
+	}
+
+	return execAt, nil
+}
+
+// parseHistoryQuery 函数从请求的查询参数中解析历史查询条件，时间使用 RFC 3339 格式
+// The parseHistoryQuery function parses the history query from the query parameters of the request, times use the RFC 3339 format
+func parseHistoryQuery(r *http.Request) (*ks.HistoryQuery, error) {
+	values := r.URL.Query()
+	query := &ks.HistoryQuery{Name: values.Get("name")}
+
+	var err error
+	if v := values.Get("since"); v != "" {
+		if query.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("until"); v != "" {
+		if query.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+	if v := values.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return query, nil
+}
+
+// statusOf 函数根据调度器返回的错误选择 HTTP 状态码
+// The statusOf function chooses the HTTP status code according to the error returned by the scheduler
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, ks.ErrorTaskNotFound), errors.Is(err, ks.ErrorHandlerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ks.ErrorTaskNotPending), errors.Is(err, ks.ErrorTaskNotPaused), errors.Is(err, ks.ErrorTaskIDConflict):
+		return http.StatusConflict
+	case errors.Is(err, ks.ErrorGroupRemoved), errors.Is(err, ks.ErrorTaskContextCanceled):
+		// 任务所属的组已经被删除，或者调用者上下文已经结束，重试同样的请求不会成功
+		// The group of the task has been deleted or the caller context has ended, retrying the same request does not succeed
+		return http.StatusConflict
+	case errors.Is(err, ks.ErrorSchedulerNotRunning):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ks.ErrorSchedulerFull):
+		// 任务被容量限制拒绝，客户端可以稍后重试
+		// The task is rejected by a capacity limit, the client may retry later
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// redirect 函数重定向到相对路径。不使用 http.Redirect，因为它会根据去掉前缀后的路径把相对路径转换为绝对路径
+// The redirect function redirects to a relative path. http.Redirect is not used because it converts the relative path to an absolute path based on the path with the prefix stripped
+func redirect(w http.ResponseWriter, location string) {
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusFound)
+}
+
+// writeJSON 函数将数据以 JSON 格式写入响应
+// The writeJSON function writes the data to the response in JSON format
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeError 函数将错误以 JSON 格式写入响应
+// The writeError function writes the error to the response in JSON format
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, conf *Config) (*ks.Scheduler, *httptest.Server) {
+	history, err := ks.NewHistory(nil)
+	assert.Nil(t, err)
+
+	scheduler := ks.New(ks.NewConfig().WithHistory(history))
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin", NewHandler(scheduler, conf)))
+	server := httptest.NewServer(mux)
+
+	t.Cleanup(func() {
+		server.Close()
+		scheduler.Stop()
+	})
+
+	return scheduler, server
+}
+
+func doRequest(t *testing.T, method, url, payload string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, url, strings.NewReader(payload))
+	assert.Nil(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp, body
+}
+
+func TestHandler_Tasks(t *testing.T) {
+	scheduler, server := newTestServer(t, nil)
+
+	taskID, err := scheduler.Set("test", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// List all tasks
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/admin/tasks", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var infos []*ks.TaskInfo
+	assert.Nil(t, json.Unmarshal(body, &infos))
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, taskID, infos[0].ID)
+
+	// Get one task
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/admin/tasks/"+taskID, "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var info ks.TaskInfo
+	assert.Nil(t, json.Unmarshal(body, &info))
+	assert.Equal(t, ks.TaskStatePending, info.State)
+
+	// Pause and resume the task
+	resp, body = doRequest(t, http.MethodPost, server.URL+"/admin/tasks/"+taskID+"/pause", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, json.Unmarshal(body, &info))
+	assert.Equal(t, ks.TaskStatePaused, info.State)
+
+	resp, _ = doRequest(t, http.MethodPost, server.URL+"/admin/tasks/"+taskID+"/resume", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Reschedule the task
+	resp, body = doRequest(t, http.MethodPost, server.URL+"/admin/tasks/"+taskID+"/reschedule", `{"delay":"2h"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, json.Unmarshal(body, &info))
+	assert.True(t, info.ExecAt.After(time.Now().Add(time.Hour)))
+
+	// Trigger an early return, the task is removed after it is executed
+	resp, _ = doRequest(t, http.MethodPost, server.URL+"/admin/tasks/"+taskID+"/early-return", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	time.Sleep(time.Millisecond * 100)
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/tasks/"+taskID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Query the history
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/admin/history?name=test", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var records []ks.HistoryRecord
+	assert.Nil(t, json.Unmarshal(body, &records))
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, ks.ErrorTaskEarlyReturn.Error(), records[0].Reason)
+}
+
+func TestHandler_Labels(t *testing.T) {
+	scheduler, server := newTestServer(t, nil)
+	scheduler.Registry().Register("noop", nil)
+
+	// Create a task with labels
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/admin/tasks", `{"name":"web","handler":"noop","delay":"1h","labels":{"env":"prod"}}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var info ks.TaskInfo
+	assert.Nil(t, json.Unmarshal(body, &info))
+	assert.Equal(t, ks.Labels{"env": "prod"}, info.Labels)
+
+	_, err := scheduler.Set("plain", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// List the tasks matching the selector
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/admin/tasks?selector=env%3Dprod", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var infos []*ks.TaskInfo
+	assert.Nil(t, json.Unmarshal(body, &infos))
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, info.ID, infos[0].ID)
+
+	// An invalid selector is rejected
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/tasks?selector=env+in+prod", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandler_CreateExpired(t *testing.T) {
+	scheduler, server := newTestServer(t, nil)
+	scheduler.Registry().Register("noop", nil)
+
+	// A task due right away may finish before its snapshot is taken, it is still reported as created
+	for i := 0; i < 20; i++ {
+		resp, body := doRequest(t, http.MethodPost, server.URL+"/admin/tasks", `{"name":"now","handler":"noop","delay":"0s","priority":3}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var info ks.TaskInfo
+		assert.Nil(t, json.Unmarshal(body, &info))
+		assert.NotEmpty(t, info.ID)
+		assert.Equal(t, "now", info.Name)
+		assert.Equal(t, 3, info.Priority)
+	}
+}
+
+func TestHandler_Cancel(t *testing.T) {
+	scheduler, server := newTestServer(t, nil)
+
+	taskID, err := scheduler.Set("test", nil, time.Hour)
+	assert.Nil(t, err)
+
+	resp, _ := doRequest(t, http.MethodDelete, server.URL+"/admin/tasks/"+taskID, "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, 0, scheduler.Count())
+
+	// Cancel a task which does not exist
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/admin/tasks/"+taskID, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Get the statistics
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/admin/stats", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats ks.Stats
+	assert.Nil(t, json.Unmarshal(body, &stats))
+	assert.Equal(t, uint64(1), stats.Added)
+	assert.Equal(t, uint64(1), stats.Canceled)
+	assert.Equal(t, uint64(1), stats.Removed)
+}
+
+func TestHandler_Authorize(t *testing.T) {
+	conf := NewConfig().WithAuthorizeFunc(func(r *http.Request, action Action) error {
+		if action != ActionList && r.Header.Get("X-Token") != "secret" {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+	_, server := newTestServer(t, conf)
+
+	resp, _ := doRequest(t, http.MethodGet, server.URL+"/admin/tasks", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/stats", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Wrong method and unknown path
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/admin/tasks", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/admin/unknown", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{ks.ErrorTaskNotFound, http.StatusNotFound},
+		{ks.ErrorTaskNotPaused, http.StatusConflict},
+		{ks.ErrorTaskIDConflict, http.StatusConflict},
+		{ks.ErrorGroupRemoved, http.StatusConflict},
+		{ks.ErrorTaskContextCanceled, http.StatusConflict},
+		{ks.ErrorSchedulerNotRunning, http.StatusServiceUnavailable},
+		{ks.ErrorSchedulerFull, http.StatusTooManyRequests},
+		{ks.ErrorMaxPendingTasks, http.StatusTooManyRequests},
+		{ks.ErrorTenantQuota, http.StatusTooManyRequests},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, statusOf(tt.err), tt.err.Error())
+	}
+}
@@ -0,0 +1,164 @@
+// Kairos dashboard, it only talks to the admin API it is served from and works fully offline.
+(function () {
+  "use strict";
+
+  // The dashboard is served under <prefix>/ui/, the API lives under <prefix>/.
+  var api = "../";
+  var state = { tasks: [], filter: "" };
+
+  function $(id) { return document.getElementById(id); }
+
+  function request(method, path) {
+    return fetch(api + path, { method: method, credentials: "same-origin" }).then(function (resp) {
+      if (resp.status === 204) { return null; }
+      return resp.json().then(function (body) {
+        if (!resp.ok) { throw new Error(body && body.error ? body.error : resp.statusText); }
+        return body;
+      });
+    });
+  }
+
+  // Escapes a value for both element content and quoted attribute values, task names and IDs are caller-controlled.
+  var entities = { "&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;", "'": "&#39;" };
+
+  function text(value) {
+    var str = value === undefined || value === null ? "" : String(value);
+    return str.replace(/[&<>"']/g, function (ch) { return entities[ch]; });
+  }
+
+  function formatTime(value) {
+    if (!value || value.indexOf("0001-01-01") === 0) { return "-"; }
+    return new Date(value).toLocaleString();
+  }
+
+  function formatDuration(ms) {
+    if (ms === null || ms === undefined || isNaN(ms)) { return "-"; }
+    var sign = ms < 0 ? "-" : "";
+    ms = Math.abs(ms);
+    if (ms < 1000) { return sign + Math.round(ms) + "ms"; }
+    if (ms < 60000) { return sign + (ms / 1000).toFixed(1) + "s"; }
+    if (ms < 3600000) { return sign + Math.floor(ms / 60000) + "m" + Math.round((ms % 60000) / 1000) + "s"; }
+    return sign + Math.floor(ms / 3600000) + "h" + Math.round((ms % 3600000) / 60000) + "m";
+  }
+
+  function formatLabels(labels) {
+    if (!labels) { return ""; }
+    return Object.keys(labels).sort().map(function (key) {
+      return ' <span class="label">' + text(key + "=" + labels[key]) + "</span>";
+    }).join("");
+  }
+
+  function renderStats(stats) {
+    var keys = ["pending", "running", "paused", "added", "executed", "canceled", "removed", "duplicated"];
+    $("stats").innerHTML = keys.map(function (key) {
+      return '<div class="card"><div class="value">' + text(stats[key]) + '</div><div class="label">' + key + "</div></div>";
+    }).join("");
+  }
+
+  function renderTimeline(tasks) {
+    var windowMs = parseInt($("window").value, 10);
+    var now = Date.now();
+    var html = "";
+    var visible = 0;
+    tasks.forEach(function (task) {
+      var offset = new Date(task.exec_at).getTime() - now;
+      if (offset > windowMs) { return; }
+      var left = Math.max(0, offset) / windowMs * 100;
+      visible++;
+      html += '<div class="marker ' + text(task.state) + '" style="left:' + left + '%" title="' +
+        text(task.name + " @ " + formatTime(task.exec_at)) + '"></div>';
+    });
+    for (var i = 0; i <= 4; i++) {
+      html += '<div class="tick" style="left:' + (i * 25) + '%">+' + formatDuration(windowMs * i / 4) + "</div>";
+    }
+    html += '<div class="count">' + visible + " of " + tasks.length + " tasks</div>";
+    $("timeline").innerHTML = html;
+  }
+
+  function renderTasks(tasks) {
+    var now = Date.now();
+    var filter = state.filter.toLowerCase();
+    var running = tasks.filter(function (task) { return task.state === "running"; });
+    $("running").innerHTML = running.length === 0 ? '<tr><td colspan="3" class="muted">no running handlers</td></tr>' :
+      running.map(function (task) {
+        return "<tr><td>" + text(task.id) + "</td><td>" + text(task.name) + "</td><td>" + formatTime(task.exec_at) + "</td></tr>";
+      }).join("");
+
+    var pending = tasks.filter(function (task) {
+      return task.state !== "running" && (!filter || task.name.toLowerCase().indexOf(filter) >= 0);
+    });
+    $("tasks").innerHTML = pending.length === 0 ? '<tr><td colspan="6" class="muted">no tasks</td></tr>' :
+      pending.map(function (task) {
+        var toggle = task.state === "paused" ? "resume" : "pause";
+        return "<tr><td>" + text(task.id) + "</td><td>" + text(task.name) + formatLabels(task.labels) + '</td><td class="state-' + text(task.state) + '">' +
+          text(task.state) + "</td><td>" + formatTime(task.exec_at) + "</td><td>" +
+          formatDuration(new Date(task.exec_at).getTime() - now) + "</td><td>" +
+          '<button data-action="early-return" data-id="' + text(task.id) + '">fire</button>' +
+          '<button data-action="' + toggle + '" data-id="' + text(task.id) + '">' + toggle + "</button>" +
+          '<button class="danger" data-action="cancel" data-id="' + text(task.id) + '">cancel</button></td></tr>';
+      }).join("");
+  }
+
+  function renderAggregates(aggregates) {
+    $("aggregates").innerHTML = aggregates.length === 0 ? '<tr><td colspan="11" class="muted">no data</td></tr>' :
+      aggregates.map(function (agg) {
+        return '<tr class="' + (agg.failed > 0 ? "error" : "") + '"><td>' + text(agg.name) + "</td><td>" + agg.pending + "</td><td>" +
+          agg.running + "</td><td>" + agg.paused + "</td><td>" + agg.executed + "</td><td>" + agg.canceled + "</td><td>" + agg.skipped + "</td><td>" +
+          agg.failed + "</td><td>" + formatDuration(agg.avg_duration_ms) + "</td><td>" + formatDuration(agg.max_duration_ms) +
+          "</td><td>" + formatTime(agg.last_finished_at) + "</td></tr>";
+      }).join("");
+  }
+
+  function renderHistory(records) {
+    records = records.slice().reverse();
+    $("history").innerHTML = records.length === 0 ? '<tr><td colspan="7" class="muted">no history</td></tr>' :
+      records.map(function (rec) {
+        var started = rec.started_at && rec.started_at.indexOf("0001-01-01") !== 0;
+        var duration = started ? new Date(rec.finished_at).getTime() - new Date(rec.started_at).getTime() : null;
+        return '<tr class="' + (rec.error ? "error" : "") + '"><td>' + formatTime(rec.finished_at) + "</td><td>" + text(rec.id) +
+          "</td><td>" + text(rec.name) + "</td><td>" + text(rec.reason) + "</td><td>" + formatDuration(duration) +
+          '</td><td class="wrap">' + text(rec.error) + '</td><td class="wrap">' + text(rec.result) + "</td></tr>";
+      }).join("");
+  }
+
+  function refresh() {
+    return Promise.all([
+      request("GET", "stats").then(renderStats),
+      request("GET", "tasks").then(function (tasks) {
+        state.tasks = tasks;
+        renderTimeline(tasks);
+        renderTasks(tasks);
+      }),
+      request("GET", "aggregates").then(renderAggregates),
+      request("GET", "history?limit=50").then(renderHistory, function () {
+        $("history").innerHTML = '<tr><td colspan="7" class="muted">history is not enabled</td></tr>';
+      })
+    ]).then(function () {
+      $("status").textContent = "updated " + new Date().toLocaleTimeString();
+    }, function (err) {
+      $("status").textContent = "error: " + err.message;
+    });
+  }
+
+  $("tasks").addEventListener("click", function (event) {
+    var button = event.target;
+    var action = button.getAttribute("data-action");
+    var id = button.getAttribute("data-id");
+    if (!action || !id) { return; }
+    var call = action === "cancel" ? request("DELETE", "tasks/" + encodeURIComponent(id)) :
+      request("POST", "tasks/" + encodeURIComponent(id) + "/" + action);
+    call.then(refresh, function (err) { window.alert(action + " failed: " + err.message); });
+  });
+
+  $("filter").addEventListener("input", function (event) {
+    state.filter = event.target.value;
+    renderTasks(state.tasks);
+  });
+
+  $("window").addEventListener("change", function () { renderTimeline(state.tasks); });
+
+  refresh();
+  setInterval(function () {
+    if ($("auto").checked) { refresh(); }
+  }, 2000);
+})();
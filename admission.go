@@ -0,0 +1,214 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 定义准入控制的错误
+// Define the errors of admission control
+var (
+	// ErrorSchedulerFull 表示调度器中的任务达到了容量限制，新的任务被拒绝
+	// ErrorSchedulerFull indicates the tasks in the scheduler reached a capacity limit, the new task is rejected
+	ErrorSchedulerFull = errors.New("scheduler full")
+
+	// ErrorMaxPendingTasks 表示调度器中的任务达到了 WithMaxPendingTasks 的限制
+	// ErrorMaxPendingTasks indicates the tasks in the scheduler reached the limit of WithMaxPendingTasks
+	ErrorMaxPendingTasks = fmt.Errorf("%w: max pending tasks reached", ErrorSchedulerFull)
+
+	// ErrorNameQuota 表示同名的任务达到了 WithNameQuota 的限制
+	// ErrorNameQuota indicates the tasks with the same name reached the limit of WithNameQuota
+	ErrorNameQuota = fmt.Errorf("%w: name quota reached", ErrorSchedulerFull)
+
+	// ErrorGroupQuota 表示组内的任务达到了组的 WithMaxPendingTasks 的限制
+	// ErrorGroupQuota indicates the tasks of the group reached the limit of WithMaxPendingTasks of the group
+	ErrorGroupQuota = fmt.Errorf("%w: group quota reached", ErrorSchedulerFull)
+
+	// ErrorTenantQuota 表示租户的任务达到了租户的 WithMaxPendingTasks 的限制
+	// ErrorTenantQuota indicates the tasks of the tenant reached the limit of WithMaxPendingTasks of the tenant
+	ErrorTenantQuota = fmt.Errorf("%w: tenant quota reached", ErrorSchedulerFull)
+
+	// ErrorTenantLimit 表示调度器中的租户数量达到了 WithMaxTenants 的限制，新的租户的任务被拒绝
+	// ErrorTenantLimit indicates the number of tenants in the scheduler reached the limit of WithMaxTenants, the tasks of a new tenant are rejected
+	ErrorTenantLimit = fmt.Errorf("%w: tenant limit reached", ErrorSchedulerFull)
+)
+
+// AdmissionCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，调度器会在任务因为容量限制被拒绝时调用它
+// AdmissionCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it when a task is rejected by a capacity limit
+type AdmissionCallback interface {
+	// OnTaskRejected 是当任务因为容量限制被拒绝时的回调函数，它接收任务名称和拒绝的原因作为参数，原因是包装了 ErrorSchedulerFull 的错误
+	// OnTaskRejected is the callback function when a task is rejected by a capacity limit, it takes the task name and the reason of the rejection as parameters, the reason is an error wrapping ErrorSchedulerFull
+	OnTaskRejected(name string, reason error)
+}
+
+// admission 结构体统计调度器、任务名称、组和租户中的任务数量，在添加任务之前检查容量限制
+// The admission struct counts the tasks in the scheduler, per task name, per group and per tenant, it checks the capacity limits before a task is added
+type admission struct {
+	// lock 用于保护计数和 freed
+	// lock is used to protect the counts and freed
+	lock sync.Mutex
+
+	// maxPending 是调度器中任务的最大数量，为 0 表示不限制
+	// maxPending is the maximum number of tasks in the scheduler, 0 means unlimited
+	maxPending int
+
+	// nameQuota 是同名任务的最大数量，为 0 表示不限制
+	// nameQuota is the maximum number of tasks with the same name, 0 means unlimited
+	nameQuota int
+
+	// total 是被统计的任务数量
+	// total is the number of counted tasks
+	total int
+
+	// names 是任务名称到任务数量的映射，只在限制了同名任务的数量时使用
+	// names is the map from the task name to the number of tasks, it is only used when the number of tasks with the same name is limited
+	names map[string]int
+
+	// groups 是组到任务数量的映射，只包含限制了任务数量的组
+	// groups is the map from the group to the number of tasks, it only contains groups limiting their number of tasks
+	groups map[*Group]int
+
+	// tenants 是租户到任务数量的映射，只包含限制了任务数量的租户
+	// tenants is the map from the tenant to the number of tasks, it only contains tenants limiting their number of tasks
+	tenants map[*tenant]int
+
+	// freed 在被统计的任务被移除时关闭，用于唤醒等待容量的调用者，没有等待者时为 nil
+	// freed is closed when a counted task is removed, it wakes up the callers waiting for capacity, nil if there are no waiters
+	freed chan struct{}
+}
+
+// newAdmission 函数创建一个新的 admission 实例，小于 0 的限制表示不限制
+// The newAdmission function creates a new admission instance, limits less than 0 mean unlimited
+func newAdmission(maxPending, nameQuota int) *admission {
+	if maxPending < 0 {
+		maxPending = 0
+	}
+	if nameQuota < 0 {
+		nameQuota = 0
+	}
+	return &admission{maxPending: maxPending, nameQuota: nameQuota, names: make(map[string]int), groups: make(map[*Group]int), tenants: make(map[*tenant]int)}
+}
+
+// limited 方法判断属于给定组和租户的任务是否受到容量限制，只有受到限制的任务被统计
+// The limited method reports whether tasks belonging to the given group and tenant are subject to a capacity limit, only such tasks are counted
+func (a *admission) limited(group *Group, tenant *tenant) bool {
+	return a.maxPending > 0 || a.nameQuota > 0 || (group != nil && group.cfg.maxPending > 0) || (tenant != nil && tenant.cfg.maxPending > 0)
+}
+
+// acquire 方法为任务占用容量，容量不足时返回拒绝的原因和容量被释放时关闭的通道。force 为 true 时忽略限制，只统计任务
+// The acquire method takes capacity for the task, when there is not enough capacity it returns the reason of the rejection and a channel closed when capacity is released. When force is true the limits are ignored and the task is only counted
+func (a *admission) acquire(name string, group *Group, tenant *tenant, force bool) (<-chan struct{}, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	// 按照调度器、任务名称、组和租户的顺序检查限制
+	// Check the limits of the scheduler, the task name, the group and the tenant in this order
+	if !force {
+		var err error
+		switch {
+		case a.maxPending > 0 && a.total >= a.maxPending:
+			err = ErrorMaxPendingTasks
+		case a.nameQuota > 0 && a.names[name] >= a.nameQuota:
+			err = ErrorNameQuota
+		case group != nil && group.cfg.maxPending > 0 && a.groups[group] >= group.cfg.maxPending:
+			err = ErrorGroupQuota
+		case tenant != nil && tenant.cfg.maxPending > 0 && a.tenants[tenant] >= tenant.cfg.maxPending:
+			err = ErrorTenantQuota
+		}
+		if err != nil {
+			if a.freed == nil {
+				a.freed = make(chan struct{})
+			}
+			return a.freed, err
+		}
+	}
+
+	// 统计任务
+	// Count the task
+	a.total++
+	if a.nameQuota > 0 {
+		a.names[name]++
+	}
+	if group != nil && group.cfg.maxPending > 0 {
+		a.groups[group]++
+	}
+	if tenant != nil && tenant.cfg.maxPending > 0 {
+		a.tenants[tenant]++
+	}
+	return nil, nil
+}
+
+// release 方法释放任务占用的容量，并唤醒等待容量的调用者
+// The release method releases the capacity taken by the task, and wakes up the callers waiting for capacity
+func (a *admission) release(name string, group *Group, tenant *tenant) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.total--
+	if a.nameQuota > 0 {
+		if a.names[name]--; a.names[name] <= 0 {
+			delete(a.names, name)
+		}
+	}
+	if group != nil && group.cfg.maxPending > 0 {
+		if a.groups[group]--; a.groups[group] <= 0 {
+			delete(a.groups, group)
+		}
+	}
+	if tenant != nil && tenant.cfg.maxPending > 0 {
+		if a.tenants[tenant]--; a.tenants[tenant] <= 0 {
+			delete(a.tenants, tenant)
+		}
+	}
+
+	if a.freed != nil {
+		close(a.freed)
+		a.freed = nil
+	}
+}
+
+// admit 是一个方法，在添加任务之前为它占用容量。容量不足时，如果任务选项设置了 WithWaitForCapacity，阻塞直到有容量、上下文结束或者调度器停止，
+// 否则立即拒绝任务。被拒绝的任务会通过 AdmissionCallback、事件、Stats 和 TenantStats 报告。恢复的任务不会被拒绝，避免丢失持久化的任务
+// admit is a method taking capacity for a task before it is added. When there is not enough capacity and WithWaitForCapacity is set in the task options, it blocks until there is capacity, the context ends or the scheduler stops,
+// otherwise the task is rejected immediately. Rejected tasks are reported through AdmissionCallback, events, Stats and TenantStats. Restored tasks are never rejected, to avoid losing persisted tasks
+func (s *Scheduler) admit(name string, tenant *tenant, opts *TaskOptions) error {
+	for {
+		freed, err := s.admission.acquire(name, opts.group, tenant, opts.restored)
+		if err == nil {
+			return nil
+		}
+
+		// 没有设置等待的上下文时立即拒绝
+		// Reject immediately if no context to wait with is set
+		if opts.waitCtx == nil {
+			s.reject(name, tenant, err)
+			return err
+		}
+
+		// 等待容量被释放之后重新检查
+		// Wait for capacity to be released, then check again
+		select {
+		case <-freed:
+		case <-opts.waitCtx.Done():
+			s.reject(name, tenant, err)
+			return err
+		case <-s.ctx.Done():
+			return ErrorSchedulerNotRunning
+		}
+	}
+}
+
+// reject 是一个方法，记录并通知任务因为容量限制被拒绝
+// reject is a method recording and notifying that a task is rejected by a capacity limit
+func (s *Scheduler) reject(name string, tenant *tenant, reason error) {
+	s.counters.rejected.Add(1)
+	if tenant != nil {
+		tenant.rejected.Add(1)
+	}
+	if callback, ok := s.cfg.callback.(AdmissionCallback); ok {
+		callback.OnTaskRejected(name, reason)
+	}
+	s.events.publish(&Event{Type: EventTaskRejected, Name: name, Time: time.Now(), Error: reason.Error()})
+}
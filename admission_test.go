@@ -0,0 +1,209 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRejectCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	reasons []error
+}
+
+func (c *testRejectCallback) OnTaskRejected(name string, reason error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reasons = append(c.reasons, reason)
+}
+
+func (c *testRejectCallback) get() []error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]error(nil), c.reasons...)
+}
+
+func TestAdmission_Acquire(t *testing.T) {
+	a := newAdmission(3, 2)
+	group := &Group{name: "g", cfg: &GroupConfig{maxPending: 1}}
+
+	_, err := a.acquire("a", nil, nil, false)
+	assert.Nil(t, err)
+	_, err = a.acquire("a", nil, nil, false)
+	assert.Nil(t, err)
+
+	// The name quota is checked before the group quota
+	_, err = a.acquire("a", group, nil, false)
+	assert.True(t, errors.Is(err, ErrorNameQuota))
+
+	_, err = a.acquire("b", group, nil, false)
+	assert.Nil(t, err)
+
+	// The limit of the scheduler is checked first
+	freed, err := a.acquire("c", nil, nil, false)
+	assert.True(t, errors.Is(err, ErrorMaxPendingTasks))
+	assert.True(t, errors.Is(err, ErrorSchedulerFull))
+
+	// Forced tasks are counted beyond the limits
+	_, err = a.acquire("c", group, nil, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, a.total)
+	assert.Equal(t, 2, a.groups[group])
+
+	// Releasing capacity wakes up the waiters
+	a.release("a", nil, nil)
+	select {
+	case <-freed:
+	default:
+		t.Fatal("waiters not woken up")
+	}
+	a.release("a", nil, nil)
+	a.release("b", group, nil)
+	a.release("c", group, nil)
+	assert.Equal(t, 0, a.total)
+	assert.Equal(t, 0, len(a.names))
+	assert.Equal(t, 0, len(a.groups))
+
+	// The group quota applies once the other limits allow the task
+	a = newAdmission(0, 0)
+	_, err = a.acquire("a", group, nil, false)
+	assert.Nil(t, err)
+	_, err = a.acquire("b", group, nil, false)
+	assert.True(t, errors.Is(err, ErrorGroupQuota))
+
+	// Tasks without any limit are not counted
+	assert.False(t, a.limited(nil, nil))
+	assert.True(t, a.limited(group, nil))
+}
+
+func TestScheduler_MaxPendingTasks(t *testing.T) {
+	callback := &testRejectCallback{}
+	scheduler := New(NewConfig().WithCallback(callback).WithMaxPendingTasks(2))
+	defer scheduler.Stop()
+
+	events, cancel := scheduler.Subscribe(16)
+	defer cancel()
+
+	id, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	bID, err := scheduler.Set("b", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// The third task is rejected
+	_, err = scheduler.Set("c", nil, time.Hour)
+	assert.True(t, errors.Is(err, ErrorSchedulerFull))
+	assert.True(t, errors.Is(err, ErrorMaxPendingTasks))
+	assert.Equal(t, 2, scheduler.Count())
+
+	// The rejection is reported in the callback, the statistics and the events
+	assert.Equal(t, []error{ErrorMaxPendingTasks}, callback.get())
+	assert.Equal(t, uint64(1), scheduler.Stats().Rejected)
+	for event := range events {
+		if event.Type == EventTaskRejected {
+			assert.Equal(t, "c", event.Name)
+			assert.Equal(t, ErrorMaxPendingTasks.Error(), event.Error)
+			break
+		}
+	}
+
+	// Removing a task frees its capacity
+	scheduler.Delete(id)
+	_, err = scheduler.Set("c", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// Executed tasks free their capacity too
+	assert.Nil(t, scheduler.EarlyReturn(bID))
+	assert.Eventually(t, func() bool { return scheduler.Count() == 1 }, time.Second, time.Millisecond)
+	_, err = scheduler.Set("d", nil, time.Hour)
+	assert.Nil(t, err)
+}
+
+func TestScheduler_NameQuota(t *testing.T) {
+	scheduler := New(NewConfig().WithNameQuota(1).WithUniqued(false))
+	defer scheduler.Stop()
+
+	_, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	_, err = scheduler.Set("a", nil, time.Hour)
+	assert.True(t, errors.Is(err, ErrorNameQuota))
+
+	// Other names are not affected
+	_, err = scheduler.Set("b", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// Duplicated unique tasks return the existing ID instead of being rejected
+	id, err := scheduler.SetWithOptions("u", nil, time.Hour, NewTaskOptions().WithUniqued(true))
+	assert.Nil(t, err)
+	existingID, err := scheduler.SetWithOptions("u", nil, time.Hour, NewTaskOptions().WithUniqued(true))
+	assert.Nil(t, err)
+	assert.Equal(t, id, existingID)
+	assert.Equal(t, uint64(1), scheduler.Stats().Rejected)
+}
+
+func TestGroup_MaxPendingTasks(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	group := scheduler.GroupWithConfig("limited", NewGroupConfig().WithMaxPendingTasks(1))
+
+	_, err := group.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	_, err = group.Set("b", nil, time.Hour)
+	assert.True(t, errors.Is(err, ErrorGroupQuota))
+
+	// Tasks outside the group are not limited
+	for i := 0; i < 3; i++ {
+		_, err = scheduler.Set("free", nil, time.Hour)
+		assert.Nil(t, err)
+	}
+
+	// Canceling the group frees its capacity
+	group.CancelAll()
+	assert.Eventually(t, func() bool { return group.Count() == 0 }, time.Second, time.Millisecond)
+	_, err = group.Set("b", nil, time.Hour)
+	assert.Nil(t, err)
+}
+
+func TestScheduler_WaitForCapacity(t *testing.T) {
+	scheduler := New(NewConfig().WithMaxPendingTasks(1))
+	defer scheduler.Stop()
+
+	id, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// The addition blocks until the capacity is released
+	added := make(chan error, 1)
+	go func() {
+		_, err := scheduler.SetWithOptions("b", nil, time.Hour, NewTaskOptions().WithWaitForCapacity(context.Background()))
+		added <- err
+	}()
+	select {
+	case <-added:
+		t.Fatal("task added beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	scheduler.Delete(id)
+	assert.Nil(t, <-added)
+	assert.Equal(t, 1, scheduler.Count())
+
+	// The rejection is returned when the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = scheduler.SetWithOptions("c", nil, time.Hour, NewTaskOptions().WithWaitForCapacity(ctx))
+	assert.True(t, errors.Is(err, ErrorMaxPendingTasks))
+	assert.Equal(t, uint64(1), scheduler.Stats().Rejected)
+
+	// Stopping the scheduler releases the waiters
+	go func() {
+		_, err := scheduler.SetWithOptions("d", nil, time.Hour, NewTaskOptions().WithWaitForCapacity(context.Background()))
+		added <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	scheduler.Stop()
+	assert.True(t, errors.Is(<-added, ErrorSchedulerNotRunning))
+}
@@ -0,0 +1,285 @@
+package kairos
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrorBatchResultMissing 表示批量处理函数返回的结果少于任务，缺少结果的任务使用这个错误
+// ErrorBatchResultMissing indicates the batch handling function returned fewer results than tasks, the tasks without a result use this error
+var ErrorBatchResultMissing = errors.New("batch result missing")
+
+// 定义批量执行的默认设置
+// Define the default settings of batch execution
+const (
+	// DefaultBatchMaxSize 是一个批次默认的最大任务数量
+	// DefaultBatchMaxSize is the default maximum number of tasks in a batch
+	DefaultBatchMaxSize = 100
+
+	// DefaultBatchMaxWait 是批次中第一个任务默认的最长等待时间
+	// DefaultBatchMaxWait is the default maximum time the first task of a batch waits
+	DefaultBatchMaxWait = 100 * time.Millisecond
+)
+
+// BatchItem 结构体是批次中的一个任务
+// The BatchItem struct is a task in a batch
+type BatchItem struct {
+	// ID 是任务的 id
+	// ID is the id of the task
+	ID string
+
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string
+
+	// Payload 是使用 TaskOptions 的 WithPayload 设置的任务数据
+	// Payload is the data of the task set with WithPayload of TaskOptions
+	Payload any
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels Labels
+
+	// ExecAt 是任务计划执行的时间
+	// ExecAt is the scheduled execution time of the task
+	ExecAt time.Time
+}
+
+// BatchResult 结构体是批次中一个任务的执行结果
+// The BatchResult struct is the execution result of a task in a batch
+type BatchResult struct {
+	// Data 是任务的结果
+	// Data is the result of the task
+	Data any
+
+	// Err 是任务的错误
+	// Err is the error of the task
+	Err error
+}
+
+// BatchHandleFunc 是批量处理函数，它接收一个批次中的所有任务，按照相同的顺序返回每个任务的结果。
+// 返回非 nil 的错误时，所有任务都使用这个错误；返回的结果少于任务时，缺少结果的任务使用 ErrorBatchResultMissing 作为错误。
+// done 在调度器停止时关闭
+// BatchHandleFunc is a batch handling function, it takes all tasks of a batch and returns the result of every task in the same order.
+// When a non-nil error is returned, all tasks use this error; when fewer results than tasks are returned, the tasks without a result use ErrorBatchResultMissing as the error.
+// done is closed when the scheduler stops
+type BatchHandleFunc = func(done WaitForContextDone, items []*BatchItem) (results []BatchResult, err error)
+
+// BatchConfig 是一个结构体，包含批量执行的设置
+// BatchConfig is a struct that contains the settings of batch execution
+type BatchConfig struct {
+	// handleFunc 是批量处理函数
+	// handleFunc is the batch handling function
+	handleFunc BatchHandleFunc
+
+	// maxSize 是一个批次的最大任务数量
+	// maxSize is the maximum number of tasks in a batch
+	maxSize int
+
+	// maxWait 是批次中第一个任务的最长等待时间
+	// maxWait is the maximum time the first task of a batch waits
+	maxWait time.Duration
+}
+
+// NewBatchConfig 是一个函数，用于使用给定的批量处理函数创建一个新的 BatchConfig 实例
+// NewBatchConfig is a function used to create a new instance of BatchConfig with the given batch handling function
+func NewBatchConfig(handleFunc BatchHandleFunc) *BatchConfig {
+	return &BatchConfig{handleFunc: handleFunc, maxSize: DefaultBatchMaxSize, maxWait: DefaultBatchMaxWait}
+}
+
+// WithMaxSize 是一个方法，用于设置一个批次的最大任务数量，批次达到这个数量时立即执行，默认为 DefaultBatchMaxSize
+// WithMaxSize is a method used to set the maximum number of tasks in a batch, the batch runs immediately when it reaches this number, the default is DefaultBatchMaxSize
+func (c *BatchConfig) WithMaxSize(size int) *BatchConfig {
+	c.maxSize = size
+	return c
+}
+
+// WithMaxWait 是一个方法，用于设置批次中第一个任务的最长等待时间，到期之后批次立即执行，默认为 DefaultBatchMaxWait
+// WithMaxWait is a method used to set the maximum time the first task of a batch waits, the batch runs immediately after it, the default is DefaultBatchMaxWait
+func (c *BatchConfig) WithMaxWait(wait time.Duration) *BatchConfig {
+	c.maxWait = wait
+	return c
+}
+
+// isBatchConfigValid 是一个函数，用于检查 BatchConfig 实例是否有效，没有批量处理函数时返回 nil
+// isBatchConfigValid is a function used to check if the instance of BatchConfig is valid, nil is returned if there is no batch handling function
+func isBatchConfigValid(conf *BatchConfig) *BatchConfig {
+	if conf == nil || conf.handleFunc == nil {
+		return nil
+	}
+	if conf.maxSize <= 0 {
+		conf.maxSize = DefaultBatchMaxSize
+	}
+	if conf.maxWait <= 0 {
+		conf.maxWait = DefaultBatchMaxWait
+	}
+	return conf
+}
+
+// batchCall 结构体是批次中一个等待结果的任务
+// The batchCall struct is a task in a batch waiting for its result
+type batchCall struct {
+	// item 是任务
+	// item is the task
+	item *BatchItem
+
+	// result 是任务的结果
+	// result is the result of the task
+	result BatchResult
+
+	// ready 在结果可用时关闭
+	// ready is closed when the result is available
+	ready chan struct{}
+}
+
+// batch 结构体是一个正在收集任务的批次
+// The batch struct is a batch collecting tasks
+type batch struct {
+	// calls 是批次中的任务
+	// calls are the tasks in the batch
+	calls []*batchCall
+
+	// timer 在最长等待时间之后执行批次
+	// timer runs the batch after the maximum waiting time
+	timer *time.Timer
+}
+
+// batcher 结构体把同名的任务收集到批次中，批次达到最大数量或者最长等待时间时调用批量处理函数
+// The batcher struct collects tasks with the same name into batches, the batch handling function is called when a batch reaches the maximum size or the maximum waiting time
+type batcher struct {
+	// lock 用于保护 current 和 closed
+	// lock is used to protect current and closed
+	lock sync.Mutex
+
+	// cfg 是批量执行的设置
+	// cfg is the settings of batch execution
+	cfg *BatchConfig
+
+	// done 在调度器停止时关闭，传递给批量处理函数
+	// done is closed when the scheduler stops, it is passed to the batch handling function
+	done WaitForContextDone
+
+	// current 是正在收集任务的批次，没有时为 nil
+	// current is the batch collecting tasks, nil if there is none
+	current *batch
+
+	// closed 表示调度器已经停止，之后的任务不再等待，立即单独执行
+	// closed indicates the scheduler has stopped, later tasks no longer wait and run alone immediately
+	closed bool
+}
+
+// newBatcher 函数创建一个新的 batcher 实例
+// The newBatcher function creates a new batcher instance
+func newBatcher(conf *BatchConfig, done WaitForContextDone) *batcher {
+	return &batcher{cfg: conf, done: done}
+}
+
+// submit 方法把任务加入当前的批次，阻塞直到批次执行完成，返回任务的结果
+// The submit method adds the task to the current batch, it blocks until the batch has run and returns the result of the task
+func (b *batcher) submit(item *BatchItem) (any, error) {
+	call := &batchCall{item: item, ready: make(chan struct{})}
+
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		b.run([]*batchCall{call})
+		return call.result.Data, call.result.Err
+	}
+
+	// 第一个任务创建批次，并启动最长等待时间的定时器
+	// The first task creates the batch and starts the timer of the maximum waiting time
+	current := b.current
+	if current == nil {
+		current = &batch{}
+		current.timer = time.AfterFunc(b.cfg.maxWait, func() { b.flush(current) })
+		b.current = current
+	}
+	current.calls = append(current.calls, call)
+
+	// 批次达到最大数量时，由最后加入的任务执行批次
+	// When the batch reaches the maximum size, the task joining last runs the batch
+	var full []*batchCall
+	if len(current.calls) >= b.cfg.maxSize {
+		current.timer.Stop()
+		full, b.current = current.calls, nil
+	}
+	b.lock.Unlock()
+
+	if full != nil {
+		b.run(full)
+	}
+	<-call.ready
+	return call.result.Data, call.result.Err
+}
+
+// flush 方法在最长等待时间之后执行批次，批次已经因为达到最大数量而执行时不做任何事情
+// The flush method runs the batch after the maximum waiting time, it does nothing if the batch has already run because it reached the maximum size
+func (b *batcher) flush(current *batch) {
+	b.lock.Lock()
+	if b.current != current {
+		b.lock.Unlock()
+		return
+	}
+	b.current = nil
+	b.lock.Unlock()
+
+	b.run(current.calls)
+}
+
+// run 方法调用批量处理函数，并把结果分配给批次中的每个任务
+// The run method calls the batch handling function and distributes the results to every task of the batch
+func (b *batcher) run(calls []*batchCall) {
+	items := make([]*BatchItem, len(calls))
+	for i, call := range calls {
+		items[i] = call.item
+	}
+
+	// 无论批量处理函数是否返回，都要唤醒等待的任务
+	// Wake up the waiting tasks whether or not the batch handling function returns
+	defer func() {
+		for _, call := range calls {
+			close(call.ready)
+		}
+	}()
+
+	results, err := b.cfg.handleFunc(b.done, items)
+	for i, call := range calls {
+		switch {
+		case err != nil:
+			call.result.Err = err
+		case i < len(results):
+			call.result = results[i]
+		default:
+			call.result.Err = ErrorBatchResultMissing
+		}
+	}
+}
+
+// close 方法在调度器停止时立即执行正在收集的批次，之后的任务不再等待
+// The close method runs the batch being collected immediately when the scheduler stops, later tasks no longer wait
+func (b *batcher) close() {
+	b.lock.Lock()
+	b.closed = true
+	current := b.current
+	b.current = nil
+	b.lock.Unlock()
+
+	if current != nil {
+		current.timer.Stop()
+		go b.run(current.calls)
+	}
+}
+
+// batchHandleFunc 是一个方法，返回把任务加入同名批次的处理函数，任务的名称没有配置批量执行时返回 nil
+// batchHandleFunc is a method returning the handling function adding the task to the batch of its name, nil is returned if batch execution is not configured for the name of the task
+func (s *Scheduler) batchHandleFunc(taskRef *TaskRef) TaskHandleFunc {
+	b, ok := s.batchers[taskRef.name]
+	if !ok {
+		return nil
+	}
+	item := &BatchItem{ID: taskRef.id, Name: taskRef.name, Payload: taskRef.payload, Labels: taskRef.labels.clone(), ExecAt: taskRef.execAt}
+	return func(WaitForContextDone) (any, error) {
+		return b.submit(item)
+	}
+}
@@ -0,0 +1,218 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBatchCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	results map[string]any
+	errs    map[string]error
+}
+
+func (c *testBatchCallback) OnTaskExecuted(id, name string, data interface{}, reason, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.results[id] = data
+	c.errs[id] = err
+}
+
+func (c *testBatchCallback) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.results)
+}
+
+func submitAll(b *batcher, n int) ([]any, []error) {
+	var wg sync.WaitGroup
+	results := make([]any, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = b.submit(&BatchItem{ID: fmt.Sprint(i), Payload: i})
+		}(i)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+func TestBatchConfig_Valid(t *testing.T) {
+	assert.Nil(t, isBatchConfigValid(nil))
+	assert.Nil(t, isBatchConfigValid(NewBatchConfig(nil)))
+
+	conf := isBatchConfigValid(NewBatchConfig(func(WaitForContextDone, []*BatchItem) ([]BatchResult, error) { return nil, nil }).WithMaxSize(0).WithMaxWait(-1))
+	assert.Equal(t, DefaultBatchMaxSize, conf.maxSize)
+	assert.Equal(t, DefaultBatchMaxWait, conf.maxWait)
+
+	// A configuration without a batch handling function removes the name
+	c := NewConfig().WithBatch("a", conf).WithBatch("b", conf)
+	assert.Equal(t, 2, len(c.batches))
+	c.WithBatch("a", nil)
+	assert.Equal(t, 1, len(c.batches))
+}
+
+func TestBatcher_MaxSize(t *testing.T) {
+	var calls atomic.Int32
+	conf := NewBatchConfig(func(done WaitForContextDone, items []*BatchItem) ([]BatchResult, error) {
+		calls.Add(1)
+		results := make([]BatchResult, len(items))
+		for i, item := range items {
+			results[i].Data = item.Payload.(int) * 10
+		}
+		return results, nil
+	}).WithMaxSize(4).WithMaxWait(time.Hour)
+	b := newBatcher(conf, make(chan struct{}))
+
+	// A full batch runs without waiting, every task gets its own result
+	results, errs := submitAll(b, 8)
+	assert.Equal(t, int32(2), calls.Load())
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, i*10, results[i])
+		assert.Nil(t, errs[i])
+	}
+}
+
+func TestBatcher_MaxWait(t *testing.T) {
+	var sizes []int
+	conf := NewBatchConfig(func(done WaitForContextDone, items []*BatchItem) ([]BatchResult, error) {
+		sizes = append(sizes, len(items))
+		// Only the first task gets a result
+		return []BatchResult{{Data: "first", Err: errors.New("item")}}, nil
+	}).WithMaxSize(10).WithMaxWait(30 * time.Millisecond)
+	b := newBatcher(conf, make(chan struct{}))
+
+	start := time.Now()
+	results, errs := submitAll(b, 3)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, []int{3}, sizes)
+
+	// Tasks without a result report ErrorBatchResultMissing instead of success
+	count := 0
+	for i := 0; i < 3; i++ {
+		if results[i] == "first" {
+			count++
+			assert.EqualError(t, errs[i], "item")
+		} else {
+			assert.Nil(t, results[i])
+			assert.True(t, errors.Is(errs[i], ErrorBatchResultMissing))
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestBatcher_Error(t *testing.T) {
+	conf := NewBatchConfig(func(done WaitForContextDone, items []*BatchItem) ([]BatchResult, error) {
+		return []BatchResult{{Data: "ignored"}}, errors.New("batch")
+	}).WithMaxSize(2)
+	b := newBatcher(conf, make(chan struct{}))
+
+	// The error of the batch applies to every task
+	results, errs := submitAll(b, 2)
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, results[i])
+		assert.EqualError(t, errs[i], "batch")
+	}
+}
+
+func TestBatcher_Close(t *testing.T) {
+	var sizes []int
+	var lock sync.Mutex
+	conf := NewBatchConfig(func(done WaitForContextDone, items []*BatchItem) ([]BatchResult, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sizes = append(sizes, len(items))
+		return make([]BatchResult, len(items)), nil
+	}).WithMaxWait(time.Hour)
+	b := newBatcher(conf, make(chan struct{}))
+
+	// The batch being collected runs when the batcher is closed
+	finished := make(chan struct{})
+	go func() {
+		submitAll(b, 2)
+		close(finished)
+	}()
+	assert.Eventually(t, func() bool {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		return b.current != nil && len(b.current.calls) == 2
+	}, time.Second, time.Millisecond)
+	b.close()
+	<-finished
+
+	// Later tasks run alone immediately
+	_, err := b.submit(&BatchItem{ID: "late"})
+	assert.Nil(t, err)
+	lock.Lock()
+	assert.Equal(t, []int{2, 1}, sizes)
+	lock.Unlock()
+}
+
+func TestScheduler_Batch(t *testing.T) {
+	var lock sync.Mutex
+	batches := make([][]*BatchItem, 0)
+	conf := NewBatchConfig(func(done WaitForContextDone, items []*BatchItem) ([]BatchResult, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		batches = append(batches, items)
+		results := make([]BatchResult, len(items))
+		for i, item := range items {
+			results[i].Data = item.Payload
+		}
+		return results, nil
+	}).WithMaxSize(10).WithMaxWait(50 * time.Millisecond)
+
+	callback := &testBatchCallback{results: make(map[string]any), errs: make(map[string]error)}
+	scheduler := New(NewConfig().WithCallback(callback).WithBatch("flush", conf))
+	defer scheduler.Stop()
+
+	// The handling functions of batched tasks are not called
+	var called atomic.Bool
+	handleFunc := func(WaitForContextDone) (any, error) {
+		called.Store(true)
+		return nil, nil
+	}
+
+	execAt := time.Now().Add(20 * time.Millisecond)
+	ids := make(map[string]int)
+	for i := 0; i < 5; i++ {
+		id, err := scheduler.SetAtWithOptions("flush", handleFunc, execAt, NewTaskOptions().WithPayload(i).WithLabels(Labels{"item": fmt.Sprint(i)}))
+		assert.Nil(t, err)
+		ids[id] = i
+	}
+
+	// Tasks with other names run on their own
+	otherID, err := scheduler.SetAt("other", handleFunc, execAt)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool { return callback.count() == 6 }, time.Second, time.Millisecond)
+	assert.True(t, called.Load())
+
+	// One batch with all payloads, every member task reports its own outcome
+	lock.Lock()
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, 5, len(batches[0]))
+	for _, item := range batches[0] {
+		assert.Equal(t, "flush", item.Name)
+		assert.Equal(t, ids[item.ID], item.Payload)
+		assert.Equal(t, fmt.Sprint(item.Payload), item.Labels["item"])
+		assert.Equal(t, execAt, item.ExecAt)
+	}
+	lock.Unlock()
+
+	callback.lock.Lock()
+	for id, i := range ids {
+		assert.Equal(t, i, callback.results[id])
+	}
+	assert.Nil(t, callback.results[otherID])
+	callback.lock.Unlock()
+}
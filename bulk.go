@@ -0,0 +1,177 @@
+package kairos
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskSpec 结构体描述 SetBatch 批量添加的一个任务
+// The TaskSpec struct describes one task added in bulk by SetBatch
+type TaskSpec struct {
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string
+
+	// HandleFunc 是任务的处理函数，设置了 Handler 时被忽略
+	// HandleFunc is the handling function of the task, it is ignored if Handler is set
+	HandleFunc TaskHandleFunc
+
+	// Handler 是注册表中处理函数的名称，设置时使用注册的处理函数，和 SetAtRegistered 一样，配置了 Store 时任务会被持久化
+	// Handler is the name of the handling function in the registry, the registered handling function is used if it is set, like SetAtRegistered the task is persisted when the Store is configured
+	Handler string
+
+	// ExecAt 是任务的执行时间，为零值时使用当前时间加上 Delay
+	// ExecAt is the execution time of the task, the current time plus Delay is used if it is the zero value
+	ExecAt time.Time
+
+	// Delay 是任务的延迟，只有 ExecAt 为零值时使用，和 Set 一样使用相对的延迟
+	// Delay is the delay of the task, it is only used if ExecAt is the zero value, like Set it is a relative delay
+	Delay time.Duration
+
+	// Options 是任务的选项，为 nil 时使用默认的任务选项，多个任务可以共享同一个选项
+	// Options are the options of the task, the default task options are used if it is nil, several tasks may share the same options
+	Options *TaskOptions
+}
+
+// resolve 是一个方法，返回任务描述对应的处理函数、执行时间和任务选项
+// resolve is a method returning the handling function, the execution time and the task options of the task description
+func (s *Scheduler) resolve(spec *TaskSpec, now time.Time) (TaskHandleFunc, time.Time, *TaskOptions, error) {
+	// 使用注册的处理函数时，从注册表中查找它。
+	// When the registered handling function is used, look it up in the registry.
+	handleFunc := spec.HandleFunc
+	if spec.Handler != "" {
+		var ok bool
+		if handleFunc, ok = s.cfg.registry.Lookup(spec.Handler); !ok {
+			return nil, time.Time{}, nil, ErrorHandlerNotFound
+		}
+	}
+
+	// 没有设置执行时间时，使用相对的延迟。
+	// If the execution time is not set, use the relative delay.
+	if spec.ExecAt.IsZero() {
+		return handleFunc, now.Add(spec.Delay), relativeOptions(spec.Options), nil
+	}
+	return handleFunc, spec.ExecAt, isTaskOptionsValid(spec.Options), nil
+}
+
+// SetBatch 是一个方法，用于一次添加多个任务，返回和 specs 一一对应的任务 ID 和错误，添加成功的任务的错误为 nil。
+// 每个任务和单独添加时一样逐个在任务缓存中占用 ID 并经过唯一性检查和准入控制，开销和逐个调用 SetAt 相同，一个任务失败不影响其他的任务；调度器没有运行时所有任务都返回 ErrorSchedulerNotRunning。
+// 任务按顺序逐个准备、通知和启动，添加的回调函数和事件在任务启动之前发出，零延迟的任务也不会先于添加的通知报告执行完成；
+// 此时任务引用仍然被锁定，回调函数不能同步地操作同一个任务。使用 WithWaitForCapacity 等待容量的任务会阻塞后面的任务，但是之前的任务已经启动，可以被删除。
+// SetBatch is a method used to add many tasks at once, it returns the task IDs and the errors matching specs one by one, the error of a task added successfully is nil.
+// Every task reserves its ID in the task cache and goes through the uniqueness check and the admission control one by one like when it is added alone, so it costs as much as calling SetAt for each task, the failure of one task does not affect the others; all tasks return ErrorSchedulerNotRunning if the scheduler is not running.
+// The tasks are prepared, notified and started one by one in order, the added callbacks and events are emitted before the task starts, so even a zero-delay task never reports its execution before it is reported as added;
+// the task reference is still locked at that point, so the callbacks must not operate on the same task synchronously. A task waiting for capacity with WithWaitForCapacity blocks the following tasks, but the previous tasks are already started and can be deleted.
+func (s *Scheduler) SetBatch(specs []TaskSpec) ([]string, []error) {
+	ids := make([]string, len(specs))
+	errs := make([]error, len(specs))
+
+	// 如果调度器没有运行，所有任务都返回错误。
+	// If the scheduler is not running, all tasks return the error.
+	if !s.running.Load() {
+		for i := range errs {
+			errs[i] = ErrorSchedulerNotRunning
+		}
+		return ids, errs
+	}
+
+	// 逐个准备并启动任务，任务引用在启动之后立即解锁。后面的任务可能在准入控制中等待容量，
+	// 持有之前的任务的锁等待会和删除它们释放容量的操作互相阻塞。
+	// Prepare and start the tasks one by one, every task reference is unlocked right after it is started. A later task may wait for capacity in the admission control,
+	// waiting while holding the locks of the previous tasks would block the operations deleting them to release capacity.
+	now := time.Now()
+	for i := range specs {
+		spec := &specs[i]
+		handleFunc, execAt, opts, err := s.resolve(spec, now)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		taskRef, taskID, err := s.prepare(spec.Name, spec.Handler, handleFunc, execAt, opts)
+		ids[i], errs[i] = taskID, err
+		if err != nil {
+			continue
+		}
+
+		// 在任务启动之前通知任务已被添加，重复的任务没有任务引用。
+		// Notify that the task has been added before it starts, duplicated tasks have no task reference.
+		s.notifyAdded(taskID, spec.Name, opts.labels, execAt)
+		if taskRef != nil {
+			s.start(taskRef)
+			taskRef.lock.Unlock()
+		}
+	}
+
+	return ids, errs
+}
+
+// DeleteBatch 是一个方法，用于一次删除多个任务，返回被删除的任务数量，不存在的任务和重复的 ID 会被忽略。
+// 任务引用按照任务缓存的分片批量删除，所有任务先被取消，然后再统一等待它们结束。
+// DeleteBatch is a method used to delete many tasks at once, it returns the number of deleted tasks, missing tasks and repeated IDs are ignored.
+// The task references are deleted in bulk per shard of the task cache, all tasks are canceled first and then waited for together.
+func (s *Scheduler) DeleteBatch(ids []string) int {
+	// 如果调度器没有运行，返回 0。
+	// If the scheduler is not running, return 0.
+	if !s.running.Load() {
+		return 0
+	}
+
+	// 去掉重复的 ID 并排序，并发的 DeleteBatch 按照相同的顺序锁定任务引用，避免死锁。
+	// Remove repeated IDs and sort them, concurrent calls of DeleteBatch lock the task references in the same order to avoid deadlocks.
+	seen := make(map[string]struct{}, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	sort.Strings(unique)
+
+	// 获取并锁定所有任务引用。
+	// Get and lock all task references.
+	taskRefs := make([]*TaskRef, 0, len(unique))
+	keys := make([]string, 0, len(unique))
+	for _, id := range unique {
+		taskRef, err := s.lookup(id)
+		if err != nil {
+			continue
+		}
+		taskRefs = append(taskRefs, taskRef)
+		keys = append(keys, id)
+	}
+
+	// 按照分片批量删除任务引用，然后将它们从其他的缓存中移除并解锁。
+	// Delete the task references in bulk per shard, then remove them from the other caches and unlock them.
+	s.taskCache.DeleteMany(keys)
+	names := make([]string, len(taskRefs))
+	labels := make([]Labels, len(taskRefs))
+	tasks := make([]*Task, len(taskRefs))
+	for i, taskRef := range taskRefs {
+		names[i], labels[i], tasks[i] = taskRef.name, taskRef.labels, taskRef.task
+		s.unlink(taskRef)
+		taskRef.lock.Unlock()
+	}
+
+	// 先取消所有没有被暂停的任务，再等待它们完成。
+	// Cancel all tasks which are not paused first, then wait for them to complete.
+	for _, task := range tasks {
+		if task != nil {
+			task.Cancel()
+		}
+	}
+	for _, task := range tasks {
+		if task != nil {
+			task.Wait()
+		}
+	}
+
+	// 重置任务引用，并通知任务已经被删除。
+	// Reset the task references, and notify that the tasks have been deleted.
+	for i, taskRef := range taskRefs {
+		s.release(taskRef, keys[i], names[i], labels[i])
+	}
+
+	return len(taskRefs)
+}
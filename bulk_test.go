@@ -0,0 +1,194 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_SetBatch(t *testing.T) {
+	callback := &testLabeledCallback{}
+	scheduler := New(NewConfig().WithCallback(callback).WithMaxPendingTasks(5))
+	defer scheduler.Stop()
+
+	var executed atomic.Int32
+	handleFunc := func(WaitForContextDone) (any, error) {
+		executed.Add(1)
+		return nil, nil
+	}
+	scheduler.Registry().Register("registered", handleFunc)
+
+	opts := NewTaskOptions().WithLabels(Labels{"batch": "1"})
+	specs := []TaskSpec{
+		{Name: "a", HandleFunc: handleFunc, Delay: 20 * time.Millisecond, Options: opts},
+		{Name: "b", HandleFunc: handleFunc, ExecAt: time.Now().Add(20 * time.Millisecond), Options: opts},
+		{Name: "c", Handler: "registered", Delay: 20 * time.Millisecond},
+		{Name: "d", Handler: "missing"},
+		{Name: "e", HandleFunc: handleFunc, Delay: time.Hour},
+		{Name: "f", HandleFunc: handleFunc, Delay: time.Hour},
+		{Name: "g", HandleFunc: handleFunc, Delay: time.Hour},
+	}
+	ids, errs := scheduler.SetBatch(specs)
+	assert.Equal(t, len(specs), len(ids))
+	assert.Equal(t, len(specs), len(errs))
+
+	// Every item reports its own outcome, a failed item does not stop the others
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, errs[i])
+		assert.NotEmpty(t, ids[i])
+	}
+	assert.True(t, errors.Is(errs[3], ErrorHandlerNotFound))
+	assert.Empty(t, ids[3])
+	assert.Nil(t, errs[4])
+	assert.Nil(t, errs[5])
+	assert.True(t, errors.Is(errs[6], ErrorMaxPendingTasks))
+	assert.Equal(t, 5, scheduler.Count())
+
+	// The labels and the tasks added are reported once per added task
+	info, err := scheduler.GetInfo(ids[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "1", info.Labels["batch"])
+	callback.lock.Lock()
+	assert.Equal(t, 5, len(callback.added))
+	callback.lock.Unlock()
+	assert.Equal(t, uint64(5), scheduler.Stats().Added)
+
+	// Due tasks run and are removed as usual
+	assert.Eventually(t, func() bool { return executed.Load() == 3 && scheduler.Count() == 2 }, time.Second, time.Millisecond)
+}
+
+func TestScheduler_SetBatchEventOrder(t *testing.T) {
+	scheduler := New(NewConfig().WithMaxPendingTasks(1))
+	defer scheduler.Stop()
+	events, unsubscribe := scheduler.Subscribe(64)
+	defer unsubscribe()
+
+	// Zero-delay tasks fire right away, every later task waits for the capacity released by the previous one,
+	// so every task executes while the batch is still being added, yet it is reported as added before it is reported as executed
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	specs := make([]TaskSpec, 10)
+	for i := range specs {
+		specs[i] = TaskSpec{Name: fmt.Sprintf("task-%d", i), HandleFunc: DefaultTaskHandleFunc, Options: NewTaskOptions().WithWaitForCapacity(ctx)}
+	}
+	ids, errs := scheduler.SetBatch(specs)
+	for _, err := range errs {
+		assert.Nil(t, err)
+	}
+
+	added := make(map[string]bool, len(ids))
+	executed := 0
+	timeout := time.After(time.Second)
+	for executed < len(ids) {
+		select {
+		case event := <-events:
+			switch event.Type {
+			case EventTaskAdded:
+				added[event.ID] = true
+			case EventTaskExecuted:
+				assert.True(t, added[event.ID], event.ID)
+				executed++
+			}
+		case <-timeout:
+			t.Fatalf("only %d of %d tasks executed", executed, len(ids))
+		}
+	}
+}
+
+func TestScheduler_SetBatchUniqued(t *testing.T) {
+	scheduler := New(NewConfig().WithUniqued(true))
+	defer scheduler.Stop()
+
+	existingID, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// Duplicated tasks return the existing ID, inside the batch too
+	ids, errs := scheduler.SetBatch([]TaskSpec{{Name: "a", Delay: time.Hour}, {Name: "b", Delay: time.Hour}, {Name: "b", Delay: time.Hour}})
+	assert.Equal(t, []error{nil, nil, nil}, errs)
+	assert.Equal(t, existingID, ids[0])
+	assert.Equal(t, ids[1], ids[2])
+	assert.Equal(t, 2, scheduler.Count())
+	assert.Equal(t, uint64(2), scheduler.Stats().Duplicated)
+
+	// Nothing is added once the scheduler is stopped
+	scheduler.Stop()
+	ids, errs = scheduler.SetBatch([]TaskSpec{{Name: "c"}})
+	assert.Equal(t, []string{""}, ids)
+	assert.True(t, errors.Is(errs[0], ErrorSchedulerNotRunning))
+}
+
+func TestScheduler_SetBatchWaitForCapacity(t *testing.T) {
+	scheduler := New(NewConfig().WithMaxPendingTasks(1))
+	defer scheduler.Stop()
+
+	// The second task waits for the capacity taken by the first one, the first task is started and can be deleted meanwhile
+	go func() {
+		assert.Eventually(t, func() bool {
+			_, err := scheduler.GetInfo("first")
+			return err == nil
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 1, scheduler.DeleteBatch([]string{"first"}))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ids, errs := scheduler.SetBatch([]TaskSpec{
+		{Name: "a", Delay: time.Hour, Options: NewTaskOptions().WithID("first")},
+		{Name: "b", Delay: time.Hour, Options: NewTaskOptions().WithWaitForCapacity(ctx)},
+	})
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, "first", ids[0])
+	_, err := scheduler.GetInfo(ids[1])
+	assert.Nil(t, err)
+	assert.Equal(t, 1, scheduler.Count())
+}
+
+func TestScheduler_DeleteBatch(t *testing.T) {
+	callback := &testLabeledCallback{}
+	scheduler := New(NewConfig().WithCallback(callback))
+	defer scheduler.Stop()
+
+	specs := make([]TaskSpec, 100)
+	for i := range specs {
+		specs[i] = TaskSpec{Name: fmt.Sprint(i), Delay: time.Hour}
+	}
+	ids, _ := scheduler.SetBatch(specs)
+	assert.Equal(t, 100, scheduler.Count())
+
+	// Paused tasks are deleted too
+	assert.Nil(t, scheduler.Pause(ids[0]))
+
+	// Missing and repeated IDs are ignored
+	deleted := scheduler.DeleteBatch(append(ids[:50:50], ids[0], "missing"))
+	assert.Equal(t, 50, deleted)
+	assert.Equal(t, 50, scheduler.Count())
+	_, err := scheduler.GetInfo(ids[0])
+	assert.True(t, errors.Is(err, ErrorTaskNotFound))
+	_, err = scheduler.GetInfo(ids[50])
+	assert.Nil(t, err)
+
+	callback.lock.Lock()
+	assert.Equal(t, 50, len(callback.removed))
+	callback.lock.Unlock()
+	assert.Equal(t, uint64(50), scheduler.Stats().Removed)
+
+	// Concurrent deletions of overlapping IDs delete every task once
+	rest := ids[50:]
+	reversed := make([]string, len(rest))
+	for i, id := range rest {
+		reversed[len(rest)-1-i] = id
+	}
+	results := make(chan int, 2)
+	go func() { results <- scheduler.DeleteBatch(rest) }()
+	go func() { results <- scheduler.DeleteBatch(reversed) }()
+	assert.Equal(t, 50, <-results+<-results)
+	assert.Equal(t, 0, scheduler.Count())
+
+	scheduler.Stop()
+	assert.Equal(t, 0, scheduler.DeleteBatch(ids))
+}
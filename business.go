@@ -0,0 +1,93 @@
+package kairos
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorNoBusinessTime 表示工作日历中没有工作时间，无法计算执行时间
+// ErrorNoBusinessTime indicates the business calendar has no business time, so the execution time can not be calculated
+var ErrorNoBusinessTime = errors.New("no business time in calendar")
+
+// BusinessCalendar 是一个接口，用于将工作时间的延迟转换为具体的执行时间，calendar 子包中的 Calendar 实现了它
+// BusinessCalendar is an interface used to convert a delay in business time into a concrete execution time, the Calendar in the calendar subpackage implements it
+type BusinessCalendar interface {
+	// Add 返回从 from 开始经过 d 的工作时间之后的时间，没有工作时间时返回零值
+	// Add returns the time after d of business time has elapsed since from, a zero value is returned if there is no business time
+	Add(from time.Time, d time.Duration) time.Time
+
+	// Watch 注册一个在日历被修改之后调用的函数，返回取消注册的函数。函数被调用时不能持有日历的锁
+	// Watch registers a function called after the calendar is modified, it returns the function to unregister. The function must be called without holding the lock of the calendar
+	Watch(fn func()) func()
+}
+
+// business 结构体记录使用工作时间延迟添加的任务的延迟，日历被修改时用于重新计算执行时间
+// The business struct records the delay of a task added with a business delay, it is used to recalculate the execution time when the calendar is modified
+type business struct {
+	// calendar 是计算执行时间的工作日历
+	// calendar is the business calendar calculating the execution time
+	calendar BusinessCalendar
+
+	// from 是开始计算延迟的时间
+	// from is the time the delay is counted from
+	from time.Time
+
+	// delay 是工作时间的延迟
+	// delay is the delay in business time
+	delay time.Duration
+
+	// unwatch 取消对日历的监视
+	// unwatch stops watching the calendar
+	unwatch func()
+}
+
+// SetBusinessDelay 是一个方法，用于在经过指定的工作时间之后执行任务，例如 "4 个工作小时之后"。执行时间由日历计算，节假日和工作时间之外的时间不计算在内，
+// 任务默认使用墙上时钟。日历被修改时，等待中的任务的执行时间会被重新计算。日历中没有工作时间时返回 ErrorNoBusinessTime。
+// SetBusinessDelay is a method used to execute tasks after the specified business time has elapsed, such as "after 4 business hours". The execution time is calculated by the calendar, holidays and the time outside business hours are not counted,
+// the task uses the wall clock by default. When the calendar is modified, the execution time of the pending task is recalculated. ErrorNoBusinessTime is returned if the calendar has no business time.
+func (s *Scheduler) SetBusinessDelay(name string, handleFunc TaskHandleFunc, delay time.Duration, calendar BusinessCalendar) (string, error) {
+	return s.SetBusinessDelayWithOptions(name, handleFunc, delay, calendar, nil)
+}
+
+// SetBusinessDelayWithOptions 是一个方法，用于在经过指定的工作时间之后执行任务，并使用给定的任务选项。
+// SetBusinessDelayWithOptions is a method used to execute tasks after the specified business time has elapsed with the given task options.
+func (s *Scheduler) SetBusinessDelayWithOptions(name string, handleFunc TaskHandleFunc, delay time.Duration, calendar BusinessCalendar, opts *TaskOptions) (string, error) {
+	// 使用日历计算执行时间。
+	// Calculate the execution time with the calendar.
+	from := time.Now()
+	execAt := calendar.Add(from, delay)
+	if execAt.IsZero() {
+		return "", ErrorNoBusinessTime
+	}
+
+	// 复制任务选项并记录工作时间的延迟，没有设置时钟时使用墙上时钟。
+	// Copy the task options and record the business delay, the wall clock is used if no clock is set.
+	copied := *isTaskOptionsValid(opts)
+	copied.business = &business{calendar: calendar, from: from, delay: delay}
+	if !copied.clockSet {
+		copied.clockMode, copied.clockSet = ClockWall, true
+	}
+
+	return s.SetAtWithOptions(name, handleFunc, execAt, &copied)
+}
+
+// recalculate 是一个方法，在日历被修改之后重新计算任务的执行时间，执行时间改变时重新调度任务。
+// 任务已经被替换、已经触发或者日历中没有工作时间时不做任何操作
+// recalculate is a method recalculating the execution time of a task after the calendar is modified, the task is rescheduled if the execution time changes.
+// It does nothing if the task has been replaced or has fired, or the calendar has no business time
+func (s *Scheduler) recalculate(id string, b *business) {
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return
+	}
+	defer taskRef.lock.Unlock()
+
+	if taskRef.business != b {
+		return
+	}
+	execAt := b.calendar.Add(b.from, b.delay)
+	if execAt.IsZero() || execAt.Equal(taskRef.execAt) {
+		return
+	}
+	_ = s.reschedule(taskRef, execAt)
+}
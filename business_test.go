@@ -0,0 +1,152 @@
+package kairos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/kairos/calendar"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBusinessCalendar struct {
+	lock     sync.Mutex
+	extra    time.Duration
+	closed   bool
+	watchers map[int]func()
+	next     int
+}
+
+func newTestBusinessCalendar() *testBusinessCalendar {
+	return &testBusinessCalendar{watchers: make(map[int]func())}
+}
+
+func (c *testBusinessCalendar) Add(from time.Time, d time.Duration) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return time.Time{}
+	}
+	return from.Add(d + c.extra)
+}
+
+func (c *testBusinessCalendar) Watch(fn func()) func() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	id := c.next
+	c.next++
+	c.watchers[id] = fn
+	return func() {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		delete(c.watchers, id)
+	}
+}
+
+func (c *testBusinessCalendar) set(extra time.Duration, closed bool) {
+	c.lock.Lock()
+	c.extra, c.closed = extra, closed
+	watchers := make([]func(), 0, len(c.watchers))
+	for _, fn := range c.watchers {
+		watchers = append(watchers, fn)
+	}
+	c.lock.Unlock()
+	for _, fn := range watchers {
+		fn()
+	}
+}
+
+func (c *testBusinessCalendar) watching() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.watchers)
+}
+
+func TestScheduler_SetBusinessDelay(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	var count atomic.Int32
+	handleFunc := func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}
+
+	// A calendar without business time is rejected
+	cal := newTestBusinessCalendar()
+	cal.set(0, true)
+	_, err := scheduler.SetBusinessDelay("closed", handleFunc, time.Hour, cal)
+	assert.ErrorIs(t, err, ErrorNoBusinessTime)
+
+	// The execution time follows modifications of the calendar
+	cal.set(time.Hour, false)
+	id, err := scheduler.SetBusinessDelay("sla", handleFunc, time.Millisecond*100, cal)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cal.watching())
+	info, err := scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	before := info.ExecAt
+
+	cal.set(0, false)
+	info, err = scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Hour, before.Sub(info.ExecAt))
+
+	// A calendar without business time keeps the current execution time
+	cal.set(0, true)
+	info, err = scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	assert.Equal(t, before.Add(-time.Hour), info.ExecAt)
+	cal.set(0, false)
+
+	// The task keeps its ID, fires and stops watching the calendar
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond*10)
+	assert.Eventually(t, func() bool { return cal.watching() == 0 }, time.Second, time.Millisecond*10)
+}
+
+func TestScheduler_SetBusinessDelayReschedule(t *testing.T) {
+	scheduler := New(nil)
+	cal := newTestBusinessCalendar()
+	handleFunc := func(_ WaitForContextDone) (any, error) { return nil, nil }
+
+	// An explicit execution time overrides the business delay
+	id, err := scheduler.SetBusinessDelay("sla", handleFunc, time.Hour, cal)
+	assert.Nil(t, err)
+	execAt := time.Now().Add(time.Hour * 2)
+	assert.Nil(t, scheduler.Reschedule(id, execAt))
+	assert.Equal(t, 0, cal.watching())
+	cal.set(time.Hour, false)
+	info, err := scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	assert.True(t, execAt.Equal(info.ExecAt))
+
+	// Stopping the scheduler stops watching the calendar
+	_, err = scheduler.SetBusinessDelay("other", handleFunc, time.Hour, cal)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cal.watching())
+	scheduler.Stop()
+	assert.Equal(t, 0, cal.watching())
+}
+
+func TestScheduler_SetBusinessDelayCalendar(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	cal := calendar.NewCalendar(time.UTC)
+	assert.Nil(t, cal.SetWeekdayHours(calendar.Window{Start: time.Hour * 9, End: time.Hour * 17}))
+
+	// Adding a holiday on the computed day moves the task to the next business day
+	id, err := scheduler.SetBusinessDelay("sla", func(_ WaitForContextDone) (any, error) { return nil, nil }, time.Hour*4, cal)
+	assert.Nil(t, err)
+	info, err := scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	first := info.ExecAt
+	assert.True(t, cal.IsBusinessTime(first.Add(-time.Minute)))
+
+	cal.AddHoliday(first.In(time.UTC), "closed")
+	info, err = scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	assert.True(t, info.ExecAt.After(first))
+	assert.False(t, cal.IsHoliday(info.ExecAt))
+}
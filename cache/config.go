@@ -0,0 +1,226 @@
+package cache
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// DefaultShardCount 是默认的分片数量
+// DefaultShardCount is the default number of shards
+const DefaultShardCount = 1 << 8
+
+// HashFunc 是一个函数类型，它计算键的哈希值，用于选择键所在的分片
+// HashFunc is a function type that calculates the hash value of a key, used to select the shard of the key
+type HashFunc[K comparable] func(key K) uint64
+
+// EvictionReason 是键值对被淘汰的原因
+// EvictionReason is the reason why a key-value pair is evicted
+type EvictionReason int
+
+// 定义键值对被淘汰的原因
+// Define the reasons why a key-value pair is evicted
+const (
+	// EvictionExpired 表示键值对已经过期
+	// EvictionExpired indicates the key-value pair has expired
+	EvictionExpired EvictionReason = iota + 1
+
+	// EvictionCapacity 表示键值对因为超过最大数量，作为最久未使用的键值对被淘汰
+	// EvictionCapacity indicates the key-value pair was evicted as the least recently used one because the maximum number was exceeded
+	EvictionCapacity
+)
+
+// String 方法返回淘汰原因的字符串形式
+// The String method returns the string form of the eviction reason
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictionExpired:
+		return "expired"
+	case EvictionCapacity:
+		return "capacity"
+	}
+	return "unknown"
+}
+
+// EvictionFunc 是一个函数类型，在键值对因为过期或者容量被淘汰时调用，调用时不持有分片的锁。显式的删除和覆盖不会调用它
+// EvictionFunc is a function type called when a key-value pair is evicted because of expiration or capacity, it is called without holding the lock of the shard. Explicit deletions and overwrites do not call it
+type EvictionFunc[K comparable, V any] func(key K, value V, reason EvictionReason)
+
+// Config 结构体定义了缓存的配置
+// The Config struct defines the configuration of the cache
+type Config[K comparable, V any] struct {
+	// shardCount 是分片的数量，New 会把它向上取整到 2 的幂
+	// shardCount is the number of shards, New rounds it up to a power of two
+	shardCount int
+
+	// hashFunc 是计算键的哈希值的函数
+	// hashFunc is the function calculating the hash value of a key
+	hashFunc HashFunc[K]
+
+	// ttl 是 Set 使用的默认存活时间，为 0 表示永不过期
+	// ttl is the default time to live used by Set, 0 means never expire
+	ttl time.Duration
+
+	// maxEntries 是键值对的最大数量，为 0 表示不限制
+	// maxEntries is the maximum number of key-value pairs, 0 means unlimited
+	maxEntries int
+
+	// onEvict 是键值对被淘汰时的回调函数
+	// onEvict is the callback function when a key-value pair is evicted
+	onEvict EvictionFunc[K, V]
+
+	// cleanupInterval 是后台清理过期键值对的间隔，为 0 表示只在访问时清理
+	// cleanupInterval is the interval of the background cleanup of expired key-value pairs, 0 means they are only cleaned up on access
+	cleanupInterval time.Duration
+}
+
+// NewConfig 函数用于创建一个新的 Config 实例
+// The NewConfig function is used to create a new instance of Config
+func NewConfig[K comparable, V any]() *Config[K, V] {
+	return &Config[K, V]{
+		shardCount: DefaultShardCount,
+		hashFunc:   DefaultHashFunc[K],
+	}
+}
+
+// DefaultConfig 函数用于获取默认的 Config 实例
+// The DefaultConfig function is used to get the default instance of Config
+func DefaultConfig[K comparable, V any]() *Config[K, V] {
+	return NewConfig[K, V]()
+}
+
+// WithShardCount 方法用于设置分片的数量。分片按照哈希值的低位选择，因此不是 2 的幂的数量会被向上取整到 2 的幂（例如 100 变为 128），
+// 小于 1 的数量会使用默认值，WithMaxEntries 还可能减少分片的数量。创建的缓存实际使用的数量由 Cache.ShardCount 返回
+// The WithShardCount method is used to set the number of shards. Shards are selected by the low bits of the hash value, so a count that is not a power of two is rounded up to the next power of two (for example 100 becomes 128),
+// a count less than 1 falls back to the default, and WithMaxEntries may reduce the number of shards. Cache.ShardCount returns the number actually used by the created cache
+func (c *Config[K, V]) WithShardCount(count int) *Config[K, V] {
+	c.shardCount = count
+	return c
+}
+
+// WithHashFunc 方法用于设置计算键的哈希值的函数
+// The WithHashFunc method is used to set the function calculating the hash value of a key
+func (c *Config[K, V]) WithHashFunc(fn HashFunc[K]) *Config[K, V] {
+	c.hashFunc = fn
+	return c
+}
+
+// WithTTL 方法用于设置 Set 使用的默认存活时间，不大于 0 表示永不过期
+// The WithTTL method is used to set the default time to live used by Set, not greater than 0 means never expire
+func (c *Config[K, V]) WithTTL(ttl time.Duration) *Config[K, V] {
+	c.ttl = ttl
+	return c
+}
+
+// WithMaxEntries 方法用于设置键值对的最大数量，超过时淘汰最久未使用的键值对，不大于 0 表示不限制。
+// 数量按分片限制，maxEntries 被分配给各个分片，它们的限制之和正好等于 maxEntries，因此键值对的总数不会超过它；
+// 一个分片满了之后即使其他分片还有空间也会淘汰，分片数量超过 maxEntries 时会被减少
+// The WithMaxEntries method is used to set the maximum number of key-value pairs, the least recently used ones are evicted when it is exceeded, not greater than 0 means unlimited.
+// The number is limited per shard, maxEntries is split among the shards so their limits add up to exactly maxEntries and the total number of pairs never exceeds it;
+// a full shard evicts even if other shards still have room, the number of shards is reduced if it exceeds maxEntries
+func (c *Config[K, V]) WithMaxEntries(max int) *Config[K, V] {
+	c.maxEntries = max
+	return c
+}
+
+// WithEvictionFunc 方法用于设置键值对因为过期或者容量被淘汰时的回调函数
+// The WithEvictionFunc method is used to set the callback function when a key-value pair is evicted because of expiration or capacity
+func (c *Config[K, V]) WithEvictionFunc(fn EvictionFunc[K, V]) *Config[K, V] {
+	c.onEvict = fn
+	return c
+}
+
+// WithCleanupInterval 方法用于设置后台清理过期键值对的间隔，不大于 0 表示不启动后台清理，过期的键值对只在访问时删除。
+// 启动后台清理的缓存在不再使用时需要调用 Close 方法
+// The WithCleanupInterval method is used to set the interval of the background cleanup of expired key-value pairs, not greater than 0 means no background cleanup and expired pairs are only deleted on access.
+// A cache with background cleanup must be closed with the Close method when it is no longer used
+func (c *Config[K, V]) WithCleanupInterval(interval time.Duration) *Config[K, V] {
+	c.cleanupInterval = interval
+	return c
+}
+
+// isConfigValid 函数用于检查 Config 实例是否有效，无效的字段会被设置为默认值
+// The isConfigValid function is used to check if the instance of Config is valid, invalid fields are set to the default values
+func isConfigValid[K comparable, V any](conf *Config[K, V]) *Config[K, V] {
+	if conf != nil {
+		// 分片数量必须是正数，不是 2 的幂的数量被向上取整，而不是被拒绝
+		// The number of shards must be positive, a count that is not a power of two is rounded up instead of being rejected
+		if conf.shardCount < 1 {
+			conf.shardCount = DefaultShardCount
+		} else {
+			conf.shardCount = nextPowerOfTwo(conf.shardCount)
+		}
+
+		// 哈希函数不能为 nil
+		// The hash function can not be nil
+		if conf.hashFunc == nil {
+			conf.hashFunc = DefaultHashFunc[K]
+		}
+
+		// 最大数量不大于 0 表示不限制，分片数量不能超过最大数量
+		// A maximum number not greater than 0 means unlimited, the number of shards can not exceed the maximum number
+		if conf.maxEntries < 0 {
+			conf.maxEntries = 0
+		}
+		for conf.maxEntries > 0 && conf.shardCount > conf.maxEntries {
+			conf.shardCount >>= 1
+		}
+	} else {
+		conf = DefaultConfig[K, V]()
+	}
+
+	return conf
+}
+
+// nextPowerOfTwo 函数返回大于等于 n 的最小的 2 的幂
+// The nextPowerOfTwo function returns the smallest power of two greater than or equal to n
+func nextPowerOfTwo(n int) int {
+	power := 1
+	for power < n {
+		power <<= 1
+	}
+	return power
+}
+
+// DefaultHashFunc 函数是默认的哈希函数，字符串和整数类型的键直接使用 xxhash 计算，其他类型的键使用它们的 Go 语法表示计算
+// The DefaultHashFunc function is the default hash function, keys of string and integer types are hashed with xxhash directly, keys of other types are hashed by their Go-syntax representation
+func DefaultHashFunc[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return xxhash.Sum64String(k)
+	case int:
+		return hashUint64(uint64(k))
+	case int8:
+		return hashUint64(uint64(k))
+	case int16:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint8:
+		return hashUint64(uint64(k))
+	case uint16:
+		return hashUint64(uint64(k))
+	case uint32:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	case uintptr:
+		return hashUint64(uint64(k))
+	default:
+		return xxhash.Sum64String(fmt.Sprintf("%#v", key))
+	}
+}
+
+// hashUint64 函数计算一个整数的哈希值
+// The hashUint64 function calculates the hash value of an integer
+func hashUint64(n uint64) uint64 {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], n)
+	return xxhash.Sum64(buf[:])
+}
@@ -0,0 +1,583 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// entry 结构体是分片中的一个键值对，包含过期时间和 LRU 链表的指针
+// The entry struct is one key-value pair in a shard, it contains the expiration time and the pointers of the LRU list
+type entry[K comparable, V any] struct {
+	// key 是键
+	// key is the key
+	key K
+
+	// value 是值
+	// value is the value
+	value V
+
+	// expireAt 是过期时间的 Unix 纳秒数，为 0 表示永不过期
+	// expireAt is the expiration time in Unix nanoseconds, 0 means it never expires
+	expireAt int64
+
+	// prev 和 next 是 LRU 链表中的前一个和后一个元素，只有限制了最大数量时才使用
+	// prev and next are the previous and next elements in the LRU list, only used when the maximum number of entries is limited
+	prev, next *entry[K, V]
+}
+
+// expired 方法返回键值对在给定时间是否已经过期
+// The expired method returns whether the key-value pair has expired at the given time
+func (e *entry[K, V]) expired(now int64) bool {
+	return e.expireAt > 0 && now >= e.expireAt
+}
+
+// segmentOptions 结构体是分片的选项
+// The segmentOptions struct is the options of a shard
+type segmentOptions[K comparable, V any] struct {
+	// ttl 是默认的存活时间，为 0 表示永不过期
+	// ttl is the default time to live, 0 means never expire
+	ttl time.Duration
+
+	// maxEntries 是分片中键值对的最大数量，为 0 表示不限制
+	// maxEntries is the maximum number of key-value pairs in the shard, 0 means unlimited
+	maxEntries int
+
+	// onEvict 是键值对因为过期或者容量被淘汰时的回调函数
+	// onEvict is the callback function when a key-value pair is evicted because of expiration or capacity
+	onEvict EvictionFunc[K, V]
+}
+
+// Segment 结构体是缓存中的一个分片，它使用读写锁保护自己的 map，读操作之间不会互相阻塞。
+// 限制了最大数量时，Get 需要更新 LRU 链表，会使用写锁
+// The Segment struct is one shard of the cache, it protects its own map with a read-write lock so reads do not block each other.
+// When the maximum number of entries is limited, Get updates the LRU list and takes the write lock
+type Segment[K comparable, V any] struct {
+	// lock 是一个读写锁，用于在多个 goroutine 之间同步对 storage 的访问
+	// lock is a read-write lock used to synchronize access to storage across multiple goroutines
+	lock sync.RWMutex
+
+	// storage 是一个 map，用于存储键值对
+	// storage is a map used to store key-value pairs
+	storage map[K]*entry[K, V]
+
+	// root 是 LRU 链表的哨兵，root.next 是最近使用的元素，root.prev 是最久未使用的元素
+	// root is the sentinel of the LRU list, root.next is the most recently used element and root.prev is the least recently used element
+	root entry[K, V]
+
+	// size 是 storage 中键值对的数量，在持有写锁时更新，可以不加锁读取
+	// size is the number of key-value pairs in storage, it is updated while holding the write lock and can be read without locking
+	size atomic.Int64
+
+	// opts 是分片的选项
+	// opts are the options of the shard
+	opts segmentOptions[K, V]
+}
+
+// pair 结构体是从分片中复制出来的键值对
+// The pair struct is a key-value pair copied out of a shard
+type pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// eviction 结构体是一个被淘汰的键值对，回调函数在释放锁之后调用
+// The eviction struct is an evicted key-value pair, the callback function is called after the lock is released
+type eviction[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictionReason
+}
+
+// NewSegment 函数创建并返回一个新的 Segment 实例，它的键值对永不过期，数量也不受限制
+// The NewSegment function creates and returns a new Segment instance, its key-value pairs never expire and their number is unlimited
+func NewSegment[K comparable, V any]() *Segment[K, V] {
+	return newSegment(segmentOptions[K, V]{})
+}
+
+// newSegment 函数根据选项创建一个新的 Segment 实例
+// The newSegment function creates a new Segment instance according to the options
+func newSegment[K comparable, V any](opts segmentOptions[K, V]) *Segment[K, V] {
+	s := &Segment[K, V]{
+		// 创建一个新的 map
+		// Create a new map
+		storage: make(map[K]*entry[K, V]),
+		opts:    opts,
+	}
+
+	// 初始化 LRU 链表
+	// Initialize the LRU list
+	s.root.next = &s.root
+	s.root.prev = &s.root
+
+	return s
+}
+
+// lru 方法返回分片是否限制了最大数量，需要维护 LRU 链表
+// The lru method returns whether the shard limits the maximum number of entries and needs to maintain the LRU list
+func (s *Segment[K, V]) lru() bool {
+	return s.opts.maxEntries > 0
+}
+
+// expireAt 方法根据存活时间计算过期时间，存活时间不大于 0 表示永不过期
+// The expireAt method calculates the expiration time from the time to live, a time to live not greater than 0 means never expire
+func expireAt(now int64, ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return now + int64(ttl)
+}
+
+// lookup 方法返回未过期的键值对，已经过期的键值对会被删除并加入 evicted，调用者需要持有写锁
+// The lookup method returns the unexpired key-value pair, an expired one is deleted and appended to evicted, the caller must hold the write lock
+func (s *Segment[K, V]) lookup(key K, now int64, evicted *[]eviction[K, V]) (*entry[K, V], bool) {
+	e, ok := s.storage[key]
+	if !ok {
+		return nil, false
+	}
+	if e.expired(now) {
+		s.remove(e)
+		*evicted = append(*evicted, eviction[K, V]{key: e.key, value: e.value, reason: EvictionExpired})
+		return nil, false
+	}
+	return e, true
+}
+
+// store 方法保存一个键值对，超过最大数量时淘汰最久未使用的键值对，调用者需要持有写锁
+// The store method stores a key-value pair, the least recently used one is evicted when the maximum number is exceeded, the caller must hold the write lock
+func (s *Segment[K, V]) store(key K, value V, expire int64, evicted *[]eviction[K, V]) {
+	// 如果键已经存在，更新它的值和过期时间
+	// If the key already exists, update its value and expiration time
+	if e, ok := s.storage[key]; ok {
+		e.value = value
+		e.expireAt = expire
+		s.touch(e)
+		return
+	}
+
+	// 保存新的键值对
+	// Store the new key-value pair
+	e := &entry[K, V]{key: key, value: value, expireAt: expire}
+	s.storage[key] = e
+	s.size.Add(1)
+	if !s.lru() {
+		return
+	}
+	s.pushFront(e)
+
+	// 超过最大数量时，从链表尾部淘汰最久未使用的键值对
+	// When the maximum number is exceeded, evict the least recently used key-value pairs from the tail of the list
+	for len(s.storage) > s.opts.maxEntries {
+		oldest := s.root.prev
+		s.remove(oldest)
+		*evicted = append(*evicted, eviction[K, V]{key: oldest.key, value: oldest.value, reason: EvictionCapacity})
+	}
+}
+
+// remove 方法删除一个键值对，调用者需要持有写锁
+// The remove method deletes a key-value pair, the caller must hold the write lock
+func (s *Segment[K, V]) remove(e *entry[K, V]) {
+	delete(s.storage, e.key)
+	s.size.Add(-1)
+	if s.lru() {
+		e.prev.next = e.next
+		e.next.prev = e.prev
+		e.prev, e.next = nil, nil
+	}
+}
+
+// pushFront 方法将键值对插入 LRU 链表的头部，调用者需要持有写锁
+// The pushFront method inserts the key-value pair at the head of the LRU list, the caller must hold the write lock
+func (s *Segment[K, V]) pushFront(e *entry[K, V]) {
+	e.prev = &s.root
+	e.next = s.root.next
+	s.root.next.prev = e
+	s.root.next = e
+}
+
+// touch 方法将键值对移动到 LRU 链表的头部，调用者需要持有写锁
+// The touch method moves the key-value pair to the head of the LRU list, the caller must hold the write lock
+func (s *Segment[K, V]) touch(e *entry[K, V]) {
+	if !s.lru() || s.root.next == e {
+		return
+	}
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	s.pushFront(e)
+}
+
+// notify 方法在释放锁之后调用淘汰回调函数
+// The notify method calls the eviction callback function after the lock is released
+func (s *Segment[K, V]) notify(evicted []eviction[K, V]) {
+	if s.opts.onEvict == nil {
+		return
+	}
+	for _, ev := range evicted {
+		s.opts.onEvict(ev.key, ev.value, ev.reason)
+	}
+}
+
+// Get 方法根据给定的键从 storage 中获取值，已经过期的键值对会被删除
+// The Get method gets the value from storage based on the given key, an expired key-value pair is deleted
+func (s *Segment[K, V]) Get(key K) (V, bool) {
+	var zero V
+	now := time.Now().UnixNano()
+
+	// 没有限制最大数量时，先使用读锁查找，读操作之间不会互相阻塞
+	// When the maximum number is not limited, look up with the read lock first, reads do not block each other
+	if !s.lru() {
+		s.lock.RLock()
+		e, ok := s.storage[key]
+		if !ok {
+			s.lock.RUnlock()
+			return zero, false
+		}
+		if !e.expired(now) {
+			value := e.value
+			s.lock.RUnlock()
+			return value, true
+		}
+		s.lock.RUnlock()
+	}
+
+	// 需要更新 LRU 链表或者删除过期的键值对时，使用写锁
+	// Take the write lock when the LRU list must be updated or the expired key-value pair must be deleted
+	var evicted []eviction[K, V]
+	s.lock.Lock()
+	e, ok := s.lookup(key, now, &evicted)
+	if ok {
+		s.touch(e)
+		zero = e.value
+	}
+	s.lock.Unlock()
+	s.notify(evicted)
+
+	// 返回获取到的值和一个布尔值，该布尔值表示是否找到了该键
+	// Return the obtained value and a boolean value, which indicates whether the key was found
+	return zero, ok
+}
+
+// Set 方法将给定的键值对设置到 storage 中，使用默认的存活时间
+// The Set method sets the given key-value pair to storage with the default time to live
+func (s *Segment[K, V]) Set(key K, value V) {
+	s.SetWithTTL(key, value, s.opts.ttl)
+}
+
+// SetWithTTL 方法将给定的键值对设置到 storage 中，并指定存活时间，ttl 不大于 0 表示永不过期
+// The SetWithTTL method sets the given key-value pair to storage with the specified time to live, a ttl not greater than 0 means never expire
+func (s *Segment[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	s.store(key, value, expireAt(now, ttl), &evicted)
+	s.lock.Unlock()
+	s.notify(evicted)
+}
+
+// Touch 方法重新设置已有键值对的存活时间，ttl 不大于 0 表示永不过期，返回键是否存在
+// The Touch method resets the time to live of an existing key-value pair, a ttl not greater than 0 means never expire, it returns whether the key exists
+func (s *Segment[K, V]) Touch(key K, ttl time.Duration) bool {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	e, ok := s.lookup(key, now, &evicted)
+	if ok {
+		e.expireAt = expireAt(now, ttl)
+		s.touch(e)
+	}
+	s.lock.Unlock()
+	s.notify(evicted)
+
+	return ok
+}
+
+// Delete 方法从 storage 中删除给定的键
+// The Delete method deletes the given key from storage
+func (s *Segment[K, V]) Delete(key K) {
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 从 storage 中删除键
+	// Delete the key from storage
+	if e, ok := s.storage[key]; ok {
+		s.remove(e)
+	}
+}
+
+// SetMany 方法在一次加锁中将给定的键值对设置到 storage 中，使用默认的存活时间，keys 和 values 的长度必须相同
+// The SetMany method sets the given key-value pairs to storage under a single lock with the default time to live, keys and values must have the same length
+func (s *Segment[K, V]) SetMany(keys []K, values []V) {
+	var evicted []eviction[K, V]
+	expire := expireAt(time.Now().UnixNano(), s.opts.ttl)
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	for i, key := range keys {
+		s.store(key, values[i], expire, &evicted)
+	}
+	s.lock.Unlock()
+	s.notify(evicted)
+}
+
+// DeleteMany 方法在一次加锁中从 storage 中删除给定的键，返回被删除的键的数量
+// The DeleteMany method deletes the given keys from storage under a single lock, it returns the number of deleted keys
+func (s *Segment[K, V]) DeleteMany(keys []K) int {
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if e, ok := s.storage[key]; ok {
+			s.remove(e)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// GetOrSet 方法在键存在时返回已有的值，否则设置给定的值并返回它，loaded 表示值是否已经存在
+// The GetOrSet method returns the existing value if the key exists, otherwise it sets and returns the given value, loaded reports whether the value already existed
+func (s *Segment[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+	defer func() { s.notify(evicted) }()
+
+	// 加锁以同步访问，检查和设置在同一个临界区内完成
+	// Lock to synchronize access, the check and the set happen in the same critical section
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 如果键已经存在，返回已有的值
+	// If the key already exists, return the existing value
+	if e, ok := s.lookup(key, now, &evicted); ok {
+		s.touch(e)
+		return e.value, true
+	}
+
+	// 设置给定的值
+	// Set the given value
+	s.store(key, value, expireAt(now, s.opts.ttl), &evicted)
+	return value, false
+}
+
+// CompareAndSwap 方法在键存在并且当前值等于 old 时将值替换为 new，返回是否替换成功。值的类型必须是可比较的，否则会 panic
+// The CompareAndSwap method replaces the value with new if the key exists and its current value equals old, it returns whether the swap happened. The type of the value must be comparable, otherwise it panics
+func (s *Segment[K, V]) CompareAndSwap(key K, old, new V) bool {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+	defer func() { s.notify(evicted) }()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 如果键不存在或者当前值不等于 old，不做替换
+	// If the key does not exist or its current value does not equal old, do not swap
+	if e, ok := s.lookup(key, now, &evicted); !ok || any(e.value) != any(old) {
+		return false
+	}
+
+	// 替换为新的值
+	// Replace with the new value
+	s.store(key, new, expireAt(now, s.opts.ttl), &evicted)
+	return true
+}
+
+// CompareAndDelete 方法在键存在并且当前值等于 old 时删除该键，返回是否删除成功。值的类型必须是可比较的，否则会 panic
+// The CompareAndDelete method deletes the key if it exists and its current value equals old, it returns whether the deletion happened. The type of the value must be comparable, otherwise it panics
+func (s *Segment[K, V]) CompareAndDelete(key K, old V) bool {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+	defer func() { s.notify(evicted) }()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 如果键不存在或者当前值不等于 old，不做删除
+	// If the key does not exist or its current value does not equal old, do not delete
+	e, ok := s.lookup(key, now, &evicted)
+	if !ok || any(e.value) != any(old) {
+		return false
+	}
+
+	// 删除该键
+	// Delete the key
+	s.remove(e)
+	return true
+}
+
+// Compute 方法在持有锁的情况下使用键的当前值调用 fn，fn 返回新的值以及是否保留它，keep 为 false 时键会被删除。
+// 方法返回新的值和它是否被保存。fn 不能访问同一个缓存，否则会死锁
+// The Compute method calls fn with the current value of the key while holding the lock, fn returns the new value and whether to keep it, the key is deleted if keep is false.
+// The method returns the new value and whether it was stored. fn must not access the same cache, otherwise it deadlocks
+func (s *Segment[K, V]) Compute(key K, fn func(value V, exists bool) (newValue V, keep bool)) (V, bool) {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+	defer func() { s.notify(evicted) }()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// 使用当前值计算新的值
+	// Calculate the new value from the current value
+	var current V
+	e, exists := s.lookup(key, now, &evicted)
+	if exists {
+		current = e.value
+	}
+	value, keep := fn(current, exists)
+
+	// 根据 keep 保存或者删除新的值
+	// Store or delete the new value according to keep
+	if keep {
+		s.store(key, value, expireAt(now, s.opts.ttl), &evicted)
+	} else if exists {
+		s.remove(e)
+	}
+
+	return value, keep
+}
+
+// Expire 方法删除所有已经过期的键值对，并对它们调用淘汰回调函数，返回删除的数量
+// The Expire method deletes all expired key-value pairs and calls the eviction callback function for them, it returns the number of deleted pairs
+func (s *Segment[K, V]) Expire() int {
+	var evicted []eviction[K, V]
+	now := time.Now().UnixNano()
+
+	// 加锁以同步访问
+	// Lock to synchronize access
+	s.lock.Lock()
+	for _, e := range s.storage {
+		if e.expired(now) {
+			s.remove(e)
+			evicted = append(evicted, eviction[K, V]{key: e.key, value: e.value, reason: EvictionExpired})
+		}
+	}
+	s.lock.Unlock()
+	s.notify(evicted)
+
+	return len(evicted)
+}
+
+// Count 方法返回 storage 中的键值对数量，已经过期但还没有被删除的键值对也会被计算在内
+// The Count method returns the number of key-value pairs in storage, expired pairs that have not been deleted yet are also counted
+func (s *Segment[K, V]) Count() int {
+	// 加读锁以同步访问
+	// Read lock to synchronize access
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	// 返回 storage 中的键值对数量
+	// Return the number of key-value pairs in storage
+	return len(s.storage)
+}
+
+// Len 方法不加锁地返回 storage 中的键值对数量，它和并发的写操作之间没有同步，已经过期但还没有被删除的键值对也会被计算在内
+// The Len method returns the number of key-value pairs in storage without locking, it is not synchronized with concurrent writes, expired pairs that have not been deleted yet are also counted
+func (s *Segment[K, V]) Len() int {
+	return int(s.size.Load())
+}
+
+// snapshot 方法在读锁内复制所有未过期的键值对，调用者可以在不持有锁的情况下使用它们
+// The snapshot method copies all unexpired key-value pairs under the read lock, the caller can use them without holding the lock
+func (s *Segment[K, V]) snapshot() []pair[K, V] {
+	now := time.Now().UnixNano()
+
+	// 加读锁以同步访问
+	// Read lock to synchronize access
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	// 复制所有未过期的键值对
+	// Copy all unexpired key-value pairs
+	pairs := make([]pair[K, V], 0, len(s.storage))
+	for key, e := range s.storage {
+		if !e.expired(now) {
+			pairs = append(pairs, pair[K, V]{key: key, value: e.value})
+		}
+	}
+
+	return pairs
+}
+
+// Keys 方法返回 storage 中所有未过期的键
+// The Keys method returns all unexpired keys in storage
+func (s *Segment[K, V]) Keys() []K {
+	pairs := s.snapshot()
+	keys := make([]K, len(pairs))
+	for i, p := range pairs {
+		keys[i] = p.key
+	}
+	return keys
+}
+
+// Snapshot 方法返回 storage 中所有未过期的键值对的副本，值本身不会被深拷贝
+// The Snapshot method returns a copy of all unexpired key-value pairs in storage, the values themselves are not deep copied
+func (s *Segment[K, V]) Snapshot() map[K]V {
+	pairs := s.snapshot()
+	copied := make(map[K]V, len(pairs))
+	for _, p := range pairs {
+		copied[p.key] = p.value
+	}
+	return copied
+}
+
+// Cleanup 方法删除 storage 中所有的键值对，然后在释放锁之后对每个未过期的值执行给定的函数，fn 可以访问同一个缓存
+// The Cleanup method deletes all key-value pairs in storage, then performs the given function on each unexpired value after the lock is released, fn may access the same cache
+func (s *Segment[K, V]) Cleanup(fn func(V)) {
+	now := time.Now().UnixNano()
+
+	// 加锁，取出所有的键值对并换上新的 storage
+	// Lock, take out all key-value pairs and swap in a new storage
+	s.lock.Lock()
+	storage := s.storage
+	s.storage = make(map[K]*entry[K, V])
+	s.size.Store(0)
+	s.root.next = &s.root
+	s.root.prev = &s.root
+	s.lock.Unlock()
+
+	// 对每个未过期的值执行给定的函数
+	// Perform the given function on each unexpired value
+	for _, e := range storage {
+		if !e.expired(now) {
+			fn(e.value)
+		}
+	}
+}
+
+// Range 方法遍历 storage 中所有未过期的键值对的快照，并对每个键值对执行给定的函数，如果函数返回 false，则停止遍历。
+// fn 在不持有锁的情况下执行，可以访问同一个缓存，遍历期间的修改不会影响快照
+// The Range method traverses a snapshot of all unexpired key-value pairs in storage and performs the given function on each of them, it stops if the function returns false.
+// fn runs without holding the lock and may access the same cache, modifications during the traversal do not affect the snapshot
+func (s *Segment[K, V]) Range(fn func(key K, value V) bool) bool {
+	// 遍历快照中的所有键值对
+	// Traverse all key-value pairs in the snapshot
+	for _, p := range s.snapshot() {
+		// 如果函数返回 false，停止遍历
+		// If the function returns false, stop traversing
+		if !fn(p.key, p.value) {
+			return false
+		}
+	}
+
+	// 返回 true，表示遍历完成
+	// Return true, indicating that the traversal is complete
+	return true
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegment_Set(t *testing.T) {
+	segment := NewSegment[string, any]()
+
+	// Test case 1: Set a key-value pair
+	segment.Set("key1", "value1")
+	v, _ := segment.Get("key1")
+	assert.Equal(t, "value1", v)
+
+	// Test case 2: Set a key-value pair with concurrent access
+	var wg sync.WaitGroup
+	numRoutines := 10
+	lastV := int64(0)
+	wg.Add(numRoutines)
+	for i := 0; i < numRoutines; i++ {
+		v := i
+		go func() {
+			defer wg.Done()
+			segment.Set("key2", v)
+			atomic.StoreInt64(&lastV, int64(v))
+		}()
+	}
+	wg.Wait()
+
+	v, _ = segment.Get("key2")
+	assert.Equal(t, lastV, int64(v.(int)))
+}
+
+func TestSegment_Delete(t *testing.T) {
+	segment := NewSegment[string, any]()
+
+	// Test case 1: Delete an existing key
+	segment.Set("key1", "value1")
+	segment.Delete("key1")
+	v, _ := segment.Get("key1")
+	assert.Nil(t, v)
+
+	// Test case 2: Delete a non-existing key
+	segment.Delete("key2")
+	v, _ = segment.Get("key2")
+	assert.Nil(t, v)
+}
+
+func TestSegment_SetManyDeleteMany(t *testing.T) {
+	segment := NewSegment[string, any]()
+
+	segment.SetMany([]string{"key1", "key2"}, []any{"value1", "value2"})
+	v, ok := segment.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, "value2", v)
+
+	// Missing keys are ignored
+	assert.Equal(t, 1, segment.DeleteMany([]string{"key1", "key3"}))
+	assert.Equal(t, 1, segment.Count())
+}
+
+func TestSegment_Count(t *testing.T) {
+	segment := NewSegment[string, any]()
+
+	// Test case 1: Count with an empty segment
+	assert.Equal(t, 0, segment.Count())
+
+	// Test case 2: Count with non-empty segment
+	segment.Set("key1", "value1")
+	segment.Set("key2", "value2")
+	assert.Equal(t, 2, segment.Count())
+}
+
+func TestSegment_Range(t *testing.T) {
+	segment := NewSegment[string, any]()
+	segment.Set("key1", "value1")
+	segment.Set("key2", "value2")
+
+	// Test case 1: Range over all key-value pairs
+	keys := map[string]any{}
+	assert.True(t, segment.Range(func(key string, value any) bool {
+		keys[key] = value
+		return true
+	}))
+	assert.Equal(t, map[string]any{"key1": "value1", "key2": "value2"}, keys)
+
+	// Test case 2: Stop ranging early
+	count := 0
+	assert.False(t, segment.Range(func(key string, value any) bool {
+		count++
+		return false
+	}))
+	assert.Equal(t, 1, count)
+}
+
+func TestSegment_Snapshot(t *testing.T) {
+	segment := NewSegment[string, any]()
+	segment.Set("key1", "value1")
+	segment.Set("key2", "value2")
+	assert.Equal(t, 2, segment.Len())
+
+	// The snapshot is a copy, later writes do not affect it
+	snapshot := segment.Snapshot()
+	segment.Set("key3", "value3")
+	segment.Delete("key1")
+	assert.Equal(t, map[string]any{"key1": "value1", "key2": "value2"}, snapshot)
+
+	keys := segment.Keys()
+	sort.Strings(keys)
+	assert.Equal(t, []string{"key2", "key3"}, keys)
+	assert.Equal(t, 2, segment.Len())
+
+	// Cleanup resets the length
+	segment.Cleanup(func(any) {})
+	assert.Equal(t, 0, segment.Len())
+	assert.Empty(t, segment.Snapshot())
+}
@@ -0,0 +1,284 @@
+// Package cache 提供一个按键分片、并发安全的泛型缓存，调度器使用它保存任务
+// Package cache provides a generic, concurrency-safe cache sharded by key, the scheduler uses it to keep tasks
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache 结构体是一个按键分片的并发安全缓存，每个分片有自己的读写锁
+// The Cache struct is a concurrency-safe cache sharded by key, each shard has its own read-write lock
+type Cache[K comparable, V any] struct {
+	// segments 是一个 Segment 的切片
+	// segments is a slice of Segments
+	segments []*Segment[K, V]
+
+	// mask 是分片数量减 1，用于根据哈希值计算分片的索引
+	// mask is the number of shards minus 1, used to calculate the index of the shard from the hash value
+	mask uint64
+
+	// hashFunc 是计算键的哈希值的函数
+	// hashFunc is the function calculating the hash value of a key
+	hashFunc HashFunc[K]
+
+	// stop 用于停止后台清理，没有启动后台清理时为 nil
+	// stop is used to stop the background cleanup, nil if the background cleanup is not started
+	stop chan struct{}
+
+	// once 用于确保 Close 只执行一次
+	// once is used to ensure Close is executed only once
+	once sync.Once
+}
+
+// New 函数根据配置创建并返回一个新的 Cache 实例，conf 为 nil 时使用默认配置
+// The New function creates and returns a new Cache instance according to the configuration, the default configuration is used if conf is nil
+func New[K comparable, V any](conf *Config[K, V]) *Cache[K, V] {
+	conf = isConfigValid(conf)
+
+	// 为每个分片创建一个新的 Segment 实例。最大数量被平均分配给各个分片，余数分配给前面的分片，所有分片的最大数量之和正好等于 maxEntries
+	// Create a new Segment instance for each shard. The maximum number is split evenly among the shards and the remainder goes to the first shards, so the maximum numbers of all shards add up to exactly maxEntries
+	segments := make([]*Segment[K, V], conf.shardCount)
+	for i := range segments {
+		opts := segmentOptions[K, V]{ttl: conf.ttl, onEvict: conf.onEvict}
+		if conf.maxEntries > 0 {
+			opts.maxEntries = conf.maxEntries / conf.shardCount
+			if i < conf.maxEntries%conf.shardCount {
+				opts.maxEntries++
+			}
+		}
+		segments[i] = newSegment(opts)
+	}
+
+	c := &Cache[K, V]{
+		segments: segments,
+		mask:     uint64(conf.shardCount - 1),
+		hashFunc: conf.hashFunc,
+	}
+
+	// 如果设置了清理间隔，启动后台清理
+	// If the cleanup interval is set, start the background cleanup
+	if conf.cleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		go c.janitor(conf.cleanupInterval)
+	}
+
+	// 返回一个新的 Cache 实例
+	// Return a new Cache instance
+	return c
+}
+
+// janitor 方法定期删除所有过期的键值对，直到缓存被关闭
+// The janitor method periodically deletes all expired key-value pairs until the cache is closed
+func (c *Cache[K, V]) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Expire()
+		}
+	}
+}
+
+// Close 方法停止后台清理，缓存仍然可以使用，过期的键值对只在访问时删除
+// The Close method stops the background cleanup, the cache can still be used and expired key-value pairs are only deleted on access
+func (c *Cache[K, V]) Close() {
+	c.once.Do(func() {
+		if c.stop != nil {
+			close(c.stop)
+		}
+	})
+}
+
+// segment 方法返回给定的键所在的分片
+// The segment method returns the shard of the given key
+func (c *Cache[K, V]) segment(key K) *Segment[K, V] {
+	// 计算键的哈希值，然后与 mask 进行与操作，得到索引
+	// Calculate the hash value of the key, then perform a bitwise AND operation with mask to get the index
+	return c.segments[c.hashFunc(key)&c.mask]
+}
+
+// ShardCount 方法返回分片的数量，即 WithShardCount 设置的数量经过取整和 WithMaxEntries 的减少之后实际使用的数量
+// The ShardCount method returns the number of shards, which is the number set by WithShardCount as actually used after the rounding and the reduction by WithMaxEntries
+func (c *Cache[K, V]) ShardCount() int {
+	return len(c.segments)
+}
+
+// Get 方法根据给定的键从 Cache 中获取值
+// The Get method gets the value from Cache based on the given key
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	return c.segment(key).Get(key)
+}
+
+// Set 方法将给定的键值对设置到 Cache 中，使用默认的存活时间
+// The Set method sets the given key-value pair to Cache with the default time to live
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.segment(key).Set(key, value)
+}
+
+// SetWithTTL 方法将给定的键值对设置到 Cache 中，并指定存活时间，ttl 不大于 0 表示永不过期
+// The SetWithTTL method sets the given key-value pair to Cache with the specified time to live, a ttl not greater than 0 means never expire
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.segment(key).SetWithTTL(key, value, ttl)
+}
+
+// Touch 方法重新设置已有键值对的存活时间，ttl 不大于 0 表示永不过期，返回键是否存在
+// The Touch method resets the time to live of an existing key-value pair, a ttl not greater than 0 means never expire, it returns whether the key exists
+func (c *Cache[K, V]) Touch(key K, ttl time.Duration) bool {
+	return c.segment(key).Touch(key, ttl)
+}
+
+// Expire 方法删除所有已经过期的键值对，并对它们调用淘汰回调函数，返回删除的数量
+// The Expire method deletes all expired key-value pairs and calls the eviction callback function for them, it returns the number of deleted pairs
+func (c *Cache[K, V]) Expire() int {
+	expired := 0
+	for _, segment := range c.segments {
+		expired += segment.Expire()
+	}
+	return expired
+}
+
+// Delete 方法从 Cache 中删除给定的键
+// The Delete method deletes the given key from Cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.segment(key).Delete(key)
+}
+
+// SetMany 方法将给定的键值对设置到 Cache 中，使用默认的存活时间，keys 和 values 的长度必须相同。
+// 键值对按照分片分组，每个分片只加锁一次，适合批量写入；不同分片的写入不是原子的
+// The SetMany method sets the given key-value pairs to Cache with the default time to live, keys and values must have the same length.
+// The pairs are grouped by shard and every shard is locked only once, which suits bulk writes; the writes to different shards are not atomic
+func (c *Cache[K, V]) SetMany(keys []K, values []V) {
+	shardKeys, shardValues := make([][]K, len(c.segments)), make([][]V, len(c.segments))
+	for i, key := range keys {
+		index := c.hashFunc(key) & c.mask
+		shardKeys[index] = append(shardKeys[index], key)
+		shardValues[index] = append(shardValues[index], values[i])
+	}
+	for index, segment := range c.segments {
+		if len(shardKeys[index]) > 0 {
+			segment.SetMany(shardKeys[index], shardValues[index])
+		}
+	}
+}
+
+// DeleteMany 方法从 Cache 中删除给定的键，返回被删除的键的数量。键按照分片分组，每个分片只加锁一次
+// The DeleteMany method deletes the given keys from Cache, it returns the number of deleted keys. The keys are grouped by shard and every shard is locked only once
+func (c *Cache[K, V]) DeleteMany(keys []K) int {
+	shardKeys := make([][]K, len(c.segments))
+	for _, key := range keys {
+		index := c.hashFunc(key) & c.mask
+		shardKeys[index] = append(shardKeys[index], key)
+	}
+	deleted := 0
+	for index, segment := range c.segments {
+		if len(shardKeys[index]) > 0 {
+			deleted += segment.DeleteMany(shardKeys[index])
+		}
+	}
+	return deleted
+}
+
+// GetOrSet 方法在键存在时返回已有的值，否则设置给定的值并返回它，loaded 表示值是否已经存在，检查和设置是原子的
+// The GetOrSet method returns the existing value if the key exists, otherwise it sets and returns the given value, loaded reports whether the value already existed, the check and the set are atomic
+func (c *Cache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	return c.segment(key).GetOrSet(key, value)
+}
+
+// CompareAndSwap 方法在键存在并且当前值等于 old 时原子地将值替换为 new，返回是否替换成功。值的类型必须是可比较的，否则会 panic
+// The CompareAndSwap method atomically replaces the value with new if the key exists and its current value equals old, it returns whether the swap happened. The type of the value must be comparable, otherwise it panics
+func (c *Cache[K, V]) CompareAndSwap(key K, old, new V) bool {
+	return c.segment(key).CompareAndSwap(key, old, new)
+}
+
+// CompareAndDelete 方法在键存在并且当前值等于 old 时原子地删除该键，返回是否删除成功。值的类型必须是可比较的，否则会 panic
+// The CompareAndDelete method atomically deletes the key if it exists and its current value equals old, it returns whether the deletion happened. The type of the value must be comparable, otherwise it panics
+func (c *Cache[K, V]) CompareAndDelete(key K, old V) bool {
+	return c.segment(key).CompareAndDelete(key, old)
+}
+
+// Compute 方法原子地使用键的当前值调用 fn，fn 返回新的值以及是否保留它，keep 为 false 时键会被删除。
+// 方法返回新的值和它是否被保存。fn 在持有分片锁的情况下执行，不能访问同一个缓存
+// The Compute method atomically calls fn with the current value of the key, fn returns the new value and whether to keep it, the key is deleted if keep is false.
+// The method returns the new value and whether it was stored. fn runs while holding the lock of the shard and must not access the same cache
+func (c *Cache[K, V]) Compute(key K, fn func(value V, exists bool) (newValue V, keep bool)) (V, bool) {
+	return c.segment(key).Compute(key, fn)
+}
+
+// Count 方法返回 Cache 中的键值对数量，它会依次锁定每个分片，已经过期但还没有被删除的键值对也会被计算在内
+// The Count method returns the number of key-value pairs in Cache, it locks every shard in turn, expired pairs that have not been deleted yet are also counted
+func (c *Cache[K, V]) Count() int {
+	// 定义一个变量 count，用于存储键值对的数量
+	// Define a variable count to store the number of key-value pairs
+	count := 0
+
+	// 遍历所有的 Segment，并将每个 Segment 中的键值对数量加到 count 上
+	// Traverse all Segments and add the number of key-value pairs in each Segment to count
+	for _, segment := range c.segments {
+		count += segment.Count()
+	}
+
+	// 返回 count
+	// Return count
+	return count
+}
+
+// Len 方法不加锁地返回 Cache 中键值对的近似数量，适合用于指标和预分配容量，已经过期但还没有被删除的键值对也会被计算在内
+// The Len method returns the approximate number of key-value pairs in Cache without locking, it is suitable for metrics and capacity hints, expired pairs that have not been deleted yet are also counted
+func (c *Cache[K, V]) Len() int {
+	count := 0
+	for _, segment := range c.segments {
+		count += segment.Len()
+	}
+	return count
+}
+
+// Keys 方法返回 Cache 中所有未过期的键，每个分片的键是一致的快照，不同分片的快照在不同的时间获取
+// The Keys method returns all unexpired keys in Cache, the keys of each shard are a consistent snapshot, the snapshots of different shards are taken at different times
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.Len())
+	for _, segment := range c.segments {
+		keys = append(keys, segment.Keys()...)
+	}
+	return keys
+}
+
+// Snapshot 方法返回 Cache 中所有未过期的键值对的副本，每个分片的键值对是一致的快照，值本身不会被深拷贝
+// The Snapshot method returns a copy of all unexpired key-value pairs in Cache, the pairs of each shard are a consistent snapshot, the values themselves are not deep copied
+func (c *Cache[K, V]) Snapshot() map[K]V {
+	copied := make(map[K]V, c.Len())
+	for _, segment := range c.segments {
+		for key, value := range segment.Snapshot() {
+			copied[key] = value
+		}
+	}
+	return copied
+}
+
+// Cleanup 方法依次清空每个分片，并对其中每个未过期的值执行给定的函数，fn 在不持有锁的情况下执行，可以访问同一个缓存
+// The Cleanup method empties every shard in turn and performs the given function on each unexpired value of it, fn runs without holding the lock and may access the same cache
+func (c *Cache[K, V]) Cleanup(fn func(value V)) {
+	for _, segment := range c.segments {
+		segment.Cleanup(fn)
+	}
+}
+
+// Range 方法遍历 Cache 中所有的键值对，并对每个键值对执行给定的函数，如果函数返回 false，则停止遍历。
+// 每个分片在遍历前被复制，fn 在不持有锁的情况下执行，可以访问同一个缓存，写入还没有遍历的分片的键值对会被遍历到
+// The Range method traverses all key-value pairs in Cache and performs the given function on each of them, it stops if the function returns false.
+// Every shard is copied before it is traversed, fn runs without holding the lock and may access the same cache, pairs written to shards which have not been traversed yet are visited
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	// 依次遍历所有的 Segment
+	// Traverse all Segments one by one
+	for _, segment := range c.segments {
+		// 如果遍历被中止，直接返回
+		// If the traversal is aborted, return directly
+		if !segment.Range(fn) {
+			return
+		}
+	}
+}
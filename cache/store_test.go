@@ -0,0 +1,574 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_ShardCount(t *testing.T) {
+	tests := []struct {
+		count    int
+		expected int
+	}{
+		{-1, DefaultShardCount},
+		{0, DefaultShardCount},
+		{1, 1},
+		{3, 4},
+		{16, 16},
+		{1000, 1024},
+	}
+
+	for _, tt := range tests {
+		c := New[string, int](NewConfig[string, int]().WithShardCount(tt.count))
+		assert.Equal(t, tt.expected, c.ShardCount(), tt.count)
+	}
+
+	// The default configuration is used when the configuration is nil
+	assert.Equal(t, DefaultShardCount, New[string, int](nil).ShardCount())
+
+	// The rounded count is reported by the cache, not the requested one
+	c := New[string, int](NewConfig[string, int]().WithShardCount(100))
+	assert.Equal(t, 128, c.ShardCount())
+}
+
+func TestConfig_HashFunc(t *testing.T) {
+	// All keys go to the first shard with a constant hash function
+	c := New[string, int](NewConfig[string, int]().WithShardCount(4).WithHashFunc(func(string) uint64 { return 0 }))
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+	assert.Equal(t, 10, c.segments[0].Count())
+	assert.Equal(t, 10, c.Count())
+
+	// A nil hash function falls back to the default one
+	c = New[string, int](NewConfig[string, int]().WithHashFunc(nil))
+	c.Set("key", 1)
+	v, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+}
+
+func TestDefaultHashFunc(t *testing.T) {
+	type point struct{ X, Y int }
+
+	// The same key always has the same hash value
+	assert.Equal(t, DefaultHashFunc("key"), DefaultHashFunc("key"))
+	assert.Equal(t, DefaultHashFunc(42), DefaultHashFunc(42))
+	assert.Equal(t, DefaultHashFunc(point{1, 2}), DefaultHashFunc(point{1, 2}))
+
+	// Different keys are spread over the shards
+	assert.NotEqual(t, DefaultHashFunc(1), DefaultHashFunc(2))
+	assert.NotEqual(t, DefaultHashFunc(point{1, 2}), DefaultHashFunc(point{2, 1}))
+
+	// Structs can be used as keys
+	c := New[point, string](nil)
+	c.Set(point{1, 2}, "a")
+	v, ok := c.Get(point{1, 2})
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+}
+
+func TestCache_SetGetDelete(t *testing.T) {
+	c := New[int, string](NewConfig[int, string]().WithShardCount(8))
+
+	c.Set(1, "one")
+	c.Set(2, "two")
+	v, ok := c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+	assert.Equal(t, 2, c.Count())
+
+	c.Delete(1)
+	v, ok = c.Get(1)
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+	assert.Equal(t, 1, c.Count())
+}
+
+func TestCache_SetManyDeleteMany(t *testing.T) {
+	c := New[int, string](NewConfig[int, string]().WithShardCount(8))
+
+	keys := make([]int, 100)
+	values := make([]string, 100)
+	for i := range keys {
+		keys[i], values[i] = i, fmt.Sprint(i)
+	}
+	c.SetMany(keys, values)
+	assert.Equal(t, 100, c.Count())
+	for i := range keys {
+		v, ok := c.Get(i)
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprint(i), v)
+	}
+
+	// Existing keys are updated in place
+	c.SetMany([]int{1, 2}, []string{"one", "two"})
+	v, _ := c.Get(1)
+	assert.Equal(t, "one", v)
+	assert.Equal(t, 100, c.Count())
+
+	// Only existing keys are counted as deleted
+	assert.Equal(t, 50, c.DeleteMany(append(keys[:50:50], 1000, 1001)))
+	assert.Equal(t, 50, c.Count())
+	_, ok := c.Get(10)
+	assert.False(t, ok)
+
+	// Capacity limits still apply and report evictions
+	var evicted []int
+	c = New[int, string](NewConfig[int, string]().WithShardCount(1).WithMaxEntries(2).WithEvictionFunc(func(key int, _ string, reason EvictionReason) {
+		evicted = append(evicted, key)
+	}))
+	c.SetMany([]int{1, 2, 3}, []string{"a", "b", "c"})
+	assert.Equal(t, []int{1}, evicted)
+	assert.Equal(t, 2, c.Count())
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := New[string, int](NewConfig[string, int]().WithShardCount(16))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Set(fmt.Sprintf("key%d-%d", i, j), j)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_, _ = c.Get(fmt.Sprintf("key%d-%d", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 8000, c.Count())
+}
+
+func TestCache_RangeAndCleanup(t *testing.T) {
+	c := New[string, int](nil)
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+
+	// Range over all key-value pairs
+	keys := make([]string, 0, 100)
+	c.Range(func(key string, value int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, 100, len(keys))
+
+	// Stop ranging early
+	count := 0
+	c.Range(func(key string, value int) bool {
+		count++
+		return count < 10
+	})
+	assert.Equal(t, 10, count)
+
+	// Clean up all key-value pairs
+	var lock sync.Mutex
+	values := make([]int, 0, 100)
+	c.Cleanup(func(value int) {
+		lock.Lock()
+		defer lock.Unlock()
+		values = append(values, value)
+	})
+	sort.Ints(values)
+	assert.Equal(t, 100, len(values))
+	assert.Equal(t, 99, values[99])
+	assert.Equal(t, 0, c.Count())
+}
+
+func BenchmarkCache_Get(b *testing.B) {
+	c := New[string, int](nil)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		c.Set(keys[i], i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = c.Get(keys[i&1023])
+			i++
+		}
+	})
+}
+
+func TestCache_GetOrSet(t *testing.T) {
+	c := New[string, int](nil)
+
+	actual, loaded := c.GetOrSet("key", 1)
+	assert.False(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	actual, loaded = c.GetOrSet("key", 2)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, actual)
+
+	// Only one of the concurrent callers sets the value
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	stored := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, loaded := c.GetOrSet("race", i); !loaded {
+				lock.Lock()
+				stored++
+				lock.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, stored)
+}
+
+func TestCache_CompareAndSwap(t *testing.T) {
+	c := New[string, string](nil)
+
+	// A missing key is never swapped
+	assert.False(t, c.CompareAndSwap("key", "", "a"))
+
+	c.Set("key", "a")
+	assert.False(t, c.CompareAndSwap("key", "b", "c"))
+	assert.True(t, c.CompareAndSwap("key", "a", "b"))
+	v, _ := c.Get("key")
+	assert.Equal(t, "b", v)
+}
+
+func TestCache_CompareAndDelete(t *testing.T) {
+	c := New[string, string](nil)
+	c.Set("key", "a")
+
+	assert.False(t, c.CompareAndDelete("key", "b"))
+	assert.True(t, c.CompareAndDelete("key", "a"))
+	assert.False(t, c.CompareAndDelete("key", "a"))
+	assert.Equal(t, 0, c.Count())
+
+	// Values of non comparable types panic
+	slices := New[string, any](nil)
+	slices.Set("key", []int{1})
+	assert.Panics(t, func() { slices.CompareAndDelete("key", []int{1}) })
+}
+
+func TestCache_Compute(t *testing.T) {
+	c := New[string, int](nil)
+
+	// Increase a counter concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Compute("counter", func(value int, exists bool) (int, bool) {
+				return value + 1, true
+			})
+		}()
+	}
+	wg.Wait()
+	v, _ := c.Get("counter")
+	assert.Equal(t, 100, v)
+
+	// Delete the key by not keeping the value
+	v, kept := c.Compute("counter", func(value int, exists bool) (int, bool) {
+		assert.True(t, exists)
+		return 0, false
+	})
+	assert.False(t, kept)
+	assert.Equal(t, 0, v)
+	_, ok := c.Get("counter")
+	assert.False(t, ok)
+
+	// Not keeping a missing key does nothing
+	c.Compute("missing", func(value int, exists bool) (int, bool) {
+		assert.False(t, exists)
+		return 0, false
+	})
+	assert.Equal(t, 0, c.Count())
+}
+
+type evicted struct {
+	key    string
+	value  int
+	reason EvictionReason
+}
+
+type evictionRecorder struct {
+	lock    sync.Mutex
+	evicted []evicted
+}
+
+func (r *evictionRecorder) onEvict(key string, value int, reason EvictionReason) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.evicted = append(r.evicted, evicted{key, value, reason})
+}
+
+func (r *evictionRecorder) list() []evicted {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]evicted(nil), r.evicted...)
+}
+
+func TestConfig_MaxEntries(t *testing.T) {
+	// The number of shards is reduced so every shard can hold at least one entry
+	conf := isConfigValid(NewConfig[string, int]().WithMaxEntries(10))
+	assert.Equal(t, 8, conf.shardCount)
+
+	conf = isConfigValid(NewConfig[string, int]().WithMaxEntries(-1))
+	assert.Equal(t, 0, conf.maxEntries)
+	assert.Equal(t, DefaultShardCount, conf.shardCount)
+}
+
+func TestCache_MaxEntriesTotal(t *testing.T) {
+	for _, max := range []int{1, 10, 300, 1000} {
+		c := New[int, int](NewConfig[int, int]().WithMaxEntries(max))
+
+		// The limits of the shards add up to exactly the maximum number
+		total := 0
+		for _, segment := range c.segments {
+			total += segment.opts.maxEntries
+		}
+		assert.Equal(t, max, total, max)
+
+		// The total number of entries never exceeds the maximum number
+		for i := 0; i < max*20; i++ {
+			c.Set(i, i)
+			if c.Len() > max {
+				assert.Fail(t, "too many entries", "max %d, len %d", max, c.Len())
+				break
+			}
+		}
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	recorder := &evictionRecorder{}
+	c := New[string, int](NewConfig[string, int]().WithShardCount(4).WithTTL(50 * time.Millisecond).WithEvictionFunc(recorder.onEvict))
+
+	c.Set("default", 1)
+	c.SetWithTTL("short", 2, 10*time.Millisecond)
+	c.SetWithTTL("forever", 3, 0)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// Expired entries are removed lazily on access
+	_, ok := c.Get("short")
+	assert.False(t, ok)
+	assert.Equal(t, []evicted{{"short", 2, EvictionExpired}}, recorder.list())
+
+	// Touch extends the time to live of an existing entry
+	assert.True(t, c.Touch("default", time.Hour))
+	assert.False(t, c.Touch("short", time.Hour))
+
+	time.Sleep(50 * time.Millisecond)
+
+	value, ok := c.Get("default")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	value, ok = c.Get("forever")
+	assert.True(t, ok)
+	assert.Equal(t, 3, value)
+
+	// Expired entries are invisible to GetOrSet and Range
+	c.SetWithTTL("gone", 4, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	actual, loaded := c.GetOrSet("gone", 5)
+	assert.False(t, loaded)
+	assert.Equal(t, 5, actual)
+
+	c.SetWithTTL("hidden", 6, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	keys := []string{}
+	c.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+	assert.Equal(t, []string{"default", "forever", "gone"}, keys)
+
+	// Expire removes the remaining expired entries eagerly
+	assert.Equal(t, 1, c.Expire())
+	assert.Equal(t, 3, c.Count())
+	assert.Len(t, recorder.list(), 3)
+}
+
+func TestCache_Janitor(t *testing.T) {
+	recorder := &evictionRecorder{}
+	c := New[string, int](NewConfig[string, int]().WithTTL(10 * time.Millisecond).WithCleanupInterval(5 * time.Millisecond).WithEvictionFunc(recorder.onEvict))
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		c.Set(fmt.Sprintf("key-%d", i), i)
+	}
+
+	assert.Eventually(t, func() bool { return c.Count() == 0 }, time.Second, 5*time.Millisecond)
+	assert.Len(t, recorder.list(), 10)
+
+	// Close is idempotent and the cache is still usable afterwards
+	c.Close()
+	c.Close()
+	c.Set("key", 1)
+	value, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+}
+
+func TestCache_LRU(t *testing.T) {
+	recorder := &evictionRecorder{}
+	c := New[string, int](NewConfig[string, int]().WithShardCount(1).WithMaxEntries(3).WithEvictionFunc(recorder.onEvict))
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	// Reading "a" makes "b" the least recently used entry
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Set("d", 4)
+	assert.Equal(t, 3, c.Count())
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, []evicted{{"b", 2, EvictionCapacity}}, recorder.list())
+
+	// Overwriting an existing key does not evict anything
+	c.Set("c", 30)
+	assert.Len(t, recorder.list(), 1)
+
+	// Deleted entries free their slot without calling the eviction callback
+	c.Delete("a")
+	c.Set("e", 5)
+	assert.Len(t, recorder.list(), 1)
+
+	c.Set("f", 6)
+	assert.Equal(t, []evicted{{"b", 2, EvictionCapacity}, {"d", 4, EvictionCapacity}}, recorder.list())
+
+	keys := []string{}
+	c.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+	assert.Equal(t, []string{"c", "e", "f"}, keys)
+}
+
+func TestCache_LRUConcurrent(t *testing.T) {
+	c := New[int, int](NewConfig[int, int]().WithShardCount(4).WithMaxEntries(64))
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Set(g*1000+i, i)
+				c.Get(g*1000 + i/2)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, c.Count(), 64)
+}
+
+func TestEvictionReason_String(t *testing.T) {
+	assert.Equal(t, "expired", EvictionExpired.String())
+	assert.Equal(t, "capacity", EvictionCapacity.String())
+	assert.Equal(t, "unknown", EvictionReason(0).String())
+}
+
+func TestCache_KeysAndSnapshot(t *testing.T) {
+	c := New[string, int](NewConfig[string, int]().WithShardCount(8))
+	for i := 0; i < 50; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+	c.SetWithTTL("expired", -1, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	// Len includes expired entries which have not been removed yet
+	assert.Equal(t, 51, c.Len())
+
+	keys := c.Keys()
+	assert.Len(t, keys, 50)
+	assert.NotContains(t, keys, "expired")
+
+	snapshot := c.Snapshot()
+	assert.Len(t, snapshot, 50)
+	assert.Equal(t, 42, snapshot["key42"])
+
+	// The snapshot is not affected by later writes
+	c.Delete("key42")
+	assert.Equal(t, 42, snapshot["key42"])
+	assert.Equal(t, 50, c.Len())
+}
+
+func TestCache_RangeReentrant(t *testing.T) {
+	c := New[string, int](NewConfig[string, int]().WithShardCount(4))
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("key%d", i), i)
+	}
+
+	// The callback may modify the same cache without deadlocking, entries added to shards
+	// which have not been visited yet are seen by the traversal
+	visited := 0
+	c.Range(func(key string, value int) bool {
+		if strings.HasPrefix(key, "new-") {
+			return true
+		}
+		visited++
+		c.Delete(key)
+		c.Set("new-"+key, value)
+		return true
+	})
+	assert.Equal(t, 100, visited)
+	assert.Equal(t, 100, c.Count())
+
+	// The cleanup callback may also access the same cache
+	cleaned := 0
+	c.Cleanup(func(value int) {
+		cleaned++
+		_, ok := c.Get(fmt.Sprintf("new-key%d", value))
+		assert.False(t, ok)
+	})
+	assert.Equal(t, 100, cleaned)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_LenConcurrent(t *testing.T) {
+	c := New[int, int](NewConfig[int, int]().WithShardCount(16))
+
+	wg := sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Set(g*1000+i, i)
+				_ = c.Len()
+				if i%2 == 0 {
+					c.Delete(g*1000 + i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4000, c.Len())
+	assert.Equal(t, c.Count(), c.Len())
+}
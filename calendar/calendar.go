@@ -0,0 +1,323 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSearchDays 是计算工作时间时向后搜索的最大天数，超过之后认为日历中没有工作时间
+// maxSearchDays is the maximum number of days searched forward when calculating business time, the calendar is considered to have no business time beyond it
+const maxSearchDays = 366 * 5
+
+// dateLayout 是节假日日期的格式
+// dateLayout is the format of the date of a holiday
+const dateLayout = "2006-01-02"
+
+// ErrorInvalidWindow 表示工作时间窗口无效
+// ErrorInvalidWindow indicates the working window is invalid
+var ErrorInvalidWindow = errors.New("invalid working window")
+
+// Window 结构体是一天中的一个工作时间窗口，Start 和 End 是距离当天零点的时长，窗口包含 Start 不包含 End
+// The Window struct is a working window of a day, Start and End are the durations since midnight of the day, the window includes Start and excludes End
+type Window struct {
+	// Start 是窗口的开始时间
+	// Start is the start of the window
+	Start time.Duration `json:"start"`
+
+	// End 是窗口的结束时间，最大为 24 小时
+	// End is the end of the window, at most 24 hours
+	End time.Duration `json:"end"`
+}
+
+// NewWindow 函数使用 "15:04" 格式的开始和结束时间创建一个工作时间窗口，结束时间可以是 "24:00"
+// The NewWindow function creates a working window from a start and an end in the "15:04" format, the end can be "24:00"
+func NewWindow(start, end string) (Window, error) {
+	s, err := parseClock(start)
+	if err != nil {
+		return Window{}, err
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return Window{}, err
+	}
+	window := Window{Start: s, End: e}
+	if !window.valid() {
+		return Window{}, fmt.Errorf("%w: %s-%s", ErrorInvalidWindow, start, end)
+	}
+	return window, nil
+}
+
+// parseClock 函数解析 "15:04" 格式的时间，返回距离零点的时长
+// The parseClock function parses a time in the "15:04" format, it returns the duration since midnight
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrorInvalidWindow, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// valid 方法返回窗口是否有效
+// The valid method returns whether the window is valid
+func (w Window) valid() bool {
+	return w.Start >= 0 && w.Start < w.End && w.End <= 24*time.Hour
+}
+
+// bounds 方法返回窗口在指定日期的开始和结束时间。时间按照墙上时钟的读数计算，夏令时切换的日期也能得到正确的时间
+// The bounds method returns the start and the end of the window on the specified date. The times are calculated as wall clock readings, so the dates of daylight saving switches get the correct times too
+func (w Window) bounds(year int, month time.Month, day int, location *time.Location) (time.Time, time.Time) {
+	return time.Date(year, month, day, 0, 0, 0, int(w.Start), location), time.Date(year, month, day, 0, 0, 0, int(w.End), location)
+}
+
+// Calendar 结构体是工作日历，包含每个星期几的工作时间窗口、节假日和时区。它可以被多个 goroutine 并发使用，
+// 修改日历时会通知所有监视它的函数，使调度器重新计算使用它的任务的执行时间
+// The Calendar struct is a business calendar, it contains the working windows of every weekday, the holidays and the time zone. It can be used concurrently by several goroutines,
+// all functions watching it are notified when it is modified, so the scheduler recalculates the execution times of the tasks using it
+type Calendar struct {
+	// lock 用于保护日历的字段
+	// lock is used to protect the fields of the calendar
+	lock sync.RWMutex
+
+	// location 是日历的时区，工作时间窗口和节假日都按照这个时区解释
+	// location is the time zone of the calendar, the working windows and the holidays are interpreted in this time zone
+	location *time.Location
+
+	// hours 是每个星期几的工作时间窗口，按照开始时间排序
+	// hours are the working windows of every weekday, sorted by the start
+	hours [7][]Window
+
+	// holidays 是节假日，键是 "2006-01-02" 格式的日期，值是节假日的名称
+	// holidays are the holidays, the key is the date in the "2006-01-02" format, the value is the name of the holiday
+	holidays map[string]string
+
+	// watchers 是监视日历修改的函数，键是注册的序号
+	// watchers are the functions watching modifications of the calendar, the key is the sequence number of the registration
+	watchers map[uint64]func()
+
+	// nextWatcher 是下一个监视函数的序号
+	// nextWatcher is the sequence number of the next watching function
+	nextWatcher uint64
+}
+
+// NewCalendar 函数创建一个新的 Calendar 实例，location 为 nil 时使用 time.Local。新的日历没有工作时间，需要使用 SetHours 或者 SetWeekdayHours 设置
+// The NewCalendar function creates a new Calendar instance, time.Local is used if location is nil. A new calendar has no business time, it must be set with SetHours or SetWeekdayHours
+func NewCalendar(location *time.Location) *Calendar {
+	if location == nil {
+		location = time.Local
+	}
+	return &Calendar{
+		location: location,
+		holidays: make(map[string]string),
+		watchers: make(map[uint64]func()),
+	}
+}
+
+// Location 方法返回日历的时区
+// The Location method returns the time zone of the calendar
+func (c *Calendar) Location() *time.Location {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.location
+}
+
+// SetLocation 方法修改日历的时区，location 为 nil 时使用 time.Local
+// The SetLocation method changes the time zone of the calendar, time.Local is used if location is nil
+func (c *Calendar) SetLocation(location *time.Location) {
+	if location == nil {
+		location = time.Local
+	}
+	c.update(func() { c.location = location })
+}
+
+// SetHours 方法设置指定星期几的工作时间窗口，没有窗口表示这一天不工作。窗口不能重叠
+// The SetHours method sets the working windows of the specified weekday, no windows means the day is not worked. The windows must not overlap
+func (c *Calendar) SetHours(day time.Weekday, windows ...Window) error {
+	if day < time.Sunday || day > time.Saturday {
+		return fmt.Errorf("%w: weekday %d", ErrorInvalidWindow, day)
+	}
+
+	// 复制并排序窗口，检查它们是否有效并且没有重叠
+	// Copy and sort the windows, check they are valid and do not overlap
+	sorted := append([]Window(nil), windows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	for i, window := range sorted {
+		if !window.valid() || (i > 0 && window.Start < sorted[i-1].End) {
+			return fmt.Errorf("%w: %v-%v on %s", ErrorInvalidWindow, window.Start, window.End, day)
+		}
+	}
+
+	c.update(func() { c.hours[day] = sorted })
+	return nil
+}
+
+// SetWeekdayHours 方法将星期一到星期五的工作时间窗口设置为相同的窗口
+// The SetWeekdayHours method sets the working windows from Monday to Friday to the same windows
+func (c *Calendar) SetWeekdayHours(windows ...Window) error {
+	for day := time.Monday; day <= time.Friday; day++ {
+		if err := c.SetHours(day, windows...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hours 方法返回指定星期几的工作时间窗口
+// The Hours method returns the working windows of the specified weekday
+func (c *Calendar) Hours(day time.Weekday) []Window {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if day < time.Sunday || day > time.Saturday {
+		return nil
+	}
+	return append([]Window(nil), c.hours[day]...)
+}
+
+// AddHoliday 方法添加一个节假日，只使用 date 本身的年月日，节假日按照日历的时区解释
+// The AddHoliday method adds a holiday, only the year, month and day of date itself are used, the holiday is interpreted in the time zone of the calendar
+func (c *Calendar) AddHoliday(date time.Time, name string) {
+	c.update(func() { c.holidays[date.Format(dateLayout)] = name })
+}
+
+// RemoveHoliday 方法删除一个节假日
+// The RemoveHoliday method removes a holiday
+func (c *Calendar) RemoveHoliday(date time.Time) {
+	c.update(func() { delete(c.holidays, date.Format(dateLayout)) })
+}
+
+// SetHolidays 方法使用给定的节假日替换日历中所有的节假日，日期无效时返回错误并且不修改日历
+// The SetHolidays method replaces all the holidays of the calendar with the given holidays, an error is returned and the calendar is not modified if a date is invalid
+func (c *Calendar) SetHolidays(holidays []Holiday) error {
+	replaced := make(map[string]string, len(holidays))
+	for _, holiday := range holidays {
+		date, err := time.Parse(dateLayout, holiday.Date)
+		if err != nil {
+			return fmt.Errorf("invalid holiday %q: %w", holiday.Date, err)
+		}
+		replaced[date.Format(dateLayout)] = holiday.Name
+	}
+	c.update(func() { c.holidays = replaced })
+	return nil
+}
+
+// Holidays 方法返回日历中所有的节假日，按照日期排序
+// The Holidays method returns all the holidays of the calendar, sorted by the date
+func (c *Calendar) Holidays() []Holiday {
+	c.lock.RLock()
+	holidays := make([]Holiday, 0, len(c.holidays))
+	for date, name := range c.holidays {
+		holidays = append(holidays, Holiday{Date: date, Name: name})
+	}
+	c.lock.RUnlock()
+
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays
+}
+
+// IsHoliday 方法返回指定时间在日历时区中的日期是否是节假日
+// The IsHoliday method returns whether the date of the specified time in the time zone of the calendar is a holiday
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	_, ok := c.holidays[t.In(c.location).Format(dateLayout)]
+	return ok
+}
+
+// IsBusinessTime 方法返回指定时间是否在工作时间内
+// The IsBusinessTime method returns whether the specified time is within business time
+func (c *Calendar) IsBusinessTime(t time.Time) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	local := t.In(c.location)
+	if _, ok := c.holidays[local.Format(dateLayout)]; ok {
+		return false
+	}
+	year, month, day := local.Date()
+	for _, window := range c.hours[local.Weekday()] {
+		start, end := window.bounds(year, month, day, c.location)
+		if !local.Before(start) && local.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add 方法返回从 from 开始经过 d 的工作时间之后的时间，节假日和工作时间窗口之外的时间不计算在内。
+// from 不在工作时间内时从下一个工作时间窗口的开始计算。日历中没有工作时间时返回零值
+// The Add method returns the time after d of business time has elapsed since from, holidays and the time outside working windows are not counted.
+// If from is not within business time, the calculation starts at the beginning of the next working window. A zero value is returned if the calendar has no business time
+func (c *Calendar) Add(from time.Time, d time.Duration) time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if d < 0 {
+		d = 0
+	}
+	current := from.In(c.location)
+
+	// 逐天遍历工作时间窗口，从每个窗口中扣除剩余的工作时间
+	// Walk through the working windows day by day, the remaining business time is deducted from every window
+	for i := 0; i < maxSearchDays; i++ {
+		year, month, day := current.Date()
+		if _, ok := c.holidays[current.Format(dateLayout)]; !ok {
+			for _, window := range c.hours[current.Weekday()] {
+				start, end := window.bounds(year, month, day, c.location)
+				if !current.Before(end) {
+					continue
+				}
+				if current.Before(start) {
+					current = start
+				}
+				available := end.Sub(current)
+				if d <= available {
+					return current.Add(d)
+				}
+				d -= available
+				current = end
+			}
+		}
+
+		// 移动到下一天的零点
+		// Move to midnight of the next day
+		current = time.Date(year, month, day+1, 0, 0, 0, 0, c.location)
+	}
+	return time.Time{}
+}
+
+// Watch 方法注册一个在日历被修改之后调用的函数，返回取消注册的函数。函数在修改日历的 goroutine 中调用，调用时不持有日历的锁
+// The Watch method registers a function called after the calendar is modified, it returns the function to unregister. The function is called in the goroutine modifying the calendar, without holding the lock of the calendar
+func (c *Calendar) Watch(fn func()) func() {
+	c.lock.Lock()
+	id := c.nextWatcher
+	c.nextWatcher++
+	c.watchers[id] = fn
+	c.lock.Unlock()
+
+	return func() {
+		c.lock.Lock()
+		delete(c.watchers, id)
+		c.lock.Unlock()
+	}
+}
+
+// update 方法在日历的锁的保护下修改日历，然后通知所有监视日历的函数
+// The update method modifies the calendar under the lock of the calendar, then notifies all functions watching the calendar
+func (c *Calendar) update(modify func()) {
+	c.lock.Lock()
+	modify()
+	watchers := make([]func(), 0, len(c.watchers))
+	for _, fn := range c.watchers {
+		watchers = append(watchers, fn)
+	}
+	c.lock.Unlock()
+
+	for _, fn := range watchers {
+		fn()
+	}
+}
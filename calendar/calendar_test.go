@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustWindow(t *testing.T, start, end string) Window {
+	window, err := NewWindow(start, end)
+	assert.Nil(t, err)
+	return window
+}
+
+func newOfficeCalendar(t *testing.T, location *time.Location) *Calendar {
+	c := NewCalendar(location)
+	assert.Nil(t, c.SetWeekdayHours(mustWindow(t, "09:00", "12:00"), mustWindow(t, "13:00", "18:00")))
+	return c
+}
+
+func TestNewWindow(t *testing.T) {
+	window, err := NewWindow("09:30", "24:00")
+	assert.Nil(t, err)
+	assert.Equal(t, Window{Start: time.Hour*9 + time.Minute*30, End: time.Hour * 24}, window)
+
+	_, err = NewWindow("18:00", "09:00")
+	assert.ErrorIs(t, err, ErrorInvalidWindow)
+	_, err = NewWindow("9am", "18:00")
+	assert.ErrorIs(t, err, ErrorInvalidWindow)
+}
+
+func TestCalendar_SetHours(t *testing.T) {
+	c := NewCalendar(time.UTC)
+	assert.Nil(t, c.SetHours(time.Saturday, mustWindow(t, "13:00", "15:00"), mustWindow(t, "09:00", "11:00")))
+	assert.Equal(t, []Window{mustWindow(t, "09:00", "11:00"), mustWindow(t, "13:00", "15:00")}, c.Hours(time.Saturday))
+
+	// Overlapping windows and invalid weekdays are rejected
+	assert.ErrorIs(t, c.SetHours(time.Monday, mustWindow(t, "09:00", "12:00"), mustWindow(t, "11:00", "13:00")), ErrorInvalidWindow)
+	assert.ErrorIs(t, c.SetHours(time.Weekday(7)), ErrorInvalidWindow)
+	assert.Empty(t, c.Hours(time.Monday))
+}
+
+func TestCalendar_Add(t *testing.T) {
+	c := newOfficeCalendar(t, time.UTC)
+
+	// Monday 2024-03-04 10:00 plus 4 business hours skips the lunch break
+	monday := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC), c.Add(monday, time.Hour*4))
+
+	// Friday evening rolls over the weekend
+	friday := time.Date(2024, 3, 8, 17, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 11, 0, 0, 0, time.UTC), c.Add(friday, time.Hour*3))
+
+	// Outside business time the calculation starts at the next window
+	saturday := time.Date(2024, 3, 9, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC), c.Add(saturday, 0))
+
+	// Holidays are skipped
+	c.AddHoliday(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), "closed")
+	assert.Equal(t, time.Date(2024, 3, 6, 10, 0, 0, 0, time.UTC), c.Add(monday, time.Hour*8))
+	assert.True(t, c.IsHoliday(time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)))
+	c.RemoveHoliday(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), c.Add(monday, time.Hour*8))
+
+	// A calendar without business time returns a zero value
+	assert.True(t, NewCalendar(time.UTC).Add(monday, time.Hour).IsZero())
+}
+
+func TestCalendar_Location(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	c := newOfficeCalendar(t, shanghai)
+	assert.Equal(t, shanghai, c.Location())
+
+	// 01:00 UTC is 09:00 in Shanghai
+	from := time.Date(2024, 3, 4, 1, 0, 0, 0, time.UTC)
+	assert.True(t, c.IsBusinessTime(from))
+	assert.True(t, c.Add(from, time.Hour).Equal(time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC)))
+
+	c.SetLocation(time.UTC)
+	assert.False(t, c.IsBusinessTime(from))
+	assert.True(t, c.Add(from, time.Hour).Equal(time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestCalendar_DaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	c := NewCalendar(newYork)
+	assert.Nil(t, c.SetHours(time.Sunday, mustWindow(t, "00:00", "24:00")))
+
+	// 2024-03-10 is 23 hours long in New York, working windows follow the wall clock
+	from := time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)
+	assert.Equal(t, time.Date(2024, 3, 17, 0, 0, 0, 0, newYork).Add(time.Hour), c.Add(from, time.Hour*24))
+}
+
+func TestCalendar_Watch(t *testing.T) {
+	c := NewCalendar(time.UTC)
+	count := 0
+	cancel := c.Watch(func() { count++ })
+
+	assert.Nil(t, c.SetWeekdayHours(mustWindow(t, "09:00", "17:00")))
+	c.AddHoliday(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "new year")
+	assert.Equal(t, 6, count)
+
+	cancel()
+	c.SetLocation(time.Local)
+	assert.Equal(t, 6, count)
+}
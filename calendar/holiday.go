@@ -0,0 +1,157 @@
+package calendar
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// icsDateLayout 是 ICS 文件中日期的格式
+// icsDateLayout is the format of dates in ICS files
+const icsDateLayout = "20060102"
+
+// Holiday 结构体是一个节假日
+// The Holiday struct is a holiday
+type Holiday struct {
+	// Date 是 "2006-01-02" 格式的日期
+	// Date is the date in the "2006-01-02" format
+	Date string `json:"date"`
+
+	// Name 是节假日的名称
+	// Name is the name of the holiday
+	Name string `json:"name,omitempty"`
+}
+
+// ParseJSON 函数从 JSON 数组中解析节假日，数组的元素是 {"date": "2006-01-02", "name": "..."} 形式的对象
+// The ParseJSON function parses holidays from a JSON array, the elements of the array are objects of the {"date": "2006-01-02", "name": "..."} form
+func ParseJSON(r io.Reader) ([]Holiday, error) {
+	var holidays []Holiday
+	if err := json.NewDecoder(r).Decode(&holidays); err != nil {
+		return nil, err
+	}
+	for _, holiday := range holidays {
+		if _, err := time.Parse(dateLayout, holiday.Date); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: %w", holiday.Date, err)
+		}
+	}
+	return holidays, nil
+}
+
+// ParseICS 函数从 iCalendar 文件中解析节假日。每个 VEVENT 的 DTSTART 是第一天，DTEND 是结束之后的第一天（不包含），
+// 没有 DTEND 时只有一天，SUMMARY 是节假日的名称。包含时间的 DTSTART 和 DTEND 只使用其中的日期
+// The ParseICS function parses holidays from an iCalendar file. The DTSTART of every VEVENT is the first day, DTEND is the first day after it ends (excluded),
+// there is only one day without DTEND, SUMMARY is the name of the holiday. Only the date is used from a DTSTART or DTEND containing a time
+func ParseICS(r io.Reader) ([]Holiday, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		holidays   []Holiday
+		inEvent    bool
+		name       string
+		start, end time.Time
+	)
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		// 去掉属性的参数，例如 DTSTART;VALUE=DATE
+		// Strip the parameters of the property, such as DTSTART;VALUE=DATE
+		property, _, _ := strings.Cut(key, ";")
+		switch strings.ToUpper(property) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, name, start, end = true, "", time.Time{}, time.Time{}
+			}
+		case "SUMMARY":
+			name = unescapeICS(value)
+		case "DTSTART":
+			if start, err = parseICSDate(value); err != nil {
+				return nil, err
+			}
+		case "DTEND":
+			if end, err = parseICSDate(value); err != nil {
+				return nil, err
+			}
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("invalid ICS event %q: missing DTSTART", name)
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, Holiday{Date: day.Format(dateLayout), Name: name})
+			}
+		}
+	}
+	return holidays, nil
+}
+
+// unfoldICS 函数读取 iCalendar 文件的所有行，以空格或者制表符开头的行是上一行的延续
+// The unfoldICS function reads all lines of an iCalendar file, a line starting with a space or a tab continues the previous line
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseICSDate 函数解析 ICS 的日期或者日期时间，只返回日期
+// The parseICSDate function parses an ICS date or date-time, only the date is returned
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len(icsDateLayout) {
+		return time.Time{}, fmt.Errorf("invalid ICS date %q", value)
+	}
+	date, err := time.Parse(icsDateLayout, value[:len(icsDateLayout)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ICS date %q: %w", value, err)
+	}
+	return date, nil
+}
+
+// unescapeICS 函数还原 ICS 文本中转义的字符
+// The unescapeICS function restores the escaped characters in ICS text
+func unescapeICS(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// LoadHolidays 方法从文件中加载节假日并替换日历中所有的节假日，扩展名为 .ics 的文件按照 iCalendar 解析，其他文件按照 JSON 解析
+// The LoadHolidays method loads holidays from a file and replaces all the holidays of the calendar, files with the .ics extension are parsed as iCalendar, other files are parsed as JSON
+func (c *Calendar) LoadHolidays(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	parse := ParseJSON
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		parse = ParseICS
+	}
+	holidays, err := parse(file)
+	if err != nil {
+		return err
+	}
+	return c.SetHolidays(holidays)
+}
@@ -0,0 +1,69 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20240101\r\n" +
+	"SUMMARY:New Year\\, observed\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"DTSTART;VALUE=DATE:20240210\r\n" +
+	"DTEND;VALUE=DATE:20240213\r\n" +
+	"SUMMARY:Spring\r\n" +
+	"  Festival\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	holidays, err := ParseICS(strings.NewReader(testICS))
+	assert.Nil(t, err)
+	assert.Equal(t, []Holiday{
+		{Date: "2024-01-01", Name: "New Year, observed"},
+		{Date: "2024-02-10", Name: "Spring Festival"},
+		{Date: "2024-02-11", Name: "Spring Festival"},
+		{Date: "2024-02-12", Name: "Spring Festival"},
+	}, holidays)
+
+	_, err = ParseICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:broken\nEND:VEVENT\n"))
+	assert.NotNil(t, err)
+	_, err = ParseICS(strings.NewReader("BEGIN:VEVENT\nDTSTART:2024\nEND:VEVENT\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseJSON(t *testing.T) {
+	holidays, err := ParseJSON(strings.NewReader(`[{"date": "2024-05-01", "name": "Labour Day"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Holiday{{Date: "2024-05-01", Name: "Labour Day"}}, holidays)
+
+	_, err = ParseJSON(strings.NewReader(`[{"date": "05/01/2024"}]`))
+	assert.NotNil(t, err)
+}
+
+func TestCalendar_LoadHolidays(t *testing.T) {
+	dir := t.TempDir()
+	icsPath := filepath.Join(dir, "holidays.ics")
+	jsonPath := filepath.Join(dir, "holidays.json")
+	assert.Nil(t, os.WriteFile(icsPath, []byte(testICS), 0o644))
+	assert.Nil(t, os.WriteFile(jsonPath, []byte(`[{"date": "2024-05-01", "name": "Labour Day"}]`), 0o644))
+
+	c := NewCalendar(time.UTC)
+	assert.Nil(t, c.LoadHolidays(icsPath))
+	assert.Equal(t, 4, len(c.Holidays()))
+	assert.True(t, c.IsHoliday(time.Date(2024, 2, 11, 8, 0, 0, 0, time.UTC)))
+
+	// Loading replaces every holiday
+	assert.Nil(t, c.LoadHolidays(jsonPath))
+	assert.Equal(t, []Holiday{{Date: "2024-05-01", Name: "Labour Day"}}, c.Holidays())
+
+	assert.NotNil(t, c.LoadHolidays(filepath.Join(dir, "missing.json")))
+}
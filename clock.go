@@ -0,0 +1,109 @@
+package kairos
+
+import "time"
+
+// DefaultClockJumpThreshold 是默认的时钟跳变阈值，墙上时钟与单调时钟的差异超过它时被视为时钟跳变
+// DefaultClockJumpThreshold is the default threshold of a clock jump, a difference between the wall clock and the monotonic clock exceeding it is considered a clock jump
+const DefaultClockJumpThreshold = time.Second
+
+// ClockMode 是任务计算执行时间所使用的时钟
+// ClockMode is the clock a task uses to calculate its execution time
+type ClockMode int
+
+// 定义任务使用的时钟
+// Define the clocks used by tasks
+const (
+	// ClockMonotonic 表示使用单调时钟，任务在添加之后经过指定的时间执行，不受系统时钟调整的影响，适用于相对的延迟
+	// ClockMonotonic means the monotonic clock is used, the task runs after the specified time has elapsed since it was added, regardless of adjustments of the system clock, it suits relative delays
+	ClockMonotonic ClockMode = iota
+
+	// ClockWall 表示使用墙上时钟，任务在系统时钟到达执行时间时执行，系统时钟被校正或者主机休眠之后会重新计算，适用于 "明天 03:00" 这样的绝对时间
+	// ClockWall means the wall clock is used, the task runs when the system clock reaches the execution time, it is recalculated after the system clock is corrected or the host suspends, it suits absolute times such as "03:00 tomorrow"
+	ClockWall
+)
+
+// String 方法返回时钟的名称
+// The String method returns the name of the clock
+func (m ClockMode) String() string {
+	switch m {
+	case ClockMonotonic:
+		return "monotonic"
+	case ClockWall:
+		return "wall"
+	}
+	return "unknown"
+}
+
+// ClockCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，调度器检测到时钟跳变时会调用它
+// ClockCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it when a clock jump is detected
+type ClockCallback interface {
+	// OnClockJump 是当检测到时钟跳变时的回调函数，它接收跳变的大小（向前为正，向后为负）和重新计算执行时间的任务数量作为参数
+	// OnClockJump is the callback function when a clock jump is detected, it takes the size of the jump (positive forwards, negative backwards) and the number of tasks whose execution time was recalculated as parameters
+	OnClockJump(jump time.Duration, rearmed int)
+}
+
+// watchClock 是一个方法，定期比较墙上时钟和单调时钟经过的时间，差异超过阈值时重新计算所有使用墙上时钟的任务，调度器停止时返回。
+// 只有在添加了第一个使用墙上时钟的任务之后才会启动。
+// watchClock is a method comparing the time elapsed on the wall clock and on the monotonic clock periodically, all tasks using the wall clock are recalculated when the difference exceeds the threshold, it returns when the scheduler stops.
+// It is only started after the first task using the wall clock is added.
+func (s *Scheduler) watchClock() {
+	ticker := time.NewTicker(s.cfg.clockJumpThreshold)
+	defer ticker.Stop()
+
+	// 单调时钟的读数来自 time.Now，墙上时钟的读数去掉了单调时钟的部分。
+	// The monotonic reading comes from time.Now, the wall reading has the monotonic part stripped.
+	lastMono := time.Now()
+	lastWall := s.wallClock().Round(0)
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mono := time.Now()
+		wall := s.wallClock().Round(0)
+		jump := wall.Sub(lastWall) - mono.Sub(lastMono)
+		lastMono, lastWall = mono, wall
+
+		// 差异没有超过阈值时，认为时钟是正常的。
+		// The clock is considered normal if the difference does not exceed the threshold.
+		if jump < s.cfg.clockJumpThreshold && jump > -s.cfg.clockJumpThreshold {
+			continue
+		}
+
+		// 重新计算所有使用墙上时钟的任务，并通知时钟跳变。
+		// Recalculate all tasks using the wall clock, and notify the clock jump.
+		rearmed := 0
+		for _, id := range s.taskCache.Keys() {
+			if s.rearm(id) {
+				rearmed++
+			}
+		}
+		if callback, ok := s.cfg.callback.(ClockCallback); ok {
+			callback.OnClockJump(jump, rearmed)
+		}
+		s.events.publish(&Event{Type: EventClockJump, Time: wall, Jump: jump})
+	}
+}
+
+// rearm 是一个方法，使用相同的执行时间重新启动使用墙上时钟、正在等待的任务，使截止时间按照当前的墙上时钟重新计算，返回任务是否被重新启动
+// rearm is a method restarting a pending task using the wall clock with the same execution time, so its deadline is recalculated from the current wall clock, it returns whether the task was restarted
+func (s *Scheduler) rearm(id string) bool {
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return false
+	}
+	defer taskRef.lock.Unlock()
+
+	// 被暂停的任务在恢复时会重新计算，已经触发的任务不能重新启动。
+	// A paused task is recalculated when it is resumed, a task that has fired can not be restarted.
+	if taskRef.clockMode != ClockWall || taskRef.paused || !taskRef.task.stop(errTaskReplaced) {
+		return false
+	}
+
+	taskRef.parentRef.cancel()
+	s.arm(taskRef)
+	return true
+}
@@ -0,0 +1,115 @@
+package kairos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClockCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	jumps   []time.Duration
+	rearmed []int
+}
+
+func (c *testClockCallback) OnClockJump(jump time.Duration, rearmed int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.jumps = append(c.jumps, jump)
+	c.rearmed = append(c.rearmed, rearmed)
+}
+
+func (c *testClockCallback) reported() ([]time.Duration, []int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]time.Duration(nil), c.jumps...), append([]int(nil), c.rearmed...)
+}
+
+func TestClockMode_String(t *testing.T) {
+	assert.Equal(t, "monotonic", ClockMonotonic.String())
+	assert.Equal(t, "wall", ClockWall.String())
+	assert.Equal(t, "unknown", ClockMode(-1).String())
+}
+
+func TestScheduler_ClockJump(t *testing.T) {
+	callback := &testClockCallback{}
+	scheduler := New(NewConfig().WithCallback(callback).WithClockMode(ClockWall).WithClockJumpThreshold(time.Millisecond * 50))
+	defer scheduler.Stop()
+
+	// Simulate the wall clock with an adjustable offset
+	var offset atomic.Int64
+	scheduler.wallClock = func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+
+	events, unsubscribe := scheduler.Subscribe(16)
+	defer unsubscribe()
+
+	var count atomic.Int32
+	handleFunc := func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}
+
+	// Absolute tasks use the configured wall clock, relative tasks keep the monotonic clock
+	wallID, err := scheduler.SetAt("wall", handleFunc, time.Now().Add(time.Millisecond*400))
+	assert.Nil(t, err)
+	monoID, err := scheduler.Set("mono", handleFunc, time.Hour)
+	assert.Nil(t, err)
+	forcedID, err := scheduler.SetWithOptions("forced", handleFunc, time.Hour, NewTaskOptions().WithClockMode(ClockWall))
+	assert.Nil(t, err)
+
+	// A jump of the wall clock re-arms only the tasks using the wall clock
+	time.Sleep(time.Millisecond * 80)
+	offset.Store(int64(time.Minute))
+	assert.Eventually(t, func() bool {
+		jumps, _ := callback.reported()
+		return len(jumps) == 1
+	}, time.Second, time.Millisecond*10)
+	jumps, rearmed := callback.reported()
+	assert.InDelta(t, float64(time.Minute), float64(jumps[0]), float64(time.Millisecond*50))
+	assert.Equal(t, []int{2}, rearmed)
+
+	var jump *Event
+	assert.Eventually(t, func() bool {
+		for {
+			select {
+			case event := <-events:
+				if event.Type == EventClockJump {
+					jump = event
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, jumps[0], jump.Jump)
+	assert.Empty(t, jump.ID)
+
+	// Re-armed tasks keep their IDs and still fire at their execution time
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond*10)
+	_, err = scheduler.Get(wallID)
+	assert.ErrorIs(t, err, ErrorTaskNotFound)
+	assert.Equal(t, 2, scheduler.Count())
+	scheduler.Delete(monoID)
+	scheduler.Delete(forcedID)
+}
+
+func TestScheduler_ClockModeDefault(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	handleFunc := func(_ WaitForContextDone) (any, error) { return nil, nil }
+
+	// Without WithClockMode every task uses the monotonic clock, no watcher is started
+	_, err := scheduler.SetAt("at", handleFunc, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	_, err = scheduler.Set("delay", handleFunc, time.Hour)
+	assert.Nil(t, err)
+	started := true
+	scheduler.clockOnce.Do(func() { started = false })
+	assert.False(t, started)
+}
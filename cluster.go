@@ -0,0 +1,325 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClusterCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，调度器会在领导者身份变化、持久化、协调或者加锁失败时调用它
+// ClusterCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it when the leadership changes, or persisting, coordinating or locking fails
+type ClusterCallback interface {
+	// OnLeadershipChanged 是当节点获得或者失去领导者租约时的回调函数，它接收节点名称和节点是否为领导者作为参数
+	// OnLeadershipChanged is the callback function when the node gains or loses the leader lease, it takes the node name and whether the node is the leader as parameters
+	OnLeadershipChanged(node string, leader bool)
+
+	// OnClusterError 是当 Store、Coordinator 或者 Locker 返回错误时的回调函数，它接收任务 id 和错误作为参数，与具体任务无关时任务 id 为空
+	// OnClusterError is the callback function when the Store, the Coordinator or the Locker returns an error, it takes the task id and the error as parameters, the task id is empty if the error is not related to a task
+	OnClusterError(id string, err error)
+}
+
+// cluster 结构体包含调度器作为集群节点的状态
+// The cluster struct contains the state of the scheduler as a node of the cluster
+type cluster struct {
+	// leader 表示节点是否持有领导者租约
+	// leader indicates whether the node holds the leader lease
+	leader atomic.Bool
+
+	// done 在维护租约的 goroutine 退出时被关闭
+	// done is closed when the goroutine maintaining the lease exits
+	done chan struct{}
+
+	// lock 用于保护 adopted
+	// lock is used to protect adopted
+	lock sync.Mutex
+
+	// adopted 是领导者从 Store 中接管的任务的 ID
+	// adopted are the IDs of the tasks the leader took over from the Store
+	adopted map[string]struct{}
+}
+
+// defaultNodeName 函数返回由主机名和进程 ID 组成的节点名称
+// The defaultNodeName function returns the node name made of the host name and the process ID
+func defaultNodeName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// newTaskDefinition 函数根据任务引用创建任务的定义，调用者需要持有任务引用的锁
+// The newTaskDefinition function creates the definition of a task from the task reference, the caller must hold the lock of the task reference
+func newTaskDefinition(taskRef *TaskRef) *TaskDefinition {
+	def := &TaskDefinition{
+		ID:       taskRef.id,
+		Name:     taskRef.name,
+		Handler:  taskRef.handler,
+		ExecAt:   taskRef.execAt,
+		Labels:   taskRef.labels.clone(),
+		Priority: taskRef.priority,
+		Uniqued:  taskRef.uniqKey != "",
+
+		MisfireThreshold: taskRef.misfireThreshold,
+		MisfirePolicy:    taskRef.misfirePolicy,
+		ClockMode:        taskRef.clockMode,
+	}
+	if taskRef.tenant != nil {
+		def.Tenant = taskRef.tenant.name
+	}
+	if taskRef.group != nil {
+		def.Group = taskRef.group.name
+	}
+	def.Schedule, _ = scheduleText(taskRef.schedule)
+	return def
+}
+
+// claimKey 函数返回一次触发在协调器中的键，同一个任务在同一个执行时间的触发在所有节点上使用相同的键
+// The claimKey function returns the key of a firing in the coordinator, the firings of the same task at the same execution time use the same key on all nodes
+func claimKey(id string, execAt time.Time) string {
+	return id + "@" + execAt.UTC().Format(time.RFC3339Nano)
+}
+
+// reportClusterError 是一个方法，通过 ClusterCallback 报告 Store、Coordinator 或者 Locker 返回的错误
+// reportClusterError is a method reporting the error returned by the Store, the Coordinator or the Locker through ClusterCallback
+func (s *Scheduler) reportClusterError(id string, err error) {
+	if callback, ok := s.cfg.callback.(ClusterCallback); ok {
+		callback.OnClusterError(id, err)
+	}
+}
+
+// persist 是一个方法，将任务的定义保存到 Store，调用者需要持有任务引用的锁
+// persist is a method saving the definition of the task to the Store, the caller must hold the lock of the task reference
+func (s *Scheduler) persist(taskRef *TaskRef) {
+	if err := s.cfg.store.Save(newTaskDefinition(taskRef)); err != nil {
+		s.reportClusterError(taskRef.id, err)
+	}
+}
+
+// unpersist 是一个方法，从 Store 中删除任务的定义
+// unpersist is a method deleting the definition of the task from the Store
+func (s *Scheduler) unpersist(id string) {
+	if err := s.cfg.store.Delete(id); err != nil {
+		s.reportClusterError(id, err)
+	}
+}
+
+// claim 是一个方法，返回持久化的任务在触发之前认领这次触发的回调函数。如果 Store 中的任务已经被删除或者执行时间已经改变，
+// 说明这次触发已经过时，任务被跳过并保留 Store 中的定义；如果这次触发已经被其他节点认领，任务被跳过，Store 中的定义由执行它的节点删除。
+// claim is a method returning the callback function a persisted task uses to claim the firing before it fires. If the task has been deleted from the Store or its execution time has changed,
+// the firing is stale, the task is skipped and the definition in the Store is kept; if the firing has been claimed by another node, the task is skipped and the definition in the Store is deleted by the node executing it.
+func (s *Scheduler) claim(taskRef *TaskRef, task *Task) onFireHandleFunc {
+	// keep 在任务被移除时保留 Store 中的定义
+	// keep keeps the definition in the Store when the task is removed
+	keep := func() {
+		taskRef.lock.Lock()
+		if taskRef.task == task {
+			taskRef.persisted = false
+		}
+		taskRef.lock.Unlock()
+	}
+
+	return func(metadata *TaskMetadata) error {
+		// 检查 Store 中的定义，其他节点可能已经删除或者重新调度了这个任务。
+		// Check the definition in the Store, another node may have deleted or rescheduled the task.
+		def, err := s.cfg.store.Get(metadata.id)
+		switch {
+		case errors.Is(err, ErrorTaskNotFound):
+			keep()
+			return ErrorTaskClaimedElsewhere
+		case err != nil:
+			s.reportClusterError(metadata.id, err)
+		case !def.ExecAt.Equal(metadata.execAt):
+			keep()
+			return ErrorTaskClaimedElsewhere
+		}
+
+		// 认领这次触发，只有认领成功的节点执行处理函数。
+		// Claim the firing, only the node that claims it successfully executes the handling function.
+		claimed, err := s.cfg.coordinator.ClaimTask(s.cfg.node, claimKey(metadata.id, metadata.execAt), DefaultClaimTTL)
+		if err != nil {
+			s.reportClusterError(metadata.id, err)
+			keep()
+			return fmt.Errorf("%w: %v", ErrorTaskSkipped, err)
+		}
+		if !claimed {
+			return ErrorTaskClaimedElsewhere
+		}
+		return nil
+	}
+}
+
+// restore 是一个方法，从 Store 中恢复任务，已经在调度器中的任务会被跳过，返回被恢复的任务的 ID
+// restore is a method restoring tasks from the Store, tasks already in the scheduler are skipped, it returns the IDs of the restored tasks
+func (s *Scheduler) restore(defs []*TaskDefinition) []string {
+	ids := make([]string, 0, len(defs))
+	for _, def := range defs {
+		// 如果调度器已经停止或者任务已经存在，跳过它。
+		// Skip the task if the scheduler has stopped or the task already exists.
+		if !s.running.Load() {
+			break
+		}
+		if _, ok := s.taskCache.Get(def.ID); ok {
+			continue
+		}
+
+		// 从注册表中查找处理函数，没有注册的处理函数无法恢复。
+		// Look up the handling function in the registry, tasks whose handling function is not registered can not be restored.
+		handleFunc, ok := s.cfg.registry.Lookup(def.Handler)
+		if !ok {
+			s.reportClusterError(def.ID, fmt.Errorf("%w: %s", ErrorHandlerNotFound, def.Handler))
+			continue
+		}
+
+		// 使用保存的 ID、时间表和选项添加任务，任务不需要再次被保存。
+		// Add the task with the saved ID, timetable and options, the task does not need to be saved again.
+		opts := &TaskOptions{labels: def.Labels.clone(), priority: def.Priority, tenant: def.Tenant, id: def.ID, restored: true}
+		opts.uniqued, opts.clockMode, opts.clockSet = def.Uniqued, def.ClockMode, true
+		if def.MisfireThreshold > 0 {
+			opts.misfireThreshold, opts.misfirePolicy, opts.misfireSet = def.MisfireThreshold, def.MisfirePolicy, true
+		}
+		if def.Group != "" {
+			opts.group = s.Group(def.Group)
+		}
+		if def.Schedule != "" {
+			schedule, err := ParseSchedule(def.Schedule)
+			if err != nil {
+				s.reportClusterError(def.ID, err)
+				continue
+			}
+			opts.schedule = schedule
+		}
+		if id, _ := s.add(def.Name, def.Handler, handleFunc, def.ExecAt, opts); id == def.ID {
+			s.notifyAdded(id, def.Name, opts.labels, def.ExecAt)
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// runCluster 是一个方法，维护领导者租约直到调度器停止，领导者定期从 Store 中接管其他节点的任务
+// runCluster is a method maintaining the leader lease until the scheduler stops, the leader takes over the tasks of other nodes from the Store periodically
+func (s *Scheduler) runCluster() {
+	defer close(s.cluster.done)
+
+	ticker := time.NewTicker(s.cfg.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		// 获取或者续期租约，领导者同步 Store 中的任务。
+		// Acquire or renew the lease, the leader synchronizes the tasks in the Store.
+		if s.elect() && s.cfg.store != nil {
+			s.reconcile()
+		}
+
+		select {
+		case <-s.ctx.Done():
+			// 调度器停止时释放租约，其他节点不需要等待租约过期。
+			// Release the lease when the scheduler stops, other nodes do not need to wait for the lease to expire.
+			if s.cluster.leader.Swap(false) {
+				if err := s.cfg.coordinator.ReleaseLease(s.cfg.node); err != nil {
+					s.reportClusterError("", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect 是一个方法，领导者续期租约，其他节点尝试获取租约，返回节点是否为领导者。协调器返回错误时节点不再是领导者
+// elect is a method where the leader renews the lease and other nodes try to acquire it, it returns whether the node is the leader. The node is no longer the leader when the coordinator returns an error
+func (s *Scheduler) elect() bool {
+	var leader bool
+	var err error
+	if s.cluster.leader.Load() {
+		leader, err = s.cfg.coordinator.RenewLease(s.cfg.node, s.cfg.leaseTTL)
+	} else {
+		leader, err = s.cfg.coordinator.AcquireLease(s.cfg.node, s.cfg.leaseTTL)
+	}
+	if err != nil {
+		s.reportClusterError("", err)
+		leader = false
+	}
+
+	// 如果领导者身份发生变化，通知回调函数。
+	// If the leadership changed, notify the callback function.
+	if s.cluster.leader.Swap(leader) != leader {
+		if callback, ok := s.cfg.callback.(ClusterCallback); ok {
+			callback.OnLeadershipChanged(s.cfg.node, leader)
+		}
+	}
+	return leader
+}
+
+// reconcile 是一个方法，将调度器中接管的任务与 Store 同步：接管新的任务，重新调度执行时间已经改变的任务，删除已经不在 Store 中的任务
+// reconcile is a method synchronizing the tasks taken over by the scheduler with the Store: it takes over new tasks, reschedules tasks whose execution time has changed, and deletes tasks no longer in the Store
+func (s *Scheduler) reconcile() {
+	defs, err := s.cfg.store.Load()
+	if err != nil {
+		s.reportClusterError("", err)
+		return
+	}
+
+	// 重新调度执行时间已经改变的接管的任务。
+	// Reschedule the taken over tasks whose execution time has changed.
+	stored := make(map[string]struct{}, len(defs))
+	for _, def := range defs {
+		stored[def.ID] = struct{}{}
+		if !s.isAdopted(def.ID) {
+			continue
+		}
+		if info, err := s.GetInfo(def.ID); err == nil && !info.ExecAt.Equal(def.ExecAt) {
+			_ = s.Reschedule(def.ID, def.ExecAt)
+		}
+	}
+
+	// 接管调度器中还没有的任务。
+	// Take over the tasks not in the scheduler yet.
+	adopted := s.restore(defs)
+
+	// 更新接管的任务，删除已经不在 Store 中的任务，已经结束的任务不再跟踪。
+	// Update the taken over tasks, delete the tasks no longer in the Store, finished tasks are no longer tracked.
+	s.cluster.lock.Lock()
+	for _, id := range adopted {
+		s.cluster.adopted[id] = struct{}{}
+	}
+	removed := make([]string, 0)
+	for id := range s.cluster.adopted {
+		if _, ok := stored[id]; !ok {
+			removed = append(removed, id)
+			delete(s.cluster.adopted, id)
+		} else if _, ok := s.taskCache.Get(id); !ok {
+			delete(s.cluster.adopted, id)
+		}
+	}
+	s.cluster.lock.Unlock()
+
+	for _, id := range removed {
+		s.remove(id)
+	}
+}
+
+// isAdopted 是一个方法，返回指定 ID 的任务是否是从 Store 中接管的
+// isAdopted is a method returning whether the task with the specified ID was taken over from the Store
+func (s *Scheduler) isAdopted(id string) bool {
+	s.cluster.lock.Lock()
+	defer s.cluster.lock.Unlock()
+	_, ok := s.cluster.adopted[id]
+	return ok
+}
+
+// Node 是一个方法，返回调度器在协调器中的节点名称，没有配置协调器时为空
+// Node is a method returning the node name of the scheduler in the coordinator, it is empty if no coordinator is configured
+func (s *Scheduler) Node() string {
+	return s.cfg.node
+}
+
+// IsLeader 是一个方法，返回调度器是否持有领导者租约，没有配置协调器时返回 false
+// IsLeader is a method returning whether the scheduler holds the leader lease, it returns false if no coordinator is configured
+func (s *Scheduler) IsLeader() bool {
+	return s.cluster != nil && s.cluster.leader.Load()
+}
@@ -0,0 +1,320 @@
+package kairos
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testClusterCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	changes []bool
+}
+
+func (c *testClusterCallback) OnLeadershipChanged(node string, leader bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.changes = append(c.changes, leader)
+}
+
+func (c *testClusterCallback) OnClusterError(id string, err error) {}
+
+func (c *testClusterCallback) leadership() []bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]bool(nil), c.changes...)
+}
+
+func TestScheduler_StoreRestore(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry().Register("noop", DefaultTaskHandleFunc)
+
+	scheduler := New(NewConfig().WithRegistry(registry).WithStore(store))
+	taskID, err := scheduler.SetAtRegisteredWithOptions("test", "noop", time.Now().Add(time.Hour), NewTaskOptions().WithPriority(2).WithTenant("acme"))
+	assert.Nil(t, err)
+
+	// Tasks with a handling function passed directly are not persisted
+	_, err = scheduler.Set("direct", DefaultTaskHandleFunc, time.Hour)
+	assert.Nil(t, err)
+
+	defs, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(defs))
+	assert.Equal(t, taskID, defs[0].ID)
+
+	// Rescheduling saves the new execution time
+	execAt := time.Now().Add(time.Hour * 2)
+	assert.Nil(t, scheduler.Reschedule(taskID, execAt))
+	def, err := store.Get(taskID)
+	assert.Nil(t, err)
+	assert.True(t, def.ExecAt.Equal(execAt))
+
+	// Stopping the scheduler keeps the persisted tasks
+	scheduler.Stop()
+	_, err = store.Get(taskID)
+	assert.Nil(t, err)
+
+	// A new scheduler restores them with the same ID
+	scheduler = New(NewConfig().WithRegistry(registry).WithStore(store))
+	defer scheduler.Stop()
+	assert.Equal(t, 1, scheduler.Count())
+	info, err := scheduler.GetInfo(taskID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, info.Priority)
+	assert.Equal(t, "acme", info.Tenant)
+	assert.True(t, info.ExecAt.Equal(execAt))
+
+	// Deleting the task deletes it from the store
+	scheduler.Delete(taskID)
+	_, err = store.Get(taskID)
+	assert.ErrorIs(t, err, ErrorTaskNotFound)
+}
+
+type testScheduleFunc func(after time.Time) time.Time
+
+func (f testScheduleFunc) Next(after time.Time) time.Time { return f(after) }
+
+func TestScheduler_StoreRestoreRecurring(t *testing.T) {
+	store := NewMemoryStore()
+	registry := NewRegistry().Register("noop", DefaultTaskHandleFunc)
+	execAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	scheduler := New(NewConfig().WithRegistry(registry).WithStore(store))
+	cron, err := ParseCron("CRON_TZ=UTC 0 3 * * *")
+	assert.Nil(t, err)
+	rule, err := ParseSchedule("DTSTART:20200101T000000Z\nRRULE:FREQ=DAILY;INTERVAL=2")
+	assert.Nil(t, err)
+	opts := scheduler.Group("reports").options(NewTaskOptions().
+		WithSchedule(Every(time.Minute)).
+		WithMisfire(time.Second, MisfireCoalesce).
+		WithClockMode(ClockWall).
+		WithUniqued(true))
+	everyID, err := scheduler.SetAtRegisteredWithOptions("every", "noop", execAt, opts)
+	assert.Nil(t, err)
+	cronID, err := scheduler.SetAtRegisteredWithOptions("cron", "noop", execAt, NewTaskOptions().WithSchedule(cron))
+	assert.Nil(t, err)
+	ruleID, err := scheduler.SetAtRegisteredWithOptions("rule", "noop", execAt, NewTaskOptions().WithSchedule(rule))
+	assert.Nil(t, err)
+
+	// Timetables which can not be turned into a text are not persisted
+	_, err = scheduler.SetAtRegisteredWithOptions("custom", "noop", execAt, NewTaskOptions().WithSchedule(testScheduleFunc(func(after time.Time) time.Time { return after.Add(time.Hour) })))
+	assert.Nil(t, err)
+	defs, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(defs))
+
+	def, err := store.Get(everyID)
+	assert.Nil(t, err)
+	assert.Equal(t, "@every 1m0s", def.Schedule)
+	assert.Equal(t, "reports", def.Group)
+	assert.True(t, def.Uniqued)
+	assert.Equal(t, time.Second, def.MisfireThreshold)
+	assert.Equal(t, MisfireCoalesce, def.MisfirePolicy)
+	assert.Equal(t, ClockWall, def.ClockMode)
+
+	upcoming := make(map[string][]time.Time)
+	for _, id := range []string{everyID, cronID, ruleID} {
+		upcoming[id], err = scheduler.Upcoming(id, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(upcoming[id]))
+	}
+	scheduler.Stop()
+
+	// A new scheduler restores the timetables and the options
+	scheduler = New(NewConfig().WithRegistry(registry).WithStore(store))
+	defer scheduler.Stop()
+	assert.Equal(t, 3, scheduler.Count())
+	for _, id := range []string{everyID, cronID, ruleID} {
+		times, err := scheduler.Upcoming(id, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, len(upcoming[id]), len(times))
+		for i := range times {
+			assert.True(t, upcoming[id][i].Equal(times[i]))
+		}
+	}
+	info, err := scheduler.GetInfo(everyID)
+	assert.Nil(t, err)
+	assert.Equal(t, "reports", info.Group)
+	taskRef, err := scheduler.lookup(everyID)
+	assert.Nil(t, err)
+	assert.Equal(t, "every", taskRef.uniqKey)
+	assert.Equal(t, time.Second, taskRef.misfireThreshold)
+	assert.Equal(t, MisfireCoalesce, taskRef.misfirePolicy)
+	assert.Equal(t, ClockWall, taskRef.clockMode)
+	taskRef.lock.Unlock()
+
+	// An invalid timetable is reported and the task is not restored
+	assert.Empty(t, scheduler.restore([]*TaskDefinition{{ID: "broken", Name: "broken", Handler: "noop", ExecAt: execAt, Schedule: "not a schedule"}}))
+}
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("@every 90s")
+	assert.Nil(t, err)
+	assert.Equal(t, "@every 1m30s", schedule.(interval).String())
+
+	for _, text := range []string{"@every 0s", "@every soon", "FREQ=FORTNIGHTLY", "* *"} {
+		_, err = ParseSchedule(text)
+		assert.NotNil(t, err, text)
+	}
+	_, err = ParseSchedule("@every -1s")
+	assert.True(t, errors.Is(err, ErrorInvalidCron))
+
+	// Every timetable turns into a text which parses into the same timetable
+	for _, text := range []string{"@every 1h0m0s", "CRON_TZ=UTC */5 * * * *", "@daily", "DTSTART:20200101T000000Z\nRRULE:FREQ=WEEKLY;BYDAY=MO,FR"} {
+		schedule, err := ParseSchedule(text)
+		assert.Nil(t, err)
+		got, ok := scheduleText(schedule)
+		assert.True(t, ok)
+		assert.Equal(t, text, got)
+	}
+	_, ok := scheduleText(testScheduleFunc(nil))
+	assert.False(t, ok)
+}
+
+func TestScheduler_StoreExecuted(t *testing.T) {
+	store := NewMemoryStore()
+	executed := make(chan struct{}, 1)
+	registry := NewRegistry().Register("notify", func(_ WaitForContextDone) (any, error) {
+		executed <- struct{}{}
+		return nil, nil
+	})
+
+	scheduler := New(NewConfig().WithRegistry(registry).WithStore(store))
+	defer scheduler.Stop()
+
+	taskID, err := scheduler.SetRegistered("test", "notify", time.Millisecond*50)
+	assert.Nil(t, err)
+
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("task should be executed")
+	}
+
+	// The task is deleted from the store once it is removed from the scheduler
+	assert.Eventually(t, func() bool {
+		_, err := store.Get(taskID)
+		return err == ErrorTaskNotFound
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestScheduler_CoordinatorSingleFire(t *testing.T) {
+	store := NewMemoryStore()
+	coordinator := NewMemoryCoordinator()
+	var count atomic.Int32
+	registry := NewRegistry().Register("count", func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	})
+
+	newNode := func(node string) *Scheduler {
+		return New(NewConfig().WithRegistry(registry).WithStore(store).WithCoordinator(coordinator, node).WithLeaseTTL(time.Millisecond * 150))
+	}
+
+	leader := newNode("a")
+	assert.Eventually(t, leader.IsLeader, time.Second, time.Millisecond*10)
+	follower := newNode("b")
+	assert.Equal(t, "b", follower.Node())
+	assert.False(t, follower.IsLeader())
+
+	// The leader takes over the task of the follower, but only one of them executes it
+	taskID, err := follower.SetRegistered("test", "count", time.Millisecond*300)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := leader.GetInfo(taskID)
+		return err == nil
+	}, time.Second, time.Millisecond*10)
+
+	assert.Eventually(t, func() bool {
+		return leader.Count() == 0 && follower.Count() == 0
+	}, time.Second*2, time.Millisecond*10)
+	assert.Equal(t, int32(1), count.Load())
+	assert.Equal(t, uint64(1), leader.Stats().Executed+follower.Stats().Executed)
+	assert.Equal(t, uint64(1), leader.Stats().Skipped+follower.Stats().Skipped)
+
+	_, err = store.Get(taskID)
+	assert.ErrorIs(t, err, ErrorTaskNotFound)
+
+	leader.Stop()
+	follower.Stop()
+}
+
+func TestScheduler_CoordinatorTakeover(t *testing.T) {
+	store := NewMemoryStore()
+	coordinator := NewMemoryCoordinator()
+	executed := make(chan string, 1)
+	registry := NewRegistry().Register("notify", func(_ WaitForContextDone) (any, error) {
+		executed <- "done"
+		return nil, nil
+	})
+
+	callback := &testClusterCallback{}
+	newNode := func(node string, callback Callback) *Scheduler {
+		return New(NewConfig().WithRegistry(registry).WithStore(store).WithCoordinator(coordinator, node).WithLeaseTTL(time.Millisecond * 150).WithCallback(callback))
+	}
+
+	first := newNode("a", nil)
+	assert.Eventually(t, first.IsLeader, time.Second, time.Millisecond*10)
+	second := newNode("b", callback)
+	defer second.Stop()
+
+	// The first node goes away before its task fires
+	taskID, err := first.SetRegistered("test", "notify", time.Millisecond*500)
+	assert.Nil(t, err)
+	first.Stop()
+
+	// The second node becomes the leader and executes the task
+	assert.Eventually(t, second.IsLeader, time.Second, time.Millisecond*10)
+	assert.Equal(t, []bool{true}, callback.leadership())
+
+	select {
+	case <-executed:
+	case <-time.After(time.Second * 2):
+		t.Fatal("task should be taken over")
+	}
+	assert.Eventually(t, func() bool {
+		_, err := store.Get(taskID)
+		return err == ErrorTaskNotFound
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestScheduler_CoordinatorDeleted(t *testing.T) {
+	store := NewMemoryStore()
+	coordinator := NewMemoryCoordinator()
+	var count atomic.Int32
+	registry := NewRegistry().Register("count", func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	})
+
+	newNode := func(node string) *Scheduler {
+		return New(NewConfig().WithRegistry(registry).WithStore(store).WithCoordinator(coordinator, node).WithLeaseTTL(time.Millisecond * 150))
+	}
+
+	leader := newNode("a")
+	defer leader.Stop()
+	assert.Eventually(t, leader.IsLeader, time.Second, time.Millisecond*10)
+	follower := newNode("b")
+	defer follower.Stop()
+
+	taskID, err := follower.SetRegistered("test", "count", time.Millisecond*400)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := leader.GetInfo(taskID)
+		return err == nil
+	}, time.Second, time.Millisecond*10)
+
+	// Deleting the task on the follower deletes the copy taken over by the leader
+	follower.Delete(taskID)
+	assert.Eventually(t, func() bool {
+		return leader.Count() == 0
+	}, time.Second, time.Millisecond*10)
+
+	time.Sleep(time.Millisecond * 400)
+	assert.Equal(t, int32(0), count.Load())
+}
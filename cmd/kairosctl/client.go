@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/shengyanli1982/kairos/admin"
+)
+
+// Client 结构体是调度器管理接口的客户端
+// The Client struct is the client of the admin API of a scheduler
+type Client struct {
+	// baseURL 是管理接口的地址，包含挂载的路径
+	// baseURL is the address of the admin API, including the mount path
+	baseURL string
+
+	// headers 是每个请求都会携带的请求头，例如认证信息
+	// headers are the request headers sent with every request, e.g. credentials
+	headers http.Header
+
+	// httpClient 是发送请求的 HTTP 客户端
+	// httpClient is the HTTP client sending requests
+	httpClient *http.Client
+}
+
+// NewClient 函数用于创建一个新的 Client 实例，如果 socket 不为空，请求会通过 Unix 域套接字发送，此时 baseURL 中的主机名会被忽略
+// The NewClient function is used to create a new instance of Client, if socket is not empty, requests are sent over the Unix domain socket and the host in baseURL is ignored
+func NewClient(baseURL, socket string, headers http.Header, timeout time.Duration) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// 如果指定了 Unix 域套接字，所有的连接都通过它建立
+	// If the Unix domain socket is specified, all connections are established through it
+	if socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		headers:    headers,
+		httpClient: &http.Client{Transport: transport, Timeout: timeout},
+	}
+}
+
+// do 方法发送一个请求，并将 JSON 响应解析到 out 中，out 为 nil 时忽略响应体
+// The do method sends a request and decodes the JSON response into out, the response body is ignored if out is nil
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	// 编码请求体
+	// Encode the request body
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	// 发送请求
+	// Send the request
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 解析响应体
+	// Decode the response body
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send 方法发送一个请求，如果响应的状态码表示失败，则返回响应中的错误信息
+// The send method sends a request, it returns the error message in the response if the status code indicates a failure
+func (c *Client) send(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置请求头
+	// Set the request headers
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 如果请求失败，读取响应中的错误信息
+	// If the request failed, read the error message in the response
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return nil, errors.New(e.Error)
+	}
+
+	return resp, nil
+}
+
+// List 方法返回调度器中标签匹配选择器的任务的快照，选择器为空时返回所有任务
+// The List method returns the snapshots of the tasks in the scheduler whose labels match the selector, all tasks are returned if the selector is empty
+func (c *Client) List(ctx context.Context, selector string) ([]*ks.TaskInfo, error) {
+	path := "/tasks"
+	if selector != "" {
+		path += "?selector=" + url.QueryEscape(selector)
+	}
+
+	var infos []*ks.TaskInfo
+	err := c.do(ctx, http.MethodGet, path, nil, &infos)
+	return infos, err
+}
+
+// Stats 方法返回调度器的统计信息
+// The Stats method returns the statistics of the scheduler
+func (c *Client) Stats(ctx context.Context) (*ks.Stats, error) {
+	var stats ks.Stats
+	err := c.do(ctx, http.MethodGet, "/stats", nil, &stats)
+	return &stats, err
+}
+
+// Cancel 方法取消指定 id 的任务，id 作为路径的一段被转义
+// The Cancel method cancels the task with the specified id, the id is escaped as a path segment
+func (c *Client) Cancel(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/tasks/"+url.PathEscape(id), nil, nil)
+}
+
+// Fire 方法让指定 id 的任务提前执行
+// The Fire method makes the task with the specified id execute early
+func (c *Client) Fire(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/tasks/"+url.PathEscape(id)+"/"+string(admin.ActionEarlyReturn), nil, nil)
+}
+
+// Create 方法使用注册的处理函数创建一个任务
+// The Create method creates a task with a registered handling function
+func (c *Client) Create(ctx context.Context, req *admin.CreateRequest) (*ks.TaskInfo, error) {
+	var info ks.TaskInfo
+	err := c.do(ctx, http.MethodPost, "/tasks", req, &info)
+	return &info, err
+}
+
+// Tail 方法持续读取调度器的事件流，并对每个事件调用 fn，直到上下文被取消或者服务端关闭连接
+// The Tail method keeps reading the event stream of the scheduler and calls fn for each event, until the context is canceled or the server closes the connection
+func (c *Client) Tail(ctx context.Context, fn func(event *ks.Event) error) error {
+	// 事件流是长连接，不能使用客户端的超时时间
+	// The event stream is a long-lived connection, the timeout of the client can not be used
+	client := *c.httpClient
+	client.Timeout = 0
+	streamer := &Client{baseURL: c.baseURL, headers: c.headers, httpClient: &client}
+
+	resp, err := streamer.send(ctx, http.MethodGet, "/events", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 逐行解析事件
+	// Decode the events line by line
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event ks.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return fmt.Errorf("invalid event: %w", err)
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+
+	// 上下文被取消时，读取会失败，这不是一个错误
+	// Reading fails when the context is canceled, which is not an error
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,332 @@
+// kairosctl 是一个命令行工具，通过管理接口查看和控制运行中的调度器
+// kairosctl is a command-line tool to inspect and control a running scheduler through its admin API
+//
+//	kairosctl [-addr url] [-socket path] [-H "Key: Value"] <command> [arguments]
+//
+//	list   [-o table|json] [-l selector]           列出等待中的任务 / list pending tasks
+//	stats                                          调度器的统计信息 / statistics of the scheduler
+//	tail                                           输出事件流 / print the event stream
+//	cancel [-name pattern] [-l selector] [id...]  取消任务 / cancel tasks
+//	fire   [-name pattern] [-l selector] [id...]  让任务提前执行 / make tasks execute early
+//	dump   [-l selector]                           以 JSON 导出任务定义 / dump task definitions as JSON
+//	import [-f file]                               从 JSON 导入任务定义 / import task definitions from JSON
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/shengyanli1982/kairos/admin"
+)
+
+// ErrorNoTarget 表示没有指定任何任务
+// ErrorNoTarget indicates no task is specified
+var ErrorNoTarget = errors.New("no task id or -name pattern specified")
+
+// headerFlags 是可以重复指定的请求头参数
+// headerFlags is the request header flag that can be specified multiple times
+type headerFlags http.Header
+
+// String 方法返回参数的字符串形式
+// The String method returns the string form of the flag
+func (h headerFlags) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+// Set 方法解析 "Key: Value" 格式的请求头
+// The Set method parses a request header in the "Key: Value" format
+func (h headerFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("invalid header %q, expect \"Key: Value\"", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(key), strings.TrimSpace(val))
+	return nil
+}
+
+func main() {
+	// 收到中断信号时取消上下文，tail 命令会正常退出
+	// Cancel the context when an interrupt signal is received, the tail command exits normally
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run 函数解析参数并执行命令，返回进程的退出码
+// The run function parses the arguments and executes the command, it returns the exit code of the process
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	headers := headerFlags{}
+
+	// 解析全局参数
+	// Parse the global flags
+	flags := flag.NewFlagSet("kairosctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", "http://127.0.0.1:8080", "address of the admin API, including the mount path")
+	socket := flags.String("socket", "", "connect to the admin API over this Unix domain socket")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	flags.Var(headers, "H", "request header in the \"Key: Value\" format, can be repeated")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: kairosctl [flags] <list|stats|tail|cancel|fire|dump|import> [arguments]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	client := NewClient(*addr, *socket, http.Header(headers), *timeout)
+	command, rest := flags.Arg(0), flags.Args()[1:]
+
+	// 执行命令
+	// Execute the command
+	var err error
+	switch command {
+	case "list":
+		err = runList(ctx, client, rest, stdout, stderr)
+	case "stats":
+		err = runStats(ctx, client, stdout)
+	case "tail":
+		err = runTail(ctx, client, stdout)
+	case "cancel":
+		err = runEach(ctx, client, "cancel", rest, stdout, stderr, client.Cancel)
+	case "fire":
+		err = runEach(ctx, client, "fire", rest, stdout, stderr, client.Fire)
+	case "dump":
+		err = runDump(ctx, client, rest, stdout, stderr)
+	case "import":
+		err = runImport(ctx, client, rest, stdin, stdout, stderr)
+	default:
+		err = fmt.Errorf("unknown command %q", command)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "kairosctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runList 函数以表格或者 JSON 格式输出等待中的任务
+// The runList function prints the pending tasks as a table or as JSON
+func runList(ctx context.Context, client *Client, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	output := flags.String("o", "table", "output format, table or json")
+	selector := flags.String("l", "", "label selector, e.g. \"env=prod,tier in (web,api)\"")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	infos, err := client.List(ctx, *selector)
+	if err != nil {
+		return err
+	}
+
+	// 以 JSON 格式输出
+	// Print as JSON
+	if *output == "json" {
+		return writeJSON(stdout, infos)
+	}
+
+	// 以表格格式输出
+	// Print as a table
+	now := time.Now()
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tHANDLER\tSTATE\tEXEC AT\tIN\tLABELS")
+	for _, info := range infos {
+		handler := info.Handler
+		if handler == "" {
+			handler = "-"
+		}
+		labels := info.Labels.String()
+		if labels == "" {
+			labels = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", info.ID, info.Name, handler, info.State,
+			info.ExecAt.Local().Format(time.RFC3339), info.ExecAt.Sub(now).Round(time.Second), labels)
+	}
+	return tw.Flush()
+}
+
+// runStats 函数输出调度器的统计信息
+// The runStats function prints the statistics of the scheduler
+func runStats(ctx context.Context, client *Client, stdout io.Writer) error {
+	stats, err := client.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, stats)
+}
+
+// runTail 函数逐行输出调度器的事件，直到被中断
+// The runTail function prints the events of the scheduler line by line until it is interrupted
+func runTail(ctx context.Context, client *Client, stdout io.Writer) error {
+	return client.Tail(ctx, func(event *ks.Event) error {
+		line := fmt.Sprintf("%s %-10s %s %s", event.Time.Local().Format(time.RFC3339Nano), event.Type, event.ID, event.Name)
+		switch event.Type {
+		case ks.EventTaskAdded:
+			line += " exec_at=" + event.ExecAt.Local().Format(time.RFC3339)
+		case ks.EventTaskExecuted:
+			line += fmt.Sprintf(" reason=%q", event.Reason)
+			if event.Error != "" {
+				line += fmt.Sprintf(" error=%q", event.Error)
+			}
+		case ks.EventTaskRejected:
+			line += fmt.Sprintf(" error=%q", event.Error)
+		}
+		_, err := fmt.Fprintln(stdout, line)
+		return err
+	})
+}
+
+// runEach 函数对参数指定的任务和名称匹配模式的任务执行操作
+// The runEach function performs the operation on the tasks given as arguments and on the tasks whose name matches the pattern
+func runEach(ctx context.Context, client *Client, command string, args []string, stdout, stderr io.Writer, op func(ctx context.Context, id string) error) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pattern := flags.String("name", "", "also select tasks whose name matches this glob pattern, e.g. \"session-*\"")
+	selector := flags.String("l", "", "also select tasks whose labels match this selector, combined with -name if both are set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// 收集要操作的任务
+	// Collect the tasks to operate on
+	ids := flags.Args()
+	if *pattern != "" || *selector != "" {
+		// 检查匹配模式是否有效
+		// Check whether the pattern is valid
+		if _, err := path.Match(*pattern, ""); err != nil {
+			return err
+		}
+
+		// 服务端按标签选择器过滤，客户端按名称匹配模式过滤
+		// The server filters by the label selector, the client filters by the name pattern
+		infos, err := client.List(ctx, *selector)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			if ok, _ := path.Match(*pattern, info.Name); ok || *pattern == "" {
+				ids = append(ids, info.ID)
+			}
+		}
+	}
+	if len(ids) == 0 && *pattern == "" && *selector == "" {
+		return ErrorNoTarget
+	}
+
+	// 依次操作每个任务，失败的任务不会影响其他任务
+	// Operate on each task in turn, a failed task does not affect the others
+	var failed int
+	for _, id := range ids {
+		if err := op(ctx, id); err != nil {
+			fmt.Fprintf(stderr, "%s %s: %v\n", command, id, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "%s %s\n", command, id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tasks failed", failed, len(ids))
+	}
+	return nil
+}
+
+// runDump 函数以 JSON 格式输出所有任务的定义
+// The runDump function prints the definitions of all tasks as JSON
+func runDump(ctx context.Context, client *Client, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("dump", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	selector := flags.String("l", "", "only dump tasks whose labels match this selector")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	infos, err := client.List(ctx, *selector)
+	if err != nil {
+		return err
+	}
+
+	defs := make([]*ks.TaskDefinition, 0, len(infos))
+	for _, info := range infos {
+		defs = append(defs, &ks.TaskDefinition{ID: info.ID, Name: info.Name, Handler: info.Handler, ExecAt: info.ExecAt, Labels: info.Labels, Priority: info.Priority})
+	}
+	return writeJSON(stdout, defs)
+}
+
+// runImport 函数从文件或者标准输入读取 JSON 格式的任务定义，并在调度器中创建任务，没有处理函数名称的定义会被跳过
+// The runImport function reads task definitions in JSON format from a file or the standard input and creates the tasks in the scheduler, definitions without a handler name are skipped
+func runImport(ctx context.Context, client *Client, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	file := flags.String("f", "-", "file to read the definitions from, - for the standard input")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// 打开输入
+	// Open the input
+	input := stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	// 解析任务定义
+	// Decode the task definitions
+	var defs []*ks.TaskDefinition
+	if err := json.NewDecoder(input).Decode(&defs); err != nil {
+		return err
+	}
+
+	// 依次创建每个任务
+	// Create each task in turn
+	var failed int
+	for _, def := range defs {
+		if def.Handler == "" {
+			fmt.Fprintf(stderr, "import %s: skipped, no handler\n", def.Name)
+			failed++
+			continue
+		}
+		info, err := client.Create(ctx, &admin.CreateRequest{Name: def.Name, Handler: def.Handler, ExecAt: def.ExecAt, Labels: def.Labels, Priority: def.Priority})
+		if err != nil {
+			fmt.Fprintf(stderr, "import %s: %v\n", def.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "import %s %s\n", info.ID, info.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d definitions failed", failed, len(defs))
+	}
+	return nil
+}
+
+// writeJSON 函数以缩进的 JSON 格式输出数据
+// The writeJSON function prints the data as indented JSON
+func writeJSON(w io.Writer, data any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
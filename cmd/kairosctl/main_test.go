@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/shengyanli1982/kairos/admin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScheduler(t *testing.T) *ks.Scheduler {
+	registry := ks.NewRegistry().Register("noop", func(_ ks.WaitForContextDone) (any, error) { return nil, nil })
+	scheduler := ks.New(ks.NewConfig().WithRegistry(registry))
+	t.Cleanup(scheduler.Stop)
+	return scheduler
+}
+
+func newTestServer(t *testing.T, scheduler *ks.Scheduler) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin", admin.NewHandler(scheduler, nil)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_ListAndCancel(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	server := newTestServer(t, scheduler)
+	addr := server.URL + "/admin"
+
+	id1, _ := scheduler.Set("session-1", nil, time.Hour)
+	id2, _ := scheduler.Set("session-2", nil, time.Hour)
+	id3, _ := scheduler.Set("report", nil, time.Hour)
+
+	// List the tasks as a table
+	code, stdout, _ := runCommand("-addr", addr, "list")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "ID")
+	assert.Contains(t, stdout, id1)
+	assert.Contains(t, stdout, "report")
+
+	// List the tasks as JSON
+	code, stdout, _ = runCommand("-addr", addr, "list", "-o", "json")
+	assert.Equal(t, 0, code)
+	var infos []*ks.TaskInfo
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &infos))
+	assert.Equal(t, 3, len(infos))
+
+	// Cancel the tasks by name pattern
+	code, stdout, _ = runCommand("-addr", addr, "cancel", "-name", "session-*")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, id1)
+	assert.Contains(t, stdout, id2)
+	assert.Equal(t, 1, scheduler.Count())
+
+	// Fire the task by id
+	code, _, _ = runCommand("-addr", addr, "fire", id3)
+	assert.Equal(t, 0, code)
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 0, scheduler.Count())
+
+	// Operations on unknown tasks fail
+	code, _, stderr := runCommand("-addr", addr, "cancel", id3)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, ks.ErrorTaskNotFound.Error())
+
+	// No target specified
+	code, _, stderr = runCommand("-addr", addr, "fire")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, ErrorNoTarget.Error())
+}
+
+func TestRun_EscapedID(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	server := newTestServer(t, scheduler)
+	addr := server.URL + "/admin"
+
+	// IDs with reserved characters of URLs still hit the task route
+	for _, id := range []string{"a/b", "c?d=1", "e#f", "g%2Fh"} {
+		_, err := scheduler.SetWithOptions("task", nil, time.Hour, ks.NewTaskOptions().WithID(id))
+		assert.Nil(t, err)
+	}
+	code, _, stderr := runCommand("-addr", addr, "cancel", "a/b")
+	assert.Equal(t, 0, code, stderr)
+	code, _, stderr = runCommand("-addr", addr, "cancel", "c?d=1")
+	assert.Equal(t, 0, code, stderr)
+	code, _, stderr = runCommand("-addr", addr, "cancel", "g%2Fh")
+	assert.Equal(t, 0, code, stderr)
+	code, _, stderr = runCommand("-addr", addr, "fire", "e#f")
+	assert.Equal(t, 0, code, stderr)
+	assert.Eventually(t, func() bool { return scheduler.Count() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestRun_Labels(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	addr := newTestServer(t, scheduler).URL + "/admin"
+
+	prod, _ := scheduler.SetWithOptions("web", nil, time.Hour, ks.NewTaskOptions().WithLabels(ks.Labels{"env": "prod"}))
+	dev, _ := scheduler.SetWithOptions("web", nil, time.Hour, ks.NewTaskOptions().WithLabels(ks.Labels{"env": "dev"}))
+
+	// List the tasks matching the selector, the labels are printed
+	code, stdout, _ := runCommand("-addr", addr, "list", "-l", "env=prod")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, prod)
+	assert.Contains(t, stdout, "env=prod")
+	assert.NotContains(t, stdout, dev)
+
+	// Cancel the tasks matching the selector
+	code, stdout, _ = runCommand("-addr", addr, "cancel", "-l", "env!=prod")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, dev)
+	assert.Equal(t, 1, scheduler.Count())
+
+	// An invalid selector is rejected by the server
+	code, _, stderr := runCommand("-addr", addr, "fire", "-l", "env in prod")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, ks.ErrorInvalidSelector.Error())
+}
+
+func TestRun_DumpAndImport(t *testing.T) {
+	source := newTestScheduler(t)
+	_, _ = source.SetAtRegisteredWithOptions("cleanup", "noop", time.Now().Add(time.Hour), ks.NewTaskOptions().WithLabels(ks.Labels{"team": "ops"}))
+	_, _ = source.Set("adhoc", nil, time.Hour)
+
+	// Dump the definitions from the source scheduler
+	code, dump, _ := runCommand("-addr", newTestServer(t, source).URL+"/admin", "dump")
+	assert.Equal(t, 0, code)
+	var defs []*ks.TaskDefinition
+	assert.Nil(t, json.Unmarshal([]byte(dump), &defs))
+	assert.Equal(t, 2, len(defs))
+
+	// Import them into the target scheduler, the definition without a handler is skipped
+	target := newTestScheduler(t)
+	var stdout, stderr bytes.Buffer
+	code = run(context.Background(), []string{"-addr", newTestServer(t, target).URL + "/admin", "import"}, strings.NewReader(dump), &stdout, &stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "import adhoc: skipped")
+
+	infos := target.List()
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, "cleanup", infos[0].Name)
+	assert.Equal(t, "noop", infos[0].Handler)
+	assert.Equal(t, ks.Labels{"team": "ops"}, infos[0].Labels)
+}
+
+func TestRun_Tail(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	server := newTestServer(t, scheduler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Tail the events in the background
+	var stdout, stderr bytes.Buffer
+	done := make(chan int)
+	go func() {
+		done <- run(ctx, []string{"-addr", server.URL + "/admin", "tail"}, nil, &stdout, &stderr)
+	}()
+
+	// Wait for the subscription to be established
+	time.Sleep(time.Millisecond * 100)
+	id, _ := scheduler.Set("test", nil, time.Millisecond*50)
+	time.Sleep(time.Millisecond * 200)
+
+	cancel()
+	assert.Equal(t, 0, <-done)
+	assert.Contains(t, stdout.String(), "added")
+	assert.Contains(t, stdout.String(), "executed")
+	assert.Contains(t, stdout.String(), id)
+}
+
+func TestRun_UnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix domain sockets are not tested on windows")
+	}
+
+	scheduler := newTestScheduler(t)
+	id, _ := scheduler.Set("test", nil, time.Hour)
+
+	// Serve the admin API on a unix domain socket
+	socket := filepath.Join(t.TempDir(), "kairos.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.Nil(t, err)
+	server := &http.Server{Handler: admin.NewHandler(scheduler, nil)}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	code, stdout, _ := runCommand("-socket", socket, "-addr", "http://unix", "list")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, id)
+}
+
+func TestRun_Headers(t *testing.T) {
+	scheduler := newTestScheduler(t)
+	mux := http.NewServeMux()
+	mux.Handle("/", admin.NewHandler(scheduler, admin.NewConfig().WithAuthorizeFunc(func(r *http.Request, action admin.Action) error {
+		if r.Header.Get("X-Token") != "secret" {
+			return ks.ErrorSchedulerNotRunning
+		}
+		return nil
+	})))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	code, _, _ := runCommand("-addr", server.URL, "stats")
+	assert.Equal(t, 1, code)
+
+	code, stdout, _ := runCommand("-addr", server.URL, "-H", "X-Token: secret", "stats")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout, "pending")
+}
@@ -1,5 +1,11 @@
 package kairos
 
+import "time"
+
+// DefaultPriorityAging 是默认的优先级老化周期，处理函数每等待一个周期，有效优先级就加 1
+// DefaultPriorityAging is the default priority aging period, the effective priority of a handling function increases by 1 for every period it waits
+const DefaultPriorityAging = time.Second
+
 // Config 是一个结构体，包含一个 Callback 类型的字段和一个布尔类型的字段。
 // Config is a struct that contains a field of type Callback and a field of type bool.
 type Config struct {
@@ -10,6 +16,106 @@ type Config struct {
 	// uniqued 是一个布尔类型的字段，用于标识任务是否唯一。
 	// uniqued is a field of type bool, used to indicate whether the task is uniqued.
 	uniqued bool
+
+	// history 是一个指向 History 结构体的指针，用于记录任务的执行历史，为 nil 表示不记录。
+	// history is a pointer to the History struct, used to record the execution history of tasks, nil means no recording.
+	history *History
+
+	// registry 是一个指向 Registry 结构体的指针，用于按名称查找任务的处理函数。
+	// registry is a pointer to the Registry struct, used to look up handling functions of tasks by name.
+	registry *Registry
+
+	// maxConcurrency 是同时执行的处理函数的最大数量，为 0 表示不限制。
+	// maxConcurrency is the maximum number of handling functions running at the same time, 0 means unlimited.
+	maxConcurrency int
+
+	// priorityAging 是优先级的老化周期，不大于 0 表示不老化。
+	// priorityAging is the aging period of the priority, not greater than 0 means no aging.
+	priorityAging time.Duration
+
+	// store 用于持久化使用注册的处理函数添加的任务，为 nil 表示不持久化。
+	// store is used to persist tasks added with registered handling functions, nil means no persistence.
+	store Store
+
+	// coordinator 用于在多个调度器副本之间协调任务的执行，为 nil 表示单节点运行。
+	// coordinator is used to coordinate the execution of tasks among several scheduler replicas, nil means running as a single node.
+	coordinator Coordinator
+
+	// node 是调度器在协调器中的节点名称。
+	// node is the name of the scheduler as a node in the coordinator.
+	node string
+
+	// leaseTTL 是领导者租约的有效期，租约每三分之一个有效期续期一次。
+	// leaseTTL is the time to live of the leader lease, the lease is renewed every third of it.
+	leaseTTL time.Duration
+
+	// locker 用于在触发之前对每个任务加锁，为 nil 表示不加锁。
+	// locker is used to lock every task before it fires, nil means no locking.
+	locker Locker
+
+	// lockScope 是任务锁的范围。
+	// lockScope is the scope of the task lock.
+	lockScope LockScope
+
+	// lockTTL 是任务锁的有效期。
+	// lockTTL is the time to live of the task lock.
+	lockTTL time.Duration
+
+	// misfireThreshold 是任务允许的最大延迟，不大于 0 表示不检查延迟。
+	// misfireThreshold is the maximum lag allowed for tasks, not greater than 0 means the lag is not checked.
+	misfireThreshold time.Duration
+
+	// misfirePolicy 是延迟超过阈值时的处理策略。
+	// misfirePolicy is the policy applied when the lag exceeds the threshold.
+	misfirePolicy MisfirePolicy
+
+	// clockMode 是使用绝对执行时间添加的任务默认使用的时钟。
+	// clockMode is the default clock of tasks added with an absolute execution time.
+	clockMode ClockMode
+
+	// clockJumpThreshold 是时钟跳变的阈值，也是检查时钟的间隔。
+	// clockJumpThreshold is the threshold of a clock jump, it is also the interval of checking the clock.
+	clockJumpThreshold time.Duration
+
+	// rateLimit 是每秒允许触发的任务数量，不大于 0 表示不限制。
+	// rateLimit is the number of tasks allowed to fire per second, not greater than 0 means unlimited.
+	rateLimit float64
+
+	// rateBurst 是令牌桶的容量，即短时间内允许连续触发的任务数量。
+	// rateBurst is the capacity of the token bucket, that is the number of tasks allowed to fire in a short burst.
+	rateBurst int
+
+	// rateScope 是速率限制的范围。
+	// rateScope is the scope of the rate limit.
+	rateScope RateLimitScope
+
+	// maxPending 是调度器中任务的最大数量，不大于 0 表示不限制。
+	// maxPending is the maximum number of tasks in the scheduler, not greater than 0 means unlimited.
+	maxPending int
+
+	// nameQuota 是同名任务的最大数量，不大于 0 表示不限制。
+	// nameQuota is the maximum number of tasks with the same name, not greater than 0 means unlimited.
+	nameQuota int
+
+	// tenants 是租户的名称到租户设置的映射。
+	// tenants is the map from the name of a tenant to the settings of the tenant.
+	tenants map[string]*TenantConfig
+
+	// defaultTenant 是没有单独设置的租户使用的设置。
+	// defaultTenant is the settings used by tenants without their own settings.
+	defaultTenant *TenantConfig
+
+	// maxTenants 是没有单独设置的租户的最大数量，不大于 0 表示不限制。
+	// maxTenants is the maximum number of tenants without their own settings, not greater than 0 means unlimited.
+	maxTenants int
+
+	// batches 是任务名称到批量执行设置的映射。
+	// batches is the map from the name of a task to the settings of batch execution.
+	batches map[string]*BatchConfig
+
+	// idGenerator 是生成任务 ID 的函数。
+	// idGenerator is the function generating the IDs of tasks.
+	idGenerator IDGenerator
 }
 
 // NewConfig 是一个函数，用于创建一个新的 Config 实例
@@ -18,7 +124,15 @@ func NewConfig() *Config {
 	// 返回一个新的 Config 实例，其中 callback 字段被设置为一个新的空任务回调
 	// Return a new instance of Config, where the callback field is set to a new empty task callback
 	return &Config{
-		callback: NewEmptyTaskCallback(),
+		callback:      NewEmptyTaskCallback(),
+		registry:      NewRegistry(),
+		priorityAging: DefaultPriorityAging,
+		leaseTTL:      DefaultLeaseTTL,
+		lockTTL:       DefaultLockTTL,
+
+		clockJumpThreshold: DefaultClockJumpThreshold,
+		defaultTenant:      NewTenantConfig(),
+		idGenerator:        NewUUIDGenerator(),
 	}
 }
 
@@ -54,6 +168,295 @@ func (c *Config) WithUniqued(disable bool) *Config {
 	return c
 }
 
+// WithHistory 是一个方法，用于设置 Config 结构体中的 history 字段。
+// WithHistory is a method used to set the history field in the Config struct.
+func (c *Config) WithHistory(history *History) *Config {
+	// 设置 history 字段的值为 history 参数的值。
+	// Set the value of the history field to the value of the history parameter.
+	c.history = history
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithRegistry 是一个方法，用于设置 Config 结构体中的 registry 字段。
+// WithRegistry is a method used to set the registry field in the Config struct.
+func (c *Config) WithRegistry(registry *Registry) *Config {
+	// 设置 registry 字段的值为 registry 参数的值。
+	// Set the value of the registry field to the value of the registry parameter.
+	c.registry = registry
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithMaxConcurrency 是一个方法，用于设置同时执行的处理函数的最大数量，不大于 0 表示不限制。
+// 到期的任务超过这个数量时，处理函数按照任务的优先级排队等待执行。
+// WithMaxConcurrency is a method used to set the maximum number of handling functions running at the same time, not greater than 0 means unlimited.
+// When more tasks expire than this number, the handling functions queue up by the priority of their tasks.
+func (c *Config) WithMaxConcurrency(max int) *Config {
+	// 设置 maxConcurrency 字段的值为 max 参数的值。
+	// Set the value of the maxConcurrency field to the value of the max parameter.
+	c.maxConcurrency = max
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithPriorityAging 是一个方法，用于设置优先级的老化周期，排队的处理函数每等待一个周期，有效优先级就加 1，避免低优先级的任务一直得不到执行。
+// 默认为 DefaultPriorityAging，不大于 0 表示不老化。
+// WithPriorityAging is a method used to set the aging period of the priority, the effective priority of a queued handling function increases by 1 for every period it waits, so low-priority tasks are not starved.
+// The default is DefaultPriorityAging, not greater than 0 means no aging.
+func (c *Config) WithPriorityAging(aging time.Duration) *Config {
+	// 设置 priorityAging 字段的值为 aging 参数的值。
+	// Set the value of the priorityAging field to the value of the aging parameter.
+	c.priorityAging = aging
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithStore 是一个方法，用于设置持久化任务的 Store。只有使用注册的处理函数添加的任务会被持久化，调度器启动时，
+// 如果没有配置协调器，它会恢复 Store 中保存的所有任务，执行时间已经过去的任务会立即执行。
+// WithStore is a method used to set the Store persisting tasks. Only tasks added with registered handling functions are persisted, when the scheduler starts
+// without a coordinator, it restores all tasks saved in the Store, tasks whose execution time has passed are executed immediately.
+func (c *Config) WithStore(store Store) *Config {
+	// 设置 store 字段的值为 store 参数的值。
+	// Set the value of the store field to the value of the store parameter.
+	c.store = store
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithCoordinator 是一个方法，用于设置协调器和调度器的节点名称，节点名称为空时使用主机名和进程 ID。
+// 持久化的任务在每次触发之前都需要被认领，只有一个节点会执行它；持有租约的领导者会接管 Store 中其他节点的任务，节点宕机后它的任务由领导者执行。
+// WithCoordinator is a method used to set the coordinator and the node name of the scheduler, the host name and the process ID are used when the node name is empty.
+// Every firing of a persisted task must be claimed first, so only one node executes it; the leader holding the lease takes over the tasks of other nodes in the Store, the tasks of a node that died are executed by the leader.
+func (c *Config) WithCoordinator(coordinator Coordinator, node string) *Config {
+	// 设置 coordinator 和 node 字段的值。
+	// Set the values of the coordinator and node fields.
+	c.coordinator = coordinator
+	c.node = node
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithLeaseTTL 是一个方法，用于设置领导者租约的有效期，默认为 DefaultLeaseTTL。领导者宕机后，其他节点最多在这段时间之后接管任务。
+// WithLeaseTTL is a method used to set the time to live of the leader lease, the default is DefaultLeaseTTL. After the leader dies, another node takes over the tasks at most after this time.
+func (c *Config) WithLeaseTTL(ttl time.Duration) *Config {
+	// 设置 leaseTTL 字段的值为 ttl 参数的值。
+	// Set the value of the leaseTTL field to the value of the ttl parameter.
+	c.leaseTTL = ttl
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithLocker 是一个方法，用于设置任务锁和锁的范围。任务到期时先获取锁，锁被其他实例持有时跳过处理函数，原因为 ErrorTaskLocked。
+// 与 WithCoordinator 不同，它不需要领导者选举，适合多个实例各自添加相同任务的情况。
+// WithLocker is a method used to set the task locker and the scope of the locks. When a task expires it acquires the lock first, the handling function is skipped with the reason ErrorTaskLocked if the lock is held by another instance.
+// Unlike WithCoordinator, it needs no leader election, it suits several instances each adding the same tasks.
+func (c *Config) WithLocker(locker Locker, scope LockScope) *Config {
+	// 设置 locker 和 lockScope 字段的值。
+	// Set the values of the locker and lockScope fields.
+	c.locker = locker
+	c.lockScope = scope
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithLockTTL 是一个方法，用于设置任务锁的有效期，默认为 DefaultLockTTL。锁在有效期之后自动释放，有效期需要大于处理函数的执行时间以及实例之间触发时间的差异。
+// WithLockTTL is a method used to set the time to live of the task lock, the default is DefaultLockTTL. The lock is released automatically after it, so it must exceed the duration of the handling function and the difference between the firing times of the instances.
+func (c *Config) WithLockTTL(ttl time.Duration) *Config {
+	// 设置 lockTTL 字段的值为 ttl 参数的值。
+	// Set the value of the lockTTL field to the value of the ttl parameter.
+	c.lockTTL = ttl
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithMisfire 是一个方法，用于设置任务允许的最大延迟和延迟超过阈值时的处理策略，例如执行时间已经过去的 SetAt，或者长时间的 GC 暂停之后到期的任务。
+// 默认不检查延迟，任务可以使用 TaskOptions 的 WithMisfire 覆盖这个设置。
+// WithMisfire is a method used to set the maximum lag allowed for tasks and the policy applied when the lag exceeds it, for example SetAt with an execution time in the past, or tasks expiring after a long GC pause.
+// The lag is not checked by default, tasks can override this setting with WithMisfire of TaskOptions.
+func (c *Config) WithMisfire(threshold time.Duration, policy MisfirePolicy) *Config {
+	// 设置 misfireThreshold 和 misfirePolicy 字段的值。
+	// Set the values of the misfireThreshold and misfirePolicy fields.
+	c.misfireThreshold = threshold
+	c.misfirePolicy = policy
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithClockMode 是一个方法，用于设置使用绝对执行时间（SetAt 等方法）添加的任务默认使用的时钟，默认为 ClockMonotonic。
+// 使用相对延迟（Set 等方法）添加的任务总是默认使用单调时钟，任务可以使用 TaskOptions 的 WithClockMode 覆盖这个设置。
+// WithClockMode is a method used to set the default clock of tasks added with an absolute execution time (methods such as SetAt), the default is ClockMonotonic.
+// Tasks added with a relative delay (methods such as Set) always use the monotonic clock by default, tasks can override this setting with WithClockMode of TaskOptions.
+func (c *Config) WithClockMode(mode ClockMode) *Config {
+	// 设置 clockMode 字段的值为 mode 参数的值。
+	// Set the value of the clockMode field to the value of the mode parameter.
+	c.clockMode = mode
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithClockJumpThreshold 是一个方法，用于设置时钟跳变的阈值，默认为 DefaultClockJumpThreshold。调度器以这个间隔比较墙上时钟和单调时钟经过的时间，
+// 差异超过阈值时重新计算使用墙上时钟的任务。
+// WithClockJumpThreshold is a method used to set the threshold of a clock jump, the default is DefaultClockJumpThreshold. The scheduler compares the time elapsed on the wall clock and on the monotonic clock at this interval,
+// tasks using the wall clock are recalculated when the difference exceeds the threshold.
+func (c *Config) WithClockJumpThreshold(threshold time.Duration) *Config {
+	// 设置 clockJumpThreshold 字段的值为 threshold 参数的值。
+	// Set the value of the clockJumpThreshold field to the value of the threshold parameter.
+	c.clockJumpThreshold = threshold
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithRateLimit 是一个方法，用于使用令牌桶限制任务触发的速率：每秒补充 rate 个令牌，最多累积 burst 个令牌，scope 决定令牌桶是全局共享、按任务名称还是按组划分。
+// 超过速率的任务不会被丢弃，而是推迟到有令牌时执行，推迟的时间通过 RateLimitCallback、事件和 Stats 报告。rate 不大于 0 表示不限制，burst 小于 1 时使用 1。
+// WithRateLimit is a method used to limit the firing rate of tasks with a token bucket: rate tokens are refilled per second and at most burst tokens accumulate, scope decides whether the token bucket is shared globally, per task name or per group.
+// Tasks exceeding the rate are not dropped but delayed until a token is available, the delay is reported through RateLimitCallback, events and Stats. A rate not greater than 0 means unlimited, 1 is used if burst is less than 1.
+func (c *Config) WithRateLimit(rate float64, burst int, scope RateLimitScope) *Config {
+	// 设置 rateLimit、rateBurst 和 rateScope 字段的值。
+	// Set the values of the rateLimit, rateBurst and rateScope fields.
+	c.rateLimit = rate
+	c.rateBurst = burst
+	c.rateScope = scope
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithMaxPendingTasks 是一个方法，用于设置调度器中任务的最大数量，包括等待、执行中和被暂停的任务，不大于 0 表示不限制。
+// 达到限制时添加任务返回 ErrorMaxPendingTasks，它包装了 ErrorSchedulerFull；任务选项设置了 WithWaitForCapacity 时，添加任务会阻塞直到有任务被移除。
+// WithMaxPendingTasks is a method used to set the maximum number of tasks in the scheduler, including pending, running and paused tasks, not greater than 0 means unlimited.
+// When the limit is reached, adding a task returns ErrorMaxPendingTasks, which wraps ErrorSchedulerFull; when WithWaitForCapacity is set in the task options, adding a task blocks until a task is removed.
+func (c *Config) WithMaxPendingTasks(max int) *Config {
+	// 设置 maxPending 字段的值为 max 参数的值。
+	// Set the value of the maxPending field to the value of the max parameter.
+	c.maxPending = max
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithNameQuota 是一个方法，用于设置同名任务的最大数量，不大于 0 表示不限制。达到限制时添加同名的任务返回 ErrorNameQuota，它包装了 ErrorSchedulerFull。
+// WithNameQuota is a method used to set the maximum number of tasks with the same name, not greater than 0 means unlimited. When the limit is reached, adding a task with that name returns ErrorNameQuota, which wraps ErrorSchedulerFull.
+func (c *Config) WithNameQuota(quota int) *Config {
+	// 设置 nameQuota 字段的值为 quota 参数的值。
+	// Set the value of the nameQuota field to the value of the quota parameter.
+	c.nameQuota = quota
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithTenant 是一个方法，用于设置指定租户的权重、任务数量和并发限制，conf 为 nil 时删除租户的设置，租户使用 WithDefaultTenant 的设置。
+// 在配置了 WithMaxConcurrency 的调度器中，执行槽位按照租户的权重公平地分配，一个租户的大量任务不会饿死其他租户。
+// WithTenant is a method used to set the weight and the limits of the number of tasks and of the concurrency of the specified tenant, when conf is nil the settings of the tenant are deleted and the tenant uses the settings of WithDefaultTenant.
+// In a scheduler configured with WithMaxConcurrency, execution slots are shared fairly among the tenants by their weights, a large number of tasks of one tenant does not starve the other tenants.
+func (c *Config) WithTenant(name string, conf *TenantConfig) *Config {
+	// 设置 tenants 字段中指定租户的设置。
+	// Set the settings of the specified tenant in the tenants field.
+	if c.tenants == nil {
+		c.tenants = make(map[string]*TenantConfig)
+	}
+	if conf != nil {
+		c.tenants[name] = isTenantConfigValid(conf)
+	} else {
+		delete(c.tenants, name)
+	}
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithDefaultTenant 是一个方法，用于设置没有使用 WithTenant 单独设置的租户使用的设置，默认为 NewTenantConfig()，即权重为 1 并且不限制。
+// WithDefaultTenant is a method used to set the settings used by tenants without their own settings from WithTenant, the default is NewTenantConfig(), that is a weight of 1 and no limits.
+func (c *Config) WithDefaultTenant(conf *TenantConfig) *Config {
+	// 设置 defaultTenant 字段的值为 conf 参数的值。
+	// Set the value of the defaultTenant field to the value of the conf parameter.
+	c.defaultTenant = isTenantConfigValid(conf)
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithMaxTenants 是一个方法，用于设置调度器中没有使用 WithTenant 单独设置的租户的最大数量，不大于 0 表示不限制。
+// 租户的名称通常来自外部的输入，达到限制时添加新的租户的任务返回 ErrorTenantLimit，它包装了 ErrorSchedulerFull，已经存在的租户不受影响。使用 RemoveTenant 删除不再使用的租户。
+// WithMaxTenants is a method used to set the maximum number of tenants in the scheduler without their own settings from WithTenant, not greater than 0 means unlimited.
+// Tenant names often come from external input, when the limit is reached, adding a task of a new tenant returns ErrorTenantLimit, which wraps ErrorSchedulerFull, existing tenants are not affected. Use RemoveTenant to delete tenants no longer used.
+func (c *Config) WithMaxTenants(max int) *Config {
+	// 设置 maxTenants 字段的值为 max 参数的值。
+	// Set the value of the maxTenants field to the value of the max parameter.
+	c.maxTenants = max
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithBatch 是一个方法，用于为指定名称的任务配置批量执行：在短时间内到期的同名任务被收集到一个批次中，批量处理函数只调用一次，
+// 而不是每个任务调用一次它自己的处理函数。每个任务仍然单独报告 OnTaskExecuted，结果来自批量处理函数。conf 为 nil 或者没有批量处理函数时取消配置。
+// WithBatch is a method used to configure batch execution for the tasks with the specified name: tasks with the same name expiring within a short time are collected into one batch, the batch handling function is called once
+// instead of every task calling its own handling function. Every task still reports OnTaskExecuted on its own, with the result from the batch handling function. The configuration is removed if conf is nil or has no batch handling function.
+func (c *Config) WithBatch(name string, conf *BatchConfig) *Config {
+	// 设置 batches 字段中指定名称的设置。
+	// Set the settings of the specified name in the batches field.
+	if c.batches == nil {
+		c.batches = make(map[string]*BatchConfig)
+	}
+	if conf = isBatchConfigValid(conf); conf != nil {
+		c.batches[name] = conf
+	} else {
+		delete(c.batches, name)
+	}
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
+// WithIDGenerator 是一个方法，用于设置生成任务 ID 的函数，默认为 NewUUIDGenerator()，为 nil 时使用默认值。
+// 可以使用 NewULIDGenerator 生成按照创建时间排序的 ID，或者使用 NewCounterIDGenerator 生成开销最小的递增 ID。
+// 生成的 ID 必须是唯一的，调用者也可以使用 TaskOptions 的 WithID 为单个任务指定 ID。
+// WithIDGenerator is a method used to set the function generating the IDs of tasks, the default is NewUUIDGenerator(), the default is used if it is nil.
+// NewULIDGenerator generates IDs sorted by creation time, and NewCounterIDGenerator generates increasing IDs at the lowest cost.
+// The generated IDs must be unique, callers may also specify the ID of a single task with WithID of TaskOptions.
+func (c *Config) WithIDGenerator(generator IDGenerator) *Config {
+	// 设置 idGenerator 字段的值为 generator 参数的值。
+	// Set the value of the idGenerator field to the value of the generator parameter.
+	c.idGenerator = generator
+
+	// 返回 Config 结构体的指针。
+	// Return the pointer to the Config struct.
+	return c
+}
+
 // isConfigValid 是一个函数，用于检查 Config 实例是否有效
 // isConfigValid is a function used to check if the instance of Config is valid
 func isConfigValid(conf *Config) *Config {
@@ -67,6 +470,56 @@ func isConfigValid(conf *Config) *Config {
 			// Set the callback field of conf to a new empty task callback
 			conf.callback = NewEmptyTaskCallback()
 		}
+
+		// 如果 conf 的 registry 字段为 nil
+		// If the registry field of conf is nil
+		if conf.registry == nil {
+			// 设置 conf 的 registry 字段为一个新的空注册表
+			// Set the registry field of conf to a new empty registry
+			conf.registry = NewRegistry()
+		}
+
+		// 如果 conf 的 maxConcurrency 字段小于 0，设置为 0，表示不限制
+		// If the maxConcurrency field of conf is less than 0, set it to 0, meaning unlimited
+		if conf.maxConcurrency < 0 {
+			conf.maxConcurrency = 0
+		}
+
+		// 如果 conf 的 leaseTTL 字段不大于 0，使用默认的租约有效期
+		// If the leaseTTL field of conf is not greater than 0, use the default time to live of the lease
+		if conf.leaseTTL <= 0 {
+			conf.leaseTTL = DefaultLeaseTTL
+		}
+
+		// 如果 conf 的 lockTTL 字段不大于 0，使用默认的锁有效期
+		// If the lockTTL field of conf is not greater than 0, use the default time to live of the lock
+		if conf.lockTTL <= 0 {
+			conf.lockTTL = DefaultLockTTL
+		}
+
+		// 如果 conf 的 clockJumpThreshold 字段不大于 0，使用默认的时钟跳变阈值
+		// If the clockJumpThreshold field of conf is not greater than 0, use the default threshold of a clock jump
+		if conf.clockJumpThreshold <= 0 {
+			conf.clockJumpThreshold = DefaultClockJumpThreshold
+		}
+
+		// 如果 conf 的 defaultTenant 字段为 nil，使用默认的租户设置
+		// If the defaultTenant field of conf is nil, use the default settings of a tenant
+		if conf.defaultTenant == nil {
+			conf.defaultTenant = NewTenantConfig()
+		}
+
+		// 如果 conf 的 idGenerator 字段为 nil，使用 UUID 生成任务 ID
+		// If the idGenerator field of conf is nil, use UUIDs as the IDs of tasks
+		if conf.idGenerator == nil {
+			conf.idGenerator = NewUUIDGenerator()
+		}
+
+		// 如果配置了协调器但是没有设置节点名称，使用主机名和进程 ID
+		// If the coordinator is configured without a node name, use the host name and the process ID
+		if conf.coordinator != nil && conf.node == "" {
+			conf.node = defaultNodeName()
+		}
 	} else {
 		// 如果 conf 为 nil，设置 conf 为默认的 Config 实例
 		// If conf is nil, set conf to the default instance of Config
@@ -0,0 +1,400 @@
+package kairos
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 定义协调器的默认值
+// Define the default values of the coordinator
+const (
+	// DefaultLeaseTTL 是领导者租约的默认有效期
+	// DefaultLeaseTTL is the default time to live of the leader lease
+	DefaultLeaseTTL = 15 * time.Second
+
+	// DefaultClaimTTL 是任务认领记录的默认保留时间，在这段时间内其他节点不能再次执行同一次触发
+	// DefaultClaimTTL is the default retention of a task claim, other nodes can not execute the same firing again during this time
+	DefaultClaimTTL = time.Hour
+
+	// fileLockStale 是文件锁被视为遗留的时间，持有锁的进程崩溃后，锁会在这段时间之后被清除
+	// fileLockStale is the time after which a file lock is considered stale, the lock is cleared after this time if the process holding it crashed
+	fileLockStale = 30 * time.Second
+
+	// fileLockTimeout 是等待文件锁的最长时间
+	// fileLockTimeout is the maximum time to wait for a file lock
+	fileLockTimeout = 5 * time.Second
+)
+
+// ErrorLockTimeout 表示在等待时间内没有获得文件锁
+// ErrorLockTimeout indicates the file lock was not acquired within the waiting time
+var ErrorLockTimeout = errors.New("lock timeout")
+
+// Coordinator 是一个接口，用于在多个调度器副本之间协调任务的执行。
+// 持有租约的节点是领导者，它负责接管其他节点持久化的任务；每一次触发在执行之前都需要被认领，确保只有一个节点执行它
+// Coordinator is an interface used to coordinate the execution of tasks among several scheduler replicas.
+// The node holding the lease is the leader, it takes over the tasks persisted by other nodes; every firing must be claimed before it is executed, so that only one node executes it
+type Coordinator interface {
+	// AcquireLease 尝试获取领导者租约，租约空闲、已经过期或者已经被该节点持有时成功，返回该节点是否持有租约
+	// AcquireLease tries to acquire the leader lease, it succeeds if the lease is free, expired or already held by the node, it returns whether the node holds the lease
+	AcquireLease(node string, ttl time.Duration) (bool, error)
+
+	// RenewLease 延长该节点持有的租约，如果租约已经被其他节点持有，返回 false
+	// RenewLease extends the lease held by the node, it returns false if the lease is held by another node
+	RenewLease(node string, ttl time.Duration) (bool, error)
+
+	// ReleaseLease 释放该节点持有的租约，租约被其他节点持有时不做任何操作
+	// ReleaseLease releases the lease held by the node, it does nothing if the lease is held by another node
+	ReleaseLease(node string) error
+
+	// ClaimTask 认领一次任务的触发，key 唯一地标识这次触发，认领记录保留 ttl 的时间，返回该节点是否拥有这次触发
+	// ClaimTask claims one firing of a task, key identifies the firing uniquely, the claim is kept for ttl, it returns whether the node owns the firing
+	ClaimTask(node, key string, ttl time.Duration) (bool, error)
+}
+
+// lease 结构体是一个租约或者认领记录
+// The lease struct is a lease or a claim
+type lease struct {
+	// Node 是持有者的名称
+	// Node is the name of the holder
+	Node string `json:"node"`
+
+	// ExpiresAt 是过期时间
+	// ExpiresAt is the expiration time
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// heldBy 方法返回租约在给定时间是否被指定的节点有效持有
+// The heldBy method returns whether the lease is validly held by the specified node at the given time
+func (l *lease) heldBy(node string, now time.Time) bool {
+	return l != nil && l.Node == node && now.Before(l.ExpiresAt)
+}
+
+// free 方法返回租约在给定时间是否空闲
+// The free method returns whether the lease is free at the given time
+func (l *lease) free(now time.Time) bool {
+	return l == nil || !now.Before(l.ExpiresAt)
+}
+
+// MemoryCoordinator 结构体是进程内的 Coordinator 实现，多个调度器可以共享同一个实例，适合测试
+// The MemoryCoordinator struct is an in-process Coordinator implementation, several schedulers can share the same instance, it is suitable for tests
+type MemoryCoordinator struct {
+	// lock 用于保护租约和认领记录
+	// lock is used to protect the lease and the claims
+	lock sync.Mutex
+
+	// leader 是领导者租约
+	// leader is the leader lease
+	leader *lease
+
+	// claims 是认领记录，键是触发的标识
+	// claims are the claims, the key is the identifier of the firing
+	claims map[string]*lease
+}
+
+// NewMemoryCoordinator 函数创建一个新的 MemoryCoordinator 实例
+// The NewMemoryCoordinator function creates a new MemoryCoordinator instance
+func NewMemoryCoordinator() *MemoryCoordinator {
+	return &MemoryCoordinator{claims: make(map[string]*lease)}
+}
+
+// AcquireLease 方法尝试获取领导者租约
+// The AcquireLease method tries to acquire the leader lease
+func (c *MemoryCoordinator) AcquireLease(node string, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if !c.leader.free(now) && c.leader.Node != node {
+		return false, nil
+	}
+	c.leader = &lease{Node: node, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// RenewLease 方法延长该节点持有的租约
+// The RenewLease method extends the lease held by the node
+func (c *MemoryCoordinator) RenewLease(node string, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if !c.leader.heldBy(node, now) {
+		return false, nil
+	}
+	c.leader.ExpiresAt = now.Add(ttl)
+	return true, nil
+}
+
+// ReleaseLease 方法释放该节点持有的租约
+// The ReleaseLease method releases the lease held by the node
+func (c *MemoryCoordinator) ReleaseLease(node string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.leader != nil && c.leader.Node == node {
+		c.leader = nil
+	}
+	return nil
+}
+
+// ClaimTask 方法认领一次任务的触发，同时清除已经过期的认领记录
+// The ClaimTask method claims one firing of a task, and clears the expired claims at the same time
+func (c *MemoryCoordinator) ClaimTask(node, key string, ttl time.Duration) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if claim, ok := c.claims[key]; ok && !claim.free(now) {
+		return claim.Node == node, nil
+	}
+
+	// 清除已经过期的认领记录
+	// Clear the expired claims
+	for k, claim := range c.claims {
+		if claim.free(now) {
+			delete(c.claims, k)
+		}
+	}
+
+	c.claims[key] = &lease{Node: node, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// FileCoordinator 结构体是基于共享文件系统的 Coordinator 实现，多个进程或者主机可以使用同一个目录进行协调。
+// 所有的操作都在一个使用 O_EXCL 创建的锁文件的保护下进行，持有锁的进程崩溃后，遗留的锁文件会在一段时间后被清除
+// The FileCoordinator struct is a Coordinator implementation over a shared filesystem, several processes or hosts can coordinate through the same directory.
+// All operations are protected by a lock file created with O_EXCL, a stale lock file left by a crashed process is cleared after a while
+type FileCoordinator struct {
+	// dir 是协调目录
+	// dir is the coordination directory
+	dir string
+}
+
+// NewFileCoordinator 函数创建一个新的 FileCoordinator 实例，目录不存在时会被创建
+// The NewFileCoordinator function creates a new FileCoordinator instance, the directory is created if it does not exist
+func NewFileCoordinator(dir string) (*FileCoordinator, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "claims"), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCoordinator{dir: dir}, nil
+}
+
+// withLock 方法在持有目录锁的情况下执行 fn
+// The withLock method runs fn while holding the lock of the directory
+func (c *FileCoordinator) withLock(fn func() error) error {
+	unlock, err := lockFile(filepath.Join(c.dir, ".lock"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
+// leasePath 方法返回领导者租约文件的路径
+// The leasePath method returns the path of the leader lease file
+func (c *FileCoordinator) leasePath() string {
+	return filepath.Join(c.dir, "leader.json")
+}
+
+// claimPath 方法返回认领记录文件的路径，键经过哈希，可以包含任意字符
+// The claimPath method returns the path of the claim file, the key is hashed so it can contain any character
+func (c *FileCoordinator) claimPath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, "claims", hex.EncodeToString(sum[:])+".json")
+}
+
+// AcquireLease 方法尝试获取领导者租约
+// The AcquireLease method tries to acquire the leader lease
+func (c *FileCoordinator) AcquireLease(node string, ttl time.Duration) (acquired bool, err error) {
+	err = c.withLock(func() error {
+		current, err := readLease(c.leasePath())
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !current.free(now) && current.Node != node {
+			return nil
+		}
+		acquired = true
+		return writeLease(c.leasePath(), &lease{Node: node, ExpiresAt: now.Add(ttl)})
+	})
+	return acquired && err == nil, err
+}
+
+// RenewLease 方法延长该节点持有的租约
+// The RenewLease method extends the lease held by the node
+func (c *FileCoordinator) RenewLease(node string, ttl time.Duration) (renewed bool, err error) {
+	err = c.withLock(func() error {
+		current, err := readLease(c.leasePath())
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !current.heldBy(node, now) {
+			return nil
+		}
+		renewed = true
+		return writeLease(c.leasePath(), &lease{Node: node, ExpiresAt: now.Add(ttl)})
+	})
+	return renewed && err == nil, err
+}
+
+// ReleaseLease 方法释放该节点持有的租约
+// The ReleaseLease method releases the lease held by the node
+func (c *FileCoordinator) ReleaseLease(node string) error {
+	return c.withLock(func() error {
+		current, err := readLease(c.leasePath())
+		if err != nil || current == nil || current.Node != node {
+			return err
+		}
+		return os.Remove(c.leasePath())
+	})
+}
+
+// ClaimTask 方法认领一次任务的触发，已经过期的认领记录可以被重新认领
+// The ClaimTask method claims one firing of a task, an expired claim can be claimed again
+func (c *FileCoordinator) ClaimTask(node, key string, ttl time.Duration) (claimed bool, err error) {
+	err = c.withLock(func() error {
+		path := c.claimPath(key)
+		current, err := readLease(path)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !current.free(now) {
+			claimed = current.Node == node
+			return nil
+		}
+		claimed = true
+		return writeLease(path, &lease{Node: node, ExpiresAt: now.Add(ttl)})
+	})
+	return claimed && err == nil, err
+}
+
+// Prune 方法删除所有已经过期的认领记录文件
+// The Prune method deletes all expired claim files
+func (c *FileCoordinator) Prune() error {
+	return c.withLock(func() error {
+		paths, err := filepath.Glob(filepath.Join(c.dir, "claims", "*.json"))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, path := range paths {
+			if claim, err := readLease(path); err == nil && claim.free(now) {
+				_ = os.Remove(path)
+			}
+		}
+		return nil
+	})
+}
+
+// readLease 函数读取租约文件，文件不存在时返回 nil
+// The readLease function reads a lease file, nil is returned if the file does not exist
+func readLease(path string) (*lease, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l := &lease{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// writeLease 函数先写入临时文件再重命名，避免其他进程读到不完整的内容
+// The writeLease function writes a temporary file and renames it, so other processes never read incomplete content
+func writeLease(path string, l *lease) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic 函数先写入同一目录中唯一命名的临时文件再重命名为目标文件，同时写入同一个文件的多个进程不会互相覆盖临时文件
+// The writeFileAtomic function writes a uniquely named temporary file in the same directory and renames it to the target file, so several processes writing the same file never overwrite each other's temporary file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// lockFile 函数使用 O_EXCL 创建锁文件来获取锁，返回释放锁的函数。超过 fileLockStale 的锁文件被视为遗留的锁并被删除，
+// 删除锁文件时只删除检查过的那个文件，不会误删其他进程刚刚创建的锁
+// The lockFile function acquires the lock by creating the lock file with O_EXCL, it returns the function releasing the lock. A lock file older than fileLockStale is considered stale and deleted,
+// only the inspected file is deleted, so a lock just created by another process is never removed by mistake
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			info, err := f.Stat()
+			_ = f.Close()
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, err
+			}
+			return func() { removeLockFile(path, info) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		// 清除遗留的锁文件
+		// Clear the stale lock file
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > fileLockStale {
+			removeLockFile(path, info)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrorLockTimeout
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+}
+
+// removeLockFile 函数删除锁文件，前提是它仍然是 info 描述的文件。锁文件先被重命名为唯一的名称，同一个文件只能被一个进程移走，
+// 移走的如果是其他进程新创建的锁，它会被放回原处
+// The removeLockFile function removes the lock file provided it is still the file described by info. The lock file is renamed to a unique name first, only one process can move a given file,
+// and if the moved file is a lock newly created by another process it is put back
+func removeLockFile(path string, info os.FileInfo) {
+	moved := fmt.Sprintf("%s.%d.%d.stale", path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(path, moved); err != nil {
+		return
+	}
+	if current, err := os.Stat(moved); err == nil && (!os.SameFile(info, current) || !current.ModTime().Equal(info.ModTime())) {
+		// 放回其他进程的锁，期间又有新的锁被创建时 Link 失败
+		// Put back the lock of the other process, Link fails if yet another lock was created meanwhile
+		_ = os.Link(moved, path)
+	}
+	_ = os.Remove(moved)
+}
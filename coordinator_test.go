@@ -0,0 +1,169 @@
+package kairos
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCoordinator(t *testing.T, coordinator Coordinator) {
+	// Only one node holds the lease at a time
+	ok, err := coordinator.AcquireLease("a", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = coordinator.AcquireLease("b", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = coordinator.RenewLease("a", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = coordinator.RenewLease("b", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// An expired lease can be taken over by another node
+	time.Sleep(time.Millisecond * 150)
+	ok, err = coordinator.AcquireLease("b", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = coordinator.RenewLease("a", time.Second)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// Releasing a lease held by another node does nothing
+	assert.Nil(t, coordinator.ReleaseLease("a"))
+	ok, err = coordinator.AcquireLease("a", time.Second)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, coordinator.ReleaseLease("b"))
+	ok, err = coordinator.AcquireLease("a", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// A firing is owned by the first node claiming it until the claim expires
+	ok, err = coordinator.ClaimTask("a", "task@1", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = coordinator.ClaimTask("a", "task@1", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = coordinator.ClaimTask("b", "task@1", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = coordinator.ClaimTask("b", "task@2", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	time.Sleep(time.Millisecond * 150)
+	ok, err = coordinator.ClaimTask("b", "task@1", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestMemoryCoordinator(t *testing.T) {
+	testCoordinator(t, NewMemoryCoordinator())
+}
+
+func TestFileCoordinator(t *testing.T) {
+	coordinator, err := NewFileCoordinator(t.TempDir())
+	assert.Nil(t, err)
+	testCoordinator(t, coordinator)
+}
+
+func TestFileCoordinator_Prune(t *testing.T) {
+	coordinator, err := NewFileCoordinator(t.TempDir())
+	assert.Nil(t, err)
+
+	ok, err := coordinator.ClaimTask("a", "task@1", time.Millisecond*50)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// Pruning keeps live claims and removes expired ones
+	assert.Nil(t, coordinator.Prune())
+	ok, err = coordinator.ClaimTask("b", "task@1", time.Second)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	time.Sleep(time.Millisecond * 100)
+	assert.Nil(t, coordinator.Prune())
+	ok, err = coordinator.ClaimTask("b", "task@1", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lease.json")
+
+	// Concurrent writers never share a temporary file, the result is one complete content
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.Nil(t, writeFileAtomic(path, []byte(fmt.Sprintf("content-%02d", i))))
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Regexp(t, `^content-\d{2}$`, string(data))
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestLockFile_Stale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	stale := time.Now().Add(-fileLockStale * 2)
+
+	// A stale lock file left by a crashed process is taken over
+	assert.Nil(t, os.WriteFile(path, nil, 0o644))
+	assert.Nil(t, os.Chtimes(path, stale, stale))
+	first, err := lockFile(path)
+	assert.Nil(t, err)
+
+	// The lock of a slow holder goes stale and is taken over, releasing it later keeps the new lock
+	assert.Nil(t, os.Chtimes(path, stale, stale))
+	second, err := lockFile(path)
+	assert.Nil(t, err)
+	first()
+	_, err = os.Stat(path)
+	assert.Nil(t, err)
+
+	second()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRemoveLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".lock")
+	assert.Nil(t, os.WriteFile(path, nil, 0o644))
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+
+	// Another process replaced the inspected lock file meanwhile, its lock is put back
+	assert.Nil(t, os.Remove(path))
+	assert.Nil(t, os.WriteFile(path, []byte("other"), 0o644))
+	removeLockFile(path, info)
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "other", string(data))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+}
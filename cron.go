@@ -0,0 +1,213 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidCron 表示 cron 表达式无效
+// ErrorInvalidCron indicates the cron expression is invalid
+var ErrorInvalidCron = errors.New("invalid cron expression")
+
+// cronYears 是查找下一次执行时间的最大年数，超过之后认为表达式没有下一次执行，例如 2 月 30 日
+// cronYears is the maximum number of years searched for the next run, beyond it the expression is considered to have no next run, such as February 30
+const cronYears = 5
+
+// cronDescriptors 是预定义的 cron 表达式
+// cronDescriptors are the predefined cron expressions
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField 结构体描述 cron 表达式中一个字段的范围和名称
+// The cronField struct describes the range and the names of a field of a cron expression
+type cronField struct {
+	// min 和 max 是字段的取值范围
+	// min and max are the range of the field
+	min, max int
+
+	// names 是字段可以使用的名称，从 min 开始依次对应
+	// names are the names the field can use, they correspond to the values starting from min
+	names []string
+}
+
+// cronFields 是 cron 表达式的五个字段：分钟、小时、月中的天、月份和星期几（0 和 7 都表示星期日）
+// cronFields are the five fields of a cron expression: minute, hour, day of the month, month and day of the week (both 0 and 7 mean Sunday)
+var cronFields = [5]cronField{
+	{min: 0, max: 59},
+	{min: 0, max: 23},
+	{min: 1, max: 31},
+	{min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// cronSchedule 结构体是标准的五字段 cron 表达式的时间表，每个字段是允许取值的位图
+// The cronSchedule struct is the timetable of a standard five-field cron expression, every field is a bitmap of the allowed values
+type cronSchedule struct {
+	// minute、hour、dom、month、dow 是分钟、小时、月中的天、月份和星期几的位图
+	// minute, hour, dom, month and dow are the bitmaps of the minute, hour, day of the month, month and day of the week
+	minute, hour, dom, month, dow uint64
+
+	// domAny 和 dowAny 表示月中的天和星期几是否为 "*"，两者都被限制时，满足其中一个即可
+	// domAny and dowAny indicate whether the day of the month and the day of the week are "*", when both are restricted, satisfying either one is enough
+	domAny, dowAny bool
+
+	// location 是计算时间使用的时区
+	// location is the time zone used to calculate the times
+	location *time.Location
+
+	// expr 是解析的 cron 表达式，用于保存持久化的周期任务
+	// expr is the parsed cron expression, it is used to save persisted recurring tasks
+	expr string
+}
+
+// ParseCron 函数解析标准的五字段 cron 表达式（分钟 小时 日 月 星期），支持 "*"、列表、范围、步长、月份和星期的英文缩写，
+// 以及 @yearly、@monthly、@weekly、@daily、@hourly 等预定义表达式。表达式可以使用 "CRON_TZ=Asia/Shanghai " 前缀指定时区，默认使用本地时区
+// The ParseCron function parses a standard five-field cron expression (minute hour day month weekday), it supports "*", lists, ranges, steps, English abbreviations of months and weekdays,
+// and predefined expressions such as @yearly, @monthly, @weekly, @daily and @hourly. The expression can specify the time zone with the "CRON_TZ=Asia/Shanghai " prefix, the local time zone is used by default
+func ParseCron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	schedule := &cronSchedule{location: time.Local, expr: expr}
+
+	// 解析时区前缀
+	// Parse the time zone prefix
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		prefix, rest, _ := strings.Cut(expr, " ")
+		_, name, _ := strings.Cut(prefix, "=")
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidCron, err)
+		}
+		schedule.location, expr = location, strings.TrimSpace(rest)
+	}
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: %q must have %d fields", ErrorInvalidCron, expr, len(cronFields))
+	}
+	bitmaps := make([]uint64, len(parts))
+	for i, part := range parts {
+		bitmap, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrorInvalidCron, part, err)
+		}
+		bitmaps[i] = bitmap
+	}
+
+	// 星期日可以写成 0 或者 7
+	// Sunday can be written as 0 or 7
+	if bitmaps[4]&(1<<7) != 0 {
+		bitmaps[4] |= 1
+	}
+	schedule.minute, schedule.hour, schedule.dom, schedule.month, schedule.dow = bitmaps[0], bitmaps[1], bitmaps[2], bitmaps[3], bitmaps[4]
+	schedule.domAny, schedule.dowAny = parts[2] == "*" || parts[2] == "?", parts[4] == "*" || parts[4] == "?"
+	return schedule, nil
+}
+
+// parseCronField 函数解析 cron 表达式的一个字段，返回允许取值的位图
+// The parseCronField function parses a field of a cron expression, it returns the bitmap of the allowed values
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bitmap uint64
+	for _, item := range strings.Split(part, ",") {
+		// 解析步长
+		// Parse the step
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		// 解析范围，"*" 表示整个范围，带有步长的单个值表示从它开始到最大值
+		// Parse the range, "*" means the whole range, a single value with a step means from it to the maximum
+		lo, hi := field.min, field.max
+		if rangePart != "*" && rangePart != "?" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseCronValue(loPart, field); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = parseCronValue(hiPart, field); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bitmap |= 1 << uint(v)
+		}
+	}
+	return bitmap, nil
+}
+
+// parseCronValue 函数解析 cron 表达式中的一个值，可以是数字或者名称
+// The parseCronValue function parses a value of a cron expression, it can be a number or a name
+func parseCronValue(value string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(name, value) {
+			return field.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", value, field.min, field.max)
+	}
+	return n, nil
+}
+
+// Next 方法返回给定时间之后的下一次执行时间，精确到分钟，没有下一次执行时返回零值
+// The Next method returns the next execution time after the given time, to the minute, a zero value is returned if there is no next run
+func (c *cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(c.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronYears, 0, 0)
+
+	// 从大到小逐个字段匹配，不匹配时跳到这个字段的下一个取值的开始
+	// Match the fields from the largest to the smallest, when a field does not match, jump to the start of its next value
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		switch {
+		case c.month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, c.location)
+		case !c.matchDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, c.location)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, c.location)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay 方法返回日期是否满足月中的天和星期几。两者都被限制时满足其中一个即可，否则两者都需要满足
+// The matchDay method returns whether the date satisfies the day of the month and the day of the week. When both are restricted, satisfying either one is enough, otherwise both must be satisfied
+func (c *cronSchedule) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if !c.domAny && !c.dowAny {
+		return dom || dow
+	}
+	return dom && dow
+}
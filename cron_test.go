@@ -0,0 +1,57 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2024, 3, 4, 10, 7, 30, 0, time.UTC) // Monday
+
+	for _, c := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"CRON_TZ=UTC * * * * *", time.Date(2024, 3, 4, 10, 8, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC */15 * * * *", time.Date(2024, 3, 4, 10, 15, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 9-17/4 * * *", time.Date(2024, 3, 4, 13, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 30 2 * * SAT,SUN", time.Date(2024, 3, 9, 2, 30, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 0 * * 7", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 12 13 * 5", time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC 0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC @hourly", time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=UTC @weekly", time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+		{"TZ=Asia/Shanghai 0 9 * * *", time.Date(2024, 3, 5, 1, 0, 0, 0, time.UTC)},
+	} {
+		schedule, err := ParseCron(c.expr)
+		assert.Nil(t, err, c.expr)
+		assert.True(t, c.want.Equal(schedule.Next(from)), "%s: %v", c.expr, schedule.Next(from))
+	}
+
+	// An impossible date has no next run
+	schedule, err := ParseCron("0 0 30 2 *")
+	assert.Nil(t, err)
+	assert.True(t, schedule.Next(from).IsZero())
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"* * * FOO *",
+		"CRON_TZ=Nowhere/Nothing * * * * *",
+	} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrorInvalidCron, expr)
+	}
+}
@@ -0,0 +1,243 @@
+package kairos
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// waiter 结构体是一个等待执行槽位的处理函数
+// The waiter struct is a handling function waiting for an execution slot
+type waiter struct {
+	// score 是等待者的排序分数，分数越高越先执行，它包含了优先级和老化的补偿
+	// score is the sorting score of the waiter, a higher score starts first, it includes the priority and the aging compensation
+	score float64
+
+	// seq 是等待者进入队列的序号，分数相同时先进入的先执行
+	// seq is the sequence number when the waiter entered the queue, the earlier one starts first when the scores are equal
+	seq uint64
+
+	// ready 在等待者获得执行槽位时被关闭
+	// ready is closed when the waiter gets an execution slot
+	ready chan struct{}
+}
+
+// waiterHeap 是等待者的最大堆，实现了 heap.Interface
+// waiterHeap is a max heap of waiters, it implements heap.Interface
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].score != h[j].score {
+		return h[i].score > h[j].score
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *waiterHeap) Push(x any) { *h = append(*h, x.(*waiter)) }
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return w
+}
+
+// tenantQueue 结构体是一个租户等待执行槽位的处理函数队列
+// The tenantQueue struct is the queue of the handling functions of a tenant waiting for an execution slot
+type tenantQueue struct {
+	// waiters 是租户的等待者，按照优先级排序
+	// waiters are the waiters of the tenant, sorted by priority
+	waiters waiterHeap
+
+	// weight 是租户的权重，权重越大分到的执行槽位越多
+	// weight is the weight of the tenant, a larger weight gets more execution slots
+	weight float64
+
+	// pass 是队列下一次被服务时的虚拟时间，每次服务增加 1/weight
+	// pass is the virtual time when the queue is served next, it increases by 1/weight on every service
+	pass float64
+}
+
+// dispatcher 结构体限制同时执行的处理函数数量，槽位不足时按优先级从高到低启动等待的处理函数。
+// 等待时间每增加一个老化周期，等待者的有效优先级就加 1，避免低优先级的处理函数一直得不到执行。
+// 不同租户的等待者使用加权公平队列：槽位按照租户的权重轮流分配，优先级只在同一个租户内比较，一个租户的大量任务不会饿死其他租户
+// The dispatcher struct limits the number of handling functions running at the same time, when there are not enough slots the waiting handling functions are started from the highest priority to the lowest.
+// The effective priority of a waiter increases by 1 for every aging period it waits, so low-priority handling functions are not starved.
+// Waiters of different tenants use weighted fair queuing: slots are handed out to the tenants in turn by their weights, priorities are only compared within the same tenant, so a large number of tasks of one tenant does not starve the other tenants
+type dispatcher struct {
+	// lock 用于保护调度器的状态
+	// lock is used to protect the state of the dispatcher
+	lock sync.Mutex
+
+	// limit 是同时执行的处理函数的最大数量
+	// limit is the maximum number of handling functions running at the same time
+	limit int
+
+	// running 是正在执行的处理函数数量
+	// running is the number of running handling functions
+	running int
+
+	// aging 是老化周期，不大于 0 表示不老化
+	// aging is the aging period, not greater than 0 means no aging
+	aging time.Duration
+
+	// epoch 是计算老化补偿的起始时间
+	// epoch is the start time for calculating the aging compensation
+	epoch time.Time
+
+	// seq 是下一个等待者的序号
+	// seq is the sequence number of the next waiter
+	seq uint64
+
+	// weight 返回租户的权重，为 nil 时所有租户的权重都是 1
+	// weight returns the weight of a tenant, all tenants have the weight 1 if it is nil
+	weight func(tenant string) float64
+
+	// queues 是有等待者的租户的队列，键是租户的名称，不属于任何租户的等待者使用空字符串
+	// queues are the queues of the tenants with waiters, the key is the name of the tenant, waiters not belonging to any tenant use the empty string
+	queues map[string]*tenantQueue
+
+	// vtime 是最近一次被服务的队列的虚拟时间，新的队列从这个时间开始，空闲的租户不会积累额度
+	// vtime is the virtual time of the queue served last, new queues start at this time, so idle tenants do not accumulate credit
+	vtime float64
+
+	// queued 是所有队列中等待者的总数
+	// queued is the total number of waiters in all queues
+	queued int
+
+	// closed 表示调度器已经关闭，关闭后不再限制并发
+	// closed indicates the dispatcher is closed, the concurrency is no longer limited after closing
+	closed bool
+}
+
+// newDispatcher 函数创建一个新的 dispatcher 实例，weight 返回租户的权重，可以为 nil
+// The newDispatcher function creates a new dispatcher instance, weight returns the weight of a tenant, it can be nil
+func newDispatcher(limit int, aging time.Duration, weight func(tenant string) float64) *dispatcher {
+	return &dispatcher{limit: limit, aging: aging, epoch: time.Now(), weight: weight, queues: make(map[string]*tenantQueue)}
+}
+
+// score 方法计算等待者的排序分数。所有等待者以相同的速度老化，
+// 因此 priority + (now-enqueuedAt)/aging 的大小关系与 priority - enqueuedAt/aging 相同，分数在入队时计算一次即可
+// The score method calculates the sorting score of a waiter. All waiters age at the same rate,
+// so priority + (now-enqueuedAt)/aging orders the same as priority - enqueuedAt/aging, the score only needs to be calculated once when enqueuing
+func (d *dispatcher) score(priority int, enqueuedAt time.Time) float64 {
+	score := float64(priority)
+	if d.aging > 0 {
+		score -= float64(enqueuedAt.Sub(d.epoch)) / float64(d.aging)
+	}
+	return score
+}
+
+// acquire 方法为租户的处理函数获取一个执行槽位，槽位不足时阻塞直到轮到它，返回等待的时间
+// The acquire method acquires an execution slot for a handling function of the tenant, it blocks until its turn when there are not enough slots, it returns the waiting time
+func (d *dispatcher) acquire(tenant string, priority int) time.Duration {
+	d.lock.Lock()
+
+	// 有空闲的槽位并且没有其他等待者，或者调度器已经关闭时，直接执行
+	// Run directly if there is a free slot and no other waiters, or the dispatcher is closed
+	if d.closed || (d.running < d.limit && d.queued == 0) {
+		d.running++
+		d.lock.Unlock()
+		return 0
+	}
+
+	// 进入租户的等待队列，新的队列从当前的虚拟时间开始
+	// Enter the waiting queue of the tenant, a new queue starts at the current virtual time
+	q, ok := d.queues[tenant]
+	if !ok {
+		q = &tenantQueue{weight: 1, pass: d.vtime}
+		if d.weight != nil {
+			if weight := d.weight(tenant); weight > 0 {
+				q.weight = weight
+			}
+		}
+		d.queues[tenant] = q
+	}
+	enqueuedAt := time.Now()
+	w := &waiter{score: d.score(priority, enqueuedAt), seq: d.seq, ready: make(chan struct{})}
+	d.seq++
+	heap.Push(&q.waiters, w)
+	d.queued++
+	d.lock.Unlock()
+
+	// 等待槽位，释放槽位的处理函数会把槽位直接交给等待者
+	// Wait for a slot, the handling function releasing a slot hands it over to the waiter directly
+	<-w.ready
+	return time.Since(enqueuedAt)
+}
+
+// next 方法取出下一个获得执行槽位的等待者：虚拟时间最小的队列中优先级最高的等待者，调用者需要持有锁并确保有等待者
+// The next method takes out the next waiter getting an execution slot: the waiter with the highest priority in the queue with the smallest virtual time, the caller must hold the lock and ensure there are waiters
+func (d *dispatcher) next() *waiter {
+	// 选择虚拟时间最小的队列，虚拟时间相同时选择等待最久的队列
+	// Choose the queue with the smallest virtual time, the queue waiting longest is chosen when the virtual times are equal
+	var key string
+	var best *tenantQueue
+	for tenant, q := range d.queues {
+		if best == nil || q.pass < best.pass || (q.pass == best.pass && q.waiters[0].seq < best.waiters[0].seq) {
+			key, best = tenant, q
+		}
+	}
+
+	// 服务这个队列，它的虚拟时间按照权重前进
+	// Serve this queue, its virtual time advances by its weight
+	w := heap.Pop(&best.waiters).(*waiter)
+	d.vtime = best.pass
+	best.pass += 1 / best.weight
+	if len(best.waiters) == 0 {
+		delete(d.queues, key)
+	}
+	d.queued--
+	return w
+}
+
+// release 方法释放一个执行槽位，如果有等待者，槽位直接交给下一个等待者
+// The release method releases an execution slot, if there are waiters the slot is handed over to the next waiter
+func (d *dispatcher) release() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.queued > 0 {
+		close(d.next().ready)
+		return
+	}
+	d.running--
+}
+
+// waiting 方法返回正在等待执行槽位的处理函数数量
+// The waiting method returns the number of handling functions waiting for an execution slot
+func (d *dispatcher) waiting() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.queued
+}
+
+// waitingOf 方法返回租户正在等待执行槽位的处理函数数量
+// The waitingOf method returns the number of handling functions of the tenant waiting for an execution slot
+func (d *dispatcher) waitingOf(tenant string) int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if q, ok := d.queues[tenant]; ok {
+		return len(q.waiters)
+	}
+	return 0
+}
+
+// close 方法关闭调度器，所有的等待者立即执行，之后的处理函数不再受并发限制
+// The close method closes the dispatcher, all waiters run immediately and later handling functions are no longer limited
+func (d *dispatcher) close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.closed = true
+	for d.queued > 0 {
+		d.running++
+		close(d.next().ready)
+	}
+}
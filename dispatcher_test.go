@@ -0,0 +1,124 @@
+package kairos
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_Priority(t *testing.T) {
+	d := newDispatcher(1, 0, nil)
+
+	// Occupy the only slot
+	assert.Equal(t, time.Duration(0), d.acquire("", 0))
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	order := make([]int, 0)
+	priorities := []int{1, 5, 3, 5, -2}
+	for i, priority := range priorities {
+		wg.Add(1)
+		go func(priority int) {
+			defer wg.Done()
+			d.acquire("", priority)
+			lock.Lock()
+			order = append(order, priority)
+			lock.Unlock()
+			d.release()
+		}(priority)
+		assert.Eventually(t, func() bool { return d.waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	// Release the slot, the waiters run from the highest priority to the lowest
+	d.release()
+	wg.Wait()
+	assert.Equal(t, []int{5, 5, 3, 1, -2}, order)
+	assert.Equal(t, 0, d.waiting())
+	assert.Equal(t, 0, d.running)
+}
+
+func TestDispatcher_Aging(t *testing.T) {
+	d := newDispatcher(1, 10*time.Millisecond, nil)
+	d.acquire("", 0)
+
+	done := make(chan int, 2)
+	go func() {
+		d.acquire("", 0)
+		done <- 0
+		d.release()
+	}()
+	assert.Eventually(t, func() bool { return d.waiting() == 1 }, time.Second, time.Millisecond)
+
+	// The low-priority waiter has waited for more than 3 aging periods, so it outranks a new waiter with priority 2
+	time.Sleep(40 * time.Millisecond)
+	go func() {
+		d.acquire("", 2)
+		done <- 2
+		d.release()
+	}()
+	assert.Eventually(t, func() bool { return d.waiting() == 2 }, time.Second, time.Millisecond)
+
+	d.release()
+	assert.Equal(t, 0, <-done)
+	assert.Equal(t, 2, <-done)
+}
+
+func TestDispatcher_Close(t *testing.T) {
+	d := newDispatcher(1, 0, nil)
+	d.acquire("", 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.acquire("", 0)
+		}()
+	}
+	assert.Eventually(t, func() bool { return d.waiting() == 3 }, time.Second, time.Millisecond)
+
+	// Closing wakes all waiters, and later acquisitions are no longer limited
+	d.close()
+	wg.Wait()
+	assert.Equal(t, time.Duration(0), d.acquire("", 0))
+	assert.Equal(t, 5, d.running)
+	for i := 0; i < 5; i++ {
+		d.release()
+	}
+	assert.Equal(t, 0, d.running)
+}
+
+func TestDispatcher_Fairness(t *testing.T) {
+	weights := map[string]float64{"a": 1, "b": 2}
+	d := newDispatcher(1, 0, func(tenant string) float64 { return weights[tenant] })
+	d.acquire("", 0)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	order := make([]string, 0)
+	tenants := []string{"a", "a", "a", "a", "a", "a", "b", "b", "b"}
+	for i, tenant := range tenants {
+		wg.Add(1)
+		go func(tenant string) {
+			defer wg.Done()
+			d.acquire(tenant, 0)
+			lock.Lock()
+			order = append(order, tenant)
+			lock.Unlock()
+			d.release()
+		}(tenant)
+		assert.Eventually(t, func() bool { return d.waiting() == i+1 }, time.Second, time.Millisecond)
+	}
+	assert.Equal(t, 6, d.waitingOf("a"))
+	assert.Equal(t, 3, d.waitingOf("b"))
+	assert.Equal(t, 0, d.waitingOf("c"))
+
+	// The tenant queued first does not block the other one, slots are shared by weight
+	d.release()
+	wg.Wait()
+	assert.Equal(t, []string{"a", "b", "b", "a", "b", "a", "a", "a", "a"}, order)
+	assert.Equal(t, 0, len(d.queues))
+	assert.Equal(t, 0, d.running)
+}
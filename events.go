@@ -0,0 +1,217 @@
+package kairos
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType 是调度器事件的类型
+// EventType is the type of a scheduler event
+type EventType string
+
+// 定义调度器事件的类型，与 Callback 中的回调函数一一对应
+// Define the types of scheduler events, they correspond to the callback functions in Callback
+const (
+	// EventTaskAdded 表示任务被添加或者重新调度
+	// EventTaskAdded indicates the task is added or rescheduled
+	EventTaskAdded EventType = "added"
+
+	// EventTaskExecuted 表示任务被执行或者取消
+	// EventTaskExecuted indicates the task is executed or canceled
+	EventTaskExecuted EventType = "executed"
+
+	// EventTaskRemoved 表示任务被移除
+	// EventTaskRemoved indicates the task is removed
+	EventTaskRemoved EventType = "removed"
+
+	// EventTaskDuplicated 表示任务重复
+	// EventTaskDuplicated indicates the task is duplicated
+	EventTaskDuplicated EventType = "duplicated"
+
+	// EventTaskRejected 表示任务因为容量限制被拒绝，事件中只设置任务的名称和拒绝的原因
+	// EventTaskRejected indicates the task is rejected by a capacity limit, only the name of the task and the reason of the rejection are set in the event
+	EventTaskRejected EventType = "rejected"
+
+	// EventClockJump 表示检测到系统时钟跳变，事件中不设置任务的信息
+	// EventClockJump indicates a jump of the system clock is detected, the information of tasks is not set in the event
+	EventClockJump EventType = "clock_jump"
+)
+
+// Event 结构体描述了调度器中发生的一个事件
+// The Event struct describes an event that happened in the scheduler
+type Event struct {
+	// Type 是事件的类型
+	// Type is the type of the event
+	Type EventType `json:"type"`
+
+	// ID 是任务的 id
+	// ID is the id of the task
+	ID string `json:"id"`
+
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Labels 是任务的标签，EventTaskDuplicated 事件中不设置
+	// Labels are the labels of the task, not set in EventTaskDuplicated events
+	Labels Labels `json:"labels,omitempty"`
+
+	// Time 是事件发生的时间
+	// Time is the time when the event happened
+	Time time.Time `json:"time"`
+
+	// ExecAt 是任务计划执行的时间，只在 EventTaskAdded 事件中设置
+	// ExecAt is the scheduled execution time of the task, only set in EventTaskAdded events
+	ExecAt time.Time `json:"exec_at"`
+
+	// Reason 是任务结束的原因，只在 EventTaskExecuted 事件中设置
+	// Reason is the reason why the task finished, only set in EventTaskExecuted events
+	Reason string `json:"reason,omitempty"`
+
+	// Error 是处理函数返回的错误，只在 EventTaskExecuted 事件中设置；在 EventTaskRejected 事件中是拒绝的原因
+	// Error is the error returned by the handling function, only set in EventTaskExecuted events; it is the reason of the rejection in EventTaskRejected events
+	Error string `json:"error,omitempty"`
+
+	// Lag 是任务到期时距离计划执行时间的延迟，只在 EventTaskExecuted 事件中设置
+	// Lag is the delay from the scheduled execution time when the task expired, only set in EventTaskExecuted events
+	Lag time.Duration `json:"lag,omitempty"`
+
+	// Throttled 是任务到期之后因为速率限制被推迟的时间，只在 EventTaskExecuted 事件中设置
+	// Throttled is the time the task was delayed by the rate limit after it expired, only set in EventTaskExecuted events
+	Throttled time.Duration `json:"throttled,omitempty"`
+
+	// Jump 是时钟跳变的大小，向前为正，向后为负，只在 EventClockJump 事件中设置
+	// Jump is the size of the clock jump, positive forwards and negative backwards, only set in EventClockJump events
+	Jump time.Duration `json:"jump,omitempty"`
+}
+
+// subscriber 结构体是事件的订阅者
+// The subscriber struct is a subscriber of events
+type subscriber struct {
+	// ch 是接收事件的通道
+	// ch is the channel receiving events
+	ch chan *Event
+}
+
+// eventHub 结构体将事件分发给所有的订阅者，订阅者处理不及时的事件会被丢弃
+// The eventHub struct dispatches events to all subscribers, events are dropped for subscribers that do not keep up
+type eventHub struct {
+	// lock 用于保护订阅者集合
+	// lock is used to protect the set of subscribers
+	lock sync.RWMutex
+
+	// subscribers 是订阅者集合
+	// subscribers is the set of subscribers
+	subscribers map[*subscriber]struct{}
+
+	// count 是订阅者的数量，没有订阅者时发布事件不需要加锁
+	// count is the number of subscribers, publishing an event does not need the lock when there are no subscribers
+	count atomic.Int32
+
+	// closed 表示事件中心是否已经关闭
+	// closed indicates whether the event hub is closed
+	closed bool
+}
+
+// newEventHub 函数用于创建一个新的 eventHub 实例
+// The newEventHub function is used to create a new instance of eventHub
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[*subscriber]struct{})}
+}
+
+// subscribe 方法添加一个订阅者，返回接收事件的通道和取消订阅的函数
+// The subscribe method adds a subscriber, it returns the channel receiving events and the function to unsubscribe
+func (h *eventHub) subscribe(buffer int) (<-chan *Event, func()) {
+	// 如果缓冲区大小无效，使用 1
+	// If the buffer size is invalid, use 1
+	if buffer <= 0 {
+		buffer = 1
+	}
+
+	sub := &subscriber{ch: make(chan *Event, buffer)}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 如果事件中心已经关闭，返回一个已经关闭的通道
+	// If the event hub is closed, return a closed channel
+	if h.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+
+	// 添加订阅者
+	// Add the subscriber
+	h.subscribers[sub] = struct{}{}
+	h.count.Add(1)
+
+	// 返回通道和取消订阅的函数
+	// Return the channel and the function to unsubscribe
+	return sub.ch, func() { h.unsubscribe(sub) }
+}
+
+// unsubscribe 方法移除一个订阅者，并关闭它的通道
+// The unsubscribe method removes a subscriber and closes its channel
+func (h *eventHub) unsubscribe(sub *subscriber) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 如果订阅者已经被移除，直接返回
+	// If the subscriber has been removed, return directly
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers, sub)
+	h.count.Add(-1)
+	close(sub.ch)
+}
+
+// publish 方法将事件发送给所有的订阅者，不会阻塞
+// The publish method sends the event to all subscribers without blocking
+func (h *eventHub) publish(event *Event) {
+	// 如果没有订阅者，直接返回
+	// If there are no subscribers, return directly
+	if h.count.Load() == 0 {
+		return
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	// 将事件发送给所有的订阅者，如果订阅者的缓冲区已满，丢弃事件
+	// Send the event to all subscribers, drop the event if the buffer of a subscriber is full
+	for sub := range h.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+		}
+	}
+}
+
+// close 方法关闭事件中心，并关闭所有订阅者的通道
+// The close method closes the event hub and the channels of all subscribers
+func (h *eventHub) close() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 关闭所有订阅者的通道
+	// Close the channels of all subscribers
+	for sub := range h.subscribers {
+		close(sub.ch)
+	}
+
+	h.subscribers = make(map[*subscriber]struct{})
+	h.count.Store(0)
+	h.closed = true
+}
+
+// errorString 函数返回错误的字符串，如果错误为 nil，则返回空字符串
+// The errorString function returns the string of the error, or an empty string if the error is nil
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,60 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventHub_Publish(t *testing.T) {
+	hub := newEventHub()
+
+	// Publishing without subscribers does nothing
+	hub.publish(&Event{Type: EventTaskAdded})
+
+	events, unsubscribe := hub.subscribe(1)
+	hub.publish(&Event{Type: EventTaskAdded, ID: "1"})
+
+	// The buffer is full, the event is dropped instead of blocking
+	hub.publish(&Event{Type: EventTaskAdded, ID: "2"})
+
+	event := <-events
+	assert.Equal(t, "1", event.ID)
+	assert.Equal(t, 0, len(events))
+
+	// The channel is closed after unsubscribing, unsubscribing twice is safe
+	unsubscribe()
+	unsubscribe()
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestScheduler_Subscribe(t *testing.T) {
+	scheduler := New(NewConfig().WithUniqued(true))
+	events, unsubscribe := scheduler.Subscribe(16)
+	defer unsubscribe()
+
+	taskID, _ := scheduler.Set("test", nil, time.Millisecond*50)
+	_, _ = scheduler.Set("test", nil, time.Millisecond*50)
+	time.Sleep(time.Millisecond * 200)
+
+	// Collect the events in order
+	var types []EventType
+	for len(events) > 0 {
+		event := <-events
+		assert.Equal(t, taskID, event.ID)
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []EventType{EventTaskAdded, EventTaskDuplicated, EventTaskAdded, EventTaskExecuted, EventTaskRemoved}, types)
+
+	// The channel is closed when the scheduler stops
+	scheduler.Stop()
+	_, ok := <-events
+	assert.False(t, ok)
+
+	// Subscribing to a stopped scheduler returns a closed channel
+	closed, _ := scheduler.Subscribe(1)
+	_, ok = <-closed
+	assert.False(t, ok)
+}
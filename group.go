@@ -0,0 +1,388 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrorGroupRemoved 表示任务组已经被 RemoveGroup 删除，不能再添加任务
+// ErrorGroupRemoved indicates the task group has been deleted by RemoveGroup, no more tasks can be added to it
+var ErrorGroupRemoved = errors.New("group removed")
+
+// GroupConfig 是一个结构体，包含任务组的可选设置。
+// GroupConfig is a struct that contains the optional settings of a task group.
+type GroupConfig struct {
+	// maxConcurrency 是组内同时执行的处理函数的最大数量，为 0 表示不限制。
+	// maxConcurrency is the maximum number of handling functions of the group running at the same time, 0 means unlimited.
+	maxConcurrency int
+
+	// uniqued 表示任务的名称是否在组内唯一。
+	// uniqued indicates whether the names of tasks are unique within the group.
+	uniqued bool
+
+	// rateLimit 是组内每秒允许触发的任务数量，不大于 0 表示不限制。
+	// rateLimit is the number of tasks of the group allowed to fire per second, not greater than 0 means unlimited.
+	rateLimit float64
+
+	// rateBurst 是组的令牌桶的容量。
+	// rateBurst is the capacity of the token bucket of the group.
+	rateBurst int
+
+	// maxPending 是组内任务的最大数量，为 0 表示不限制。
+	// maxPending is the maximum number of tasks of the group, 0 means unlimited.
+	maxPending int
+}
+
+// NewGroupConfig 是一个函数，用于创建一个新的 GroupConfig 实例
+// NewGroupConfig is a function used to create a new instance of GroupConfig
+func NewGroupConfig() *GroupConfig {
+	return &GroupConfig{}
+}
+
+// WithMaxConcurrency 是一个方法，用于设置组内同时执行的处理函数的最大数量，不大于 0 表示不限制。
+// 组的限制和调度器的 WithMaxConcurrency 同时生效，处理函数需要先获得组的执行槽位。
+// WithMaxConcurrency is a method used to set the maximum number of handling functions of the group running at the same time, not greater than 0 means unlimited.
+// The limit of the group applies together with WithMaxConcurrency of the scheduler, a handling function gets the execution slot of the group first.
+func (c *GroupConfig) WithMaxConcurrency(max int) *GroupConfig {
+	c.maxConcurrency = max
+	return c
+}
+
+// WithUniqued 是一个方法，用于设置任务的名称是否在组内唯一。为 true 时，组内同名的任务只能存在一个，
+// 并且不受调度器的 WithUniqued 影响；为 false 时，组内的任务使用调度器的 WithUniqued 设置。
+// WithUniqued is a method used to set whether the names of tasks are unique within the group. When it is true, only one task with the same name can exist in the group,
+// regardless of WithUniqued of the scheduler; when it is false, the tasks of the group follow WithUniqued of the scheduler.
+func (c *GroupConfig) WithUniqued(uniqued bool) *GroupConfig {
+	c.uniqued = uniqued
+	return c
+}
+
+// WithRateLimit 是一个方法，用于使用令牌桶限制组内任务触发的速率，rate 不大于 0 表示不限制，burst 小于 1 时使用 1。
+// 组的限制和调度器的 WithRateLimit 同时生效，任务推迟到两者都有令牌时执行。
+// WithRateLimit is a method used to limit the firing rate of the tasks of the group with a token bucket, a rate not greater than 0 means unlimited, 1 is used if burst is less than 1.
+// The limit of the group applies together with WithRateLimit of the scheduler, a task is delayed until both have a token.
+func (c *GroupConfig) WithRateLimit(rate float64, burst int) *GroupConfig {
+	c.rateLimit = rate
+	c.rateBurst = burst
+	return c
+}
+
+// WithMaxPendingTasks 是一个方法，用于设置组内任务的最大数量，不大于 0 表示不限制。达到限制时在组内添加任务返回 ErrorGroupQuota，它包装了 ErrorSchedulerFull。
+// 组的限制和调度器的 WithMaxPendingTasks 同时生效。
+// WithMaxPendingTasks is a method used to set the maximum number of tasks of the group, not greater than 0 means unlimited. When the limit is reached, adding a task to the group returns ErrorGroupQuota, which wraps ErrorSchedulerFull.
+// The limit of the group applies together with WithMaxPendingTasks of the scheduler.
+func (c *GroupConfig) WithMaxPendingTasks(max int) *GroupConfig {
+	c.maxPending = max
+	return c
+}
+
+// isGroupConfigValid 是一个函数，用于检查 GroupConfig 实例是否有效
+// isGroupConfigValid is a function used to check if the instance of GroupConfig is valid
+func isGroupConfigValid(conf *GroupConfig) *GroupConfig {
+	if conf == nil {
+		return NewGroupConfig()
+	}
+	if conf.maxConcurrency < 0 {
+		conf.maxConcurrency = 0
+	}
+	if conf.maxPending < 0 {
+		conf.maxPending = 0
+	}
+	return conf
+}
+
+// Group 结构体是调度器中一组相关的任务，例如同一个用户会话的所有超时任务。
+// 组内任务的上下文都派生自组的上下文，取消组的上下文会同时取消组内所有等待中的任务。
+// The Group struct is a set of related tasks in the scheduler, for example all timeouts of the same user session.
+// The contexts of the tasks in the group are all derived from the context of the group, canceling it cancels all pending tasks of the group at once.
+type Group struct {
+	// sched 是组所属的调度器
+	// sched is the scheduler the group belongs to
+	sched *Scheduler
+
+	// name 是组的名称
+	// name is the name of the group
+	name string
+
+	// cfg 是组的配置
+	// cfg is the configuration of the group
+	cfg *GroupConfig
+
+	// dispatcher 用于限制组内同时执行的处理函数数量，没有限制时为 nil
+	// dispatcher is used to limit the number of handling functions of the group running at the same time, nil if unlimited
+	dispatcher *dispatcher
+
+	// limiter 用于限制组内任务触发的速率，没有限制时为 nil
+	// limiter is used to limit the firing rate of the tasks of the group, nil if unlimited
+	limiter *rateLimiter
+
+	// lock 用于保护组的上下文和成员
+	// lock is used to protect the context and the members of the group
+	lock sync.Mutex
+
+	// ctx 是组的上下文，它是组内任务的父上下文
+	// ctx is the context of the group, it is the parent context of the tasks in the group
+	ctx context.Context
+
+	// cancel 用于取消组的上下文
+	// cancel is used to cancel the context of the group
+	cancel context.CancelFunc
+
+	// members 是组内任务的 ID
+	// members are the IDs of the tasks in the group
+	members map[string]struct{}
+
+	// empty 在组内没有任务时被关闭
+	// empty is closed when there are no tasks in the group
+	empty chan struct{}
+
+	// removed 表示组已经从调度器中删除
+	// removed indicates the group has been deleted from the scheduler
+	removed bool
+}
+
+// newGroup 函数创建一个新的任务组，组的上下文派生自调度器的上下文
+// The newGroup function creates a new task group, the context of the group is derived from the context of the scheduler
+func newGroup(s *Scheduler, name string, conf *GroupConfig) *Group {
+	g := &Group{
+		sched:   s,
+		name:    name,
+		cfg:     conf,
+		members: make(map[string]struct{}),
+		empty:   make(chan struct{}),
+	}
+	g.ctx, g.cancel = context.WithCancel(s.ctx)
+	close(g.empty)
+
+	// 如果限制了组内的并发数量，创建一个分派器
+	// If the concurrency of the group is limited, create a dispatcher
+	if conf.maxConcurrency > 0 {
+		g.dispatcher = newDispatcher(conf.maxConcurrency, s.cfg.priorityAging, s.tenantWeight)
+	}
+
+	// 如果限制了组内的触发速率，创建一个组内共享的限速器
+	// If the firing rate of the group is limited, create a rate limiter shared within the group
+	if conf.rateLimit > 0 {
+		g.limiter = newRateLimiter(conf.rateLimit, conf.rateBurst, RateLimitGlobal)
+	}
+
+	return g
+}
+
+// Name 方法返回组的名称
+// The Name method returns the name of the group
+func (g *Group) Name() string {
+	return g.name
+}
+
+// context 方法返回组当前的上下文，新的任务使用它作为父上下文
+// The context method returns the current context of the group, new tasks use it as their parent context
+func (g *Group) context() context.Context {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.ctx
+}
+
+// isRemoved 方法返回组是否已经从调度器中删除
+// The isRemoved method returns whether the group has been deleted from the scheduler
+func (g *Group) isRemoved() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.removed
+}
+
+// join 方法将任务加入组
+// The join method adds the task to the group
+func (g *Group) join(id string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if len(g.members) == 0 {
+		g.empty = make(chan struct{})
+	}
+	g.members[id] = struct{}{}
+}
+
+// leave 方法将任务从组中移除，组内没有任务时通知等待者
+// The leave method removes the task from the group, the waiters are notified when there are no tasks in the group
+func (g *Group) leave(id string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if _, ok := g.members[id]; !ok {
+		return
+	}
+	delete(g.members, id)
+	if len(g.members) == 0 {
+		close(g.empty)
+	}
+}
+
+// ids 方法返回组内所有任务的 ID
+// The ids method returns the IDs of all tasks in the group
+func (g *Group) ids() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// options 方法返回属于这个组的任务选项的副本
+// The options method returns a copy of the task options belonging to this group
+func (g *Group) options(opts *TaskOptions) *TaskOptions {
+	copied := *isTaskOptionsValid(opts)
+	copied.group = g
+	return &copied
+}
+
+// SetAt 方法在组内添加一个在指定时间执行的任务
+// The SetAt method adds a task executed at the specified time to the group
+func (g *Group) SetAt(name string, handleFunc TaskHandleFunc, execAt time.Time) (string, error) {
+	return g.SetAtWithOptions(name, handleFunc, execAt, nil)
+}
+
+// SetAtWithOptions 方法在组内添加一个在指定时间执行的任务，并使用给定的任务选项
+// The SetAtWithOptions method adds a task executed at the specified time to the group with the given task options
+func (g *Group) SetAtWithOptions(name string, handleFunc TaskHandleFunc, execAt time.Time, opts *TaskOptions) (string, error) {
+	return g.sched.SetAtWithOptions(name, handleFunc, execAt, g.options(opts))
+}
+
+// Set 方法在组内添加一个在指定延迟之后执行的任务
+// The Set method adds a task executed after the specified delay to the group
+func (g *Group) Set(name string, handleFunc TaskHandleFunc, delay time.Duration) (string, error) {
+	return g.SetWithOptions(name, handleFunc, delay, nil)
+}
+
+// SetWithOptions 方法在组内添加一个在指定延迟之后执行的任务，并使用给定的任务选项
+// The SetWithOptions method adds a task executed after the specified delay to the group with the given task options
+func (g *Group) SetWithOptions(name string, handleFunc TaskHandleFunc, delay time.Duration, opts *TaskOptions) (string, error) {
+	return g.SetAtWithOptions(name, handleFunc, time.Now().Add(delay), relativeOptions(opts))
+}
+
+// SetAtWithContext 方法在组内添加一个在指定时间执行、生命周期绑定到调用者上下文的任务，参见 Scheduler.SetAtWithContext
+// The SetAtWithContext method adds a task executed at the specified time whose lifetime is bound to the caller context to the group, see Scheduler.SetAtWithContext
+func (g *Group) SetAtWithContext(ctx context.Context, name string, handleFunc ContextHandleFunc, execAt time.Time) (string, error) {
+	return g.sched.setAtWithContext(ctx, name, handleFunc, execAt, g.options(nil))
+}
+
+// SetWithContext 方法在组内添加一个在指定延迟之后执行、生命周期绑定到调用者上下文的任务
+// The SetWithContext method adds a task executed after the specified delay whose lifetime is bound to the caller context to the group
+func (g *Group) SetWithContext(ctx context.Context, name string, handleFunc ContextHandleFunc, delay time.Duration) (string, error) {
+	return g.sched.setAtWithContext(ctx, name, handleFunc, time.Now().Add(delay), g.options(relativeOptions(nil)))
+}
+
+// SetBusinessDelay 方法在组内添加一个在经过指定的工作时间之后执行的任务，参见 Scheduler.SetBusinessDelay
+// The SetBusinessDelay method adds a task executed after the specified business time has elapsed to the group, see Scheduler.SetBusinessDelay
+func (g *Group) SetBusinessDelay(name string, handleFunc TaskHandleFunc, delay time.Duration, calendar BusinessCalendar) (string, error) {
+	return g.sched.SetBusinessDelayWithOptions(name, handleFunc, delay, calendar, g.options(nil))
+}
+
+// Count 方法返回组内的任务数量
+// The Count method returns the number of tasks in the group
+func (g *Group) Count() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.members)
+}
+
+// List 方法返回组内所有任务的快照，按执行时间排序
+// The List method returns the snapshots of all tasks in the group, sorted by the execution time
+func (g *Group) List() []*TaskInfo {
+	infos := make([]*TaskInfo, 0)
+	for _, info := range g.sched.List() {
+		if info.Group == g.name {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// CancelAll 方法取消组内所有的任务，返回被取消的任务数量。已经开始执行的处理函数会继续执行，
+// 但是它们的 done 通道会被关闭。取消之后组仍然可以使用，新的任务使用一个新的上下文
+// The CancelAll method cancels all tasks in the group, it returns the number of canceled tasks. Handling functions that have already started keep running,
+// but their done channels are closed. The group can still be used after the cancellation, new tasks use a new context
+func (g *Group) CancelAll() int {
+	// 取出组内的任务，并换上一个新的上下文，之后添加的任务不会被这次取消影响
+	// Take out the tasks of the group and swap in a new context, tasks added afterwards are not affected by this cancellation
+	g.lock.Lock()
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+	cancel := g.cancel
+	if !g.removed {
+		g.ctx, g.cancel = context.WithCancel(g.sched.ctx)
+	}
+	g.lock.Unlock()
+
+	// 取消旧的上下文，组内所有等待中的任务通过父上下文链同时被取消
+	// Cancel the old context, all pending tasks of the group are canceled at once through the parent context chain
+	cancel()
+
+	// 删除剩余的任务，例如被暂停的任务，它们没有派生自组上下文的任务
+	// Delete the remaining tasks, such as paused tasks, which have no task derived from the context of the group
+	for _, id := range ids {
+		g.sched.remove(id)
+	}
+
+	return len(ids)
+}
+
+// remove 方法将组标记为已删除，取消组的上下文并删除组内所有的任务，最后关闭组的分派器，返回被删除的任务数量
+// The remove method marks the group as deleted, cancels the context of the group and deletes all tasks of the group, then closes the dispatcher of the group, it returns the number of deleted tasks
+func (g *Group) remove() int {
+	// 标记组已经被删除并取出组内的任务，组的上下文不再被替换，和删除同时添加的任务会立即被取消
+	// Mark the group as deleted and take out the tasks of the group, the context of the group is not replaced anymore, tasks added at the same time as the deletion are canceled immediately
+	g.lock.Lock()
+	g.removed = true
+	ids := make([]string, 0, len(g.members))
+	for id := range g.members {
+		ids = append(ids, id)
+	}
+	g.lock.Unlock()
+
+	// 取消组的上下文，然后删除组内的任务
+	// Cancel the context of the group, then delete the tasks of the group
+	g.cancel()
+	for _, id := range ids {
+		g.sched.remove(id)
+	}
+
+	// 关闭分派器，仍在排队的处理函数立即执行
+	// Close the dispatcher, the handling functions still queued run immediately
+	if g.dispatcher != nil {
+		g.dispatcher.close()
+	}
+	return len(ids)
+}
+
+// EarlyReturnAll 方法让组内所有的任务提前执行，返回提前执行的任务数量，被暂停的任务会被跳过
+// The EarlyReturnAll method makes all tasks in the group execute early, it returns the number of affected tasks, paused tasks are skipped
+func (g *Group) EarlyReturnAll() int {
+	returned := 0
+	for _, id := range g.ids() {
+		if g.sched.EarlyReturn(id) == nil {
+			returned++
+		}
+	}
+	return returned
+}
+
+// Wait 方法阻塞直到组内没有任务，或者调度器被停止
+// The Wait method blocks until there are no tasks in the group, or the scheduler is stopped
+func (g *Group) Wait() {
+	g.lock.Lock()
+	empty := g.empty
+	g.lock.Unlock()
+
+	select {
+	case <-empty:
+	case <-g.sched.ctx.Done():
+	}
+}
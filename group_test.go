@@ -0,0 +1,220 @@
+package kairos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_SetAndCount(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	session := s.Group("session-1")
+	assert.Same(t, session, s.Group("session-1"))
+	assert.Equal(t, "session-1", session.Name())
+
+	// Tasks added to the group are also tasks of the scheduler
+	id, err := session.Set("idle", nil, time.Hour)
+	assert.Nil(t, err)
+	_, err = session.SetAtWithOptions("logout", nil, time.Now().Add(time.Hour), NewTaskOptions().WithLabels(Labels{"kind": "logout"}))
+	assert.Nil(t, err)
+	_, err = s.Set("other", nil, time.Hour)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, session.Count())
+	assert.Equal(t, 3, s.Count())
+
+	info, err := s.GetInfo(id)
+	assert.Nil(t, err)
+	assert.Equal(t, "session-1", info.Group)
+	assert.Len(t, session.List(), 2)
+
+	// Deleting a task through the scheduler removes it from the group
+	s.Delete(id)
+	assert.Equal(t, 1, session.Count())
+}
+
+func TestGroup_CancelAll(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	session := s.Group("session")
+	var executed, canceled atomic.Int32
+	handler := func(done WaitForContextDone) (any, error) {
+		executed.Add(1)
+		return nil, nil
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = session.Set("timeout", handler, time.Hour)
+	}
+	paused, _ := session.Set("paused", handler, time.Hour)
+	assert.Nil(t, s.Pause(paused))
+	_, _ = s.Set("outside", handler, time.Hour)
+
+	sub, unsubscribe := s.Subscribe(16)
+	defer unsubscribe()
+	go func() {
+		for event := range sub {
+			if event.Type == EventTaskExecuted && event.Reason == ErrorTaskCanceled.Error() {
+				canceled.Add(1)
+			}
+		}
+	}()
+
+	// Cancel all tasks of the group, including the paused one, the other tasks are not affected
+	assert.Equal(t, 6, session.CancelAll())
+	session.Wait()
+	assert.Equal(t, 0, session.Count())
+	assert.Equal(t, 1, s.Count())
+	assert.Equal(t, int32(0), executed.Load())
+	assert.Eventually(t, func() bool { return canceled.Load() == 5 }, time.Second, time.Millisecond)
+
+	// The group can be used again after the cancellation
+	_, err := session.Set("again", handler, 0)
+	assert.Nil(t, err)
+	session.Wait()
+	assert.Equal(t, int32(1), executed.Load())
+}
+
+func TestScheduler_RemoveGroup(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	session := s.GroupWithConfig("session", NewGroupConfig().WithMaxConcurrency(1).WithMaxPendingTasks(10))
+	for i := 0; i < 3; i++ {
+		_, err := session.Set("timeout", nil, time.Hour)
+		assert.Nil(t, err)
+	}
+	paused, _ := session.Set("paused", nil, time.Hour)
+	assert.Nil(t, s.Pause(paused))
+	_, _ = s.Set("outside", nil, time.Hour)
+
+	// All tasks of the group are deleted, the context of the group is canceled and the group is forgotten
+	assert.Equal(t, 4, s.RemoveGroup("session"))
+	assert.Equal(t, 0, session.Count())
+	assert.Equal(t, 1, s.Count())
+	assert.NotNil(t, session.context().Err())
+	s.groupsLock.Lock()
+	assert.Equal(t, 0, len(s.groups))
+	s.groupsLock.Unlock()
+	assert.Equal(t, 0, s.RemoveGroup("session"))
+
+	// The removed group rejects new tasks, a new group with the same name can be created
+	_, err := session.Set("late", nil, time.Hour)
+	assert.ErrorIs(t, err, ErrorGroupRemoved)
+	session.CancelAll()
+	assert.NotNil(t, session.context().Err())
+	again := s.Group("session")
+	assert.NotSame(t, session, again)
+	_, err = again.Set("new", nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, again.Count())
+}
+
+func TestGroup_EarlyReturnAllAndWait(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	group := s.Group("batch")
+	var executed atomic.Int32
+	for i := 0; i < 4; i++ {
+		_, _ = group.Set("job", func(done WaitForContextDone) (any, error) {
+			executed.Add(1)
+			return nil, nil
+		}, time.Hour)
+	}
+
+	// Wait blocks until the group is empty
+	waited := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		t.Fatal("wait returned while the group still has tasks")
+	case <-time.After(time.Millisecond * 20):
+	}
+
+	assert.Equal(t, 4, group.EarlyReturnAll())
+	<-waited
+	assert.Equal(t, int32(4), executed.Load())
+
+	// Waiting for an empty group returns immediately
+	s.Group("empty").Wait()
+}
+
+func TestGroup_WaitStop(t *testing.T) {
+	s := New(nil)
+	group := s.Group("group")
+	_, _ = group.Set("job", nil, time.Hour)
+
+	// Stopping the scheduler releases the waiters
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	group.Wait()
+	<-stopped
+}
+
+func TestGroup_Uniqued(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	a := s.GroupWithConfig("a", NewGroupConfig().WithUniqued(true))
+	b := s.GroupWithConfig("b", NewGroupConfig().WithUniqued(true))
+
+	// The names are unique within a group, but not across groups or outside of groups
+	id1, _ := a.Set("timeout", nil, time.Hour)
+	id2, _ := a.Set("timeout", nil, time.Hour)
+	id3, _ := b.Set("timeout", nil, time.Hour)
+	id4, _ := s.Set("timeout", nil, time.Hour)
+	id5, _ := s.Set("timeout", nil, time.Hour)
+	assert.Equal(t, id1, id2)
+	assert.NotEqual(t, id1, id3)
+	assert.NotEqual(t, id4, id5)
+	assert.Equal(t, 1, a.Count())
+	assert.Equal(t, 4, s.Count())
+
+	// The name can be used again after the task is removed
+	s.Delete(id1)
+	id6, _ := a.Set("timeout", nil, time.Hour)
+	assert.NotEqual(t, id1, id6)
+}
+
+func TestGroup_MaxConcurrency(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	group := s.GroupWithConfig("limited", NewGroupConfig().WithMaxConcurrency(2))
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		_, _ = group.Set("job", func(done WaitForContextDone) (any, error) {
+			defer wg.Done()
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond * 10)
+			running.Add(-1)
+			return nil, nil
+		}, 0)
+	}
+	wg.Wait()
+
+	// At most 2 handlers of the group ran at the same time
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, int32(2), peak.Load())
+}
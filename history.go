@@ -0,0 +1,415 @@
+package kairos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 定义历史记录的默认值
+// Define the default values of the history
+const (
+	// defaultHistoryCapacity 是历史记录环形缓冲区的默认容量
+	// defaultHistoryCapacity is the default capacity of the history ring buffer
+	defaultHistoryCapacity = 1024
+
+	// defaultHistoryResultLimit 是结果摘要的默认最大长度
+	// defaultHistoryResultLimit is the default maximum length of the result summary
+	defaultHistoryResultLimit = 256
+)
+
+// HistoryRecord 结构体描述了一次任务执行的记录
+// The HistoryRecord struct describes the record of one task execution
+type HistoryRecord struct {
+	// ID 是任务的 id
+	// ID is the id of the task
+	ID string `json:"id"`
+
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels Labels `json:"labels,omitempty"`
+
+	// Priority 是任务的优先级
+	// Priority is the priority of the task
+	Priority int `json:"priority,omitempty"`
+
+	// ScheduledAt 是任务计划执行的时间
+	// ScheduledAt is the scheduled execution time of the task
+	ScheduledAt time.Time `json:"scheduled_at"`
+
+	// StartedAt 是处理函数开始执行的时间，任务被取消时为零值
+	// StartedAt is the time when the handling function started, zero if the task was canceled
+	StartedAt time.Time `json:"started_at"`
+
+	// FinishedAt 是任务结束的时间
+	// FinishedAt is the time when the task finished
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Reason 是任务结束的原因
+	// Reason is the reason why the task finished
+	Reason string `json:"reason"`
+
+	// Error 是处理函数返回的错误
+	// Error is the error returned by the handling function
+	Error string `json:"error,omitempty"`
+
+	// Result 是处理函数返回结果的摘要
+	// Result is the summary of the result returned by the handling function
+	Result string `json:"result,omitempty"`
+
+	// Lag 是任务到期时距离计划执行时间的延迟，提前执行的任务为 0
+	// Lag is the delay from the scheduled execution time when the task expired, 0 for tasks executed early
+	Lag time.Duration `json:"lag,omitempty"`
+
+	// Throttled 是任务到期之后因为速率限制被推迟的时间
+	// Throttled is the time the task was delayed by the rate limit after it expired
+	Throttled time.Duration `json:"throttled,omitempty"`
+}
+
+// HistoryQuery 结构体定义了查询历史记录的条件，零值字段表示不做限制
+// The HistoryQuery struct defines the conditions to query the history, zero-value fields mean no restriction
+type HistoryQuery struct {
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string
+
+	// Since 是任务结束时间的下限（包含）
+	// Since is the lower bound (inclusive) of the finish time of the task
+	Since time.Time
+
+	// Until 是任务结束时间的上限（不包含）
+	// Until is the upper bound (exclusive) of the finish time of the task
+	Until time.Time
+
+	// Limit 是返回记录的最大数量，返回的是最新的记录
+	// Limit is the maximum number of records returned, the newest records are returned
+	Limit int
+}
+
+// HistoryConfig 结构体定义了历史记录的配置
+// The HistoryConfig struct defines the configuration of the history
+type HistoryConfig struct {
+	// capacity 是环形缓冲区的容量
+	// capacity is the capacity of the ring buffer
+	capacity int
+
+	// maxAge 是记录保留的最长时间，0 表示不限制
+	// maxAge is the maximum time a record is retained, 0 means no limit
+	maxAge time.Duration
+
+	// resultLimit 是结果摘要的最大长度
+	// resultLimit is the maximum length of the result summary
+	resultLimit int
+
+	// filePath 是审计文件的路径，记录以 JSON Lines 格式追加，为空表示不写文件
+	// filePath is the path of the audit file, records are appended in JSON Lines format, empty means no file
+	filePath string
+}
+
+// NewHistoryConfig 函数用于创建一个新的 HistoryConfig 实例
+// The NewHistoryConfig function is used to create a new instance of HistoryConfig
+func NewHistoryConfig() *HistoryConfig {
+	return &HistoryConfig{
+		capacity:    defaultHistoryCapacity,
+		resultLimit: defaultHistoryResultLimit,
+	}
+}
+
+// DefaultHistoryConfig 函数用于获取默认的 HistoryConfig 实例
+// The DefaultHistoryConfig function is used to get the default instance of HistoryConfig
+func DefaultHistoryConfig() *HistoryConfig {
+	return NewHistoryConfig()
+}
+
+// WithCapacity 方法用于设置环形缓冲区的容量
+// The WithCapacity method is used to set the capacity of the ring buffer
+func (c *HistoryConfig) WithCapacity(capacity int) *HistoryConfig {
+	c.capacity = capacity
+	return c
+}
+
+// WithMaxAge 方法用于设置记录保留的最长时间
+// The WithMaxAge method is used to set the maximum time a record is retained
+func (c *HistoryConfig) WithMaxAge(maxAge time.Duration) *HistoryConfig {
+	c.maxAge = maxAge
+	return c
+}
+
+// WithResultLimit 方法用于设置结果摘要的最大长度
+// The WithResultLimit method is used to set the maximum length of the result summary
+func (c *HistoryConfig) WithResultLimit(limit int) *HistoryConfig {
+	c.resultLimit = limit
+	return c
+}
+
+// WithFile 方法用于设置审计文件的路径
+// The WithFile method is used to set the path of the audit file
+func (c *HistoryConfig) WithFile(path string) *HistoryConfig {
+	c.filePath = path
+	return c
+}
+
+// isHistoryConfigValid 函数用于检查 HistoryConfig 实例是否有效
+// The isHistoryConfigValid function is used to check if the instance of HistoryConfig is valid
+func isHistoryConfigValid(conf *HistoryConfig) *HistoryConfig {
+	// 如果 conf 为 nil，使用默认配置
+	// If conf is nil, use the default configuration
+	if conf == nil {
+		return DefaultHistoryConfig()
+	}
+
+	// 如果容量无效，使用默认容量
+	// If the capacity is invalid, use the default capacity
+	if conf.capacity <= 0 {
+		conf.capacity = defaultHistoryCapacity
+	}
+
+	// 如果保留时间无效，不限制保留时间
+	// If the retention time is invalid, do not limit the retention time
+	if conf.maxAge < 0 {
+		conf.maxAge = 0
+	}
+
+	// 如果结果摘要长度无效，使用默认长度
+	// If the result summary length is invalid, use the default length
+	if conf.resultLimit <= 0 {
+		conf.resultLimit = defaultHistoryResultLimit
+	}
+
+	return conf
+}
+
+// History 结构体使用环形缓冲区保存任务的执行记录
+// The History struct keeps the execution records of tasks in a ring buffer
+type History struct {
+	// lock 用于保护环形缓冲区和审计文件
+	// lock is used to protect the ring buffer and the audit file
+	lock sync.Mutex
+
+	// cfg 是历史记录的配置
+	// cfg is the configuration of the history
+	cfg *HistoryConfig
+
+	// records 是环形缓冲区
+	// records is the ring buffer
+	records []HistoryRecord
+
+	// head 是最旧记录的位置
+	// head is the position of the oldest record
+	head int
+
+	// size 是环形缓冲区中的记录数量
+	// size is the number of records in the ring buffer
+	size int
+
+	// file 是审计文件
+	// file is the audit file
+	file *os.File
+
+	// encoder 是写入审计文件的 JSON 编码器
+	// encoder is the JSON encoder writing to the audit file
+	encoder *json.Encoder
+}
+
+// NewHistory 函数用于创建一个新的 History 实例，如果配置了审计文件，则以追加模式打开它
+// The NewHistory function is used to create a new instance of History, the audit file is opened in append mode if it is configured
+func NewHistory(conf *HistoryConfig) (*History, error) {
+	// 检查配置是否有效
+	// Check if the configuration is valid
+	conf = isHistoryConfigValid(conf)
+
+	// 创建 History 实例
+	// Create the History instance
+	h := &History{
+		cfg:     conf,
+		records: make([]HistoryRecord, conf.capacity),
+	}
+
+	// 如果配置了审计文件，打开审计文件
+	// If the audit file is configured, open the audit file
+	if conf.filePath != "" {
+		file, err := os.OpenFile(conf.filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		h.file = file
+		h.encoder = json.NewEncoder(file)
+	}
+
+	// 返回 History 实例
+	// Return the History instance
+	return h, nil
+}
+
+// record 方法将一条记录添加到环形缓冲区，并追加到审计文件
+// The record method adds a record to the ring buffer and appends it to the audit file
+func (h *History) record(rec HistoryRecord) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 如果缓冲区已满，覆盖最旧的记录
+	// If the buffer is full, overwrite the oldest record
+	if h.size == len(h.records) {
+		h.records[h.head] = rec
+		h.head = (h.head + 1) % len(h.records)
+	} else {
+		h.records[(h.head+h.size)%len(h.records)] = rec
+		h.size++
+	}
+
+	// 清理超过保留时间的记录
+	// Remove the records exceeding the retention time
+	h.expire(time.Now())
+
+	// 如果配置了审计文件，追加记录，写入失败不影响任务的执行
+	// If the audit file is configured, append the record, a write failure does not affect the task execution
+	if h.encoder != nil {
+		_ = h.encoder.Encode(&rec)
+	}
+}
+
+// expire 方法从最旧的记录开始，删除超过保留时间的记录，调用者需要持有锁
+// The expire method removes the records exceeding the retention time starting from the oldest one, the caller must hold the lock
+func (h *History) expire(now time.Time) {
+	// 如果没有限制保留时间，直接返回
+	// If the retention time is not limited, return directly
+	if h.cfg.maxAge <= 0 {
+		return
+	}
+
+	// 计算保留时间的下限
+	// Calculate the lower bound of the retention time
+	deadline := now.Add(-h.cfg.maxAge)
+
+	// 删除所有早于下限的记录
+	// Remove all records earlier than the lower bound
+	for h.size > 0 && h.records[h.head].FinishedAt.Before(deadline) {
+		h.records[h.head] = HistoryRecord{}
+		h.head = (h.head + 1) % len(h.records)
+		h.size--
+	}
+}
+
+// Query 方法按照查询条件返回匹配的记录，记录按照结束时间从旧到新排列
+// The Query method returns the records matching the query conditions, ordered from the oldest to the newest
+func (h *History) Query(query *HistoryQuery) []HistoryRecord {
+	// 如果查询条件为 nil，返回所有记录
+	// If the query is nil, return all records
+	if query == nil {
+		query = &HistoryQuery{}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 清理超过保留时间的记录
+	// Remove the records exceeding the retention time
+	h.expire(time.Now())
+
+	// 遍历环形缓冲区，收集匹配的记录
+	// Traverse the ring buffer and collect the matching records
+	records := make([]HistoryRecord, 0, h.size)
+	for i := 0; i < h.size; i++ {
+		rec := h.records[(h.head+i)%len(h.records)]
+		if query.Name != "" && rec.Name != query.Name {
+			continue
+		}
+		if !query.Since.IsZero() && rec.FinishedAt.Before(query.Since) {
+			continue
+		}
+		if !query.Until.IsZero() && !rec.FinishedAt.Before(query.Until) {
+			continue
+		}
+		records = append(records, rec)
+	}
+
+	// 如果设置了数量限制，只保留最新的记录
+	// If the limit is set, only keep the newest records
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[len(records)-query.Limit:]
+	}
+
+	return records
+}
+
+// Len 方法返回环形缓冲区中的记录数量
+// The Len method returns the number of records in the ring buffer
+func (h *History) Len() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.expire(time.Now())
+	return h.size
+}
+
+// Close 方法关闭审计文件
+// The Close method closes the audit file
+func (h *History) Close() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// 如果没有审计文件，直接返回
+	// If there is no audit file, return directly
+	if h.file == nil {
+		return nil
+	}
+
+	// 关闭审计文件，并停止写入
+	// Close the audit file and stop writing
+	err := h.file.Close()
+	h.file = nil
+	h.encoder = nil
+	return err
+}
+
+// newHistoryRecord 函数根据任务的元数据和执行结果创建一条记录
+// The newHistoryRecord function creates a record from the metadata and execution outcome of a task
+func newHistoryRecord(metadata *TaskMetadata, result any, reason, err error, resultLimit int) HistoryRecord {
+	rec := HistoryRecord{
+		ID:          metadata.GetID(),
+		Name:        metadata.GetName(),
+		Labels:      metadata.GetLabels(),
+		Priority:    metadata.GetPriority(),
+		ScheduledAt: metadata.GetExecAt(),
+		StartedAt:   metadata.GetStartedAt(),
+		FinishedAt:  metadata.GetFinishedAt(),
+		Lag:         metadata.GetLag(),
+		Throttled:   metadata.GetThrottled(),
+	}
+
+	// 如果处理函数没有执行，使用当前时间作为结束时间
+	// If the handling function was not executed, use the current time as the finish time
+	if rec.FinishedAt.IsZero() {
+		rec.FinishedAt = time.Now()
+	}
+
+	if reason != nil {
+		rec.Reason = reason.Error()
+	}
+
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	// 生成结果摘要，超过最大长度的部分会被截断，截断的位置回退到字符的边界，避免产生无效的 UTF-8
+	// Generate the result summary, the part exceeding the maximum length is truncated, the cut backs off to a character boundary to avoid invalid UTF-8
+	if result != nil {
+		rec.Result = fmt.Sprintf("%v", result)
+		if len(rec.Result) > resultLimit {
+			cut := resultLimit
+			for cut > 0 && !utf8.RuneStart(rec.Result[cut]) {
+				cut--
+			}
+			rec.Result = rec.Result[:cut]
+		}
+	}
+
+	return rec
+}
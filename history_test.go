@@ -0,0 +1,138 @@
+package kairos
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory_RingBuffer(t *testing.T) {
+	history, err := NewHistory(NewHistoryConfig().WithCapacity(3))
+	assert.Nil(t, err)
+
+	// Record more entries than the capacity, the oldest ones should be overwritten
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		history.record(HistoryRecord{ID: name, Name: name, FinishedAt: time.Now()})
+	}
+
+	records := history.Query(nil)
+	assert.Equal(t, 3, history.Len())
+	assert.Equal(t, []string{"c", "d", "e"}, []string{records[0].ID, records[1].ID, records[2].ID})
+}
+
+func TestHistory_MaxAge(t *testing.T) {
+	history, err := NewHistory(NewHistoryConfig().WithMaxAge(time.Hour))
+	assert.Nil(t, err)
+
+	// Record an expired entry and a fresh one
+	history.record(HistoryRecord{ID: "old", FinishedAt: time.Now().Add(-2 * time.Hour)})
+	history.record(HistoryRecord{ID: "new", FinishedAt: time.Now()})
+
+	records := history.Query(nil)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, "new", records[0].ID)
+}
+
+func TestHistory_ResultLimit(t *testing.T) {
+	metadata := &TaskMetadata{id: "a", name: "a"}
+
+	// Long results are truncated to the limit
+	rec := newHistoryRecord(metadata, "abcdef", nil, nil, 4)
+	assert.Equal(t, "abcd", rec.Result)
+
+	// The cut never splits a multi-byte character
+	rec = newHistoryRecord(metadata, "ab世界", nil, nil, 4)
+	assert.Equal(t, "ab", rec.Result)
+	assert.True(t, utf8.ValidString(rec.Result))
+	rec = newHistoryRecord(metadata, "ab世界", nil, nil, 5)
+	assert.Equal(t, "ab世", rec.Result)
+}
+
+func TestHistory_Query(t *testing.T) {
+	history, err := NewHistory(nil)
+	assert.Nil(t, err)
+
+	now := time.Now()
+	history.record(HistoryRecord{ID: "1", Name: "foo", FinishedAt: now.Add(-3 * time.Minute)})
+	history.record(HistoryRecord{ID: "2", Name: "bar", FinishedAt: now.Add(-2 * time.Minute)})
+	history.record(HistoryRecord{ID: "3", Name: "foo", FinishedAt: now.Add(-1 * time.Minute)})
+	history.record(HistoryRecord{ID: "4", Name: "foo", FinishedAt: now})
+
+	// Query by name
+	assert.Equal(t, 3, len(history.Query(&HistoryQuery{Name: "foo"})))
+
+	// Query by time range
+	records := history.Query(&HistoryQuery{Since: now.Add(-2 * time.Minute), Until: now})
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "2", records[0].ID)
+	assert.Equal(t, "3", records[1].ID)
+
+	// Query with limit returns the newest records
+	records = history.Query(&HistoryQuery{Name: "foo", Limit: 2})
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "3", records[0].ID)
+	assert.Equal(t, "4", records[1].ID)
+}
+
+func TestHistory_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	history, err := NewHistory(NewHistoryConfig().WithFile(path))
+	assert.Nil(t, err)
+
+	history.record(newHistoryRecord(&TaskMetadata{id: "1", name: "foo"}, "ok", ErrorTaskTimeout, nil, 16))
+	history.record(newHistoryRecord(&TaskMetadata{id: "2", name: "bar"}, nil, ErrorTaskCanceled, errors.New("boom"), 16))
+	assert.Nil(t, history.Close())
+
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+
+	// Each line of the audit file is one JSON record
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec HistoryRecord
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, "ok", records[0].Result)
+	assert.Equal(t, ErrorTaskTimeout.Error(), records[0].Reason)
+	assert.Equal(t, "boom", records[1].Error)
+}
+
+func TestScheduler_History(t *testing.T) {
+	history, err := NewHistory(nil)
+	assert.Nil(t, err)
+
+	// Create a new scheduler which records the history
+	scheduler := New(NewConfig().WithHistory(history))
+	defer scheduler.Stop()
+
+	execAt := time.Now().Add(time.Millisecond * 100)
+	taskID, err := scheduler.SetAt("test", func(_ WaitForContextDone) (result any, err error) {
+		return "done", nil
+	}, execAt)
+	assert.Nil(t, err)
+
+	// Sleep for a while to let the task execute
+	time.Sleep(time.Millisecond * 300)
+
+	records := scheduler.History().Query(&HistoryQuery{Name: "test"})
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, taskID, records[0].ID)
+	assert.Equal(t, "done", records[0].Result)
+	assert.Equal(t, ErrorTaskTimeout.Error(), records[0].Reason)
+	assert.True(t, records[0].ScheduledAt.Equal(execAt))
+	assert.False(t, records[0].StartedAt.Before(records[0].ScheduledAt))
+	assert.False(t, records[0].FinishedAt.Before(records[0].StartedAt))
+}
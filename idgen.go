@@ -0,0 +1,119 @@
+package kairos
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrorTaskIDConflict 表示调度器中已经存在使用 WithID 指定的 ID 的任务，或者 ID 生成函数多次生成了已经存在的 ID
+// ErrorTaskIDConflict indicates a task with the ID specified by WithID already exists in the scheduler, or the ID generator generated existing IDs repeatedly
+var ErrorTaskIDConflict = errors.New("task id conflict")
+
+// maxIDAttempts 是生成的 ID 和已经存在的任务冲突时最多尝试生成 ID 的次数
+// maxIDAttempts is the maximum number of attempts to generate an ID when the generated IDs conflict with existing tasks
+const maxIDAttempts = 16
+
+// IDGenerator 是一个函数类型，用于生成任务的 ID，它会被并发调用，生成的 ID 应该是唯一的，和已经存在的任务冲突的 ID 会被重新生成
+// IDGenerator is a function type used to generate the IDs of tasks, it is called concurrently and the generated IDs should be unique, an ID conflicting with an existing task is generated again
+type IDGenerator = func() string
+
+// NewUUIDGenerator 函数返回一个生成随机 UUID 的 IDGenerator，它是调度器默认的 ID 生成函数
+// The NewUUIDGenerator function returns an IDGenerator generating random UUIDs, it is the default ID generator of the scheduler
+func NewUUIDGenerator() IDGenerator {
+	return uuid.NewString
+}
+
+// NewCounterIDGenerator 函数返回一个使用原子计数器的 IDGenerator，生成的 ID 是前缀加上从 1 开始递增的十进制数，例如 "task-1"。
+// 它是开销最小的 ID 生成函数，但是计数器在进程重启之后从头开始，使用 Store 恢复任务时需要使用不同的前缀避免和恢复的任务冲突
+// The NewCounterIDGenerator function returns an IDGenerator using an atomic counter, the generated IDs are the prefix followed by an increasing decimal number starting from 1, for example "task-1".
+// It is the cheapest ID generator, but the counter starts over after the process restarts, a different prefix is needed to avoid conflicts with restored tasks when the Store is used
+func NewCounterIDGenerator(prefix string) IDGenerator {
+	var counter atomic.Uint64
+	return func() string {
+		return prefix + strconv.FormatUint(counter.Add(1), 10)
+	}
+}
+
+// crockford 是 ULID 使用的 Crockford Base32 字母表，字符按照 ASCII 顺序排列，编码后的 ID 可以按照字符串排序
+// crockford is the Crockford Base32 alphabet used by ULIDs, the characters are in ASCII order so the encoded IDs sort as strings
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator 结构体生成单调递增的 ULID，同一毫秒内生成的 ID 在上一个 ID 的随机部分上加 1
+// The ulidGenerator struct generates monotonic ULIDs, IDs generated within the same millisecond add 1 to the random part of the previous ID
+type ulidGenerator struct {
+	// lock 用于保护上一个 ID 的状态
+	// lock is used to protect the state of the previous ID
+	lock sync.Mutex
+
+	// ms 是上一个 ID 的毫秒时间戳
+	// ms is the millisecond timestamp of the previous ID
+	ms uint64
+
+	// hi 和 lo 是上一个 ID 的 80 位随机部分的高 16 位和低 64 位
+	// hi and lo are the high 16 bits and the low 64 bits of the 80-bit random part of the previous ID
+	hi uint16
+	lo uint64
+}
+
+// NewULIDGenerator 函数返回一个生成 ULID 的 IDGenerator。ULID 由 48 位毫秒时间戳和 80 位随机数组成，编码为 26 个字符，
+// 按照字符串排序就是按照创建时间排序；同一个生成函数在同一毫秒内生成的 ID 也严格递增
+// The NewULIDGenerator function returns an IDGenerator generating ULIDs. A ULID consists of a 48-bit millisecond timestamp and 80 random bits encoded as 26 characters,
+// sorting them as strings sorts them by creation time; IDs generated by the same generator within the same millisecond are strictly increasing too
+func NewULIDGenerator() IDGenerator {
+	g := &ulidGenerator{}
+	return g.next
+}
+
+// next 方法生成下一个 ULID
+// The next method generates the next ULID
+func (g *ulidGenerator) next() string {
+	ms := uint64(time.Now().UnixMilli())
+
+	g.lock.Lock()
+	// 时钟回拨或者在同一毫秒内时，在上一个 ID 的随机部分上加 1，随机部分溢出时使用下一毫秒
+	// When the clock goes backwards or within the same millisecond, add 1 to the random part of the previous ID, the next millisecond is used if the random part overflows
+	if ms <= g.ms {
+		ms = g.ms
+		if g.lo++; g.lo == 0 {
+			if g.hi++; g.hi == 0 {
+				ms++
+				g.entropy()
+			}
+		}
+	} else {
+		g.entropy()
+	}
+	g.ms = ms
+	hi, lo := g.hi, g.lo
+	g.lock.Unlock()
+
+	// 编码 48 位时间戳为 10 个字符，80 位随机数为 16 个字符
+	// Encode the 48-bit timestamp into 10 characters and the 80 random bits into 16 characters
+	var id [26]byte
+	for i := 9; i >= 0; i-- {
+		id[i] = crockford[ms&31]
+		ms >>= 5
+	}
+	for i := 25; i >= 10; i-- {
+		id[i] = crockford[lo&31]
+		lo = lo>>5 | uint64(hi&31)<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
+
+// entropy 方法为新的毫秒生成随机部分，调用者需要持有锁
+// The entropy method generates the random part for a new millisecond, the caller must hold the lock
+func (g *ulidGenerator) entropy() {
+	var b [10]byte
+	_, _ = rand.Read(b[:])
+	g.hi = binary.BigEndian.Uint16(b[:2])
+	g.lo = binary.BigEndian.Uint64(b[2:])
+}
@@ -0,0 +1,158 @@
+package kairos
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterIDGenerator(t *testing.T) {
+	generator := NewCounterIDGenerator("task-")
+	assert.Equal(t, "task-1", generator())
+	assert.Equal(t, "task-2", generator())
+
+	// Every generator has its own counter
+	assert.Equal(t, "1", NewCounterIDGenerator("")())
+}
+
+func TestULIDGenerator(t *testing.T) {
+	generator := NewULIDGenerator()
+
+	// IDs generated concurrently are unique
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				id := generator()
+				lock.Lock()
+				seen[id] = struct{}{}
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 8000, len(seen))
+
+	// IDs sort by creation time, also within the same millisecond
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = generator()
+		assert.Equal(t, 26, len(ids[i]))
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+
+	// The timestamp is encoded in the first 10 characters
+	before := NewULIDGenerator()()
+	time.Sleep(2 * time.Millisecond)
+	after := NewULIDGenerator()()
+	assert.Less(t, before[:10], after[:10])
+}
+
+func TestULIDGenerator_Overflow(t *testing.T) {
+	g := &ulidGenerator{ms: uint64(time.Now().Add(time.Hour).UnixMilli()), hi: 0xffff, lo: ^uint64(0)}
+	last := g.ms
+
+	// The random part overflows, the next millisecond is used
+	id := g.next()
+	assert.Equal(t, last+1, g.ms)
+	assert.Less(t, NewULIDGenerator()(), id)
+}
+
+func TestScheduler_IDGenerator(t *testing.T) {
+	scheduler := New(NewConfig().WithIDGenerator(NewCounterIDGenerator("job-")))
+	defer scheduler.Stop()
+
+	id, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "job-1", id)
+	ids, _ := scheduler.SetBatch([]TaskSpec{{Name: "b", Delay: time.Hour}})
+	assert.Equal(t, []string{"job-2"}, ids)
+
+	// A nil generator falls back to UUIDs
+	assert.NotNil(t, isConfigValid(NewConfig().WithIDGenerator(nil)).idGenerator)
+}
+
+func TestScheduler_IDGeneratorConflict(t *testing.T) {
+	scheduler := New(NewConfig().WithIDGenerator(NewCounterIDGenerator("task-")))
+	defer scheduler.Stop()
+
+	_, err := scheduler.SetWithOptions("specified", nil, time.Hour, NewTaskOptions().WithID("task-2"))
+	assert.Nil(t, err)
+
+	// A generated ID used by another task is generated again, the other task is kept
+	id, err := scheduler.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "task-1", id)
+	id, err = scheduler.Set("b", nil, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "task-3", id)
+	info, err := scheduler.GetInfo("task-2")
+	assert.Nil(t, err)
+	assert.Equal(t, "specified", info.Name)
+	assert.Equal(t, 3, scheduler.Count())
+
+	// A generator which keeps generating the same ID gives up
+	same := New(NewConfig().WithIDGenerator(func() string { return "same" }))
+	defer same.Stop()
+	_, err = same.Set("a", nil, time.Hour)
+	assert.Nil(t, err)
+	_, err = same.Set("b", nil, time.Hour)
+	assert.True(t, errors.Is(err, ErrorTaskIDConflict))
+	assert.Equal(t, 1, same.Count())
+}
+
+func TestScheduler_WithID(t *testing.T) {
+	callback := &testLabeledCallback{}
+	scheduler := New(NewConfig().WithCallback(callback).WithMaxPendingTasks(3))
+	defer scheduler.Stop()
+
+	id, err := scheduler.SetWithOptions("a", nil, time.Hour, NewTaskOptions().WithID("order-42").WithUniqued(true))
+	assert.Nil(t, err)
+	assert.Equal(t, "order-42", id)
+	info, err := scheduler.GetInfo("order-42")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", info.Name)
+
+	// A conflicting ID is rejected and the existing task is kept
+	_, err = scheduler.SetWithOptions("b", nil, time.Hour, NewTaskOptions().WithID("order-42").WithUniqued(true))
+	assert.True(t, errors.Is(err, ErrorTaskIDConflict))
+	info, err = scheduler.GetInfo("order-42")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", info.Name)
+	assert.Equal(t, 1, scheduler.Count())
+	assert.Equal(t, 1, scheduler.uniqCache.Count())
+
+	// The capacity taken by the rejected task is released
+	cID, err := scheduler.Set("c", nil, time.Hour)
+	assert.Nil(t, err)
+	_, err = scheduler.Set("d", nil, time.Hour)
+	assert.Nil(t, err)
+
+	// Conflicts inside a batch are reported per item
+	scheduler.DeleteBatch([]string{"order-42", cID})
+	ids, errs := scheduler.SetBatch([]TaskSpec{
+		{Name: "e", Delay: time.Hour, Options: NewTaskOptions().WithID("order-43")},
+		{Name: "f", Delay: time.Hour, Options: NewTaskOptions().WithID("order-43")},
+	})
+	assert.Equal(t, "order-43", ids[0])
+	assert.Nil(t, errs[0])
+	assert.True(t, errors.Is(errs[1], ErrorTaskIDConflict))
+	assert.Equal(t, 2, scheduler.Count())
+
+	// The ID becomes free again once the task is removed
+	scheduler.Delete("order-43")
+	_, err = scheduler.SetWithOptions("g", nil, time.Hour, NewTaskOptions().WithID("order-43"))
+	assert.Nil(t, err)
+
+	callback.lock.Lock()
+	assert.Equal(t, 5, len(callback.added))
+	callback.lock.Unlock()
+}
@@ -0,0 +1,174 @@
+package kairos
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// TaskState 是任务的状态
+// TaskState is the state of a task
+type TaskState string
+
+// 定义任务的状态
+// Define the states of a task
+const (
+	// TaskStatePending 表示任务正在等待执行
+	// TaskStatePending indicates the task is waiting to be executed
+	TaskStatePending TaskState = "pending"
+
+	// TaskStateRunning 表示任务的处理函数正在执行
+	// TaskStateRunning indicates the handling function of the task is running
+	TaskStateRunning TaskState = "running"
+
+	// TaskStatePaused 表示任务被暂停
+	// TaskStatePaused indicates the task is paused
+	TaskStatePaused TaskState = "paused"
+)
+
+// TaskInfo 结构体是任务在某一时刻的快照，可以安全地在调度器之外使用
+// The TaskInfo struct is a snapshot of a task at a point in time, it can be safely used outside the scheduler
+type TaskInfo struct {
+	// ID 是任务的唯一标识符
+	// ID is the unique identifier of the task
+	ID string `json:"id"`
+
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Handler 是处理函数在注册表中的名称，直接传入处理函数时为空
+	// Handler is the name of the handling function in the registry, empty when the handling function is passed directly
+	Handler string `json:"handler,omitempty"`
+
+	// ExecAt 是任务计划执行的时间
+	// ExecAt is the scheduled execution time of the task
+	ExecAt time.Time `json:"exec_at"`
+
+	// State 是任务的状态
+	// State is the state of the task
+	State TaskState `json:"state"`
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels Labels `json:"labels,omitempty"`
+
+	// Priority 是任务的优先级
+	// Priority is the priority of the task
+	Priority int `json:"priority,omitempty"`
+
+	// Group 是任务所属的组的名称，不属于任何组时为空
+	// Group is the name of the group the task belongs to, empty if it does not belong to any group
+	Group string `json:"group,omitempty"`
+
+	// Tenant 是任务所属的租户，不属于任何租户时为空
+	// Tenant is the tenant the task belongs to, empty if it does not belong to any tenant
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Stats 结构体包含调度器的统计信息
+// The Stats struct contains the statistics of the scheduler
+type Stats struct {
+	// Pending 是正在等待执行的任务数量
+	// Pending is the number of tasks waiting to be executed
+	Pending int `json:"pending"`
+
+	// Running 是处理函数正在执行的任务数量
+	// Running is the number of tasks whose handling function is running
+	Running int `json:"running"`
+
+	// Paused 是被暂停的任务数量
+	// Paused is the number of paused tasks
+	Paused int `json:"paused"`
+
+	// Waiting 是已经到期、正在排队等待执行槽位的处理函数数量，只在限制了并发数量时不为 0
+	// Waiting is the number of expired handling functions queued for an execution slot, it is only non-zero when the concurrency is limited
+	Waiting int `json:"waiting"`
+
+	// Added 是添加的任务总数
+	// Added is the total number of added tasks
+	Added uint64 `json:"added"`
+
+	// Executed 是处理函数被执行的任务总数
+	// Executed is the total number of tasks whose handling function was executed
+	Executed uint64 `json:"executed"`
+
+	// Canceled 是被取消的任务总数
+	// Canceled is the total number of canceled tasks
+	Canceled uint64 `json:"canceled"`
+
+	// Removed 是从调度器中移除的任务总数
+	// Removed is the total number of tasks removed from the scheduler
+	Removed uint64 `json:"removed"`
+
+	// Duplicated 是因为重复而被拒绝的任务总数
+	// Duplicated is the total number of tasks rejected as duplicates
+	Duplicated uint64 `json:"duplicated"`
+
+	// Skipped 是到期了但是处理函数被跳过的任务总数，例如这次触发由其他节点执行
+	// Skipped is the total number of expired tasks whose handling function was skipped, for example the firing is executed by another node
+	Skipped uint64 `json:"skipped"`
+
+	// Rejected 是因为容量限制被拒绝的任务总数
+	// Rejected is the total number of tasks rejected by a capacity limit
+	Rejected uint64 `json:"rejected"`
+
+	// Throttled 是到期之后因为速率限制被推迟的任务总数
+	// Throttled is the total number of expired tasks delayed by the rate limit
+	Throttled uint64 `json:"throttled"`
+
+	// ThrottledTime 是任务因为速率限制被推迟的总时间
+	// ThrottledTime is the total time tasks were delayed by the rate limit
+	ThrottledTime time.Duration `json:"throttled_time"`
+}
+
+// counters 结构体包含调度器的累计计数器
+// The counters struct contains the cumulative counters of the scheduler
+type counters struct {
+	added      atomic.Uint64
+	executed   atomic.Uint64
+	canceled   atomic.Uint64
+	removed    atomic.Uint64
+	duplicated atomic.Uint64
+	skipped    atomic.Uint64
+	rejected   atomic.Uint64
+
+	throttled     atomic.Uint64
+	throttledTime atomic.Int64
+}
+
+// newTaskInfo 函数根据任务引用创建任务的快照，调用者需要持有任务引用的锁
+// The newTaskInfo function creates the snapshot of a task from the task reference, the caller must hold the lock of the task reference
+func newTaskInfo(taskRef *TaskRef) *TaskInfo {
+	info := &TaskInfo{
+		ID:       taskRef.id,
+		Name:     taskRef.name,
+		Handler:  taskRef.handler,
+		ExecAt:   taskRef.execAt,
+		State:    TaskStatePending,
+		Labels:   taskRef.labels.clone(),
+		Priority: taskRef.priority,
+	}
+
+	// 设置任务所属的组的名称
+	// Set the name of the group the task belongs to
+	if taskRef.group != nil {
+		info.Group = taskRef.group.name
+	}
+
+	// 设置任务所属的租户
+	// Set the tenant the task belongs to
+	if taskRef.tenant != nil {
+		info.Tenant = taskRef.tenant.name
+	}
+
+	// 根据任务引用的状态设置任务的状态
+	// Set the state of the task according to the state of the task reference
+	switch {
+	case taskRef.paused:
+		info.State = TaskStatePaused
+	case taskRef.task != nil && taskRef.task.IsRunning():
+		info.State = TaskStateRunning
+	}
+
+	return info
+}
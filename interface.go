@@ -1,6 +1,9 @@
 package kairos
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // WaitForContextDone 是一个只能接收的通道，用于等待上下文完成
 // WaitForContextDone is a receive-only channel used to wait for context completion
@@ -10,6 +13,12 @@ type WaitForContextDone = <-chan struct{}
 // TaskHandleFunc is a function type that takes a WaitForContextDone parameter and returns an interface type data and an error
 type TaskHandleFunc = func(done WaitForContextDone) (data interface{}, err error)
 
+// ContextHandleFunc 是一个函数类型，它接收一个上下文参数，并返回一个接口类型的数据和一个错误。
+// 上下文携带调用者上下文中的值，在调用者上下文结束或者调度器停止时被取消
+// ContextHandleFunc is a function type that takes a context parameter and returns an interface type data and an error.
+// The context carries the values of the caller context, it is canceled when the caller context ends or the scheduler stops
+type ContextHandleFunc = func(ctx context.Context) (data interface{}, err error)
+
 // Callback 是一个接口，定义了任务添加、执行和移除时的回调函数
 // Callback is an interface that defines the callback functions when a task is added, executed, and removed
 type Callback interface {
@@ -53,3 +62,27 @@ func (EmptyCallback) OnTaskDuplicated(id, name string) {}
 // NewEmptyTaskCallback 是一个函数，它返回一个新的 EmptyCallback 实例
 // NewEmptyTaskCallback is a function that returns a new instance of EmptyCallback
 func NewEmptyTaskCallback() *EmptyCallback { return &EmptyCallback{} }
+
+// LabeledCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，调度器会在调用对应的 Callback 方法之后再调用它，并传入任务的标签
+// LabeledCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it after the corresponding Callback method with the labels of the task
+type LabeledCallback interface {
+	// OnLabeledTaskAdded 是当任务被添加时的回调函数，它接收任务 id、任务名称、标签和执行时间作为参数
+	// OnLabeledTaskAdded is the callback function when a task is added, it takes the task id, task name, labels and execution time as parameters
+	OnLabeledTaskAdded(id, name string, labels Labels, execAt time.Time)
+
+	// OnLabeledTaskExecuted 是当任务被执行时的回调函数，它接收任务 id、任务名称、标签、任务结果、原因和错误作为参数
+	// OnLabeledTaskExecuted is the callback function when a task is executed, it takes the task id, task name, labels, task result, reason, and error as parameters
+	OnLabeledTaskExecuted(id, name string, labels Labels, result interface{}, reason, err error)
+
+	// OnLabeledTaskRemoved 是当任务被移除时的回调函数，它接收任务 id、任务名称和标签作为参数
+	// OnLabeledTaskRemoved is the callback function when a task is removed, it takes the task id, task name and labels as parameters
+	OnLabeledTaskRemoved(id, name string, labels Labels)
+}
+
+// DispatchCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，并且调度器限制了并发数量，调度器会在处理函数获得执行槽位、即将执行时调用它
+// DispatchCallback is an optional interface, if the configured Callback also implements it and the scheduler limits the concurrency, the scheduler calls it when a handling function gets an execution slot and is about to run
+type DispatchCallback interface {
+	// OnTaskDispatched 是当处理函数即将执行时的回调函数，它接收任务 id、任务名称、优先级和等待执行槽位的时间作为参数
+	// OnTaskDispatched is the callback function when the handling function is about to run, it takes the task id, task name, priority and the time spent waiting for an execution slot as parameters
+	OnTaskDispatched(id, name string, priority int, waited time.Duration)
+}
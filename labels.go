@@ -0,0 +1,312 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 定义标签选择器的错误
+// Define the errors of the label selector
+var (
+	// ErrorInvalidSelector 表示标签选择器的语法无效
+	// ErrorInvalidSelector indicates the syntax of the label selector is invalid
+	ErrorInvalidSelector = errors.New("invalid selector")
+)
+
+// Labels 是任务的键值对标签，任务添加后不会再被修改
+// Labels are the key/value labels of a task, they are not modified after the task is added
+type Labels map[string]string
+
+// Has 方法返回是否存在指定的键
+// The Has method returns whether the specified key exists
+func (l Labels) Has(key string) bool {
+	_, ok := l[key]
+	return ok
+}
+
+// Get 方法返回指定键的值，键不存在时返回空字符串
+// The Get method returns the value of the specified key, an empty string is returned if the key does not exist
+func (l Labels) Get(key string) string {
+	return l[key]
+}
+
+// String 方法以 "key=value" 的格式返回标签，按键排序并使用逗号分隔
+// The String method returns the labels in the "key=value" format, sorted by key and separated by commas
+func (l Labels) String() string {
+	keys := make([]string, 0, len(l))
+	for key := range l {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+l[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// clone 方法返回标签的副本，空标签返回 nil
+// The clone method returns a copy of the labels, nil is returned for empty labels
+func (l Labels) clone() Labels {
+	if len(l) == 0 {
+		return nil
+	}
+	copied := make(Labels, len(l))
+	for key, value := range l {
+		copied[key] = value
+	}
+	return copied
+}
+
+// operator 是标签选择器中条件的操作符
+// operator is the operator of a requirement in the label selector
+type operator string
+
+// 定义标签选择器支持的操作符
+// Define the operators supported by the label selector
+const (
+	opEquals       operator = "="
+	opNotEquals    operator = "!="
+	opIn           operator = "in"
+	opNotIn        operator = "notin"
+	opExists       operator = "exists"
+	opDoesNotExist operator = "!"
+)
+
+// requirement 结构体是标签选择器中的一个条件
+// The requirement struct is one requirement in the label selector
+type requirement struct {
+	// key 是标签的键
+	// key is the key of the label
+	key string
+
+	// op 是条件的操作符
+	// op is the operator of the requirement
+	op operator
+
+	// values 是条件的值，只有 =、!=、in 和 notin 使用
+	// values are the values of the requirement, only used by =, !=, in and notin
+	values []string
+}
+
+// matches 方法返回标签是否满足条件，!= 和 notin 在键不存在时也满足
+// The matches method returns whether the labels satisfy the requirement, != and notin are also satisfied when the key does not exist
+func (r *requirement) matches(labels Labels) bool {
+	switch r.op {
+	case opExists:
+		return labels.Has(r.key)
+	case opDoesNotExist:
+		return !labels.Has(r.key)
+	case opEquals, opIn:
+		return labels.Has(r.key) && r.contains(labels.Get(r.key))
+	case opNotEquals, opNotIn:
+		return !labels.Has(r.key) || !r.contains(labels.Get(r.key))
+	}
+	return false
+}
+
+// contains 方法返回条件的值中是否包含指定的值
+// The contains method returns whether the values of the requirement contain the specified value
+func (r *requirement) contains(value string) bool {
+	for _, v := range r.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// String 方法返回条件的字符串形式
+// The String method returns the string form of the requirement
+func (r *requirement) String() string {
+	switch r.op {
+	case opExists:
+		return r.key
+	case opDoesNotExist:
+		return "!" + r.key
+	case opIn, opNotIn:
+		return r.key + " " + string(r.op) + " (" + strings.Join(r.values, ",") + ")"
+	}
+	return r.key + string(r.op) + r.values[0]
+}
+
+// Selector 结构体是 Kubernetes 风格的标签选择器，所有的条件都满足时才匹配
+// The Selector struct is a Kubernetes-style label selector, it matches only when all requirements are satisfied
+//
+//	env=prod, env==prod      键等于值 / the key equals the value
+//	env!=prod                键不等于值或者不存在 / the key does not equal the value or does not exist
+//	env in (prod,staging)    键的值在集合中 / the value of the key is in the set
+//	env notin (dev)          键的值不在集合中或者键不存在 / the value of the key is not in the set or the key does not exist
+//	env                      键存在 / the key exists
+//	!env                     键不存在 / the key does not exist
+type Selector struct {
+	// requirements 是选择器的条件
+	// requirements are the requirements of the selector
+	requirements []*requirement
+}
+
+// ParseSelector 函数解析逗号分隔的条件，空字符串表示匹配所有任务的选择器
+// The ParseSelector function parses comma-separated requirements, an empty string means a selector matching all tasks
+func ParseSelector(selector string) (*Selector, error) {
+	s := &Selector{}
+
+	for _, part := range splitRequirements(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if strings.TrimSpace(selector) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: empty requirement in %q", ErrorInvalidSelector, selector)
+		}
+
+		req, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		s.requirements = append(s.requirements, req)
+	}
+
+	return s, nil
+}
+
+// SelectorFromLabels 函数创建一个选择器，它匹配包含所有给定键值对的任务
+// The SelectorFromLabels function creates a selector matching the tasks that contain all given key/value pairs
+func SelectorFromLabels(labels Labels) *Selector {
+	s := &Selector{}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s.requirements = append(s.requirements, &requirement{key: key, op: opEquals, values: []string{labels[key]}})
+	}
+
+	return s
+}
+
+// Matches 方法返回标签是否满足选择器的所有条件，nil 选择器匹配所有标签
+// The Matches method returns whether the labels satisfy all requirements of the selector, a nil selector matches all labels
+func (s *Selector) Matches(labels Labels) bool {
+	if s == nil {
+		return true
+	}
+	for _, req := range s.requirements {
+		if !req.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String 方法返回选择器的字符串形式，它可以被 ParseSelector 重新解析
+// The String method returns the string form of the selector, it can be parsed again by ParseSelector
+func (s *Selector) String() string {
+	if s == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(s.requirements))
+	for _, req := range s.requirements {
+		parts = append(parts, req.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// splitRequirements 函数按逗号拆分选择器，括号中的逗号不会被拆分
+// The splitRequirements function splits the selector by commas, commas in parentheses are not split
+func splitRequirements(selector string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// parseRequirement 函数解析一个条件
+// The parseRequirement function parses one requirement
+func parseRequirement(part string) (*requirement, error) {
+	invalid := func() (*requirement, error) {
+		return nil, fmt.Errorf("%w: %q", ErrorInvalidSelector, part)
+	}
+
+	// 键不存在
+	// The key does not exist
+	if strings.HasPrefix(part, "!") && !strings.Contains(part, "=") {
+		key := strings.TrimSpace(part[1:])
+		if !isValidLabelKey(key) {
+			return invalid()
+		}
+		return &requirement{key: key, op: opDoesNotExist}, nil
+	}
+
+	// 不等于、等于
+	// Not equals, equals
+	for _, sep := range []struct {
+		token string
+		op    operator
+	}{{"!=", opNotEquals}, {"==", opEquals}, {"=", opEquals}} {
+		if key, value, ok := strings.Cut(part, sep.token); ok {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if !isValidLabelKey(key) || strings.ContainsAny(value, "=!(), ") {
+				return invalid()
+			}
+			return &requirement{key: key, op: sep.op, values: []string{value}}, nil
+		}
+	}
+
+	// 集合条件
+	// Set based requirements
+	if open := strings.Index(part, "("); open >= 0 {
+		if !strings.HasSuffix(part, ")") {
+			return invalid()
+		}
+		fields := strings.Fields(part[:open])
+		if len(fields) != 2 || !isValidLabelKey(fields[0]) {
+			return invalid()
+		}
+
+		op := operator(fields[1])
+		if op != opIn && op != opNotIn {
+			return invalid()
+		}
+
+		var values []string
+		for _, value := range strings.Split(part[open+1:len(part)-1], ",") {
+			value = strings.TrimSpace(value)
+			if value == "" || strings.ContainsAny(value, "=!() ") {
+				return invalid()
+			}
+			values = append(values, value)
+		}
+		return &requirement{key: fields[0], op: op, values: values}, nil
+	}
+
+	// 键存在
+	// The key exists
+	if !isValidLabelKey(part) {
+		return invalid()
+	}
+	return &requirement{key: part, op: opExists}, nil
+}
+
+// isValidLabelKey 函数返回键是否有效，键不能为空，也不能包含空白和选择器中的特殊字符
+// The isValidLabelKey function returns whether the key is valid, the key can not be empty or contain whitespace and the special characters of the selector
+func isValidLabelKey(key string) bool {
+	return key != "" && !strings.ContainsAny(key, "=!(), \t\n")
+}
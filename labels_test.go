@@ -0,0 +1,70 @@
+package kairos
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabels_String(t *testing.T) {
+	assert.Equal(t, "", Labels(nil).String())
+	assert.Equal(t, "app=web,env=prod", Labels{"env": "prod", "app": "web"}.String())
+}
+
+func TestParseSelector(t *testing.T) {
+	labels := Labels{"env": "prod", "tier": "web", "owner": ""}
+
+	tests := []struct {
+		selector string
+		matched  bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==prod", true},
+		{"env=dev", false},
+		{"env!=dev", true},
+		{"missing!=dev", true},
+		{"env in (prod,staging)", true},
+		{"env in (dev, staging)", false},
+		{"env notin (dev)", true},
+		{"missing notin (dev)", true},
+		{"env notin (prod)", false},
+		{"tier", true},
+		{"missing", false},
+		{"!missing", true},
+		{"!tier", false},
+		{"owner=", true},
+		{"env=prod, tier in (web,api), !missing", true},
+		{"env=prod,tier=api", false},
+	}
+
+	for _, tt := range tests {
+		selector, err := ParseSelector(tt.selector)
+		assert.Nil(t, err, tt.selector)
+		assert.Equal(t, tt.matched, selector.Matches(labels), tt.selector)
+
+		// The string form can be parsed again
+		again, err := ParseSelector(selector.String())
+		assert.Nil(t, err, tt.selector)
+		assert.Equal(t, tt.matched, again.Matches(labels), tt.selector)
+	}
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+	for _, selector := range []string{"env=prod,,tier", "=prod", "env in prod", "env in (prod", "env in ()", "env between (a,b)", "a b", "!"} {
+		_, err := ParseSelector(selector)
+		assert.True(t, errors.Is(err, ErrorInvalidSelector), selector)
+	}
+}
+
+func TestSelectorFromLabels(t *testing.T) {
+	selector := SelectorFromLabels(Labels{"env": "prod", "app": "web"})
+	assert.Equal(t, "app=web,env=prod", selector.String())
+	assert.True(t, selector.Matches(Labels{"env": "prod", "app": "web", "tier": "api"}))
+	assert.False(t, selector.Matches(Labels{"env": "prod"}))
+
+	// A nil selector matches everything
+	var nilSelector *Selector
+	assert.True(t, nilSelector.Matches(nil))
+}
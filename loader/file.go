@@ -0,0 +1,284 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrorInvalidFile 表示任务定义文件无效
+// ErrorInvalidFile indicates the task definition file is invalid
+var ErrorInvalidFile = errors.New("invalid task file")
+
+// Format 是任务定义文件的格式
+// Format is the format of the task definition file
+type Format string
+
+// 定义任务定义文件的格式
+// Define the formats of the task definition file
+const (
+	// FormatYAML 表示 YAML 格式
+	// FormatYAML means the YAML format
+	FormatYAML Format = "yaml"
+
+	// FormatJSON 表示 JSON 格式
+	// FormatJSON means the JSON format
+	FormatJSON Format = "json"
+)
+
+// File 结构体是任务定义文件的内容
+// The File struct is the content of the task definition file
+type File struct {
+	// Tasks 是文件中定义的任务，任务的名称在文件中必须唯一
+	// Tasks are the tasks defined in the file, the names of the tasks must be unique in the file
+	Tasks []*TaskSpec `json:"tasks" yaml:"tasks"`
+}
+
+// TaskSpec 结构体是文件中一个任务的定义
+// The TaskSpec struct is the definition of a task in the file
+type TaskSpec struct {
+	// Name 是任务的名称，也是重新加载时识别任务的键
+	// Name is the name of the task, it is also the key identifying the task when reloading
+	Name string `json:"name" yaml:"name"`
+
+	// Handler 是处理函数的名称，先在 Loader 的处理函数中查找，再在调度器的注册表中查找
+	// Handler is the name of the handling function, it is looked up in the handling functions of the Loader first, then in the registry of the scheduler
+	Handler string `json:"handler" yaml:"handler"`
+
+	// Schedule 是任务的执行时间
+	// Schedule is the execution time of the task
+	Schedule ScheduleSpec `json:"schedule" yaml:"schedule"`
+
+	// Payload 是传递给处理函数的数据，必须可以编码为 JSON
+	// Payload is the data passed to the handling function, it must be encodable as JSON
+	Payload any `json:"payload,omitempty" yaml:"payload,omitempty"`
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	// Unique 表示任务的名称在调度器中唯一
+	// Unique indicates the name of the task is unique in the scheduler
+	Unique bool `json:"unique,omitempty" yaml:"unique,omitempty"`
+
+	// Retry 是处理函数返回错误时的重试策略
+	// Retry is the retry policy when the handling function returns an error
+	Retry *RetrySpec `json:"retry,omitempty" yaml:"retry,omitempty"`
+}
+
+// ScheduleSpec 结构体是任务的执行时间，必须并且只能设置其中一个字段
+// The ScheduleSpec struct is the execution time of the task, exactly one of its fields must be set
+type ScheduleSpec struct {
+	// Delay 是一次性任务在加载之后的延迟，例如 "30s"
+	// Delay is the delay of a one-off task after it is loaded, such as "30s"
+	Delay string `json:"delay,omitempty" yaml:"delay,omitempty"`
+
+	// At 是一次性任务的 RFC 3339 格式的执行时间，例如 "2024-01-01T09:00:00+08:00"
+	// At is the execution time of a one-off task in the RFC 3339 format, such as "2024-01-01T09:00:00+08:00"
+	At string `json:"at,omitempty" yaml:"at,omitempty"`
+
+	// Interval 是周期任务的间隔，第一次执行在加载之后经过一个间隔，例如 "5m"
+	// Interval is the interval of a recurring task, the first run is one interval after it is loaded, such as "5m"
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+
+	// Cron 是周期任务的 cron 表达式，参见 kairos.ParseCron
+	// Cron is the cron expression of a recurring task, see kairos.ParseCron
+	Cron string `json:"cron,omitempty" yaml:"cron,omitempty"`
+}
+
+// RetrySpec 结构体是重试策略
+// The RetrySpec struct is the retry policy
+type RetrySpec struct {
+	// Attempts 是处理函数最多的执行次数，包括第一次执行
+	// Attempts is the maximum number of executions of the handling function, including the first one
+	Attempts int `json:"attempts" yaml:"attempts"`
+
+	// Backoff 是两次执行之间的等待时间，例如 "1s"，每次重试之后翻倍
+	// Backoff is the waiting time between two executions, such as "1s", it doubles after every retry
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+}
+
+// Parse 函数按照指定的格式解析任务定义文件，未知的字段会导致错误。Parse 只检查文件的语法，使用 Loader.Validate 检查文件的内容
+// The Parse function parses a task definition file in the specified format, unknown fields cause an error. Parse only checks the syntax of the file, use Loader.Validate to check the content of the file
+func Parse(data []byte, format Format) (*File, error) {
+	file := &File{}
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(file); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidFile, err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidFile, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrorInvalidFile, format)
+	}
+	return file, nil
+}
+
+// ReadFile 函数读取并解析任务定义文件，扩展名为 .json 的文件按照 JSON 解析，其他文件按照 YAML 解析
+// The ReadFile function reads and parses a task definition file, files with the .json extension are parsed as JSON, other files are parsed as YAML
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, formatOf(path))
+}
+
+// formatOf 函数根据扩展名返回文件的格式
+// The formatOf function returns the format of the file according to its extension
+func formatOf(path string) Format {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// plan 结构体是验证之后的任务定义，可以直接添加到调度器
+// The plan struct is a validated task definition, it can be added to the scheduler directly
+type plan struct {
+	// spec 是任务的定义
+	// spec is the definition of the task
+	spec *TaskSpec
+
+	// delay、at 是一次性任务的延迟和执行时间，只设置其中一个
+	// delay and at are the delay and the execution time of a one-off task, only one of them is set
+	delay time.Duration
+	at    time.Time
+
+	// schedule 是周期任务的时间表
+	// schedule is the timetable of a recurring task
+	schedule ks.Schedule
+
+	// payload 是编码为 JSON 的数据
+	// payload is the data encoded as JSON
+	payload []byte
+
+	// attempts 和 backoff 是重试策略
+	// attempts and backoff are the retry policy
+	attempts int
+	backoff  time.Duration
+
+	// fingerprint 是任务定义的指纹，用于在重新加载时判断任务是否被修改
+	// fingerprint is the fingerprint of the task definition, it is used to decide whether the task was modified when reloading
+	fingerprint string
+
+	// body 是不包含执行时间的任务定义的指纹，用于判断修改是否只涉及执行时间
+	// body is the fingerprint of the task definition without the execution time, it is used to decide whether only the execution time was modified
+	body string
+}
+
+// recurring 方法返回任务是否为周期任务
+// The recurring method returns whether the task is a recurring task
+func (p *plan) recurring() bool {
+	return p.schedule != nil
+}
+
+// execAt 方法返回任务在给定时间加载时的第一次执行时间
+// The execAt method returns the first execution time of the task when it is loaded at the given time
+func (p *plan) execAt(now time.Time) time.Time {
+	switch {
+	case p.recurring():
+		return p.schedule.Next(now)
+	case p.at.IsZero():
+		return now.Add(p.delay)
+	}
+	return p.at
+}
+
+// compile 函数验证一个任务定义并生成计划，返回发现的所有问题
+// The compile function validates a task definition and produces the plan, it returns all problems found
+func compile(spec *TaskSpec, handlerExists func(string) bool) (*plan, []string) {
+	var problems []string
+	p := &plan{spec: spec, attempts: 1}
+
+	if spec.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if spec.Handler == "" {
+		problems = append(problems, "handler is required")
+	} else if !handlerExists(spec.Handler) {
+		problems = append(problems, fmt.Sprintf("handler %q is not registered", spec.Handler))
+	}
+
+	// 执行时间必须并且只能设置一种
+	// Exactly one kind of execution time must be set
+	set := 0
+	var err error
+	if s := spec.Schedule.Delay; s != "" {
+		set++
+		if p.delay, err = time.ParseDuration(s); err != nil || p.delay < 0 {
+			problems = append(problems, fmt.Sprintf("invalid delay %q", s))
+		}
+	}
+	if s := spec.Schedule.At; s != "" {
+		set++
+		if p.at, err = time.Parse(time.RFC3339, s); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid at %q", s))
+		}
+	}
+	if s := spec.Schedule.Interval; s != "" {
+		set++
+		interval, err := time.ParseDuration(s)
+		if err != nil || interval <= 0 {
+			problems = append(problems, fmt.Sprintf("invalid interval %q", s))
+		} else {
+			p.schedule = ks.Every(interval)
+		}
+	}
+	if s := spec.Schedule.Cron; s != "" {
+		set++
+		if p.schedule, err = ks.ParseCron(s); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if set != 1 {
+		problems = append(problems, "exactly one of schedule.delay, schedule.at, schedule.interval and schedule.cron must be set")
+	}
+
+	// 数据必须可以编码为 JSON
+	// The payload must be encodable as JSON
+	if spec.Payload != nil {
+		if p.payload, err = json.Marshal(spec.Payload); err != nil {
+			problems = append(problems, fmt.Sprintf("payload is not JSON compatible: %v", err))
+		}
+	}
+
+	if retry := spec.Retry; retry != nil {
+		if retry.Attempts < 1 {
+			problems = append(problems, "retry.attempts must be at least 1")
+		}
+		p.attempts = retry.Attempts
+		if retry.Backoff != "" {
+			if p.backoff, err = time.ParseDuration(retry.Backoff); err != nil || p.backoff < 0 {
+				problems = append(problems, fmt.Sprintf("invalid retry.backoff %q", retry.Backoff))
+			}
+		}
+	}
+
+	// 使用 JSON 编码计算指纹，map 的键会被排序
+	// Calculate the fingerprints with the JSON encoding, the keys of maps are sorted
+	if len(problems) == 0 {
+		body := *spec
+		body.Schedule = ScheduleSpec{}
+		full, _ := json.Marshal(spec)
+		partial, _ := json.Marshal(&body)
+		p.fingerprint, p.body = string(full), string(partial)
+	}
+	return p, problems
+}
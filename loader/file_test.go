@@ -0,0 +1,140 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse_YAML(t *testing.T) {
+	data := []byte(`
+tasks:
+  - name: report
+    handler: send
+    schedule:
+      cron: "0 9 * * MON-FRI"
+    payload:
+      to: ops@example.com
+      retries: 3
+    labels:
+      team: ops
+    unique: true
+    retry:
+      attempts: 3
+      backoff: 1s
+  - name: cleanup
+    handler: clean
+    schedule:
+      interval: 5m
+`)
+	file, err := Parse(data, FormatYAML)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(file.Tasks))
+
+	spec := file.Tasks[0]
+	assert.Equal(t, "report", spec.Name)
+	assert.Equal(t, "send", spec.Handler)
+	assert.Equal(t, "0 9 * * MON-FRI", spec.Schedule.Cron)
+	assert.Equal(t, map[string]any{"to": "ops@example.com", "retries": 3}, spec.Payload)
+	assert.Equal(t, map[string]string{"team": "ops"}, spec.Labels)
+	assert.True(t, spec.Unique)
+	assert.Equal(t, &RetrySpec{Attempts: 3, Backoff: "1s"}, spec.Retry)
+	assert.Equal(t, "5m", file.Tasks[1].Schedule.Interval)
+
+	// An empty document has no tasks
+	file, err = Parse(nil, FormatYAML)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(file.Tasks))
+}
+
+func TestParse_JSON(t *testing.T) {
+	data := []byte(`{"tasks":[{"name":"once","handler":"send","schedule":{"at":"2030-01-01T09:00:00Z"},"payload":[1,2]}]}`)
+	file, err := Parse(data, FormatJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(file.Tasks))
+	assert.Equal(t, "2030-01-01T09:00:00Z", file.Tasks[0].Schedule.At)
+	assert.Equal(t, []any{float64(1), float64(2)}, file.Tasks[0].Payload)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	// Unknown fields are rejected
+	_, err := Parse([]byte("tasks:\n  - name: a\n    handlr: b\n"), FormatYAML)
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+
+	_, err = Parse([]byte(`{"tasks":[{"name":"a","handlr":"b"}]}`), FormatJSON)
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+
+	// Syntax errors
+	_, err = Parse([]byte("tasks: [\n"), FormatYAML)
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+
+	// Unknown format
+	_, err = Parse([]byte("{}"), Format("toml"))
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "tasks.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"tasks":[{"name":"a","handler":"b","schedule":{"delay":"1s"}}]}`), 0o644))
+	file, err := ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", file.Tasks[0].Name)
+
+	path = filepath.Join(dir, "tasks.yml")
+	assert.Nil(t, os.WriteFile(path, []byte("tasks:\n  - name: c\n    handler: d\n"), 0o644))
+	file, err = ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "c", file.Tasks[0].Name)
+
+	_, err = ReadFile(filepath.Join(dir, "missing.yaml"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestCompile(t *testing.T) {
+	exists := func(name string) bool { return name == "send" }
+	now := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	// One-off task with a delay
+	p, problems := compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Delay: "30s"}}, exists)
+	assert.Empty(t, problems)
+	assert.False(t, p.recurring())
+	assert.Equal(t, now.Add(30*time.Second), p.execAt(now))
+
+	// One-off task at an absolute time
+	p, problems = compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{At: "2024-03-05T09:00:00Z"}}, exists)
+	assert.Empty(t, problems)
+	assert.Equal(t, time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC), p.execAt(now))
+
+	// Recurring task with an interval
+	p, problems = compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Interval: "1m"}}, exists)
+	assert.Empty(t, problems)
+	assert.True(t, p.recurring())
+	assert.Equal(t, now.Add(time.Minute), p.execAt(now))
+
+	// Recurring task with a cron expression
+	p, problems = compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Cron: "CRON_TZ=UTC 30 11 * * *"}}, exists)
+	assert.Empty(t, problems)
+	assert.Equal(t, time.Date(2024, 3, 4, 11, 30, 0, 0, time.UTC), p.execAt(now))
+
+	// The fingerprint without the schedule stays the same when only the schedule changes
+	p1, _ := compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Delay: "1s"}, Labels: map[string]string{"x": "1", "y": "2"}}, exists)
+	p2, _ := compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Delay: "2s"}, Labels: map[string]string{"y": "2", "x": "1"}}, exists)
+	assert.NotEqual(t, p1.fingerprint, p2.fingerprint)
+	assert.Equal(t, p1.body, p2.body)
+
+	// Invalid definitions report every problem
+	_, problems = compile(&TaskSpec{Handler: "missing", Schedule: ScheduleSpec{Delay: "x", Cron: "* *"}, Retry: &RetrySpec{Backoff: "y"}}, exists)
+	assert.Equal(t, 7, len(problems))
+
+	_, problems = compile(&TaskSpec{Name: "a", Handler: "send"}, exists)
+	assert.Equal(t, 1, len(problems))
+
+	_, problems = compile(&TaskSpec{Name: "a", Handler: "send", Schedule: ScheduleSpec{Interval: "0s"}, Payload: make(chan int)}, exists)
+	assert.Equal(t, 2, len(problems))
+}
@@ -0,0 +1,387 @@
+package loader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+)
+
+// DefaultWatchInterval 是 Watch 检查文件修改的默认间隔
+// DefaultWatchInterval is the default interval at which Watch checks the file for modifications
+const DefaultWatchInterval = 5 * time.Second
+
+// Payload 是编码为 JSON 的任务数据
+// Payload is the data of a task encoded as JSON
+type Payload []byte
+
+// Decode 方法将数据解码到 v 中，任务没有数据时不修改 v
+// The Decode method decodes the data into v, v is not modified if the task has no data
+func (p Payload) Decode(v any) error {
+	if len(p) == 0 {
+		return nil
+	}
+	return json.Unmarshal(p, v)
+}
+
+// PayloadHandleFunc 是接收任务数据的处理函数
+// PayloadHandleFunc is a handling function receiving the data of the task
+type PayloadHandleFunc = func(done ks.WaitForContextDone, payload Payload) (data interface{}, err error)
+
+// Diff 结构体是一次加载对调度器的修改，每个字段是任务的名称，按照字母顺序排列
+// The Diff struct is the modifications of one load to the scheduler, every field is the names of tasks in alphabetical order
+type Diff struct {
+	// Added 是新添加的任务
+	// Added are the newly added tasks
+	Added []string `json:"added,omitempty"`
+
+	// Removed 是从文件中删除的任务
+	// Removed are the tasks deleted from the file
+	Removed []string `json:"removed,omitempty"`
+
+	// Rescheduled 是定义被修改而重新调度的任务
+	// Rescheduled are the tasks rescheduled because their definitions were modified
+	Rescheduled []string `json:"rescheduled,omitempty"`
+
+	// Unchanged 是定义没有修改的任务
+	// Unchanged are the tasks whose definitions were not modified
+	Unchanged []string `json:"unchanged,omitempty"`
+}
+
+// entry 结构体是 Loader 添加到调度器的一个任务
+// The entry struct is a task added to the scheduler by the Loader
+type entry struct {
+	// id 是任务在调度器中的 ID
+	// id is the ID of the task in the scheduler
+	id string
+
+	// plan 是任务的定义
+	// plan is the definition of the task
+	plan *plan
+}
+
+// Loader 结构体把任务定义文件应用到调度器，重新加载时只修改有变化的任务
+// The Loader struct applies task definition files to the scheduler, only the modified tasks are changed when reloading
+type Loader struct {
+	// scheduler 是任务被添加到的调度器
+	// scheduler is the scheduler the tasks are added to
+	scheduler *ks.Scheduler
+
+	// lock 用于保护 handlers、entries 和 digest，同一时间只有一次加载
+	// lock is used to protect handlers, entries and digest, only one load runs at a time
+	lock sync.Mutex
+
+	// handlers 是接收任务数据的处理函数
+	// handlers are the handling functions receiving the data of the task
+	handlers map[string]PayloadHandleFunc
+
+	// entries 是任务名称到任务的映射
+	// entries is the map from the name of a task to the task
+	entries map[string]*entry
+
+	// digest 是最后一次加载的文件内容的摘要
+	// digest is the digest of the file content loaded last
+	digest string
+}
+
+// New 函数用于创建一个新的 Loader 实例
+// The New function is used to create a new instance of Loader
+func New(scheduler *ks.Scheduler) *Loader {
+	return &Loader{
+		scheduler: scheduler,
+		handlers:  make(map[string]PayloadHandleFunc),
+		entries:   make(map[string]*entry),
+	}
+}
+
+// Handle 方法使用给定的名称注册一个接收任务数据的处理函数，它优先于调度器注册表中同名的处理函数
+// The Handle method registers a handling function receiving the data of the task with the given name, it takes precedence over the handling function with the same name in the registry of the scheduler
+func (l *Loader) Handle(name string, handleFunc PayloadHandleFunc) *Loader {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.handlers[name] = handleFunc
+	return l
+}
+
+// ID 方法返回 Loader 添加的指定名称的任务的 ID
+// The ID method returns the ID of the task with the specified name added by the Loader
+func (l *Loader) ID(name string) (string, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	e, ok := l.entries[name]
+	if !ok {
+		return "", false
+	}
+	return e.id, true
+}
+
+// Validate 方法检查文件的内容，返回所有发现的问题，返回的错误包装了 ErrorInvalidFile
+// The Validate method checks the content of the file, it returns all problems found, the returned error wraps ErrorInvalidFile
+func (l *Loader) Validate(file *File) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	_, err := l.compile(file)
+	return err
+}
+
+// compile 方法验证文件中的所有任务并生成计划，调用者需要持有锁
+// The compile method validates all tasks in the file and produces the plans, the caller must hold the lock
+func (l *Loader) compile(file *File) (map[string]*plan, error) {
+	if file == nil {
+		file = &File{}
+	}
+
+	var errs []error
+	plans := make(map[string]*plan, len(file.Tasks))
+	for i, spec := range file.Tasks {
+		if spec == nil {
+			errs = append(errs, fmt.Errorf("%w: tasks[%d]: empty task", ErrorInvalidFile, i))
+			continue
+		}
+		p, problems := compile(spec, l.handlerExists)
+		if _, ok := plans[spec.Name]; ok && spec.Name != "" {
+			problems = append(problems, "duplicate name")
+		}
+		for _, problem := range problems {
+			errs = append(errs, fmt.Errorf("%w: tasks[%d] %q: %s", ErrorInvalidFile, i, spec.Name, problem))
+		}
+		plans[spec.Name] = p
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return plans, nil
+}
+
+// handlerExists 方法返回指定名称的处理函数是否存在，调用者需要持有锁
+// The handlerExists method returns whether the handling function with the specified name exists, the caller must hold the lock
+func (l *Loader) handlerExists(name string) bool {
+	if _, ok := l.handlers[name]; ok {
+		return true
+	}
+	_, ok := l.scheduler.Registry().Lookup(name)
+	return ok
+}
+
+// Apply 方法把文件应用到调度器：添加新的任务，删除文件中已经不存在的任务，重新调度定义被修改的任务，其他任务保持不变。
+// 文件无效时不修改任何任务。只修改执行时间的一次性任务保持 ID 不变，其他修改会删除任务并使用新的定义重新添加
+// The Apply method applies the file to the scheduler: new tasks are added, tasks no longer in the file are deleted, tasks whose definitions were modified are rescheduled and other tasks stay unchanged.
+// No task is modified if the file is invalid. One-off tasks whose only modification is the execution time keep their IDs, other modifications delete the task and add it again with the new definition
+func (l *Loader) Apply(file *File) (*Diff, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.apply(file)
+}
+
+// apply 方法是 Apply 的实现，调用者需要持有锁
+// The apply method is the implementation of Apply, the caller must hold the lock
+func (l *Loader) apply(file *File) (*Diff, error) {
+	plans, err := l.compile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{}
+	var errs []error
+
+	// 删除文件中已经不存在的任务
+	// Delete the tasks no longer in the file
+	for name, e := range l.entries {
+		if _, ok := plans[name]; !ok {
+			l.scheduler.Delete(e.id)
+			delete(l.entries, name)
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+
+	now := time.Now()
+	for name, p := range plans {
+		old, ok := l.entries[name]
+		switch {
+		case !ok:
+			// 添加新的任务
+			// Add the new task
+			if err := l.add(p, now); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			diff.Added = append(diff.Added, name)
+
+		case old.plan.fingerprint == p.fingerprint:
+			diff.Unchanged = append(diff.Unchanged, name)
+
+		default:
+			// 只修改了一次性任务的执行时间时，保持任务的 ID 不变，任务已经执行时重新添加
+			// If only the execution time of a one-off task was modified, keep the ID of the task, add it again if the task has already run
+			if old.plan.body == p.body && !old.plan.recurring() && !p.recurring() {
+				if l.scheduler.Reschedule(old.id, p.execAt(now)) == nil {
+					old.plan = p
+					diff.Rescheduled = append(diff.Rescheduled, name)
+					continue
+				}
+			}
+			l.scheduler.Delete(old.id)
+			delete(l.entries, name)
+			if err := l.add(p, now); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			diff.Rescheduled = append(diff.Rescheduled, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Rescheduled)
+	sort.Strings(diff.Unchanged)
+	return diff, errors.Join(errs...)
+}
+
+// add 方法把一个任务添加到调度器，调用者需要持有锁
+// The add method adds a task to the scheduler, the caller must hold the lock
+func (l *Loader) add(p *plan, now time.Time) error {
+	// 优先使用 Loader 的处理函数，否则使用注册表中的处理函数
+	// The handling function of the Loader is preferred, otherwise the handling function in the registry is used
+	var handleFunc ks.TaskHandleFunc
+	if fn, ok := l.handlers[p.spec.Handler]; ok {
+		payload := Payload(p.payload)
+		handleFunc = func(done ks.WaitForContextDone) (interface{}, error) {
+			return fn(done, payload)
+		}
+	} else {
+		handleFunc, _ = l.scheduler.Registry().Lookup(p.spec.Handler)
+	}
+
+	opts := ks.NewTaskOptions().WithLabels(p.spec.Labels).WithUniqued(p.spec.Unique)
+	if p.recurring() {
+		opts.WithSchedule(p.schedule)
+	}
+
+	id, err := l.scheduler.SetAtWithOptions(p.spec.Name, retry(handleFunc, p.attempts, p.backoff), p.execAt(now), opts)
+	if err != nil {
+		return fmt.Errorf("task %q: %w", p.spec.Name, err)
+	}
+	l.entries[p.spec.Name] = &entry{id: id, plan: p}
+	return nil
+}
+
+// retry 函数返回按照重试策略执行处理函数的处理函数，每次重试之前的等待时间翻倍，任务被取消时停止重试
+// The retry function returns a handling function executing the handling function with the retry policy, the waiting time doubles before every retry, retrying stops when the task is canceled
+func retry(handleFunc ks.TaskHandleFunc, attempts int, backoff time.Duration) ks.TaskHandleFunc {
+	if attempts <= 1 || handleFunc == nil {
+		return handleFunc
+	}
+	return func(done ks.WaitForContextDone) (interface{}, error) {
+		wait := backoff
+		for attempt := 1; ; attempt++ {
+			data, err := handleFunc(done)
+			if err == nil || attempt >= attempts {
+				return data, err
+			}
+
+			// 等待之后重试，任务被取消时返回最后一次的结果
+			// Retry after waiting, return the last result when the task is canceled
+			timer := time.NewTimer(wait)
+			select {
+			case <-done:
+				timer.Stop()
+				return data, err
+			case <-timer.C:
+			}
+			wait *= 2
+		}
+	}
+}
+
+// Load 方法读取任务定义文件并应用到调度器，参见 Apply
+// The Load method reads the task definition file and applies it to the scheduler, see Apply
+func (l *Loader) Load(path string) (*Diff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.load(path, data)
+}
+
+// load 方法解析文件内容并应用到调度器，记录内容的摘要，调用者需要持有锁
+// The load method parses the content of the file and applies it to the scheduler, it records the digest of the content, the caller must hold the lock
+func (l *Loader) load(path string, data []byte) (*Diff, error) {
+	l.digest = digest(data)
+	file, err := Parse(data, formatOf(path))
+	if err != nil {
+		return nil, err
+	}
+	return l.apply(file)
+}
+
+// Watch 方法按照给定的间隔检查任务定义文件，文件内容变化时重新加载，并把结果传递给 onReload。
+// 文件无效时保留当前的任务。Watch 会阻塞直到 ctx 结束，间隔不大于 0 时使用 DefaultWatchInterval
+// The Watch method checks the task definition file at the given interval, it reloads the file when its content changes and passes the result to onReload.
+// The current tasks are kept if the file is invalid. Watch blocks until ctx is done, DefaultWatchInterval is used if the interval is not greater than 0
+func (l *Loader) Watch(ctx context.Context, path string, interval time.Duration, onReload func(diff *Diff, err error)) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if onReload == nil {
+		onReload = func(*Diff, error) {}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if diff, changed, err := l.reload(path); changed {
+				onReload(diff, err)
+			}
+		}
+	}
+}
+
+// reload 方法在文件内容变化时重新加载文件，返回加载的结果和文件是否变化。读取失败只在第一次报告
+// The reload method reloads the file when its content changes, it returns the result of the load and whether the file changed. A read failure is only reported the first time
+func (l *Loader) reload(path string) (*Diff, bool, error) {
+	data, readErr := os.ReadFile(path)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if readErr != nil {
+		sum := "error: " + readErr.Error()
+		if sum == l.digest {
+			return nil, false, nil
+		}
+		l.digest = sum
+		return nil, true, readErr
+	}
+	if digest(data) == l.digest {
+		return nil, false, nil
+	}
+	diff, err := l.load(path, data)
+	return diff, true, err
+}
+
+// digest 函数返回文件内容的摘要
+// The digest function returns the digest of the file content
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return string(sum[:])
+}
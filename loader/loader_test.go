@@ -0,0 +1,251 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ks "github.com/shengyanli1982/kairos"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLoader(t *testing.T) (*ks.Scheduler, *Loader) {
+	scheduler := ks.New(nil)
+	t.Cleanup(scheduler.Stop)
+	return scheduler, New(scheduler)
+}
+
+// writeFile replaces the file atomically so the watcher never reads a partial write
+func writeFile(t *testing.T, path, content string) {
+	tmp := path + ".tmp"
+	assert.Nil(t, os.WriteFile(tmp, []byte(content), 0o644))
+	assert.Nil(t, os.Rename(tmp, path))
+}
+
+func TestPayload_Decode(t *testing.T) {
+	var v struct {
+		To string `json:"to"`
+	}
+	assert.Nil(t, Payload(`{"to":"ops"}`).Decode(&v))
+	assert.Equal(t, "ops", v.To)
+
+	// An empty payload leaves the value untouched
+	assert.Nil(t, Payload(nil).Decode(&v))
+	assert.Equal(t, "ops", v.To)
+}
+
+func TestLoader_Validate(t *testing.T) {
+	scheduler, loader := newTestLoader(t)
+	scheduler.Registry().Register("registered", func(done ks.WaitForContextDone) (interface{}, error) { return nil, nil })
+	loader.Handle("local", func(done ks.WaitForContextDone, payload Payload) (interface{}, error) { return nil, nil })
+
+	file := &File{Tasks: []*TaskSpec{
+		{Name: "a", Handler: "registered", Schedule: ScheduleSpec{Delay: "1s"}},
+		{Name: "b", Handler: "local", Schedule: ScheduleSpec{Interval: "1s"}},
+	}}
+	assert.Nil(t, loader.Validate(file))
+
+	// Duplicate names and unknown handlers are reported together
+	file.Tasks = append(file.Tasks,
+		&TaskSpec{Name: "a", Handler: "registered", Schedule: ScheduleSpec{Delay: "1s"}},
+		&TaskSpec{Name: "c", Handler: "unknown", Schedule: ScheduleSpec{Delay: "1s"}},
+		nil,
+	)
+	err := loader.Validate(file)
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+	assert.Contains(t, err.Error(), "duplicate name")
+	assert.Contains(t, err.Error(), `handler "unknown" is not registered`)
+	assert.Contains(t, err.Error(), "empty task")
+
+	// Invalid files do not modify the scheduler
+	_, err = loader.Apply(file)
+	assert.True(t, errors.Is(err, ErrorInvalidFile))
+	assert.Equal(t, 0, scheduler.Count())
+}
+
+func TestLoader_Apply(t *testing.T) {
+	scheduler, loader := newTestLoader(t)
+
+	var lock sync.Mutex
+	payloads := make(map[string]string)
+	loader.Handle("record", func(done ks.WaitForContextDone, payload Payload) (interface{}, error) {
+		var v struct {
+			Value string `json:"value"`
+		}
+		assert.Nil(t, payload.Decode(&v))
+		lock.Lock()
+		defer lock.Unlock()
+		payloads[v.Value] = v.Value
+		return nil, nil
+	})
+
+	file := &File{Tasks: []*TaskSpec{
+		{Name: "soon", Handler: "record", Schedule: ScheduleSpec{Delay: "50ms"}, Payload: map[string]any{"value": "soon"}},
+		{Name: "later", Handler: "record", Schedule: ScheduleSpec{Delay: "1h"}, Labels: map[string]string{"team": "ops"}},
+		{Name: "recurring", Handler: "record", Schedule: ScheduleSpec{Interval: "1h"}},
+		{Name: "gone", Handler: "record", Schedule: ScheduleSpec{Cron: "@daily"}},
+	}}
+	diff, err := loader.Apply(file)
+	assert.Nil(t, err)
+	assert.Equal(t, &Diff{Added: []string{"gone", "later", "recurring", "soon"}}, diff)
+	assert.Equal(t, 4, scheduler.Count())
+
+	laterID, ok := loader.ID("later")
+	assert.True(t, ok)
+	info, err := scheduler.GetInfo(laterID)
+	assert.Nil(t, err)
+	assert.Equal(t, ks.Labels{"team": "ops"}, info.Labels)
+
+	// The payload is passed to the handling function
+	time.Sleep(200 * time.Millisecond)
+	lock.Lock()
+	assert.Equal(t, "soon", payloads["soon"])
+	lock.Unlock()
+
+	recurringID, _ := loader.ID("recurring")
+
+	// Reload with modifications
+	file = &File{Tasks: []*TaskSpec{
+		{Name: "soon", Handler: "record", Schedule: ScheduleSpec{Delay: "50ms"}, Payload: map[string]any{"value": "soon"}},
+		{Name: "later", Handler: "record", Schedule: ScheduleSpec{Delay: "2h"}, Labels: map[string]string{"team": "ops"}},
+		{Name: "recurring", Handler: "record", Schedule: ScheduleSpec{Interval: "2h"}},
+		{Name: "new", Handler: "record", Schedule: ScheduleSpec{Delay: "1h"}},
+	}}
+	diff, err = loader.Apply(file)
+	assert.Nil(t, err)
+	assert.Equal(t, &Diff{
+		Added:       []string{"new"},
+		Removed:     []string{"gone"},
+		Rescheduled: []string{"later", "recurring"},
+		Unchanged:   []string{"soon"},
+	}, diff)
+	assert.Equal(t, 3, scheduler.Count())
+
+	// A one-off task whose execution time changed keeps its ID
+	id, _ := loader.ID("later")
+	assert.Equal(t, laterID, id)
+	info, err = scheduler.GetInfo(laterID)
+	assert.Nil(t, err)
+	assert.True(t, info.ExecAt.After(time.Now().Add(time.Hour+30*time.Minute)))
+
+	// A recurring task is replaced
+	id, _ = loader.ID("recurring")
+	assert.NotEqual(t, recurringID, id)
+
+	_, ok = loader.ID("gone")
+	assert.False(t, ok)
+
+	// A one-off task that already ran is added again when its execution time changes
+	file.Tasks[0].Schedule.Delay = "1h"
+	diff, err = loader.Apply(file)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"soon"}, diff.Rescheduled)
+	assert.Equal(t, 4, scheduler.Count())
+}
+
+func TestLoader_Unique(t *testing.T) {
+	scheduler, loader := newTestLoader(t)
+	scheduler.Registry().Register("noop", func(done ks.WaitForContextDone) (interface{}, error) { return nil, nil })
+
+	// A unique task with the same name already exists in the scheduler
+	existingID, err := scheduler.SetWithOptions("report", nil, time.Hour, ks.NewTaskOptions().WithUniqued(true))
+	assert.Nil(t, err)
+
+	_, err = loader.Apply(&File{Tasks: []*TaskSpec{{Name: "report", Handler: "noop", Schedule: ScheduleSpec{Delay: "1h"}, Unique: true}}})
+	assert.Nil(t, err)
+	id, _ := loader.ID("report")
+	assert.Equal(t, existingID, id)
+	assert.Equal(t, 1, scheduler.Count())
+}
+
+func TestLoader_Retry(t *testing.T) {
+	var calls atomic.Int32
+	handleFunc := retry(func(done ks.WaitForContextDone) (interface{}, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("failed")
+		}
+		return "ok", nil
+	}, 3, time.Millisecond)
+
+	data, err := handleFunc(make(chan struct{}))
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", data)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The last error is returned when all attempts fail
+	calls.Store(0)
+	handleFunc = retry(func(done ks.WaitForContextDone) (interface{}, error) {
+		calls.Add(1)
+		return nil, errors.New("failed")
+	}, 2, time.Millisecond)
+	_, err = handleFunc(make(chan struct{}))
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Retrying stops when the task is canceled
+	calls.Store(0)
+	done := make(chan struct{})
+	close(done)
+	handleFunc = retry(func(done ks.WaitForContextDone) (interface{}, error) {
+		calls.Add(1)
+		return nil, errors.New("failed")
+	}, 5, time.Hour)
+	_, err = handleFunc(done)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestLoader_Watch(t *testing.T) {
+	scheduler, loader := newTestLoader(t)
+	scheduler.Registry().Register("noop", func(done ks.WaitForContextDone) (interface{}, error) { return nil, nil })
+
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	assert.Nil(t, os.WriteFile(path, []byte("tasks:\n  - name: a\n    handler: noop\n    schedule:\n      delay: 1h\n"), 0o644))
+
+	diff, err := loader.Load(path)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, diff.Added)
+
+	type result struct {
+		diff *Diff
+		err  error
+	}
+	results := make(chan result, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		loader.Watch(ctx, path, 10*time.Millisecond, func(diff *Diff, err error) {
+			results <- result{diff, err}
+		})
+	}()
+
+	// A modified file is reloaded
+	writeFile(t, path, "tasks:\n  - name: b\n    handler: noop\n    schedule:\n      delay: 1h\n")
+	r := <-results
+	assert.Nil(t, r.err)
+	assert.Equal(t, &Diff{Added: []string{"b"}, Removed: []string{"a"}}, r.diff)
+
+	// An invalid file keeps the current tasks
+	writeFile(t, path, "tasks:\n  - name: c\n    handler: missing\n")
+	r = <-results
+	assert.True(t, errors.Is(r.err, ErrorInvalidFile))
+	_, ok := loader.ID("b")
+	assert.True(t, ok)
+	assert.Equal(t, 1, scheduler.Count())
+
+	// A missing file is reported once
+	assert.Nil(t, os.Remove(path))
+	r = <-results
+	assert.True(t, errors.Is(r.err, os.ErrNotExist))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 0, len(results))
+
+	cancel()
+	<-stopped
+}
@@ -0,0 +1,203 @@
+package kairos
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLockTTL 是任务锁的默认有效期
+// DefaultLockTTL is the default time to live of a task lock
+const DefaultLockTTL = 30 * time.Second
+
+// LockScope 是任务锁的范围，决定锁的键
+// LockScope is the scope of a task lock, it decides the key of the lock
+type LockScope int
+
+// 定义任务锁的范围
+// Define the scopes of a task lock
+const (
+	// LockByID 使用任务的 ID 作为锁的键，适用于多个实例恢复同一个持久化任务的情况
+	// LockByID uses the ID of the task as the key of the lock, suitable when several instances restore the same persisted task
+	LockByID LockScope = iota
+
+	// LockByName 使用任务的名称作为锁的键，适用于多个实例各自添加同名任务的情况
+	// LockByName uses the name of the task as the key of the lock, suitable when several instances each add a task with the same name
+	LockByName
+)
+
+// Locker 是一个接口，用于在多个调度器实例之间对每个任务加锁。任务到期时，调度器先尝试获取任务这次触发的锁，锁的键包含触发的执行时间，
+// 锁被其他实例持有时跳过处理函数。锁在有效期之后自动释放，有效期需要大于处理函数的执行时间以及实例之间触发时间的差异
+// Locker is an interface used to lock every task among several scheduler instances. When a task expires, the scheduler tries to acquire the lock of this firing of the task first, the key of the lock contains the execution time of the firing,
+// and skips the handling function if the lock is held by another instance. The lock is released automatically after its time to live, which must exceed the duration of the handling function and the difference between the firing times of the instances
+type Locker interface {
+	// TryLock 尝试获取指定键的锁，锁空闲或者已经过期时成功，返回是否获得了锁
+	// TryLock tries to acquire the lock with the specified key, it succeeds if the lock is free or expired, it returns whether the lock was acquired
+	TryLock(key string, ttl time.Duration) (bool, error)
+}
+
+// MemoryLocker 结构体是进程内的 Locker 实现，同一个进程中的多个调度器可以共享同一个实例
+// The MemoryLocker struct is an in-process Locker implementation, several schedulers in the same process can share the same instance
+type MemoryLocker struct {
+	// lock 用于保护 locks
+	// lock is used to protect locks
+	lock sync.Mutex
+
+	// locks 是锁的过期时间，键是锁的键
+	// locks are the expiration times of the locks, the key is the key of the lock
+	locks map[string]time.Time
+}
+
+// NewMemoryLocker 函数创建一个新的 MemoryLocker 实例
+// The NewMemoryLocker function creates a new MemoryLocker instance
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]time.Time)}
+}
+
+// TryLock 方法尝试获取指定键的锁，同时清除已经过期的锁
+// The TryLock method tries to acquire the lock with the specified key, and clears the expired locks at the same time
+func (l *MemoryLocker) TryLock(key string, ttl time.Duration) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if expiresAt, ok := l.locks[key]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+
+	// 清除已经过期的锁
+	// Clear the expired locks
+	for k, expiresAt := range l.locks {
+		if !now.Before(expiresAt) {
+			delete(l.locks, k)
+		}
+	}
+
+	l.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+// FileLocker 结构体是基于文件的 Locker 实现，同一台主机上的多个进程可以使用同一个目录加锁。
+// 每个锁是目录中的一个文件，检查和获取锁在目录锁的保护下进行
+// The FileLocker struct is a file based Locker implementation, several processes on the same host can lock through the same directory.
+// Every lock is a file in the directory, checking and acquiring a lock happens under the lock of the directory
+type FileLocker struct {
+	// dir 是锁文件所在的目录
+	// dir is the directory of the lock files
+	dir string
+
+	// pruneAt 是下一次清除过期锁文件的 Unix 纳秒时间
+	// pruneAt is the time in Unix nanoseconds of the next removal of the expired lock files
+	pruneAt atomic.Int64
+}
+
+// NewFileLocker 函数创建一个新的 FileLocker 实例，目录不存在时会被创建
+// The NewFileLocker function creates a new FileLocker instance, the directory is created if it does not exist
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileLocker{dir: dir}, nil
+}
+
+// TryLock 方法尝试获取指定键的锁，键经过哈希，可以包含任意字符。每隔 DefaultLockTTL 它还会顺便删除已经过期的锁文件
+// The TryLock method tries to acquire the lock with the specified key, the key is hashed so it can contain any character. Every DefaultLockTTL it also removes the expired lock files along the way
+func (l *FileLocker) TryLock(key string, ttl time.Duration) (bool, error) {
+	unlock, err := lockFile(filepath.Join(l.dir, ".lock"))
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	if pruneAt := l.pruneAt.Load(); now.UnixNano() >= pruneAt && l.pruneAt.CompareAndSwap(pruneAt, now.Add(DefaultLockTTL).UnixNano()) {
+		if err := l.prune(now); err != nil {
+			return false, err
+		}
+	}
+
+	sum := sha1.Sum([]byte(key))
+	path := filepath.Join(l.dir, hex.EncodeToString(sum[:])+".json")
+	current, err := readLease(path)
+	if err != nil {
+		return false, err
+	}
+	if !current.free(now) {
+		return false, nil
+	}
+	if err := writeLease(path, &lease{ExpiresAt: now.Add(ttl)}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Prune 方法删除已经过期的锁文件，TryLock 会定期调用它，锁的键很多而且不会重复使用时也可以手动调用
+// The Prune method removes the expired lock files, TryLock calls it periodically, it can also be called manually when there are many keys which are never reused
+func (l *FileLocker) Prune() error {
+	unlock, err := lockFile(filepath.Join(l.dir, ".lock"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return l.prune(time.Now())
+}
+
+// prune 是一个方法，删除在给定时间已经过期的锁文件，调用者需要持有目录锁
+// prune is a method removing the lock files expired at the given time, the caller must hold the lock of the directory
+func (l *FileLocker) prune(now time.Time) error {
+	paths, err := filepath.Glob(filepath.Join(l.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if current, err := readLease(path); err == nil && current.free(now) {
+			_ = os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// lock 是一个方法，返回任务在触发之前获取任务锁的回调函数，锁被其他实例持有时跳过处理函数
+// lock is a method returning the callback function a task uses to acquire the task lock before it fires, the handling function is skipped if the lock is held by another instance
+func (s *Scheduler) lock(metadata *TaskMetadata) error {
+	// 根据锁的范围计算锁的键，键包含这次触发的执行时间，周期任务的每次触发使用不同的锁，各个实例的同一次触发使用相同的锁
+	// Calculate the key of the lock according to the scope of the lock, the key contains the execution time of this firing, so every firing of a recurring task uses its own lock and the same firing on every instance uses the same lock
+	key := "id/" + claimKey(metadata.id, metadata.execAt)
+	if s.cfg.lockScope == LockByName {
+		key = "name/" + claimKey(metadata.name, metadata.execAt)
+	}
+
+	locked, err := s.cfg.locker.TryLock(key, s.cfg.lockTTL)
+	if err != nil {
+		s.reportClusterError(metadata.id, err)
+		return fmt.Errorf("%w: %v", ErrorTaskSkipped, err)
+	}
+	if !locked {
+		return ErrorTaskLocked
+	}
+	return nil
+}
+
+// chainFire 函数将多个触发回调函数串联为一个，按顺序调用它们，第一个跳过处理函数的原因被返回，没有回调函数时返回 nil
+// The chainFire function chains several fire callback functions into one, they are called in order and the first reason skipping the handling function is returned, nil is returned if there are no callback functions
+func chainFire(fns []onFireHandleFunc) onFireHandleFunc {
+	switch len(fns) {
+	case 0:
+		return nil
+	case 1:
+		return fns[0]
+	}
+	return func(metadata *TaskMetadata) error {
+		for _, fn := range fns {
+			if skipped := fn(metadata); skipped != nil {
+				return skipped
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,170 @@
+package kairos
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLocker(t *testing.T, locker Locker) {
+	ok, err := locker.TryLock("a", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// A held lock can not be acquired again, other keys are independent
+	ok, err = locker.TryLock("a", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = locker.TryLock("b/with/slash", time.Millisecond*100)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// An expired lock can be acquired again
+	time.Sleep(time.Millisecond * 150)
+	ok, err = locker.TryLock("a", time.Second)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestMemoryLocker(t *testing.T) {
+	testLocker(t, NewMemoryLocker())
+}
+
+func TestFileLocker(t *testing.T) {
+	locker, err := NewFileLocker(t.TempDir())
+	assert.Nil(t, err)
+	testLocker(t, locker)
+}
+
+func TestFileLocker_Prune(t *testing.T) {
+	dir := t.TempDir()
+	locker, err := NewFileLocker(dir)
+	assert.Nil(t, err)
+
+	count := func() int {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		return len(paths)
+	}
+
+	ok, err := locker.TryLock("a", time.Millisecond*50)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = locker.TryLock("b", time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, count())
+
+	// Expired lock files are removed, held ones are kept
+	time.Sleep(time.Millisecond * 100)
+	assert.Nil(t, locker.Prune())
+	assert.Equal(t, 1, count())
+	ok, err = locker.TryLock("b", time.Hour)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// TryLock removes the expired lock files along the way once the prune interval is over
+	ok, err = locker.TryLock("c", time.Millisecond*50)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	time.Sleep(time.Millisecond * 100)
+	locker.pruneAt.Store(0)
+	ok, err = locker.TryLock("d", time.Hour)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, count())
+}
+
+type testReasonCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	reasons []error
+}
+
+func (c *testReasonCallback) OnTaskExecuted(id, name string, data interface{}, reason, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.reasons = append(c.reasons, reason)
+}
+
+func (c *testReasonCallback) count(target error) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	count := 0
+	for _, reason := range c.reasons {
+		if errors.Is(reason, target) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestScheduler_Locker(t *testing.T) {
+	locker, err := NewFileLocker(t.TempDir())
+	assert.Nil(t, err)
+
+	var count atomic.Int32
+	handleFunc := func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}
+
+	// Two instances add the same task, only one of them executes it
+	callback := &testReasonCallback{}
+	first := New(NewConfig().WithLocker(locker, LockByName).WithCallback(callback))
+	defer first.Stop()
+	second := New(NewConfig().WithLocker(locker, LockByName).WithCallback(callback))
+	defer second.Stop()
+
+	execAt := time.Now().Add(time.Millisecond * 100)
+	_, err = first.SetAt("report", handleFunc, execAt)
+	assert.Nil(t, err)
+	_, err = second.SetAt("report", handleFunc, execAt)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		return first.Count() == 0 && second.Count() == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, int32(1), count.Load())
+	assert.Equal(t, 1, callback.count(ErrorTaskLocked))
+	assert.Equal(t, 1, callback.count(ErrorTaskSkipped))
+	assert.Equal(t, uint64(1), first.Stats().Skipped+second.Stats().Skipped)
+
+	// Locking by ID does not affect tasks with the same name
+	byID := New(NewConfig().WithLocker(NewMemoryLocker(), LockByID))
+	defer byID.Stop()
+	for i := 0; i < 2; i++ {
+		_, err = byID.Set("report", handleFunc, time.Millisecond*50)
+		assert.Nil(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return count.Load() == 3
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, uint64(0), byID.Stats().Skipped)
+}
+
+func TestScheduler_LockerRecurring(t *testing.T) {
+	var count atomic.Int32
+	handleFunc := func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}
+
+	// Every firing of a recurring task takes its own lock, none of them is skipped on a single instance
+	callback := &testReasonCallback{}
+	scheduler := New(NewConfig().WithLocker(NewMemoryLocker(), LockByID).WithCallback(callback))
+	defer scheduler.Stop()
+
+	_, err := scheduler.SetWithOptions("tick", handleFunc, time.Millisecond*20, NewTaskOptions().WithSchedule(Every(time.Millisecond*20)))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return count.Load() >= 5
+	}, time.Second, time.Millisecond*5)
+	assert.Equal(t, 0, callback.count(ErrorTaskLocked))
+	assert.Equal(t, uint64(0), scheduler.Stats().Skipped)
+}
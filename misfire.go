@@ -0,0 +1,173 @@
+package kairos
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shengyanli1982/kairos/rrule"
+)
+
+// MisfirePolicy 是任务错过执行时间之后的处理策略
+// MisfirePolicy is the policy of handling a task that missed its execution time
+type MisfirePolicy int
+
+// 定义错过执行时间的处理策略
+// Define the policies of handling missed execution times
+const (
+	// MisfireFire 表示仍然执行任务，周期任务错过的每一次执行都会依次补上
+	// MisfireFire means the task is executed anyway, every missed run of a recurring task is made up in turn
+	MisfireFire MisfirePolicy = iota
+
+	// MisfireSkip 表示跳过这次执行，周期任务继续按照原来的时间表执行
+	// MisfireSkip means this run is skipped, a recurring task continues with its original timetable
+	MisfireSkip
+
+	// MisfireCoalesce 表示执行一次，周期任务错过的所有执行被合并为这一次，下一次执行是当前时间之后的第一个时间点
+	// MisfireCoalesce means the task is executed once, all missed runs of a recurring task are coalesced into this one, the next run is the first slot after the current time
+	MisfireCoalesce
+
+	// MisfireReschedule 表示不执行，周期任务重新调度到当前时间之后的第一个时间点，一次性任务被跳过
+	// MisfireReschedule means the task is not executed, a recurring task is rescheduled to the first slot after the current time, a one-off task is skipped
+	MisfireReschedule
+)
+
+// String 方法返回策略的名称
+// The String method returns the name of the policy
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireFire:
+		return "fire"
+	case MisfireSkip:
+		return "skip"
+	case MisfireCoalesce:
+		return "coalesce"
+	case MisfireReschedule:
+		return "reschedule"
+	}
+	return "unknown"
+}
+
+// Schedule 是一个接口，描述周期任务的时间表。任务每次触发之后，调度器使用 Next 计算下一次执行的时间，任务的 ID 保持不变
+// Schedule is an interface describing the timetable of a recurring task. After every firing of the task, the scheduler uses Next to calculate the next execution time, the ID of the task stays the same
+type Schedule interface {
+	// Next 返回给定时间之后的下一次执行时间，返回零值表示没有下一次执行
+	// Next returns the next execution time after the given time, a zero value means there is no next run
+	Next(after time.Time) time.Time
+}
+
+// interval 结构体是固定间隔的时间表
+// The interval struct is a timetable with a fixed interval
+type interval time.Duration
+
+// Next 方法返回给定时间加上间隔
+// The Next method returns the given time plus the interval
+func (i interval) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(i))
+}
+
+// String 方法返回时间表的文本，例如 "@every 1m0s"
+// The String method returns the text of the timetable, for example "@every 1m0s"
+func (i interval) String() string {
+	return "@every " + time.Duration(i).String()
+}
+
+// Every 函数返回一个固定间隔的时间表，间隔不大于 0 时返回 nil，表示任务只执行一次
+// The Every function returns a timetable with a fixed interval, nil is returned if the interval is not greater than 0, meaning the task runs once
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		return nil
+	}
+	return interval(d)
+}
+
+// ParseSchedule 函数解析时间表的文本：以 "@every " 开头的间隔（例如 "@every 5m"）使用 Every，包含 "FREQ=" 的 iCalendar 重复规则使用 rrule.Parse，
+// 其他文本作为 cron 表达式使用 ParseCron。Every、ParseCron 和 rrule 的时间表可以转换为这种文本，持久化的周期任务使用它保存时间表
+// The ParseSchedule function parses the text of a timetable: an interval starting with "@every " (for example "@every 5m") uses Every, an iCalendar recurrence rule containing "FREQ=" uses rrule.Parse,
+// any other text is a cron expression parsed by ParseCron. The timetables of Every, ParseCron and rrule can be turned into such a text, persisted recurring tasks save their timetable with it
+func ParseSchedule(text string) (Schedule, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "@every "):
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(text, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: invalid interval %q", ErrorInvalidCron, text)
+		}
+		return Every(d), nil
+	case strings.Contains(strings.ToUpper(text), "FREQ="):
+		rule, err := rrule.Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return rule, nil
+	}
+	return ParseCron(text)
+}
+
+// scheduleText 函数返回时间表的文本，ParseSchedule 解析它得到相同的时间表。没有时间表时返回空字符串，其他实现的时间表无法转换为文本，返回 false
+// The scheduleText function returns the text of the timetable, ParseSchedule parses it into the same timetable. An empty string is returned if there is no timetable, timetables of other implementations can not be turned into a text and false is returned
+func scheduleText(schedule Schedule) (string, bool) {
+	switch schedule := schedule.(type) {
+	case nil:
+		return "", true
+	case interval:
+		return schedule.String(), true
+	case *cronSchedule:
+		return schedule.expr, true
+	case *rrule.Rule:
+		return schedule.String(), true
+	}
+	return "", false
+}
+
+// nextSlot 函数返回时间表中 from 之后的下一次执行时间。如果 realign 为 true，跳过所有不晚于 now 的时间点。没有下一次执行时返回零值
+// The nextSlot function returns the next execution time of the timetable after from. If realign is true, all slots not later than now are skipped. A zero value is returned if there is no next run
+func nextSlot(schedule Schedule, from, now time.Time, realign bool) time.Time {
+	next := schedule.Next(from)
+	for realign && !next.IsZero() && !next.After(now) {
+		next = schedule.Next(next)
+	}
+	return next
+}
+
+// MisfireCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，任务的延迟超过阈值时调度器会在应用策略之前调用它
+// MisfireCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it before applying the policy when the lag of a task exceeds the threshold
+type MisfireCallback interface {
+	// OnTaskMisfired 是当任务错过执行时间时的回调函数，它接收任务 id、任务名称、计划执行时间、延迟和策略作为参数
+	// OnTaskMisfired is the callback function when a task missed its execution time, it takes the task id, task name, scheduled execution time, lag and policy as parameters
+	OnTaskMisfired(id, name string, execAt time.Time, lag time.Duration, policy MisfirePolicy)
+}
+
+// misfire 是一个方法，返回任务在触发之前检查延迟的回调函数。延迟超过阈值时根据策略执行或者跳过处理函数，跳过的原因为 ErrorTaskMisfired
+// misfire is a method returning the callback function a task uses to check its lag before it fires. When the lag exceeds the threshold, the handling function is executed or skipped according to the policy, the reason of skipping is ErrorTaskMisfired
+func (s *Scheduler) misfire(taskRef *TaskRef, task *Task, threshold time.Duration, policy MisfirePolicy) onFireHandleFunc {
+	return func(metadata *TaskMetadata) error {
+		// 延迟没有超过阈值时正常执行。
+		// Execute normally if the lag does not exceed the threshold.
+		if metadata.lag <= threshold {
+			return nil
+		}
+
+		// 通知任务错过了执行时间。
+		// Notify that the task missed its execution time.
+		if callback, ok := s.cfg.callback.(MisfireCallback); ok {
+			callback.OnTaskMisfired(metadata.id, metadata.name, metadata.execAt, metadata.lag, policy)
+		}
+
+		// 合并和重新调度的周期任务从当前时间之后的第一个时间点继续执行。
+		// Coalesced and rescheduled recurring tasks continue with the first slot after the current time.
+		if policy == MisfireCoalesce || policy == MisfireReschedule {
+			taskRef.lock.Lock()
+			if taskRef.task == task {
+				taskRef.realign = true
+			}
+			taskRef.lock.Unlock()
+		}
+
+		switch policy {
+		case MisfireSkip, MisfireReschedule:
+			return ErrorTaskMisfired
+		}
+		return nil
+	}
+}
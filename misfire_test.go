@@ -0,0 +1,217 @@
+package kairos
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shengyanli1982/kairos/rrule"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMisfireCallback struct {
+	testReasonCallback
+	lags []time.Duration
+}
+
+func (c *testMisfireCallback) OnTaskMisfired(id, name string, execAt time.Time, lag time.Duration, policy MisfirePolicy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.lags = append(c.lags, lag)
+}
+
+func (c *testMisfireCallback) misfired() []time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]time.Duration(nil), c.lags...)
+}
+
+func TestMisfirePolicy_String(t *testing.T) {
+	assert.Equal(t, "fire", MisfireFire.String())
+	assert.Equal(t, "skip", MisfireSkip.String())
+	assert.Equal(t, "coalesce", MisfireCoalesce.String())
+	assert.Equal(t, "reschedule", MisfireReschedule.String())
+	assert.Equal(t, "unknown", MisfirePolicy(-1).String())
+}
+
+func TestEvery(t *testing.T) {
+	now := time.Now()
+	assert.Nil(t, Every(0))
+	assert.Equal(t, now.Add(time.Minute), Every(time.Minute).Next(now))
+
+	// Realigning skips every slot not later than now
+	from := now.Add(-time.Second * 10)
+	assert.Equal(t, from.Add(time.Second), nextSlot(Every(time.Second), from, now, false))
+	assert.Equal(t, from.Add(time.Second*11), nextSlot(Every(time.Second), from, now, true))
+}
+
+func TestScheduler_Misfire(t *testing.T) {
+	history, err := NewHistory(nil)
+	assert.Nil(t, err)
+	callback := &testMisfireCallback{}
+	scheduler := New(NewConfig().WithHistory(history).WithCallback(callback).WithMisfire(time.Millisecond*100, MisfireSkip))
+	defer scheduler.Stop()
+
+	var count atomic.Int32
+	handleFunc := func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}
+
+	// A task overdue by more than the threshold is skipped, its lag is reported
+	_, err = scheduler.SetAt("late", handleFunc, time.Now().Add(-time.Second))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return scheduler.Count() == 0 }, time.Second, time.Millisecond*10)
+	assert.Equal(t, int32(0), count.Load())
+	assert.Equal(t, 1, callback.count(ErrorTaskMisfired))
+	lags := callback.misfired()
+	assert.Equal(t, 1, len(lags))
+	assert.GreaterOrEqual(t, lags[0], time.Second)
+
+	// A task can override the policy, the lag is still recorded
+	_, err = scheduler.SetAtWithOptions("override", handleFunc, time.Now().Add(-time.Second), NewTaskOptions().WithMisfire(time.Millisecond*100, MisfireFire))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return count.Load() == 1 }, time.Second, time.Millisecond*10)
+	assert.Eventually(t, func() bool { return len(history.Query(&HistoryQuery{Name: "override"})) == 1 }, time.Second, time.Millisecond*10)
+	assert.GreaterOrEqual(t, history.Query(&HistoryQuery{Name: "override"})[0].Lag, time.Second)
+	assert.Equal(t, 2, len(callback.misfired()))
+
+	// Tasks within the threshold and tasks executed early are not affected
+	_, err = scheduler.Set("on-time", handleFunc, time.Millisecond*20)
+	assert.Nil(t, err)
+	taskID, err := scheduler.Set("early", handleFunc, time.Hour)
+	assert.Nil(t, err)
+	assert.Nil(t, scheduler.EarlyReturn(taskID))
+	assert.Eventually(t, func() bool { return count.Load() == 3 }, time.Second, time.Millisecond*10)
+	assert.Equal(t, 2, len(callback.misfired()))
+}
+
+func TestScheduler_Recurring(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	var lock sync.Mutex
+	execAts := make([]time.Time, 0)
+	taskID, err := scheduler.SetWithOptions("tick", nil, time.Millisecond*50, NewTaskOptions().WithSchedule(Every(time.Millisecond*50)))
+	assert.Nil(t, err)
+
+	// The task keeps its ID and moves to the next slot after every firing
+	assert.Eventually(t, func() bool {
+		info, err := scheduler.GetInfo(taskID)
+		if err != nil {
+			return false
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if len(execAts) == 0 || !execAts[len(execAts)-1].Equal(info.ExecAt) {
+			execAts = append(execAts, info.ExecAt)
+		}
+		return len(execAts) >= 3
+	}, time.Second*2, time.Millisecond*5)
+	assert.Equal(t, time.Millisecond*50, execAts[2].Sub(execAts[1]))
+	assert.Equal(t, uint64(1), scheduler.Stats().Added)
+
+	// Deleting the task stops the recurrence
+	scheduler.Delete(taskID)
+	assert.Equal(t, 0, scheduler.Count())
+}
+
+func TestScheduler_MisfireRecurring(t *testing.T) {
+	run := func(policy MisfirePolicy) (executed int32, skipped int, info *TaskInfo) {
+		callback := &testMisfireCallback{}
+		scheduler := New(NewConfig().WithCallback(callback))
+		defer scheduler.Stop()
+
+		var count atomic.Int32
+		handleFunc := func(_ WaitForContextDone) (any, error) {
+			count.Add(1)
+			return nil, nil
+		}
+
+		// The task missed eleven runs, the next slot is 50ms from now
+		opts := NewTaskOptions().WithSchedule(Every(time.Millisecond*200)).WithMisfire(time.Millisecond*100, policy)
+		taskID, err := scheduler.SetAtWithOptions("tick", handleFunc, time.Now().Add(-time.Millisecond*2150), opts)
+		assert.Nil(t, err)
+
+		time.Sleep(time.Millisecond * 30)
+		info, err = scheduler.GetInfo(taskID)
+		assert.Nil(t, err)
+		return count.Load(), callback.count(ErrorTaskMisfired), info
+	}
+
+	now := time.Now()
+
+	// Coalescing fires once and continues with the first slot after now
+	executed, skipped, info := run(MisfireCoalesce)
+	assert.Equal(t, int32(1), executed)
+	assert.Equal(t, 0, skipped)
+	assert.True(t, info.ExecAt.After(now))
+
+	// Rescheduling does not fire and continues with the first slot after now
+	executed, skipped, info = run(MisfireReschedule)
+	assert.Equal(t, int32(0), executed)
+	assert.Equal(t, 1, skipped)
+	assert.True(t, info.ExecAt.After(now))
+
+	// Skipping skips every missed run in turn
+	executed, skipped, info = run(MisfireSkip)
+	assert.Equal(t, int32(0), executed)
+	assert.Equal(t, 11, skipped)
+	assert.True(t, info.ExecAt.After(now))
+
+	// Firing anyway makes up every missed run
+	executed, _, _ = run(MisfireFire)
+	assert.Equal(t, int32(11), executed)
+}
+
+func TestScheduler_Upcoming(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	handleFunc := func(_ WaitForContextDone) (any, error) { return nil, nil }
+	execAt := time.Now().Add(time.Hour)
+
+	// A one-off task only has its current execution time
+	id, err := scheduler.SetAt("once", handleFunc, execAt)
+	assert.Nil(t, err)
+	upcoming, err := scheduler.Upcoming(id, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{execAt}, upcoming)
+
+	// A recurring task follows its timetable
+	id, err = scheduler.SetAtWithOptions("every", handleFunc, execAt, NewTaskOptions().WithSchedule(Every(time.Minute)))
+	assert.Nil(t, err)
+	upcoming, err = scheduler.Upcoming(id, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{execAt, execAt.Add(time.Minute), execAt.Add(time.Minute * 2)}, upcoming)
+
+	upcoming, err = scheduler.Upcoming(id, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, upcoming)
+	_, err = scheduler.Upcoming("missing", 3)
+	assert.ErrorIs(t, err, ErrorTaskNotFound)
+}
+
+func TestScheduler_RecurringRule(t *testing.T) {
+	scheduler := New(nil)
+	defer scheduler.Stop()
+
+	// A recurrence rule drives the task until its COUNT is reached
+	start := time.Now().Truncate(time.Second).Add(time.Second)
+	rule, err := rrule.ParseRule("FREQ=SECONDLY;INTERVAL=1;COUNT=2", start)
+	assert.Nil(t, err)
+
+	var count atomic.Int32
+	id, err := scheduler.SetAtWithOptions("rule", func(_ WaitForContextDone) (any, error) {
+		count.Add(1)
+		return nil, nil
+	}, rule.Next(time.Time{}), NewTaskOptions().WithSchedule(rule).WithClockMode(ClockWall))
+	assert.Nil(t, err)
+
+	upcoming, err := scheduler.Upcoming(id, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{start, start.Add(time.Second)}, upcoming)
+
+	assert.Eventually(t, func() bool { return count.Load() == 2 && scheduler.Count() == 0 }, time.Second*4, time.Millisecond*20)
+}
@@ -0,0 +1,243 @@
+package kairos
+
+import (
+	"context"
+	"time"
+)
+
+// TaskOptions 是一个结构体，包含添加单个任务时的可选设置。
+// TaskOptions is a struct that contains the optional settings when adding a single task.
+type TaskOptions struct {
+	// labels 是任务的键值对标签，可以用于批量操作任务。
+	// labels are the key/value labels of the task, they can be used to operate on tasks in bulk.
+	labels Labels
+
+	// priority 是任务的优先级，数值越大越先执行，只在限制了并发数量时生效。
+	// priority is the priority of the task, a larger value starts first, it only takes effect when the concurrency is limited.
+	priority int
+
+	// group 是任务所属的组，只能通过 Group 的方法设置。
+	// group is the group the task belongs to, it can only be set by the methods of Group.
+	group *Group
+
+	// ctx 是绑定任务生命周期的调用者上下文，只能通过 SetAtWithContext 等方法设置。
+	// ctx is the caller context bound to the lifetime of the task, it can only be set by methods such as SetAtWithContext.
+	ctx context.Context
+
+	// schedule 是周期任务的时间表，为 nil 表示任务只执行一次。
+	// schedule is the timetable of a recurring task, nil means the task runs once.
+	schedule Schedule
+
+	// misfireThreshold 是任务允许的最大延迟，只在 misfireSet 为 true 时生效。
+	// misfireThreshold is the maximum lag allowed for the task, it only takes effect when misfireSet is true.
+	misfireThreshold time.Duration
+
+	// misfirePolicy 是延迟超过阈值时的处理策略，只在 misfireSet 为 true 时生效。
+	// misfirePolicy is the policy applied when the lag exceeds the threshold, it only takes effect when misfireSet is true.
+	misfirePolicy MisfirePolicy
+
+	// misfireSet 表示任务覆盖了调度器的 WithMisfire 设置。
+	// misfireSet indicates the task overrides the WithMisfire setting of the scheduler.
+	misfireSet bool
+
+	// clockMode 是任务使用的时钟，只在 clockSet 为 true 时生效。
+	// clockMode is the clock used by the task, it only takes effect when clockSet is true.
+	clockMode ClockMode
+
+	// clockSet 表示任务覆盖了默认的时钟。
+	// clockSet indicates the task overrides the default clock.
+	clockSet bool
+
+	// relative 表示任务的执行时间由相对的延迟计算得到，没有设置时钟时使用单调时钟。
+	// relative indicates the execution time of the task is calculated from a relative delay, the monotonic clock is used if no clock is set.
+	relative bool
+
+	// uniqued 表示任务的名称在调度器中唯一，即使调度器没有设置 WithUniqued。
+	// uniqued indicates the name of the task is unique in the scheduler, even if WithUniqued is not set for the scheduler.
+	uniqued bool
+
+	// payload 是任务的数据，批量执行时传递给批量处理函数。
+	// payload is the data of the task, it is passed to the batch handling function in batch execution.
+	payload any
+
+	// tenant 是任务所属的租户，为空表示不属于任何租户。
+	// tenant is the tenant the task belongs to, empty means it does not belong to any tenant.
+	tenant string
+
+	// waitCtx 是容量不足时等待的上下文，为 nil 表示立即拒绝任务。
+	// waitCtx is the context to wait with when there is not enough capacity, nil means the task is rejected immediately.
+	waitCtx context.Context
+
+	// business 是工作时间的延迟，只在使用 SetBusinessDelay 添加任务时设置
+	// business is the delay in business time, it is only set when the task is added with SetBusinessDelay
+	business *business
+
+	// id 是任务的 ID，为空时使用调度器的 ID 生成函数生成一个新的 ID。
+	// id is the ID of the task, a new ID is generated with the ID generator of the scheduler when it is empty.
+	id string
+
+	// restored 表示任务是从 Store 中恢复的，它不需要再次被保存。
+	// restored indicates the task is restored from the Store, it does not need to be saved again.
+	restored bool
+}
+
+// NewTaskOptions 是一个函数，用于创建一个新的 TaskOptions 实例
+// NewTaskOptions is a function used to create a new instance of TaskOptions
+func NewTaskOptions() *TaskOptions {
+	return &TaskOptions{}
+}
+
+// WithLabels 是一个方法，用于设置任务的标签，标签会被复制，之后修改传入的 labels 不会影响任务。
+// WithLabels is a method used to set the labels of the task, the labels are copied so modifying the passed labels afterwards does not affect the task.
+func (o *TaskOptions) WithLabels(labels Labels) *TaskOptions {
+	// 复制标签。
+	// Copy the labels.
+	o.labels = labels.clone()
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithPriority 是一个方法，用于设置任务的优先级，默认为 0，可以为负数。
+// 在配置了 WithMaxConcurrency 的调度器中，多个任务同时到期并且执行槽位不足时，优先级高的任务先执行。
+// WithPriority is a method used to set the priority of the task, the default is 0 and it can be negative.
+// In a scheduler configured with WithMaxConcurrency, when several tasks expire at the same time and there are not enough execution slots, tasks with higher priority run first.
+func (o *TaskOptions) WithPriority(priority int) *TaskOptions {
+	// 设置优先级。
+	// Set the priority.
+	o.priority = priority
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithSchedule 是一个方法，用于将任务设置为周期任务，例如 Every(time.Minute)。任务每次触发之后，使用时间表计算下一次执行的时间，
+// 任务的 ID 保持不变，直到时间表没有下一次执行或者任务被删除。
+// WithSchedule is a method used to make the task a recurring task, for example Every(time.Minute). After every firing, the next execution time is calculated from the timetable,
+// the ID of the task stays the same until the timetable has no next run or the task is deleted.
+func (o *TaskOptions) WithSchedule(schedule Schedule) *TaskOptions {
+	// 设置时间表。
+	// Set the timetable.
+	o.schedule = schedule
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithMisfire 是一个方法，用于设置任务允许的最大延迟和延迟超过阈值时的处理策略，覆盖调度器的 WithMisfire 设置，阈值不大于 0 表示不检查延迟。
+// WithMisfire is a method used to set the maximum lag allowed for the task and the policy applied when the lag exceeds it, overriding WithMisfire of the scheduler, a threshold not greater than 0 means the lag is not checked.
+func (o *TaskOptions) WithMisfire(threshold time.Duration, policy MisfirePolicy) *TaskOptions {
+	// 设置阈值和策略。
+	// Set the threshold and the policy.
+	o.misfireThreshold = threshold
+	o.misfirePolicy = policy
+	o.misfireSet = true
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithClockMode 是一个方法，用于设置任务使用的时钟，覆盖默认的时钟：相对的延迟（Set 等方法）默认使用单调时钟，绝对的执行时间（SetAt 等方法）默认使用调度器的 WithClockMode 设置。
+// WithClockMode is a method used to set the clock used by the task, overriding the default clock: relative delays (methods such as Set) use the monotonic clock by default, absolute execution times (methods such as SetAt) use WithClockMode of the scheduler by default.
+func (o *TaskOptions) WithClockMode(mode ClockMode) *TaskOptions {
+	// 设置时钟。
+	// Set the clock.
+	o.clockMode = mode
+	o.clockSet = true
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithUniqued 是一个方法，用于使任务的名称在调度器中唯一，即使调度器没有设置 WithUniqued。同名的唯一任务已经存在时，返回它的 ID，不添加新的任务。
+// WithUniqued is a method used to make the name of the task unique in the scheduler, even if WithUniqued is not set for the scheduler. If a unique task with the same name already exists, its ID is returned and no new task is added.
+func (o *TaskOptions) WithUniqued(uniqued bool) *TaskOptions {
+	// 设置唯一性。
+	// Set the uniqueness.
+	o.uniqued = uniqued
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithPayload 是一个方法，用于设置任务的数据。任务的名称配置了批量执行（Config 的 WithBatch）时，数据通过 BatchItem 传递给批量处理函数。
+// WithPayload is a method used to set the data of the task. When batch execution is configured for the name of the task (WithBatch of Config), the data is passed to the batch handling function through BatchItem.
+func (o *TaskOptions) WithPayload(payload any) *TaskOptions {
+	// 设置数据。
+	// Set the data.
+	o.payload = payload
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithTenant 是一个方法，用于设置任务所属的租户。租户的任务共享租户的任务数量和并发限制，
+// 在配置了 WithMaxConcurrency 的调度器中，执行槽位按照租户的权重公平地分配，参见 Config 的 WithTenant。
+// WithTenant is a method used to set the tenant the task belongs to. The tasks of a tenant share the limits of the number of tasks and of the concurrency of the tenant,
+// in a scheduler configured with WithMaxConcurrency, execution slots are shared fairly among the tenants by their weights, see WithTenant of Config.
+func (o *TaskOptions) WithTenant(tenant string) *TaskOptions {
+	// 设置租户。
+	// Set the tenant.
+	o.tenant = tenant
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithID 是一个方法，用于指定任务的 ID，而不是使用调度器的 ID 生成函数，为空时生成 ID。
+// 指定的 ID 可以和调用者自己的实体关联，如果调度器中已经存在相同 ID 的任务，添加任务返回 ErrorTaskIDConflict。
+// WithID is a method used to specify the ID of the task instead of using the ID generator of the scheduler, an ID is generated if it is empty.
+// The specified ID can be correlated with the entities of the caller, adding the task returns ErrorTaskIDConflict if a task with the same ID already exists in the scheduler.
+func (o *TaskOptions) WithID(id string) *TaskOptions {
+	// 设置任务的 ID。
+	// Set the ID of the task.
+	o.id = id
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// WithWaitForCapacity 是一个方法，用于在调度器、任务名称或者组达到容量限制时等待，而不是立即返回包装了 ErrorSchedulerFull 的错误。
+// 添加任务会阻塞直到有任务被移除，ctx 结束时返回拒绝的原因，调度器停止时返回 ErrorSchedulerNotRunning。
+// WithWaitForCapacity is a method used to wait when the scheduler, the task name or the group reached a capacity limit, instead of returning an error wrapping ErrorSchedulerFull immediately.
+// Adding the task blocks until a task is removed, the reason of the rejection is returned when ctx ends, ErrorSchedulerNotRunning is returned when the scheduler stops.
+func (o *TaskOptions) WithWaitForCapacity(ctx context.Context) *TaskOptions {
+	// 设置等待的上下文。
+	// Set the context to wait with.
+	o.waitCtx = ctx
+
+	// 返回 TaskOptions 结构体的指针。
+	// Return the pointer to the TaskOptions struct.
+	return o
+}
+
+// isTaskOptionsValid 是一个函数，用于检查 TaskOptions 实例是否有效
+// isTaskOptionsValid is a function used to check if the instance of TaskOptions is valid
+func isTaskOptionsValid(opts *TaskOptions) *TaskOptions {
+	// 如果 opts 为 nil，使用默认的 TaskOptions 实例
+	// If opts is nil, use the default instance of TaskOptions
+	if opts == nil {
+		opts = NewTaskOptions()
+	}
+
+	// 返回 opts
+	// Return opts
+	return opts
+}
+
+// relativeOptions 是一个函数，返回标记为相对延迟的任务选项的副本
+// relativeOptions is a function that returns a copy of the task options marked as a relative delay
+func relativeOptions(opts *TaskOptions) *TaskOptions {
+	copied := *isTaskOptionsValid(opts)
+	copied.relative = true
+	return &copied
+}
@@ -0,0 +1,208 @@
+package kairos
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimitScope 是触发速率限制的范围
+// RateLimitScope is the scope of the firing rate limit
+type RateLimitScope int
+
+// 定义触发速率限制的范围
+// Define the scopes of the firing rate limit
+const (
+	// RateLimitGlobal 表示调度器中的所有任务共享一个令牌桶
+	// RateLimitGlobal means all tasks of the scheduler share one token bucket
+	RateLimitGlobal RateLimitScope = iota
+
+	// RateLimitName 表示每个任务名称使用一个令牌桶
+	// RateLimitName means every task name uses one token bucket
+	RateLimitName
+
+	// RateLimitGroup 表示每个组使用一个令牌桶，不属于任何组的任务共享一个令牌桶
+	// RateLimitGroup means every group uses one token bucket, tasks not belonging to any group share one token bucket
+	RateLimitGroup
+)
+
+// String 方法返回速率限制范围的名称
+// The String method returns the name of the rate limit scope
+func (s RateLimitScope) String() string {
+	switch s {
+	case RateLimitGlobal:
+		return "global"
+	case RateLimitName:
+		return "name"
+	case RateLimitGroup:
+		return "group"
+	}
+	return "unknown"
+}
+
+// RateLimitCallback 是一个可选的接口，如果配置的 Callback 同时实现了它，到期的任务因为速率限制被推迟时，调度器会在推迟之前调用它
+// RateLimitCallback is an optional interface, if the configured Callback also implements it, the scheduler calls it before delaying an expired task held back by the rate limit
+type RateLimitCallback interface {
+	// OnTaskThrottled 是当任务因为速率限制被推迟时的回调函数，它接收任务 id、任务名称和推迟的时间作为参数
+	// OnTaskThrottled is the callback function when a task is delayed by the rate limit, it takes the task id, task name and the delay as parameters
+	OnTaskThrottled(id, name string, delay time.Duration)
+}
+
+// pruneThreshold 是按范围划分的令牌桶数量的初始清理阈值，超过之后删除已经装满的令牌桶
+// pruneThreshold is the initial cleanup threshold of the number of scoped token buckets, full token buckets are deleted beyond it
+const pruneThreshold = 1024
+
+// tokenBucket 结构体是一个令牌桶。令牌可以被预支，令牌数量为负数时，预支的调用者需要等待令牌补充
+// The tokenBucket struct is a token bucket. Tokens can be borrowed in advance, when the number of tokens is negative the borrowing caller has to wait for the tokens to refill
+type tokenBucket struct {
+	// tokens 是当前的令牌数量，可以为负数
+	// tokens is the current number of tokens, it can be negative
+	tokens float64
+
+	// last 是最后一次补充令牌的时间
+	// last is the time of the last refill
+	last time.Time
+}
+
+// rateLimiter 结构体按照范围管理令牌桶，限制任务触发的速率
+// The rateLimiter struct manages the token buckets by scope, it limits the firing rate of tasks
+type rateLimiter struct {
+	// lock 用于保护 buckets 和 prune
+	// lock is used to protect buckets and prune
+	lock sync.Mutex
+
+	// rate 是每秒补充的令牌数量
+	// rate is the number of tokens refilled per second
+	rate float64
+
+	// burst 是令牌桶的容量
+	// burst is the capacity of the token bucket
+	burst float64
+
+	// scope 是令牌桶的范围
+	// scope is the scope of the token buckets
+	scope RateLimitScope
+
+	// buckets 是范围的键到令牌桶的映射
+	// buckets is the map from the key of the scope to the token bucket
+	buckets map[string]*tokenBucket
+
+	// prune 是下一次清理令牌桶的数量阈值
+	// prune is the threshold of the number of token buckets for the next cleanup
+	prune int
+}
+
+// newRateLimiter 函数创建一个新的 rateLimiter 实例，容量小于 1 时使用 1
+// The newRateLimiter function creates a new rateLimiter instance, 1 is used if the burst is less than 1
+func newRateLimiter(rate float64, burst int, scope RateLimitScope) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), scope: scope, buckets: make(map[string]*tokenBucket), prune: pruneThreshold}
+}
+
+// key 方法返回任务在令牌桶映射中的键
+// The key method returns the key of the task in the map of token buckets
+func (l *rateLimiter) key(group *Group, metadata *TaskMetadata) string {
+	switch l.scope {
+	case RateLimitName:
+		return metadata.name
+	case RateLimitGroup:
+		if group != nil {
+			return group.name
+		}
+	}
+	return ""
+}
+
+// reserve 方法从任务的令牌桶中预支一个令牌，返回任务需要等待的时间
+// The reserve method borrows a token from the token bucket of the task, it returns the time the task has to wait
+func (l *rateLimiter) reserve(group *Group, metadata *TaskMetadata, now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	// 获取或者创建装满令牌的令牌桶
+	// Get or create the token bucket filled with tokens
+	key := l.key(group, metadata)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		l.cleanup(now)
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	// 按照经过的时间补充令牌，然后预支一个令牌
+	// Refill the tokens according to the elapsed time, then borrow one token
+	l.refill(bucket, now)
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / l.rate * float64(time.Second))
+}
+
+// refill 方法按照经过的时间补充令牌，令牌数量不超过容量，调用者需要持有锁
+// The refill method refills the tokens according to the elapsed time, the number of tokens does not exceed the burst, the caller must hold the lock
+func (l *rateLimiter) refill(bucket *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(bucket.last); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+		bucket.last = now
+	}
+}
+
+// cleanup 方法在令牌桶的数量达到阈值时删除已经装满的令牌桶，它们与新建的令牌桶没有区别，调用者需要持有锁
+// The cleanup method deletes the full token buckets when the number of token buckets reaches the threshold, they are no different from new token buckets, the caller must hold the lock
+func (l *rateLimiter) cleanup(now time.Time) {
+	if len(l.buckets) < l.prune {
+		return
+	}
+	for key, bucket := range l.buckets {
+		if l.refill(bucket, now); bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	if l.prune = len(l.buckets) * 2; l.prune < pruneThreshold {
+		l.prune = pruneThreshold
+	}
+}
+
+// throttle 是一个方法，按照所属组和调度器的速率限制推迟任务的处理函数，阻塞直到任务可以执行或者调度器停止，返回推迟的时间
+// throttle is a method delaying the handling function of the task according to the rate limits of its group and of the scheduler, it blocks until the task may run or the scheduler stops, it returns the delay
+func (s *Scheduler) throttle(group *Group, metadata *TaskMetadata) time.Duration {
+	// 同时从组和调度器的令牌桶中预支令牌，等待其中较长的时间
+	// Borrow tokens from the token buckets of the group and of the scheduler at the same time, wait for the longer of the two
+	now := time.Now()
+	var delay time.Duration
+	if group != nil && group.limiter != nil {
+		delay = group.limiter.reserve(group, metadata, now)
+	}
+	if s.limiter != nil {
+		if d := s.limiter.reserve(group, metadata, now); d > delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// 记录推迟的时间，并通知任务被推迟
+	// Record the delay, and notify that the task is delayed
+	metadata.throttled = delay
+	s.counters.throttled.Add(1)
+	s.counters.throttledTime.Add(int64(delay))
+	if callback, ok := s.cfg.callback.(RateLimitCallback); ok {
+		callback.OnTaskThrottled(metadata.id, metadata.name, delay)
+	}
+
+	// 等待推迟的时间，调度器停止时立即执行，避免阻塞停止的过程
+	// Wait for the delay, run immediately when the scheduler stops, to avoid blocking the stopping process
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-s.ctx.Done():
+	}
+	return delay
+}
@@ -0,0 +1,186 @@
+package kairos
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type throttleCallback struct {
+	EmptyCallback
+	lock   sync.Mutex
+	delays map[string]time.Duration
+}
+
+func (c *throttleCallback) OnTaskThrottled(id, name string, delay time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.delays[id] = delay
+}
+
+func (c *throttleCallback) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.delays)
+}
+
+func TestRateLimitScope_String(t *testing.T) {
+	assert.Equal(t, "global", RateLimitGlobal.String())
+	assert.Equal(t, "name", RateLimitName.String())
+	assert.Equal(t, "group", RateLimitGroup.String())
+	assert.Equal(t, "unknown", RateLimitScope(9).String())
+}
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	l := newRateLimiter(10, 2, RateLimitGlobal)
+	now := time.Now()
+	metadata := &TaskMetadata{name: "a"}
+
+	// The burst is available immediately, later tasks wait one interval each
+	assert.Equal(t, time.Duration(0), l.reserve(nil, metadata, now))
+	assert.Equal(t, time.Duration(0), l.reserve(nil, metadata, now))
+	assert.Equal(t, 100*time.Millisecond, l.reserve(nil, metadata, now))
+	assert.Equal(t, 200*time.Millisecond, l.reserve(nil, metadata, now))
+
+	// The borrowed tokens are paid back before new tokens accumulate
+	assert.Equal(t, 100*time.Millisecond, l.reserve(nil, metadata, now.Add(200*time.Millisecond)))
+
+	// The bucket never holds more than the burst
+	assert.Equal(t, time.Duration(0), l.reserve(nil, metadata, now.Add(time.Hour)))
+	assert.Equal(t, time.Duration(0), l.reserve(nil, metadata, now.Add(time.Hour)))
+	assert.Equal(t, 100*time.Millisecond, l.reserve(nil, metadata, now.Add(time.Hour)))
+
+	// A burst less than 1 is raised to 1
+	assert.Equal(t, float64(1), newRateLimiter(1, 0, RateLimitGlobal).burst)
+}
+
+func TestRateLimiter_Scope(t *testing.T) {
+	now := time.Now()
+	group := &Group{name: "g"}
+
+	// Every task name has its own bucket
+	l := newRateLimiter(1, 1, RateLimitName)
+	assert.Equal(t, time.Duration(0), l.reserve(nil, &TaskMetadata{name: "a"}, now))
+	assert.Equal(t, time.Duration(0), l.reserve(nil, &TaskMetadata{name: "b"}, now))
+	assert.Equal(t, time.Second, l.reserve(nil, &TaskMetadata{name: "a"}, now))
+
+	// Every group has its own bucket, tasks without a group share one
+	l = newRateLimiter(1, 1, RateLimitGroup)
+	assert.Equal(t, time.Duration(0), l.reserve(group, &TaskMetadata{name: "a"}, now))
+	assert.Equal(t, time.Duration(0), l.reserve(nil, &TaskMetadata{name: "a"}, now))
+	assert.Equal(t, time.Second, l.reserve(group, &TaskMetadata{name: "b"}, now))
+	assert.Equal(t, time.Second, l.reserve(nil, &TaskMetadata{name: "b"}, now))
+
+	// The global scope shares one bucket
+	l = newRateLimiter(1, 1, RateLimitGlobal)
+	assert.Equal(t, time.Duration(0), l.reserve(group, &TaskMetadata{name: "a"}, now))
+	assert.Equal(t, time.Second, l.reserve(nil, &TaskMetadata{name: "b"}, now))
+}
+
+func TestRateLimiter_Cleanup(t *testing.T) {
+	l := newRateLimiter(1, 1, RateLimitName)
+	now := time.Now()
+	for i := 0; i < pruneThreshold; i++ {
+		l.reserve(nil, &TaskMetadata{name: fmt.Sprint(i)}, now)
+	}
+	assert.Equal(t, pruneThreshold, len(l.buckets))
+
+	// Buckets that refilled completely are deleted when a new bucket is created past the threshold
+	l.reserve(nil, &TaskMetadata{name: "0"}, now.Add(500*time.Millisecond))
+	l.reserve(nil, &TaskMetadata{name: "new"}, now.Add(2*time.Second))
+	assert.Equal(t, 1, len(l.buckets))
+	assert.Equal(t, pruneThreshold, l.prune)
+}
+
+func TestScheduler_RateLimit(t *testing.T) {
+	callback := &throttleCallback{delays: make(map[string]time.Duration)}
+	scheduler := New(NewConfig().WithCallback(callback).WithRateLimit(20, 1, RateLimitGlobal))
+	defer scheduler.Stop()
+
+	events, cancel := scheduler.Subscribe(16)
+	defer cancel()
+
+	var lock sync.Mutex
+	started := make([]time.Time, 0, 5)
+	handleFunc := func(done WaitForContextDone) (interface{}, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		started = append(started, time.Now())
+		return nil, nil
+	}
+
+	// All tasks expire at once, they are spread out by the rate limit instead of being dropped
+	execAt := time.Now().Add(20 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		_, err := scheduler.SetAt("test", handleFunc, execAt)
+		assert.Nil(t, err)
+	}
+	assert.Eventually(t, func() bool { return scheduler.Count() == 0 }, 2*time.Second, time.Millisecond)
+
+	lock.Lock()
+	assert.Equal(t, 5, len(started))
+	assert.GreaterOrEqual(t, started[4].Sub(started[0]), 150*time.Millisecond)
+	lock.Unlock()
+
+	// The delay is reported in the callback, the statistics and the events
+	assert.Equal(t, 4, callback.count())
+	stats := scheduler.Stats()
+	assert.Equal(t, uint64(4), stats.Throttled)
+	assert.GreaterOrEqual(t, stats.ThrottledTime, 4*25*time.Millisecond)
+	assert.Equal(t, uint64(5), stats.Executed)
+
+	executed, throttled := 0, 0
+	for executed < 5 {
+		if event := <-events; event.Type == EventTaskExecuted {
+			executed++
+			if event.Throttled > 0 {
+				throttled++
+			}
+		}
+	}
+	assert.Equal(t, 4, throttled)
+}
+
+func TestGroup_RateLimit(t *testing.T) {
+	callback := &throttleCallback{delays: make(map[string]time.Duration)}
+	scheduler := New(NewConfig().WithCallback(callback))
+	defer scheduler.Stop()
+
+	group := scheduler.GroupWithConfig("limited", NewGroupConfig().WithRateLimit(1, 1))
+
+	// Only the tasks of the limited group are delayed
+	execAt := time.Now().Add(10 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, err := scheduler.SetAt("free", nil, execAt)
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := group.SetAt("limited", nil, execAt)
+		assert.Nil(t, err)
+	}
+
+	assert.Eventually(t, func() bool { return scheduler.Count() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, callback.count())
+	assert.Equal(t, 1, group.Count())
+
+	// Stopping the scheduler releases the delayed task immediately
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("stop blocked by the rate limit")
+	}
+
+	callback.lock.Lock()
+	for _, delay := range callback.delays {
+		assert.Greater(t, delay, 900*time.Millisecond)
+	}
+	callback.lock.Unlock()
+}
@@ -0,0 +1,121 @@
+package kairos
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 定义注册表的错误
+// Define the errors of the registry
+var (
+	// ErrorHandlerNotFound 表示没有找到注册的处理函数
+	// ErrorHandlerNotFound indicates the registered handling function was not found
+	ErrorHandlerNotFound = errors.New("handler not found")
+)
+
+// Registry 结构体按名称保存任务的处理函数，使任务可以通过处理函数的名称来定义、导出和导入
+// The Registry struct keeps handling functions of tasks by name, so tasks can be defined, exported and imported by the name of their handling function
+type Registry struct {
+	// lock 用于保护 handlers
+	// lock is used to protect handlers
+	lock sync.RWMutex
+
+	// handlers 是处理函数的名称到处理函数的映射
+	// handlers is the map from the name of a handling function to the handling function
+	handlers map[string]TaskHandleFunc
+}
+
+// NewRegistry 函数用于创建一个新的 Registry 实例
+// The NewRegistry function is used to create a new instance of Registry
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]TaskHandleFunc)}
+}
+
+// Register 方法使用给定的名称注册一个处理函数，相同名称的处理函数会被覆盖
+// The Register method registers a handling function with the given name, a handling function with the same name is overwritten
+func (r *Registry) Register(name string, handleFunc TaskHandleFunc) *Registry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.handlers[name] = handleFunc
+	return r
+}
+
+// Lookup 方法根据名称获取处理函数
+// The Lookup method gets the handling function by name
+func (r *Registry) Lookup(name string) (TaskHandleFunc, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	handleFunc, ok := r.handlers[name]
+	return handleFunc, ok
+}
+
+// Names 方法返回所有注册的处理函数的名称，名称按照字母顺序排列
+// The Names method returns the names of all registered handling functions in alphabetical order
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TaskDefinition 结构体是任务的可序列化定义，处理函数通过注册表中的名称引用
+// The TaskDefinition struct is the serializable definition of a task, the handling function is referenced by its name in the registry
+type TaskDefinition struct {
+	// ID 是任务的 id，导入时会被忽略
+	// ID is the id of the task, it is ignored when importing
+	ID string `json:"id,omitempty"`
+
+	// Name 是任务的名称
+	// Name is the name of the task
+	Name string `json:"name"`
+
+	// Handler 是注册表中处理函数的名称
+	// Handler is the name of the handling function in the registry
+	Handler string `json:"handler"`
+
+	// ExecAt 是任务计划执行的时间
+	// ExecAt is the scheduled execution time of the task
+	ExecAt time.Time `json:"exec_at"`
+
+	// Labels 是任务的标签
+	// Labels are the labels of the task
+	Labels Labels `json:"labels,omitempty"`
+
+	// Priority 是任务的优先级
+	// Priority is the priority of the task
+	Priority int `json:"priority,omitempty"`
+
+	// Tenant 是任务所属的租户
+	// Tenant is the tenant the task belongs to
+	Tenant string `json:"tenant,omitempty"`
+
+	// Schedule 是周期任务的时间表的文本，使用 ParseSchedule 解析，一次性任务为空
+	// Schedule is the text of the timetable of a recurring task, it is parsed with ParseSchedule, it is empty for one-off tasks
+	Schedule string `json:"schedule,omitempty"`
+
+	// Group 是任务所属的组的名称
+	// Group is the name of the group the task belongs to
+	Group string `json:"group,omitempty"`
+
+	// Uniqued 表示任务的名称是否唯一，组内唯一还是调度器内唯一由恢复任务的调度器中组的设置决定
+	// Uniqued indicates whether the name of the task is unique, whether it is unique within the group or within the scheduler is decided by the settings of the group in the scheduler restoring the task
+	Uniqued bool `json:"uniqued,omitempty"`
+
+	// MisfireThreshold 和 MisfirePolicy 是任务允许的最大延迟和延迟超过阈值时的处理策略，阈值为 0 时使用调度器的设置
+	// MisfireThreshold and MisfirePolicy are the maximum lag allowed for the task and the policy applied when the lag exceeds it, the settings of the scheduler are used if the threshold is 0
+	MisfireThreshold time.Duration `json:"misfire_threshold,omitempty"`
+	MisfirePolicy    MisfirePolicy `json:"misfire_policy,omitempty"`
+
+	// ClockMode 是任务使用的时钟
+	// ClockMode is the clock used by the task
+	ClockMode ClockMode `json:"clock_mode,omitempty"`
+}
@@ -0,0 +1,50 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry().
+		Register("b", DefaultTaskHandleFunc).
+		Register("a", DefaultTaskHandleFunc)
+
+	_, ok := registry.Lookup("a")
+	assert.True(t, ok)
+
+	_, ok = registry.Lookup("c")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"a", "b"}, registry.Names())
+}
+
+func TestScheduler_SetRegistered(t *testing.T) {
+	executed := make(chan struct{}, 1)
+	registry := NewRegistry().Register("notify", func(_ WaitForContextDone) (any, error) {
+		executed <- struct{}{}
+		return nil, nil
+	})
+
+	scheduler := New(NewConfig().WithRegistry(registry))
+	defer scheduler.Stop()
+
+	// Unknown handlers are rejected
+	_, err := scheduler.SetRegistered("test", "unknown", time.Millisecond*50)
+	assert.ErrorIs(t, err, ErrorHandlerNotFound)
+
+	taskID, err := scheduler.SetRegistered("test", "notify", time.Millisecond*50)
+	assert.Nil(t, err)
+
+	info, err := scheduler.GetInfo(taskID)
+	assert.Nil(t, err)
+	assert.Equal(t, "notify", info.Handler)
+
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("task should be executed")
+	}
+}
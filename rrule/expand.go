@@ -0,0 +1,419 @@
+package rrule
+
+import (
+	"sort"
+	"time"
+)
+
+// maxEmptyPeriods 是连续没有任何重复的周期的最大数量，超过之后认为规则没有下一次重复，避免无法满足的规则导致无限循环
+// maxEmptyPeriods is the maximum number of consecutive periods without any repetition, beyond it the rule is considered to have no next repetition, so an unsatisfiable rule does not loop forever
+const maxEmptyPeriods = 100000
+
+// maxEmptyYears 是没有任何重复的最大年数，公历每 400 年循环一次，可以满足的规则一定会在这个范围内重复
+// maxEmptyYears is the maximum number of years without any repetition, the Gregorian calendar repeats every 400 years, so a satisfiable rule always repeats within this range
+const maxEmptyYears = 400
+
+// Next 方法返回 after 之后的第一次重复，没有下一次重复时返回零值。它实现了 kairos.Schedule 接口
+// The Next method returns the first repetition after after, a zero value is returned if there is no next repetition. It implements the kairos.Schedule interface
+func (r *Rule) Next(after time.Time) time.Time {
+	var next time.Time
+	r.iterate(after, func(t time.Time) bool {
+		next = t
+		return false
+	})
+	return next
+}
+
+// Upcoming 方法返回 after 之后最多 n 次重复
+// The Upcoming method returns at most n repetitions after after
+func (r *Rule) Upcoming(after time.Time, n int) []time.Time {
+	occurrences := make([]time.Time, 0, n)
+	if n <= 0 {
+		return occurrences
+	}
+	r.iterate(after, func(t time.Time) bool {
+		occurrences = append(occurrences, t)
+		return len(occurrences) < n
+	})
+	return occurrences
+}
+
+// Between 方法返回在 [from, to) 范围内的所有重复
+// The Between method returns all repetitions within [from, to)
+func (r *Rule) Between(from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.iterate(from.Add(-time.Nanosecond), func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		occurrences = append(occurrences, t)
+		return true
+	})
+	return occurrences
+}
+
+// iterate 方法按照时间顺序对 after 之后的每一次重复调用 fn，fn 返回 false 时停止
+// The iterate method calls fn for every repetition after after in time order, it stops when fn returns false
+func (r *Rule) iterate(after time.Time, fn func(time.Time) bool) {
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	// 没有 COUNT 时直接跳到 after 所在的周期之前，COUNT 需要从第一个周期开始计数
+	// Without COUNT, jump right before the period of after, COUNT needs to count from the first period
+	k := 0
+	if r.Count == 0 {
+		k = r.periodsBefore(after, interval)
+	}
+
+	// 从 after 和开始时间中较晚的一个开始，超过 maxEmptyYears 年没有任何重复时停止
+	// Stop when there is no repetition for more than maxEmptyYears years since the later of after and the start
+	limit := r.Start
+	if after.After(limit) {
+		limit = after
+	}
+	limit = limit.AddDate(maxEmptyYears, 0, 0)
+
+	count, empty := 0, 0
+	for empty < maxEmptyPeriods {
+		p := r.period(k, interval)
+		candidates := r.expand(p)
+		k++
+		if len(candidates) == 0 {
+			if p.After(limit) {
+				return
+			}
+			empty++
+			continue
+		}
+		empty = 0
+		limit = p.AddDate(maxEmptyYears, 0, 0)
+
+		for _, t := range candidates {
+			if t.Before(r.Start) {
+				continue
+			}
+			if !r.Until.IsZero() && t.After(r.Until) {
+				return
+			}
+
+			// COUNT 计算被 EXDATE 排除之前的重复
+			// COUNT counts the repetitions before they are excluded by EXDATE
+			count++
+			if r.Count > 0 && count > r.Count {
+				return
+			}
+			if !t.After(after) || r.excluded(t) {
+				continue
+			}
+			if !fn(t) {
+				return
+			}
+		}
+	}
+}
+
+// excluded 方法返回重复是否被 EXDATE 排除
+// The excluded method returns whether the repetition is excluded by EXDATE
+func (r *Rule) excluded(t time.Time) bool {
+	if _, ok := r.exDays[t.Format("2006-01-02")]; ok {
+		return true
+	}
+	for _, exdate := range r.ExDates {
+		if exdate.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// periodStart 方法返回开始时间所在的周期的开始时间
+// The periodStart method returns the start of the period containing the start time
+func (r *Rule) periodStart() time.Time {
+	s := r.Start
+	year, month, day := s.Date()
+	switch r.Freq {
+	case Yearly:
+		return time.Date(year, 1, 1, 0, 0, 0, 0, s.Location())
+	case Monthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, s.Location())
+	case Weekly:
+		offset := (int(s.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, s.Location())
+	case Daily:
+		return time.Date(year, month, day, 0, 0, 0, 0, s.Location())
+	case Hourly:
+		return time.Date(year, month, day, s.Hour(), 0, 0, 0, s.Location())
+	case Minutely:
+		return time.Date(year, month, day, s.Hour(), s.Minute(), 0, 0, s.Location())
+	}
+	return s
+}
+
+// period 方法返回第 k 个周期的开始时间。天和更大的单位按照日历计算，小时和更小的单位按照经过的时间计算
+// The period method returns the start of the kth period. Days and larger units are calculated by the calendar, hours and smaller units by the elapsed time
+func (r *Rule) period(k, interval int) time.Time {
+	p := r.periodStart()
+	n := k * interval
+	switch r.Freq {
+	case Yearly:
+		return p.AddDate(n, 0, 0)
+	case Monthly:
+		return p.AddDate(0, n, 0)
+	case Weekly:
+		return p.AddDate(0, 0, 7*n)
+	case Daily:
+		return p.AddDate(0, 0, n)
+	case Hourly:
+		return p.Add(time.Duration(n) * time.Hour)
+	case Minutely:
+		return p.Add(time.Duration(n) * time.Minute)
+	}
+	return p.Add(time.Duration(n) * time.Second)
+}
+
+// periodsBefore 方法返回 after 所在的周期之前一个周期的序号，序号不小于 0
+// The periodsBefore method returns the index of the period right before the period containing after, the index is not less than 0
+func (r *Rule) periodsBefore(after time.Time, interval int) int {
+	p := r.periodStart()
+	if !after.After(p) {
+		return 0
+	}
+
+	var units int
+	switch r.Freq {
+	case Yearly:
+		units = after.In(p.Location()).Year() - p.Year()
+	case Monthly:
+		a := after.In(p.Location())
+		units = (a.Year()-p.Year())*12 + int(a.Month()) - int(p.Month())
+	case Weekly:
+		units = int(after.Sub(p) / (7 * 24 * time.Hour))
+	case Daily:
+		units = int(after.Sub(p) / (24 * time.Hour))
+	case Hourly:
+		units = int(after.Sub(p) / time.Hour)
+	case Minutely:
+		units = int(after.Sub(p) / time.Minute)
+	default:
+		units = int(after.Sub(p) / time.Second)
+	}
+
+	// 多退一个周期，弥补夏令时等造成的误差
+	// Step back one more period, to make up for errors caused by daylight saving time and such
+	if k := units/interval - 1; k > 0 {
+		return k
+	}
+	return 0
+}
+
+// expand 方法返回从 p 开始的周期内的所有候选重复，按照时间排序并应用了 BYSETPOS
+// The expand method returns all candidate repetitions within the period starting at p, sorted by time with BYSETPOS applied
+func (r *Rule) expand(p time.Time) []time.Time {
+	location := r.Start.Location()
+
+	// 周期内的天
+	// The days within the period
+	days := 1
+	switch r.Freq {
+	case Yearly:
+		days = daysIn(p.Year())
+	case Monthly:
+		days = daysInMonth(p.Year(), p.Month())
+	case Weekly:
+		days = 7
+	}
+
+	// 周期内的小时、分钟和秒，小于等于频率的单位只能是周期本身的值，大于频率的单位使用 BYxxx 展开，没有时使用开始时间的值
+	// The hours, minutes and seconds within the period, units not larger than the frequency can only be the value of the period itself, units larger than the frequency are expanded with BYxxx, or use the value of the start if there is none
+	hours := r.units(r.ByHour, Hourly, p.Hour(), r.Start.Hour())
+	minutes := r.units(r.ByMinute, Minutely, p.Minute(), r.Start.Minute())
+	seconds := r.units(r.BySecond, Secondly, p.Second(), r.Start.Second())
+
+	var candidates []time.Time
+	year, month, day := p.Date()
+	for i := 0; i < days; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, location)
+		if !r.matchDay(date) {
+			continue
+		}
+		y, m, d := date.Date()
+		for _, h := range hours {
+			for _, mi := range minutes {
+				for _, sec := range seconds {
+					candidates = append(candidates, time.Date(y, m, d, h, mi, sec, 0, location))
+				}
+			}
+		}
+	}
+
+	// 展开的值可能没有排序，夏令时切换也可能产生重复的时间
+	// The expanded values may be unsorted, and daylight saving switches may produce duplicated times
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	unique := candidates[:0]
+	for _, t := range candidates {
+		if len(unique) == 0 || !t.Equal(unique[len(unique)-1]) {
+			unique = append(unique, t)
+		}
+	}
+	return r.setPos(unique)
+}
+
+// units 方法返回周期内某个时间单位的取值列表
+// The units method returns the list of values of a time unit within the period
+func (r *Rule) units(by []int, unit Frequency, current, start int) []int {
+	if r.Freq <= unit {
+		if len(by) == 0 || contains(by, current) {
+			return []int{current}
+		}
+		return nil
+	}
+	if len(by) == 0 {
+		return []int{start}
+	}
+	values := append([]int(nil), by...)
+	sort.Ints(values)
+	return values
+}
+
+// matchDay 方法返回日期是否满足规则中和日期相关的部分，没有任何日期相关的部分时使用开始时间补全
+// The matchDay method returns whether the date satisfies the date related parts of the rule, the start is used to fill in when there are no date related parts
+func (r *Rule) matchDay(date time.Time) bool {
+	year, month, day := date.Date()
+	if len(r.ByMonth) > 0 && !contains(r.ByMonth, int(month)) {
+		return false
+	}
+	if len(r.ByWeekNo) > 0 && !r.matchWeekNo(date) {
+		return false
+	}
+	if len(r.ByYearDay) > 0 && !matchOrdinal(r.ByYearDay, date.YearDay(), daysIn(year)) {
+		return false
+	}
+	if len(r.ByMonthDay) > 0 && !matchOrdinal(r.ByMonthDay, day, daysInMonth(year, month)) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !r.matchWeekday(date) {
+		return false
+	}
+
+	// 没有设置的部分使用开始时间补全
+	// The parts not set are filled in with the start
+	noDays := len(r.ByYearDay) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0
+	switch r.Freq {
+	case Yearly:
+		if len(r.ByWeekNo) > 0 {
+			return !noDays || date.Weekday() == r.Start.Weekday()
+		}
+		if noDays && len(r.ByMonth) == 0 && month != r.Start.Month() {
+			return false
+		}
+		return !noDays || day == r.Start.Day()
+	case Monthly:
+		return !noDays || day == r.Start.Day()
+	case Weekly:
+		return len(r.ByDay) > 0 || date.Weekday() == r.Start.Weekday()
+	}
+	return true
+}
+
+// matchWeekday 方法返回日期是否满足 BYDAY，带有序号的项在月内或者年内计算
+// The matchWeekday method returns whether the date satisfies BYDAY, items with an ordinal are counted within the month or the year
+func (r *Rule) matchWeekday(date time.Time) bool {
+	year, month, day := date.Date()
+	for _, wd := range r.ByDay {
+		if wd.Day != date.Weekday() {
+			continue
+		}
+		if wd.N == 0 {
+			return true
+		}
+
+		// 频率为每月，或者每年并且设置了 BYMONTH 时在月内计算，否则在年内计算
+		// Counted within the month when the frequency is monthly, or yearly with BYMONTH set, otherwise within the year
+		index, total := date.YearDay(), daysIn(year)
+		if r.Freq == Monthly || len(r.ByMonth) > 0 {
+			index, total = day, daysInMonth(year, month)
+		}
+		if wd.N == (index-1)/7+1 || wd.N == -((total-index)/7+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekNo 方法返回日期是否满足 BYWEEKNO。第一周是包含至少四天的第一个以 WeekStart 开始的周
+// The matchWeekNo method returns whether the date satisfies BYWEEKNO. The first week is the first week starting on WeekStart that contains at least four days
+func (r *Rule) matchWeekNo(date time.Time) bool {
+	year := date.Year()
+	first := r.firstWeek(year, date.Location())
+	if date.Before(first) {
+		year--
+		first = r.firstWeek(year, date.Location())
+	} else if next := r.firstWeek(year+1, date.Location()); !date.Before(next) {
+		year++
+		first = next
+	}
+	weeks := int(r.firstWeek(year+1, date.Location()).Sub(first).Hours()+12) / (24 * 7)
+	week := int(date.Sub(first).Hours()+12)/(24*7) + 1
+	return matchOrdinal(r.ByWeekNo, week, weeks)
+}
+
+// firstWeek 方法返回指定年份的第一周的开始日期
+// The firstWeek method returns the start date of the first week of the specified year
+func (r *Rule) firstWeek(year int, location *time.Location) time.Time {
+	offset := (int(time.Date(year, 1, 1, 0, 0, 0, 0, location).Weekday()) - int(r.WeekStart) + 7) % 7
+	if 7-offset >= 4 {
+		return time.Date(year, 1, 1-offset, 0, 0, 0, 0, location)
+	}
+	return time.Date(year, 1, 1+7-offset, 0, 0, 0, 0, location)
+}
+
+// setPos 方法对周期内排序之后的候选重复应用 BYSETPOS
+// The setPos method applies BYSETPOS to the sorted candidate repetitions within a period
+func (r *Rule) setPos(candidates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 || len(candidates) == 0 {
+		return candidates
+	}
+	selected := make([]time.Time, 0, len(r.BySetPos))
+	for i, t := range candidates {
+		if matchOrdinal(r.BySetPos, i+1, len(candidates)) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// matchOrdinal 函数返回从 1 开始的序号 index 是否在列表中，负数表示从末尾计算，total 是总数
+// The matchOrdinal function returns whether the 1-based ordinal index is in the list, negative values count from the end, total is the total number
+func matchOrdinal(list []int, index, total int) bool {
+	for _, n := range list {
+		if n == index || n == index-total-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// contains 函数返回列表是否包含指定的值
+// The contains function returns whether the list contains the specified value
+func contains(list []int, value int) bool {
+	for _, n := range list {
+		if n == value {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn 函数返回指定年份的天数
+// The daysIn function returns the number of days of the specified year
+func daysIn(year int) int {
+	return time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// daysInMonth 函数返回指定月份的天数
+// The daysInMonth function returns the number of days of the specified month
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,121 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newYork(t *testing.T) *time.Location {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	return location
+}
+
+func mustParse(t *testing.T, text string) *Rule {
+	rule, err := Parse(text)
+	assert.Nil(t, err)
+	return rule
+}
+
+// dates formats the occurrences as local dates and times for compact comparisons
+func dates(occurrences []time.Time) []string {
+	formatted := make([]string, 0, len(occurrences))
+	for _, t := range occurrences {
+		formatted = append(formatted, t.Format("2006-01-02 15:04"))
+	}
+	return formatted
+}
+
+func TestRule_Examples(t *testing.T) {
+	location := newYork(t)
+	before := time.Date(1997, 1, 1, 0, 0, 0, 0, location)
+
+	for _, c := range []struct {
+		name  string
+		start string
+		rule  string
+		n     int
+		want  []string
+	}{
+		{"daily count", "19970902T090000", "FREQ=DAILY;COUNT=3", 10, []string{"1997-09-02 09:00", "1997-09-03 09:00", "1997-09-04 09:00"}},
+		{"weekly until", "19970902T090000", "FREQ=WEEKLY;UNTIL=19971007T000000Z;WKST=SU;BYDAY=TU,TH", 20, []string{
+			"1997-09-02 09:00", "1997-09-04 09:00", "1997-09-09 09:00", "1997-09-11 09:00", "1997-09-16 09:00",
+			"1997-09-18 09:00", "1997-09-23 09:00", "1997-09-25 09:00", "1997-09-30 09:00", "1997-10-02 09:00"}},
+		{"week start monday", "19970805T090000", "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO", 10, []string{
+			"1997-08-05 09:00", "1997-08-10 09:00", "1997-08-19 09:00", "1997-08-24 09:00"}},
+		{"week start sunday", "19970805T090000", "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU", 10, []string{
+			"1997-08-05 09:00", "1997-08-17 09:00", "1997-08-19 09:00", "1997-08-31 09:00"}},
+		{"first friday", "19970905T090000", "FREQ=MONTHLY;COUNT=4;BYDAY=1FR", 10, []string{
+			"1997-09-05 09:00", "1997-10-03 09:00", "1997-11-07 09:00", "1997-12-05 09:00"}},
+		{"last friday", "19970902T090000", "FREQ=MONTHLY;BYDAY=-1FR", 4, []string{
+			"1997-09-26 09:00", "1997-10-31 09:00", "1997-11-28 09:00", "1997-12-26 09:00"}},
+		{"last workday", "19970902T090000", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", 4, []string{
+			"1997-09-30 09:00", "1997-10-31 09:00", "1997-11-28 09:00", "1997-12-31 09:00"}},
+		{"third to last day", "19970928T090000", "FREQ=MONTHLY;BYMONTHDAY=-3", 4, []string{
+			"1997-09-28 09:00", "1997-10-29 09:00", "1997-11-28 09:00", "1997-12-29 09:00"}},
+		{"friday the 13th", "19970902T090000", "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", 4, []string{
+			"1998-02-13 09:00", "1998-03-13 09:00", "1998-11-13 09:00", "1999-08-13 09:00"}},
+		{"june and july", "19970610T090000", "FREQ=YEARLY;COUNT=4;BYMONTH=6,7", 10, []string{
+			"1997-06-10 09:00", "1997-07-10 09:00", "1998-06-10 09:00", "1998-07-10 09:00"}},
+		{"monday of week 20", "19970512T090000", "FREQ=YEARLY;BYWEEKNO=20;BYDAY=MO", 3, []string{
+			"1997-05-12 09:00", "1998-05-11 09:00", "1999-05-17 09:00"}},
+		{"20th monday", "19970519T090000", "FREQ=YEARLY;BYDAY=20MO", 3, []string{
+			"1997-05-19 09:00", "1998-05-18 09:00", "1999-05-17 09:00"}},
+		{"days of the year", "19970101T090000", "FREQ=YEARLY;INTERVAL=3;COUNT=4;BYYEARDAY=1,100,200", 10, []string{
+			"1997-01-01 09:00", "1997-04-10 09:00", "1997-07-19 09:00", "2000-01-01 09:00"}},
+		{"every 3 hours", "19970902T090000", "FREQ=HOURLY;INTERVAL=3;UNTIL=19970902T210000Z", 10, []string{
+			"1997-09-02 09:00", "1997-09-02 12:00", "1997-09-02 15:00"}},
+		{"every 15 minutes", "19970902T090000", "FREQ=MINUTELY;INTERVAL=15;COUNT=4", 10, []string{
+			"1997-09-02 09:00", "1997-09-02 09:15", "1997-09-02 09:30", "1997-09-02 09:45"}},
+		{"every 20 minutes in office hours", "19970902T090000", "FREQ=DAILY;BYHOUR=9,10,11,12,13,14,15,16;BYMINUTE=0,20,40", 4, []string{
+			"1997-09-02 09:00", "1997-09-02 09:20", "1997-09-02 09:40", "1997-09-02 10:00"}},
+		{"across daylight saving", "19971031T090000", "FREQ=DAILY;COUNT=4", 10, []string{
+			"1997-10-31 09:00", "1997-11-01 09:00", "1997-11-02 09:00", "1997-11-03 09:00"}},
+	} {
+		rule := mustParse(t, "DTSTART;TZID=America/New_York:"+c.start+"\nRRULE:"+c.rule)
+		assert.Equal(t, c.want, dates(rule.Upcoming(before, c.n)), c.name)
+	}
+}
+
+func TestRule_ExDate(t *testing.T) {
+	newYork(t)
+	rule := mustParse(t, "DTSTART;TZID=America/New_York:19970902T090000\n"+
+		"RRULE:FREQ=DAILY;COUNT=5\n"+
+		"EXDATE;TZID=America/New_York:19970903T090000\n"+
+		"EXDATE;VALUE=DATE:19970905")
+
+	// Excluded occurrences still count towards COUNT
+	assert.Equal(t, []string{"1997-09-02 09:00", "1997-09-04 09:00", "1997-09-06 09:00"}, dates(rule.Upcoming(time.Time{}, 10)))
+}
+
+func TestRule_Next(t *testing.T) {
+	rule, err := ParseRule("FREQ=DAILY;BYHOUR=3", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	// Next is strictly after the given time and jumps over the past periods
+	assert.Equal(t, time.Date(2000, 1, 1, 3, 0, 0, 0, time.UTC), rule.Next(time.Time{}))
+	assert.Equal(t, time.Date(2024, 6, 2, 3, 0, 0, 0, time.UTC), rule.Next(time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC), rule.Next(time.Date(2024, 6, 1, 2, 59, 59, 0, time.UTC)))
+
+	// A finished rule has no next occurrence
+	rule, err = ParseRule("FREQ=DAILY;COUNT=2", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.True(t, rule.Next(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)).IsZero())
+
+	// An unsatisfiable rule has no next occurrence either
+	rule, err = ParseRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.True(t, rule.Next(time.Time{}).IsZero())
+}
+
+func TestRule_Between(t *testing.T) {
+	rule, err := ParseRule("FREQ=WEEKLY;BYDAY=MO,FR", time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	occurrences := rule.Between(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, []string{"2024-01-01 08:00", "2024-01-05 08:00", "2024-01-08 08:00", "2024-01-12 08:00"}, dates(occurrences))
+	assert.Empty(t, rule.Upcoming(time.Time{}, 0))
+}
@@ -0,0 +1,500 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorInvalidRule 表示重复规则无效
+// ErrorInvalidRule indicates the recurrence rule is invalid
+var ErrorInvalidRule = errors.New("invalid recurrence rule")
+
+// Frequency 是重复规则的频率
+// Frequency is the frequency of a recurrence rule
+type Frequency int
+
+// 定义重复规则的频率，从小到大排列
+// Define the frequencies of recurrence rules, from the smallest to the largest
+const (
+	// Secondly 表示每秒重复
+	// Secondly means repeating every second
+	Secondly Frequency = iota
+
+	// Minutely 表示每分钟重复
+	// Minutely means repeating every minute
+	Minutely
+
+	// Hourly 表示每小时重复
+	// Hourly means repeating every hour
+	Hourly
+
+	// Daily 表示每天重复
+	// Daily means repeating every day
+	Daily
+
+	// Weekly 表示每周重复
+	// Weekly means repeating every week
+	Weekly
+
+	// Monthly 表示每月重复
+	// Monthly means repeating every month
+	Monthly
+
+	// Yearly 表示每年重复
+	// Yearly means repeating every year
+	Yearly
+)
+
+// frequencyNames 是频率在 RRULE 中的名称，按照频率排列
+// frequencyNames are the names of the frequencies in RRULE, in the order of the frequencies
+var frequencyNames = [...]string{"SECONDLY", "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+
+// String 方法返回频率在 RRULE 中的名称
+// The String method returns the name of the frequency in RRULE
+func (f Frequency) String() string {
+	if f < Secondly || f > Yearly {
+		return "UNKNOWN"
+	}
+	return frequencyNames[f]
+}
+
+// parseFrequency 函数解析 FREQ 的值
+// The parseFrequency function parses the value of FREQ
+func parseFrequency(value string) (Frequency, bool) {
+	for freq, name := range frequencyNames {
+		if strings.EqualFold(name, value) {
+			return Frequency(freq), true
+		}
+	}
+	return 0, false
+}
+
+// weekdays 是 RRULE 中星期几的缩写和 time.Weekday 的对应关系
+// weekdays maps the abbreviations of weekdays in RRULE to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// weekdayNames 是星期几在 RRULE 中的名称，按照 time.Weekday 排列
+// weekdayNames are the names of the weekdays in RRULE, in the order of time.Weekday
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum 结构体是 BYDAY 中的一项，N 不为 0 时表示月或者年中的第 N 个（负数从末尾计算）星期几
+// The WeekdayNum struct is an item of BYDAY, when N is not 0 it means the Nth (counted from the end if negative) weekday of the month or the year
+type WeekdayNum struct {
+	// N 是序号，0 表示每一个
+	// N is the ordinal, 0 means every one
+	N int
+
+	// Day 是星期几
+	// Day is the weekday
+	Day time.Weekday
+}
+
+// Rule 结构体是 RFC 5545 的重复规则，包含 DTSTART、RRULE 和 EXDATE。它实现了 kairos.Schedule 接口，可以驱动调度器中的周期任务
+// The Rule struct is an RFC 5545 recurrence rule, it contains DTSTART, RRULE and EXDATE. It implements the kairos.Schedule interface, so it can drive recurring tasks in the scheduler
+type Rule struct {
+	// Freq 是频率
+	// Freq is the frequency
+	Freq Frequency
+
+	// Interval 是间隔的频率单位数量，默认为 1
+	// Interval is the number of frequency units between repetitions, the default is 1
+	Interval int
+
+	// Count 是最多的重复次数，0 表示不限制
+	// Count is the maximum number of repetitions, 0 means unlimited
+	Count int
+
+	// Until 是最后一次重复的时间（包含），零值表示不限制
+	// Until is the time of the last repetition (inclusive), a zero value means unlimited
+	Until time.Time
+
+	// BySecond、ByMinute、ByHour 是秒、分钟和小时的列表
+	// BySecond, ByMinute and ByHour are the lists of seconds, minutes and hours
+	BySecond, ByMinute, ByHour []int
+
+	// ByDay 是星期几的列表
+	// ByDay is the list of weekdays
+	ByDay []WeekdayNum
+
+	// ByMonthDay、ByYearDay、ByWeekNo 是月中的天、年中的天和年中的周的列表，负数从末尾计算
+	// ByMonthDay, ByYearDay and ByWeekNo are the lists of days of the month, days of the year and weeks of the year, negative values count from the end
+	ByMonthDay, ByYearDay, ByWeekNo []int
+
+	// ByMonth 是月份的列表
+	// ByMonth is the list of months
+	ByMonth []int
+
+	// BySetPos 是每个周期内保留的重复的序号列表，负数从末尾计算
+	// BySetPos is the list of ordinals of the repetitions kept in every period, negative values count from the end
+	BySetPos []int
+
+	// WeekStart 是一周开始的星期几，默认为星期一
+	// WeekStart is the weekday a week starts on, the default is Monday
+	WeekStart time.Weekday
+
+	// Start 是第一次重复的时间（DTSTART），它的时区决定了规则展开使用的时区
+	// Start is the time of the first repetition (DTSTART), its time zone decides the time zone used to expand the rule
+	Start time.Time
+
+	// ExDates 是被排除的重复时间（EXDATE）
+	// ExDates are the excluded repetition times (EXDATE)
+	ExDates []time.Time
+
+	// exDays 是被排除的日期，来自只包含日期的 EXDATE，格式为 "2006-01-02"
+	// exDays are the excluded dates, from EXDATE values with only a date, in the "2006-01-02" format
+	exDays map[string]struct{}
+}
+
+// Parse 函数解析 iCalendar 格式的重复规则，可以包含 DTSTART、RRULE 和 EXDATE 行，也可以只是 RRULE 的值，例如 "FREQ=MONTHLY;BYDAY=-1FR"。
+// 没有 DTSTART 时使用当前时间（精确到秒，本地时区）作为开始时间
+// The Parse function parses a recurrence rule in the iCalendar format, it can contain DTSTART, RRULE and EXDATE lines, or only the value of RRULE, such as "FREQ=MONTHLY;BYDAY=-1FR".
+// The current time (to the second, in the local time zone) is used as the start if there is no DTSTART
+func Parse(text string) (*Rule, error) {
+	var (
+		rrule   string
+		start   time.Time
+		exdates []string
+	)
+
+	// 将折叠的行展开之后逐行解析
+	// Parse line by line after unfolding the folded lines
+	text = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(text)
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			// 没有属性名称的行是 RRULE 的值
+			// A line without a property name is the value of RRULE
+			key, value = "RRULE", line
+		}
+		name, params, _ := strings.Cut(key, ";")
+		switch strings.ToUpper(name) {
+		case "DTSTART":
+			t, _, err := parseTime(value, params, time.Local)
+			if err != nil {
+				return nil, err
+			}
+			start = t
+		case "RRULE":
+			if rrule != "" {
+				return nil, fmt.Errorf("%w: only one RRULE is supported", ErrorInvalidRule)
+			}
+			rrule = value
+		case "EXDATE":
+			exdates = append(exdates, params+":"+value)
+		default:
+			return nil, fmt.Errorf("%w: unsupported property %s", ErrorInvalidRule, name)
+		}
+	}
+	if rrule == "" {
+		return nil, fmt.Errorf("%w: missing RRULE", ErrorInvalidRule)
+	}
+	if start.IsZero() {
+		start = time.Now().Truncate(time.Second)
+	}
+
+	rule, err := ParseRule(rrule, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// EXDATE 没有时区时使用开始时间的时区
+	// EXDATE uses the time zone of the start if it has none
+	for _, exdate := range exdates {
+		params, values, _ := strings.Cut(exdate, ":")
+		for _, value := range strings.Split(values, ",") {
+			t, dateOnly, err := parseTime(value, params, start.Location())
+			if err != nil {
+				return nil, err
+			}
+			if dateOnly {
+				rule.ExcludeDate(t)
+			} else {
+				rule.ExDates = append(rule.ExDates, t)
+			}
+		}
+	}
+	return rule, nil
+}
+
+// ParseRule 函数解析 RRULE 的值，使用给定的开始时间作为 DTSTART
+// The ParseRule function parses the value of RRULE, the given start time is used as DTSTART
+func ParseRule(value string, start time.Time) (*Rule, error) {
+	rule := &Rule{Interval: 1, WeekStart: time.Monday, Start: start.Truncate(time.Second)}
+	freqSet := false
+
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(value), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrorInvalidRule, part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq, freqSet = parseFrequency(val)
+			if !freqSet {
+				err = fmt.Errorf("unknown frequency %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(val)
+			if err == nil && rule.Interval < 1 {
+				err = fmt.Errorf("interval %d is not positive", rule.Interval)
+			}
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(val)
+			if err == nil && rule.Count < 1 {
+				err = fmt.Errorf("count %d is not positive", rule.Count)
+			}
+		case "UNTIL":
+			var dateOnly bool
+			rule.Until, dateOnly, err = parseTime(val, "", start.Location())
+			if err == nil && dateOnly {
+				// 只包含日期的 UNTIL 包含这一整天
+				// An UNTIL with only a date includes the whole day
+				rule.Until = rule.Until.AddDate(0, 0, 1).Add(-time.Second)
+			}
+		case "BYSECOND":
+			rule.BySecond, err = parseInts(val, 0, 60, false)
+		case "BYMINUTE":
+			rule.ByMinute, err = parseInts(val, 0, 59, false)
+		case "BYHOUR":
+			rule.ByHour, err = parseInts(val, 0, 23, false)
+		case "BYDAY":
+			rule.ByDay, err = parseWeekdays(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseInts(val, 1, 31, true)
+		case "BYYEARDAY":
+			rule.ByYearDay, err = parseInts(val, 1, 366, true)
+		case "BYWEEKNO":
+			rule.ByWeekNo, err = parseInts(val, 1, 53, true)
+		case "BYMONTH":
+			rule.ByMonth, err = parseInts(val, 1, 12, false)
+		case "BYSETPOS":
+			rule.BySetPos, err = parseInts(val, 1, 366, true)
+		case "WKST":
+			var ok bool
+			if rule.WeekStart, ok = weekdays[strings.ToUpper(val)]; !ok {
+				err = fmt.Errorf("unknown weekday %q", val)
+			}
+		default:
+			err = fmt.Errorf("unsupported part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrorInvalidRule, err)
+		}
+	}
+
+	if err := rule.validate(freqSet); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrorInvalidRule, err)
+	}
+	return rule, nil
+}
+
+// validate 方法检查规则各个部分之间的约束
+// The validate method checks the constraints between the parts of the rule
+func (r *Rule) validate(freqSet bool) error {
+	if !freqSet {
+		return errors.New("missing FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("COUNT and UNTIL must not both be set")
+	}
+	if len(r.ByWeekNo) > 0 && r.Freq != Yearly {
+		return errors.New("BYWEEKNO is only valid with FREQ=YEARLY")
+	}
+	if len(r.ByYearDay) > 0 && (r.Freq == Daily || r.Freq == Weekly || r.Freq == Monthly) {
+		return errors.New("BYYEARDAY is not valid with FREQ=DAILY, WEEKLY or MONTHLY")
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq == Weekly {
+		return errors.New("BYMONTHDAY is not valid with FREQ=WEEKLY")
+	}
+	for _, day := range r.ByDay {
+		if day.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return errors.New("BYDAY with an ordinal is only valid with FREQ=MONTHLY or YEARLY")
+		}
+		if day.N != 0 && r.Freq == Yearly && len(r.ByWeekNo) > 0 {
+			return errors.New("BYDAY with an ordinal is not valid with BYWEEKNO")
+		}
+	}
+	return nil
+}
+
+// ExcludeDate 方法排除指定日期上的所有重复，只使用 date 本身的年月日
+// The ExcludeDate method excludes all repetitions on the specified date, only the year, month and day of date itself are used
+func (r *Rule) ExcludeDate(date time.Time) {
+	if r.exDays == nil {
+		r.exDays = make(map[string]struct{})
+	}
+	r.exDays[date.Format("2006-01-02")] = struct{}{}
+}
+
+// String 方法返回规则的 iCalendar 文本，包含 DTSTART、RRULE 和 EXDATE 行，Parse 解析它得到相同的规则
+// The String method returns the iCalendar text of the rule, it contains the DTSTART, RRULE and EXDATE lines, Parse parses it into the same rule
+func (r *Rule) String() string {
+	var b strings.Builder
+	b.WriteString("DTSTART" + formatTime(r.Start) + "\n")
+
+	// RRULE 只包含和默认值不同的部分
+	// RRULE only contains the parts different from the defaults
+	b.WriteString("RRULE:FREQ=" + r.Freq.String())
+	if r.Interval > 1 {
+		b.WriteString(";INTERVAL=" + strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		b.WriteString(";COUNT=" + strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		b.WriteString(";UNTIL=" + r.Until.UTC().Format("20060102T150405Z"))
+	}
+	for _, part := range []struct {
+		name   string
+		values []int
+	}{
+		{"BYSECOND", r.BySecond}, {"BYMINUTE", r.ByMinute}, {"BYHOUR", r.ByHour}, {"BYMONTHDAY", r.ByMonthDay},
+		{"BYYEARDAY", r.ByYearDay}, {"BYWEEKNO", r.ByWeekNo}, {"BYMONTH", r.ByMonth}, {"BYSETPOS", r.BySetPos},
+	} {
+		if len(part.values) > 0 {
+			values := make([]string, len(part.values))
+			for i, v := range part.values {
+				values[i] = strconv.Itoa(v)
+			}
+			b.WriteString(";" + part.name + "=" + strings.Join(values, ","))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayNames[day.Day]
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		b.WriteString(";BYDAY=" + strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		b.WriteString(";WKST=" + weekdayNames[r.WeekStart])
+	}
+
+	// 排除的时间使用 UTC，排除的日期按照日期排序
+	// The excluded times use UTC, the excluded dates are sorted by date
+	for _, exdate := range r.ExDates {
+		b.WriteString("\nEXDATE:" + exdate.UTC().Format("20060102T150405Z"))
+	}
+	days := make([]string, 0, len(r.exDays))
+	for day := range r.exDays {
+		days = append(days, strings.ReplaceAll(day, "-", ""))
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		b.WriteString("\nEXDATE;VALUE=DATE:" + day)
+	}
+	return b.String()
+}
+
+// formatTime 函数返回 DTSTART 的参数和值，本地时区的时间不带时区，UTC 的时间以 Z 结尾，其他时区使用 TZID 参数
+// The formatTime function returns the parameters and the value of DTSTART, times in the local time zone have no time zone, times in UTC end with Z, other time zones use the TZID parameter
+func formatTime(t time.Time) string {
+	switch location := t.Location(); location {
+	case time.Local:
+		return ":" + t.Format("20060102T150405")
+	case time.UTC:
+		return ":" + t.Format("20060102T150405Z")
+	default:
+		return ";TZID=" + location.String() + ":" + t.Format("20060102T150405")
+	}
+}
+
+// parseInts 函数解析逗号分隔的整数列表，允许负数时绝对值需要在范围内
+// The parseInts function parses a comma separated list of integers, the absolute value must be within the range when negative values are allowed
+func parseInts(value string, lo, hi int, negative bool) ([]int, error) {
+	parts := strings.Split(value, ",")
+	values := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+		if abs < lo || abs > hi {
+			return nil, fmt.Errorf("value %d out of range", n)
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// parseWeekdays 函数解析 BYDAY 的值，例如 "MO,WE" 或者 "-1FR"
+// The parseWeekdays function parses the value of BYDAY, such as "MO,WE" or "-1FR"
+func parseWeekdays(value string) ([]WeekdayNum, error) {
+	parts := strings.Split(value, ",")
+	days := make([]WeekdayNum, 0, len(parts))
+	for _, part := range parts {
+		if len(part) < 2 {
+			return nil, fmt.Errorf("invalid weekday %q", part)
+		}
+		day, ok := weekdays[strings.ToUpper(part[len(part)-2:])]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", part)
+		}
+		n := 0
+		if ordinal := part[:len(part)-2]; ordinal != "" {
+			var err error
+			if n, err = strconv.Atoi(ordinal); err != nil || n == 0 || n > 53 || n < -53 {
+				return nil, fmt.Errorf("invalid weekday %q", part)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+// parseTime 函数解析 iCalendar 的日期或者日期时间，params 中的 TZID 指定时区，以 Z 结尾的值使用 UTC，其他值使用 location。
+// 只包含日期时 dateOnly 为 true，时间为当天零点
+// The parseTime function parses an iCalendar date or date-time, TZID in params specifies the time zone, values ending with Z use UTC, other values use location.
+// dateOnly is true when there is only a date, the time is midnight of the day
+func parseTime(value, params string, location *time.Location) (t time.Time, dateOnly bool, err error) {
+	for _, param := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(param, "="); ok && strings.EqualFold(k, "TZID") {
+			if location, err = time.LoadLocation(strings.Trim(v, `"`)); err != nil {
+				return time.Time{}, false, fmt.Errorf("%w: %v", ErrorInvalidRule, err)
+			}
+		}
+	}
+
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) == 8:
+		t, err = time.ParseInLocation("20060102", value, location)
+		dateOnly = true
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	default:
+		t, err = time.ParseInLocation("20060102T150405", value, location)
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %v", ErrorInvalidRule, err)
+	}
+	return t, dateOnly, nil
+}
@@ -0,0 +1,88 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrequency_String(t *testing.T) {
+	assert.Equal(t, "SECONDLY", Secondly.String())
+	assert.Equal(t, "MONTHLY", Monthly.String())
+	assert.Equal(t, "YEARLY", Yearly.String())
+	assert.Equal(t, "UNKNOWN", Frequency(-1).String())
+}
+
+func TestParse(t *testing.T) {
+	// Folded lines are unfolded, EXDATE may repeat and carry its own time zone
+	rule, err := Parse("DTSTART;TZID=America/New_York:19970902T090000\r\n" +
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=8;WKST=SU;\r\n BYDAY=TU,TH\r\n" +
+		"EXDATE;TZID=America/New_York:19970904T090000,19970916T090000\r\n" +
+		"EXDATE;VALUE=DATE:19970918\r\n")
+	assert.Nil(t, err)
+	assert.Equal(t, Weekly, rule.Freq)
+	assert.Equal(t, 2, rule.Interval)
+	assert.Equal(t, 8, rule.Count)
+	assert.Equal(t, time.Sunday, rule.WeekStart)
+	assert.Equal(t, []WeekdayNum{{Day: time.Tuesday}, {Day: time.Thursday}}, rule.ByDay)
+	assert.Equal(t, "America/New_York", rule.Start.Location().String())
+	assert.Equal(t, 2, len(rule.ExDates))
+	assert.True(t, rule.ExDates[0].Equal(time.Date(1997, 9, 4, 13, 0, 0, 0, time.UTC)))
+
+	// A bare RRULE value starts now
+	rule, err = Parse("FREQ=MONTHLY;BYDAY=-1FR")
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), rule.Start, time.Second*2)
+	assert.Equal(t, []WeekdayNum{{N: -1, Day: time.Friday}}, rule.ByDay)
+
+	// UNTIL in UTC and as a date
+	rule, err = ParseRule("RRULE:FREQ=DAILY;UNTIL=19971224T000000Z", time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(1997, 12, 24, 0, 0, 0, 0, time.UTC), rule.Until)
+	rule, err = ParseRule("FREQ=DAILY;UNTIL=19971224", time.Date(1997, 9, 2, 9, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(1997, 12, 24, 23, 59, 59, 0, time.UTC), rule.Until)
+}
+
+func TestRule_String(t *testing.T) {
+	for _, text := range []string{
+		"DTSTART;TZID=America/New_York:19970902T090000\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=8;BYDAY=TU,TH;WKST=SU\nEXDATE:19970904T130000Z\nEXDATE;VALUE=DATE:19970918",
+		"DTSTART:19970902T090000Z\nRRULE:FREQ=MONTHLY;UNTIL=19971224T000000Z;BYMONTHDAY=-1,1;BYSETPOS=1;BYDAY=-1FR",
+		"DTSTART:19970902T090000\nRRULE:FREQ=YEARLY;BYHOUR=9,17;BYWEEKNO=20;BYMONTH=3",
+	} {
+		rule, err := Parse(text)
+		assert.Nil(t, err)
+		assert.Equal(t, text, rule.String())
+
+		// The text parses into a rule with the same repetitions
+		parsed, err := Parse(rule.String())
+		assert.Nil(t, err)
+		assert.Equal(t, rule.Upcoming(rule.Start, 10), parsed.Upcoming(parsed.Start, 10))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=FORTNIGHTLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=19971224T000000Z",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYWEEKNO=1",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYYEARDAY=1",
+		"FREQ=DAILY;BYMONTHDAY=0",
+		"FREQ=DAILY;FOO=1",
+		"DTSTART;TZID=Nowhere/Nothing:19970902T090000\nRRULE:FREQ=DAILY",
+		"DTSTART:1997\nRRULE:FREQ=DAILY",
+		"RRULE:FREQ=DAILY\nRRULE:FREQ=WEEKLY",
+		"RDATE:19970902T090000\nRRULE:FREQ=DAILY",
+	} {
+		_, err := Parse(text)
+		assert.ErrorIs(t, err, ErrorInvalidRule, text)
+	}
+}
@@ -3,11 +3,12 @@ package kairos
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/shengyanli1982/kairos/internal/cache"
+	"github.com/shengyanli1982/kairos/cache"
 )
 
 // 定义两个全局的错误变量
@@ -29,13 +30,13 @@ type Scheduler struct {
 	// cfg is a pointer to the Config struct, used to store the configuration information of the scheduler.
 	cfg *Config
 
-	// taskCache 是一个指向 cache.Cache 结构体的指针，用于存储已经调度的任务。
-	// taskCache is a pointer to the cache.Cache struct, used to store the scheduled tasks.
-	taskCache *cache.Cache
+	// taskCache 是一个指向 cache.Cache 结构体的指针，用于存储已经调度的任务，键是任务的 ID。
+	// taskCache is a pointer to the cache.Cache struct, used to store the scheduled tasks, the key is the ID of the task.
+	taskCache *cache.Cache[string, *TaskRef]
 
-	// uniqCache 是一个指向 cache.Cache 结构体的指针，用于存储唯一的任务。
-	// uniqCache is a pointer to the cache.Cache struct, used to store unique tasks.
-	uniqCache *cache.Cache
+	// uniqCache 是一个指向 cache.Cache 结构体的指针，用于存储唯一的任务，键是任务的名称，值是任务的 ID。
+	// uniqCache is a pointer to the cache.Cache struct, used to store unique tasks, the key is the name of the task and the value is the ID of the task.
+	uniqCache *cache.Cache[string, string]
 
 	// ctx 是一个 context.Context 类型的变量，用于存储调度器的上下文信息。
 	// ctx is a variable of type context.Context, used to store the context information of the scheduler.
@@ -48,6 +49,62 @@ type Scheduler struct {
 	// once 是一个 sync.Once 类型的变量，用于确保某个操作只执行一次。
 	// once is a variable of type sync.Once, used to ensure that a certain operation is performed only once.
 	once sync.Once
+
+	// counters 是调度器的累计计数器。
+	// counters are the cumulative counters of the scheduler.
+	counters counters
+
+	// events 是调度器的事件中心，用于将事件分发给订阅者。
+	// events is the event hub of the scheduler, used to dispatch events to subscribers.
+	events *eventHub
+
+	// dispatcher 用于限制同时执行的处理函数数量，没有限制并发数量时为 nil。
+	// dispatcher is used to limit the number of handling functions running at the same time, nil if the concurrency is not limited.
+	dispatcher *dispatcher
+
+	// limiter 用于限制任务触发的速率，没有限制速率时为 nil。
+	// limiter is used to limit the firing rate of tasks, nil if the rate is not limited.
+	limiter *rateLimiter
+
+	// admission 统计任务的数量，在添加任务之前检查容量限制。
+	// admission counts the tasks, it checks the capacity limits before a task is added.
+	admission *admission
+
+	// batchers 是任务名称到批量执行器的映射，创建之后不再修改。
+	// batchers is the map from the name of a task to its batcher, it is not modified after creation.
+	batchers map[string]*batcher
+
+	// groupsLock 用于保护 groups。
+	// groupsLock is used to protect groups.
+	groupsLock sync.Mutex
+
+	// groups 是调度器中的任务组，键是组的名称。
+	// groups are the task groups of the scheduler, the key is the name of the group.
+	groups map[string]*Group
+
+	// tenantsLock 用于保护 tenants。
+	// tenantsLock is used to protect tenants.
+	tenantsLock sync.Mutex
+
+	// tenants 是调度器中已经使用过的租户，键是租户的名称。
+	// tenants are the tenants used in the scheduler, the key is the name of the tenant.
+	tenants map[string]*tenant
+
+	// defaultTenants 是 tenants 中没有单独设置的租户的数量，用于 WithMaxTenants 的限制。
+	// defaultTenants is the number of tenants in tenants without their own settings, it is used for the limit of WithMaxTenants.
+	defaultTenants int
+
+	// cluster 是调度器作为集群节点的状态，没有配置协调器时为 nil。
+	// cluster is the state of the scheduler as a node of the cluster, nil if no coordinator is configured.
+	cluster *cluster
+
+	// clockOnce 用于确保检测时钟跳变的 goroutine 只启动一次。
+	// clockOnce is used to ensure the goroutine detecting clock jumps is started only once.
+	clockOnce sync.Once
+
+	// wallClock 返回墙上时钟的当前时间，用于检测时钟跳变。
+	// wallClock returns the current time of the wall clock, it is used to detect clock jumps.
+	wallClock func() time.Time
 }
 
 // New 是一个函数，接收一个指向 Config 结构体的指针作为参数，返回一个新的 Scheduler 结构体指针。
@@ -70,25 +127,79 @@ func New(conf *Config) *Scheduler {
 
 		// taskCache 字段被设置为一个新的 Cache 结构体。
 		// The taskCache field is set to a new Cache struct.
-		taskCache: cache.NewCache(),
+		taskCache: cache.New[string, *TaskRef](nil),
 
 		// uniqCache 字段被设置为一个新的 Cache 结构体。
 		// The uniqCache field is set to a new Cache struct.
-		uniqCache: cache.NewCache(),
+		uniqCache: cache.New[string, string](nil),
 
 		// once 字段被设置为一个新的 Once 结构体。
 		// The once field is set to a new Once struct.
 		once: sync.Once{},
+
+		// events 字段被设置为一个新的事件中心。
+		// The events field is set to a new event hub.
+		events: newEventHub(),
+
+		// groups 字段被设置为一个新的 map。
+		// The groups field is set to a new map.
+		groups: make(map[string]*Group),
+
+		// tenants 字段被设置为一个新的 map。
+		// The tenants field is set to a new map.
+		tenants: make(map[string]*tenant),
+
+		// admission 字段被设置为使用配置中容量限制的准入控制。
+		// The admission field is set to an admission control using the capacity limits in the configuration.
+		admission: newAdmission(conf.maxPending, conf.nameQuota),
+
+		// wallClock 字段被设置为 time.Now。
+		// The wallClock field is set to time.Now.
+		wallClock: time.Now,
 	}
 
 	// ctx 和 cancel 字段被设置为一个新的带取消功能的上下文。
 	// The ctx and cancel fields are set to a new context with cancellation.
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 
+	// 如果限制了并发数量，创建一个分派器，执行槽位按照租户的权重公平地分配。
+	// If the concurrency is limited, create a dispatcher, execution slots are shared fairly among the tenants by their weights.
+	if conf.maxConcurrency > 0 {
+		s.dispatcher = newDispatcher(conf.maxConcurrency, conf.priorityAging, s.tenantWeight)
+	}
+
+	// 为配置了批量执行的任务名称创建批量执行器。
+	// Create the batchers for the task names configured with batch execution.
+	s.batchers = make(map[string]*batcher, len(conf.batches))
+	for name, batchConf := range conf.batches {
+		s.batchers[name] = newBatcher(batchConf, s.ctx.Done())
+	}
+
+	// 如果限制了触发速率，创建一个限速器。
+	// If the firing rate is limited, create a rate limiter.
+	if conf.rateLimit > 0 {
+		s.limiter = newRateLimiter(conf.rateLimit, conf.rateBurst, conf.rateScope)
+	}
+
 	// 我们将 running 字段设置为 true，表示调度器已经开始运行。
 	// we set the running field to true, indicating that the scheduler has started running.
 	s.running.Store(true)
 
+	// 如果配置了协调器，启动维护领导者租约的 goroutine，领导者会接管 Store 中的任务；
+	// 否则，如果配置了 Store，恢复其中保存的所有任务。
+	// If the coordinator is configured, start the goroutine maintaining the leader lease, the leader takes over the tasks in the Store;
+	// otherwise, if the Store is configured, restore all tasks saved in it.
+	if conf.coordinator != nil {
+		s.cluster = &cluster{done: make(chan struct{}), adopted: make(map[string]struct{})}
+		go s.runCluster()
+	} else if conf.store != nil {
+		if defs, err := conf.store.Load(); err != nil {
+			s.reportClusterError("", err)
+		} else {
+			s.restore(defs)
+		}
+	}
+
 	// 最后，我们返回新创建的 Scheduler 结构体的指针。
 	// Finally, we return the pointer to the newly created Scheduler struct.
 	return s
@@ -108,122 +219,723 @@ func (s *Scheduler) Stop() {
 		// Call the cancel function to cancel the context of the scheduler, thereby stopping all tasks.
 		s.cancel()
 
+		// 等待维护领导者租约的 goroutine 释放租约并退出。
+		// Wait for the goroutine maintaining the leader lease to release the lease and exit.
+		if s.cluster != nil {
+			<-s.cluster.done
+		}
+
+		// 关闭分派器，正在排队的处理函数立即执行，避免等待任务完成时阻塞。
+		// Close the dispatcher, the queued handling functions run immediately, to avoid blocking while waiting for the tasks to complete.
+		if s.dispatcher != nil {
+			s.dispatcher.close()
+		}
+		s.groupsLock.Lock()
+		for _, group := range s.groups {
+			if group.dispatcher != nil {
+				group.dispatcher.close()
+			}
+		}
+		s.groupsLock.Unlock()
+		s.tenantsLock.Lock()
+		for _, tenant := range s.tenants {
+			if tenant.dispatcher != nil {
+				tenant.dispatcher.close()
+			}
+		}
+		s.tenantsLock.Unlock()
+
+		// 立即执行正在收集的批次，避免等待任务完成时阻塞。
+		// Run the batches being collected immediately, to avoid blocking while waiting for the tasks to complete.
+		for _, b := range s.batchers {
+			b.close()
+		}
+
 		// 清理 taskCache，取消所有已经调度的任务。
 		// Clean up taskCache, cancel all scheduled tasks.
-		s.taskCache.Cleanup(func(data any) {
-			// 将数据转换为任务引用。
-			// Convert the data to a task reference.
-			taskRef := data.(*TaskRef)
+		s.taskCache.Cleanup(func(taskRef *TaskRef) {
+			// 取出任务引用中的任务和父引用，暂停的任务没有正在运行的任务。
+			// Take the task and the parent reference out of the task reference, a paused task has no running task.
+			taskRef.lock.Lock()
+			task, parentCancel := taskRef.task, taskRef.parentRef.cancel
+			taskRef.task = nil
+			taskRef.lock.Unlock()
 
-			// 取消任务。
-			// Cancel the task.
-			taskRef.task.Cancel()
+			// 如果任务存在
+			// If the task exists
+			if task != nil {
+				// 取消任务。
+				// Cancel the task.
+				task.Cancel()
 
-			// 取消父引用的上下文。
-			// Cancel the context of the parent reference.
-			taskRef.parentRef.cancel()
+				// 取消父引用的上下文。
+				// Cancel the context of the parent reference.
+				parentCancel()
 
-			// 等待任务完成。
-			// Wait for the task to complete.
-			taskRef.task.Wait()
+				// 等待任务完成。
+				// Wait for the task to complete.
+				task.Wait()
+			}
 
-			// 重置任务引用。
-			// Reset the task reference.
+			// 重置任务引用，如果任务使用工作时间延迟，停止监视日历。
+			// Reset the task reference, if the task uses a business delay, stop watching the calendar.
+			taskRef.lock.Lock()
+			business := taskRef.business
 			taskRef.Reset()
+			taskRef.lock.Unlock()
+			if business != nil {
+				business.unwatch()
+			}
 
 			// 将任务引用放回到任务引用池中。
 			// Put the task reference back into the task reference pool.
 			taskRefPool.Put(taskRef)
 		})
 
-		// 如果调度器的配置中 uniqued 为 true
-		// If uniqued in the scheduler's configuration is true
-		if s.cfg.uniqued {
-			// 调用 uniqCache 的 Cleanup 方法，清理其中的所有任务
-			// Call the Cleanup method of uniqCache to clean up all the tasks in it
-			s.uniqCache.Cleanup(func(value any) {})
-		}
+		// 调用 uniqCache 的 Cleanup 方法，清理其中的所有任务，组内唯一的任务即使调度器没有设置 uniqued 也会使用它。
+		// Call the Cleanup method of uniqCache to clean up all the tasks in it, tasks unique within a group use it even if uniqued is not set for the scheduler.
+		s.uniqCache.Cleanup(func(string) {})
+
+		// 关闭事件中心，所有订阅者的通道都会被关闭。
+		// Close the event hub, the channels of all subscribers are closed.
+		s.events.close()
 	})
 }
 
 // add 是一个方法，用于向调度器添加新的任务。
 // add is a method used to add new tasks to the scheduler.
-func (s *Scheduler) add(ctx context.Context, cancel context.CancelFunc, name string, handleFunc TaskHandleFunc) string {
-	// 如果调度器的配置中 uniqued 为 true
-	// If uniqued in the scheduler's configuration is true
-	if s.cfg.uniqued {
-		// 从 uniqCache 中获取任务
-		// Get the task from uniqCache
-		if data, ok := s.uniqCache.Get(name); ok {
-			// 获取任务的 ID
-			// Get the ID of the task
-			taskID := data.(string)
-
-			// 调用回调函数，通知任务已经存在。
-			// Call the callback function to notify that the task already exists.
-			s.cfg.callback.OnTaskDuplicated(taskID, name)
-
-			// 返回任务的 ID。
-			// Return the ID of the task.
-			return taskID
-		}
+func (s *Scheduler) add(name, handler string, handleFunc TaskHandleFunc, execAt time.Time, opts *TaskOptions) (string, error) {
+	// 准备任务引用，重复的任务和被拒绝的任务没有任务引用。
+	// Prepare the task reference, duplicated and rejected tasks have no task reference.
+	taskRef, taskID, err := s.prepare(name, handler, handleFunc, execAt, opts)
+	if taskRef == nil {
+		return taskID, err
 	}
+	defer taskRef.lock.Unlock()
 
-	// 创建一个新的任务，并设置任务执行后和任务完成后的回调函数。
-	// Create a new task, and set the callback functions after the task is executed and after the task is finished.
-	task := NewTask(ctx, name, handleFunc).
-		// 设置任务执行后的回调函数。
-		// Set the callback function after the task is executed.
-		onExecuted(s.cfg.callback.OnTaskExecuted).
+	// 启动任务。
+	// Start the task.
+	s.start(taskRef)
 
-		// 设置任务完成后的回调函数。
-		// Set the callback function after the task is finished.
-		onFinished(func(metadata *TaskMetadata) {
-			// 任务完成后，从调度器中删除该任务。
-			// After the task is finished, delete the task from the scheduler.
-			s.Delete(metadata.GetID())
-		})
+	// 返回任务的 ID。
+	// Return the ID of the task.
+	return taskID, nil
+}
+
+// prepare 是一个方法，为新的任务占用容量，并在任务缓存中保存一个锁定的任务引用，但是不启动它。
+// 同名的唯一任务已经存在时返回 nil 和已经存在的任务的 ID，任务被拒绝时返回 nil 和错误。调用者需要在启动任务之后解锁任务引用。
+// prepare is a method that takes capacity for a new task and saves a locked task reference in the task cache, without starting it.
+// When a unique task with the same name already exists, nil and the ID of the existing task are returned, when the task is rejected, nil and the error are returned. The caller must unlock the task reference after starting the task.
+func (s *Scheduler) prepare(name, handler string, handleFunc TaskHandleFunc, execAt time.Time, opts *TaskOptions) (*TaskRef, string, error) {
+	// 使用调度器的 ID 生成函数为任务生成一个新的 ID，恢复的任务使用保存的 ID，调用者也可以指定任务的 ID。
+	// Generate a new ID for the task with the ID generator of the scheduler, restored tasks use the saved ID, callers may also specify the ID of the task.
+	taskID := opts.id
+	if taskID == "" {
+		taskID = s.cfg.idGenerator()
+	}
+
+	// 计算任务在 uniqCache 中的键，组内唯一的任务使用组的名称限定键的范围。
+	// Calculate the key of the task in uniqCache, tasks unique within a group use the name of the group to scope the key.
+	uniqKey := s.uniqKey(name, opts)
+
+	// 如果同名的唯一任务已经存在，直接返回它的 ID，重复的任务不需要占用容量。
+	// If a unique task with the same name already exists, return its ID directly, duplicated tasks do not need to take capacity.
+	if uniqKey != "" {
+		if existingID, ok := s.uniqCache.Get(uniqKey); ok {
+			return nil, s.duplicate(existingID, name), nil
+		}
+	}
 
-	// 获取任务的 ID。
-	// Get the ID of the task.
-	taskID := task.GetMetadata().GetID()
+	// 已经被删除的组不能再添加任务。
+	// No more tasks can be added to a deleted group.
+	if opts.group != nil && opts.group.isRemoved() {
+		return nil, "", ErrorGroupRemoved
+	}
 
-	// 如果调度器的配置中 uniqued 为 true
-	// If uniqued in the scheduler's configuration is true
-	if s.cfg.uniqued {
-		// 在 uniqCache 中设置该任务的 ID
-		// Set the ID of the task in uniqCache
-		s.uniqCache.Set(name, taskID)
+	// 获取任务所属的租户，租户的数量达到限制时拒绝任务。
+	// Get the tenant the task belongs to, the task is rejected when the number of tenants reached the limit.
+	tenant, err := s.acquireTenant(opts.tenant)
+	if err != nil {
+		s.reject(name, nil, err)
+		return nil, "", err
+	}
+
+	// 如果任务受到容量限制，在添加任务之前占用容量，容量不足时拒绝任务或者等待。
+	// If the task is subject to a capacity limit, take capacity before adding the task, the task is rejected or waits when there is not enough capacity.
+	admitted := s.admission.limited(opts.group, tenant)
+	if admitted {
+		if err := s.admit(name, tenant, opts); err != nil {
+			tenant.release()
+			return nil, "", err
+		}
 	}
 
 	// 从任务引用池中获取一个任务引用。
 	// Get a task reference from the task reference pool.
 	taskRef := taskRefPool.Get().(*TaskRef)
 
-	// 设置任务引用的父引用的上下文。
-	// Set the context of the parent reference of the task reference.
-	taskRef.parentRef.ctx = ctx
+	// 锁定任务引用，在任务启动之前，其他的操作不能访问它。
+	// Lock the task reference, other operations can not access it before the task is started.
+	taskRef.lock.Lock()
+
+	// 原子地在任务缓存中占用任务的 ID。生成的 ID 和已经存在的任务冲突时重新生成，
+	// 调用者指定的 ID 和恢复的任务的 ID 冲突时撤销之前的操作并拒绝任务。
+	// Atomically take the ID of the task in the task cache. A generated ID conflicting with an existing task is generated again,
+	// an ID specified by the caller or restored from the Store undoes the previous steps and rejects the task on a conflict.
+	for attempt := 1; ; attempt++ {
+		if _, loaded := s.taskCache.GetOrSet(taskID, taskRef); !loaded {
+			break
+		}
+		if opts.id != "" || attempt >= maxIDAttempts {
+			taskRef.lock.Unlock()
+			taskRefPool.Put(taskRef)
+			if admitted {
+				s.admission.release(name, opts.group, tenant)
+			}
+			tenant.release()
+			return nil, "", ErrorTaskIDConflict
+		}
+		taskID = s.cfg.idGenerator()
+	}
+
+	// 如果任务的名称需要唯一
+	// If the name of the task needs to be unique
+	if uniqKey != "" {
+		// 原子地在 uniqCache 中设置该任务的 ID，如果同名的任务已经存在，则返回它的 ID。
+		// 检查和设置在同一个临界区内完成，并发添加同名的任务时只有一个会成功。
+		// Atomically set the ID of the task in uniqCache, if a task with the same name already exists, its ID is returned.
+		// The check and the set happen in the same critical section, only one of the concurrent additions with the same name succeeds.
+		if existingID, loaded := s.uniqCache.GetOrSet(uniqKey, taskID); loaded {
+			// 归还占用的 ID 和任务引用，并释放占用的容量。
+			// Give back the ID and the task reference taken, and release the capacity taken.
+			s.taskCache.CompareAndDelete(taskID, taskRef)
+			taskRef.lock.Unlock()
+			taskRefPool.Put(taskRef)
+			if admitted {
+				s.admission.release(name, opts.group, tenant)
+			}
+			tenant.release()
+			return nil, s.duplicate(existingID, name), nil
+		}
+	}
+
+	// 设置任务的定义。
+	// Set the definition of the task.
+	taskRef.id = taskID
+	taskRef.name = name
+	taskRef.handleFunc = handleFunc
+	taskRef.handler = handler
+	taskRef.execAt = execAt
+	taskRef.labels = opts.labels
+	taskRef.priority = opts.priority
+	taskRef.group = opts.group
+	taskRef.uniqKey = uniqKey
+	taskRef.schedule = opts.schedule
+	taskRef.payload = opts.payload
+	taskRef.tenant = tenant
+	taskRef.admitted = admitted
+
+	// 任务使用的时钟来自任务选项；没有设置时，相对的延迟使用单调时钟，绝对的执行时间使用调度器的设置。
+	// 使用墙上时钟的任务需要启动检测时钟跳变的 goroutine。
+	// The clock used by the task comes from the task options; if it is not set, relative delays use the monotonic clock and absolute execution times use the setting of the scheduler.
+	// Tasks using the wall clock need the goroutine detecting clock jumps to be started.
+	taskRef.clockMode = s.cfg.clockMode
+	switch {
+	case opts.clockSet:
+		taskRef.clockMode = opts.clockMode
+	case opts.relative:
+		taskRef.clockMode = ClockMonotonic
+	}
+	if taskRef.clockMode == ClockWall {
+		s.clockOnce.Do(func() { go s.watchClock() })
+	}
+
+	// 任务的延迟阈值和策略来自任务选项，没有设置时使用调度器的设置。
+	// The lag threshold and the policy of the task come from the task options, the settings of the scheduler are used if they are not set.
+	taskRef.misfireThreshold, taskRef.misfirePolicy = s.cfg.misfireThreshold, s.cfg.misfirePolicy
+	if opts.misfireSet {
+		taskRef.misfireThreshold, taskRef.misfirePolicy = opts.misfireThreshold, opts.misfirePolicy
+	}
+
+	// 如果配置了 Store，使用注册的处理函数添加的任务需要持久化，恢复的任务已经在 Store 中。时间表无法转换为文本的周期任务不会被持久化。
+	// If the Store is configured, tasks added with registered handling functions are persisted, restored tasks are already in the Store. Recurring tasks whose timetable can not be turned into a text are not persisted.
+	_, serializable := scheduleText(opts.schedule)
+	taskRef.persisted = s.cfg.store != nil && handler != "" && serializable
+	if taskRef.persisted && !opts.restored {
+		s.persist(taskRef)
+	}
+
+	// 如果任务使用工作时间延迟，监视日历，日历被修改时重新计算执行时间。
+	// If the task uses a business delay, watch the calendar and recalculate the execution time when the calendar is modified.
+	if opts.business != nil {
+		b := *opts.business
+		b.unwatch = b.calendar.Watch(func() { s.recalculate(taskID, &b) })
+		taskRef.business = &b
+	}
+
+	// 如果任务属于一个组，将任务加入组。
+	// If the task belongs to a group, add the task to the group.
+	if opts.group != nil {
+		opts.group.join(taskID)
+	}
+
+	// 如果绑定了调用者上下文，启动一个 goroutine 监视它，直到任务被移除。
+	// If a caller context is bound, start a goroutine watching it until the task is removed.
+	if opts.ctx != nil && opts.ctx.Done() != nil {
+		taskRef.detached = make(chan struct{})
+		go s.watch(opts.ctx, taskRef.detached, taskID)
+	}
+
+	// 返回锁定的任务引用。
+	// Return the locked task reference.
+	return taskRef, taskID, nil
+}
+
+// start 是一个方法，启动已经保存到任务缓存中的任务，并增加添加任务的计数，调用者需要持有任务引用的锁。
+// 在任务引用保存到缓存之后再启动任务，确保已经过期的任务也能在完成时被删除。
+// start is a method that starts a task already saved in the task cache and increases the count of added tasks, the caller must hold the lock of the task reference.
+// The task is started after the task reference is saved in the cache, so that an already expired task can still be deleted when it finishes.
+func (s *Scheduler) start(taskRef *TaskRef) {
+	s.arm(taskRef)
+
+	// 增加添加任务的计数。
+	// Increase the count of added tasks.
+	s.counters.added.Add(1)
+	if taskRef.tenant != nil {
+		taskRef.tenant.added.Add(1)
+	}
+}
+
+// duplicate 是一个方法，记录并通知同名的唯一任务已经存在，返回已经存在的任务的 ID。
+// duplicate is a method recording and notifying that a unique task with the same name already exists, it returns the ID of the existing task.
+func (s *Scheduler) duplicate(existingID, name string) string {
+	// 增加重复任务的计数。
+	// Increase the count of duplicated tasks.
+	s.counters.duplicated.Add(1)
+
+	// 调用回调函数，通知任务已经存在。
+	// Call the callback function to notify that the task already exists.
+	s.cfg.callback.OnTaskDuplicated(existingID, name)
+	s.events.publish(&Event{Type: EventTaskDuplicated, ID: existingID, Name: name, Time: time.Now()})
+
+	// 返回已经存在的任务的 ID。
+	// Return the ID of the existing task.
+	return existingID
+}
+
+// arm 是一个方法，根据任务引用中的定义创建并启动一个新的任务，调用者需要持有任务引用的锁。
+// arm is a method that creates and starts a new task from the definition in the task reference, the caller must hold the lock of the task reference.
+func (s *Scheduler) arm(taskRef *TaskRef) {
+	// 任务的父上下文是所属组的上下文，不属于任何组时是调度器的上下文。
+	// The parent context of the task is the context of its group, or the context of the scheduler if it does not belong to any group.
+	parent := s.ctx
+	if taskRef.group != nil {
+		parent = taskRef.group.context()
+	}
+
+	// 使用墙上时钟的任务去掉截止时间中单调时钟的部分，使截止时间按照墙上时钟计算。
+	// Tasks using the wall clock have the monotonic part of the deadline stripped, so the deadline is calculated from the wall clock.
+	deadline := taskRef.execAt
+	if taskRef.clockMode == ClockWall {
+		deadline = deadline.Round(0)
+	}
+
+	// 创建一个新的上下文，该上下文将在指定时间被取消。
+	// Create a new context that will be cancelled at the specified time.
+	ctx, cancel := context.WithDeadline(parent, deadline)
+
+	// 创建一个新的任务，任务的 ID 与任务引用的 ID 相同。
+	// Create a new task, the ID of the task is the same as the ID of the task reference.
+	// 如果任务的名称配置了批量执行，处理函数把任务加入同名的批次。
+	// If batch execution is configured for the name of the task, the handling function adds the task to the batch of its name.
+	handleFunc := taskRef.handleFunc
+	if batchFunc := s.batchHandleFunc(taskRef); batchFunc != nil {
+		handleFunc = batchFunc
+	}
+	task := newTask(ctx, taskRef.id, taskRef.name, handleFunc)
 
-	// 设置任务引用的父引用的取消函数。
-	// Set the cancel function of the parent reference of the task reference.
+	// 设置任务的标签，标签在任务的整个生命周期内不会被修改。
+	// Set the labels of the task, the labels are not modified during the whole lifecycle of the task.
+	task.metadata.labels = taskRef.labels
+	task.metadata.priority = taskRef.priority
+
+	// 设置任务所属的租户，处理函数执行期间计入租户正在执行的任务数量。
+	// Set the tenant the task belongs to, the task counts as a running task of the tenant while its handling function runs.
+	tenant := taskRef.tenant
+	if tenant != nil {
+		task.metadata.tenant = tenant.name
+		taskFunc := task.metadata.handleFunc
+		task.metadata.handleFunc = func(done WaitForContextDone) (any, error) {
+			tenant.running.Add(1)
+			defer tenant.running.Add(-1)
+			return taskFunc(done)
+		}
+	}
+
+	// 如果限制了触发速率或者并发数量，处理函数需要先等待令牌，再从分派器获得执行槽位。
+	// If the firing rate or the concurrency is limited, the handling function must wait for a token first, then get an execution slot from the dispatcher.
+	if group := taskRef.group; s.limiter != nil || s.dispatcher != nil || (group != nil && (group.limiter != nil || group.dispatcher != nil)) || (tenant != nil && tenant.dispatcher != nil) {
+		task.onDispatchFunc = func(metadata *TaskMetadata) func() {
+			return s.dispatch(group, tenant, metadata)
+		}
+	}
+
+	// 如果设置了延迟阈值，任务在触发之前先检查延迟；如果配置了协调器，持久化的任务在触发之前需要认领这次触发；如果配置了任务锁，任务在触发之前需要获取锁。
+	// If the lag threshold is set, the task checks its lag before it fires first; if the coordinator is configured, a persisted task must claim the firing before it fires; if the task locker is configured, the task must acquire the lock before it fires.
+	guards := make([]onFireHandleFunc, 0, 3)
+	if taskRef.misfireThreshold > 0 {
+		guards = append(guards, s.misfire(taskRef, task, taskRef.misfireThreshold, taskRef.misfirePolicy))
+	}
+	if s.cfg.coordinator != nil && taskRef.persisted {
+		guards = append(guards, s.claim(taskRef, task))
+	}
+	if s.cfg.locker != nil {
+		guards = append(guards, s.lock)
+	}
+	task.onFireFunc = chainFire(guards)
+
+	// 设置任务执行后的回调函数。
+	// Set the callback function after the task is executed.
+	task.onExecuted(func(id, name string, result any, reason, err error) {
+		// 根据任务结束的原因更新计数。
+		// Update the counters according to the reason why the task finished.
+		switch {
+		case errors.Is(reason, ErrorTaskCanceled):
+			s.counters.canceled.Add(1)
+			if tenant != nil {
+				tenant.canceled.Add(1)
+			}
+		case errors.Is(reason, ErrorTaskSkipped):
+			s.counters.skipped.Add(1)
+			if tenant != nil {
+				tenant.skipped.Add(1)
+			}
+		default:
+			s.counters.executed.Add(1)
+			if tenant != nil {
+				tenant.executed.Add(1)
+			}
+		}
+
+		// 如果配置了历史记录，记录这次执行。
+		// If the history is configured, record this execution.
+		if s.cfg.history != nil {
+			s.cfg.history.record(newHistoryRecord(task.GetMetadata(), result, reason, err, s.cfg.history.cfg.resultLimit))
+		}
+
+		// 调用回调函数，通知任务已经被执行。
+		// Call the callback function to notify that the task has been executed.
+		s.cfg.callback.OnTaskExecuted(id, name, result, reason, err)
+		if callback, ok := s.cfg.callback.(LabeledCallback); ok {
+			callback.OnLabeledTaskExecuted(id, name, task.metadata.GetLabels(), result, reason, err)
+		}
+		s.events.publish(&Event{Type: EventTaskExecuted, ID: id, Name: name, Labels: task.metadata.GetLabels(), Time: time.Now(), Reason: errorString(reason), Error: errorString(err), Lag: task.metadata.GetLag(), Throttled: task.metadata.GetThrottled()})
+	})
+
+	// 设置任务完成后的回调函数。
+	// Set the callback function after the task is finished.
+	task.onFinished(func(_ *TaskMetadata) {
+		// 任务完成后，从调度器中删除该任务。
+		// After the task is finished, delete the task from the scheduler.
+		s.finish(taskRef, task)
+	})
+
+	// 设置任务引用的父引用的上下文和取消函数。
+	// Set the context and the cancel function of the parent reference of the task reference.
+	taskRef.parentRef.ctx = ctx
 	taskRef.parentRef.cancel = cancel
 
 	// 设置任务引用的任务。
 	// Set the task of the task reference.
 	taskRef.task = task
 
-	// 在任务缓存中设置任务引用。
-	// Set the task reference in the task cache.
-	s.taskCache.Set(taskID, taskRef)
+	// 启动任务。
+	// Start the task.
+	task.start()
+}
 
-	// 返回任务的 ID。
-	// Return the ID of the task.
-	return taskID
+// dispatch 是一个方法，阻塞直到任务的处理函数满足所属组和调度器的速率限制，并获得所属租户、组和调度器的执行槽位，返回释放执行槽位的函数。
+// dispatch is a method that blocks until the handling function of the task satisfies the rate limits of its group and of the scheduler and gets the execution slots of its tenant, its group and the scheduler, it returns the function releasing the execution slots.
+func (s *Scheduler) dispatch(group *Group, tenant *tenant, metadata *TaskMetadata) func() {
+	// 先等待速率限制，等待期间不占用执行槽位。
+	// Wait for the rate limit first, no execution slot is held while waiting.
+	if s.limiter != nil || (group != nil && group.limiter != nil) {
+		s.throttle(group, metadata)
+	}
+
+	var waited time.Duration
+	releases := make([]func(), 0, 3)
+
+	// 按任务的优先级依次等待租户、组和调度器的执行槽位，组和调度器的执行槽位按照租户的权重公平地分配。
+	// Wait for the execution slots of the tenant, the group and the scheduler in turn by the priority of the task, the execution slots of the group and the scheduler are shared fairly among the tenants by their weights.
+	if tenant != nil && tenant.dispatcher != nil {
+		waited += tenant.dispatcher.acquire(metadata.tenant, metadata.priority)
+		releases = append(releases, tenant.dispatcher.release)
+	}
+	if group != nil && group.dispatcher != nil {
+		waited += group.dispatcher.acquire(metadata.tenant, metadata.priority)
+		releases = append(releases, group.dispatcher.release)
+	}
+	if s.dispatcher != nil {
+		waited += s.dispatcher.acquire(metadata.tenant, metadata.priority)
+		releases = append(releases, s.dispatcher.release)
+	}
+
+	// 如果限制了并发数量，并且回调函数实现了 DispatchCallback，通知处理函数即将执行。
+	// If the concurrency is limited and the callback function implements DispatchCallback, notify that the handling function is about to run.
+	if callback, ok := s.cfg.callback.(DispatchCallback); ok && len(releases) > 0 {
+		callback.OnTaskDispatched(metadata.id, metadata.name, metadata.priority, waited)
+	}
+
+	return func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+}
+
+// uniqKey 是一个方法，返回任务在 uniqCache 中的键，任务的名称不需要唯一时返回空字符串。
+// uniqKey is a method that returns the key of the task in uniqCache, it returns an empty string if the name of the task does not need to be unique.
+func (s *Scheduler) uniqKey(name string, opts *TaskOptions) string {
+	switch group := opts.group; {
+	case group != nil && group.cfg.uniqued:
+		// 组内唯一的任务使用不会出现在普通名称中的分隔符限定范围。
+		// Tasks unique within a group are scoped with a separator that does not appear in ordinary names.
+		return group.name + "\x00" + name
+	case s.cfg.uniqued, opts.uniqued:
+		return name
+	}
+	return ""
+}
+
+// finish 是一个方法，在任务结束后将其从调度器中删除，如果任务已经被替换或者删除，则什么都不做。
+// finish is a method that deletes the task from the scheduler after it finishes, it does nothing if the task has been replaced or deleted.
+func (s *Scheduler) finish(taskRef *TaskRef, task *Task) {
+	// 如果调度器没有运行，Stop 方法会负责清理任务。
+	// If the scheduler is not running, the Stop method takes care of cleaning up the tasks.
+	if !s.running.Load() {
+		return
+	}
+
+	// 锁定任务引用。
+	// Lock the task reference.
+	taskRef.lock.Lock()
+
+	// 如果任务引用中的任务不是结束的任务，说明任务已经被替换或者删除。
+	// If the task in the task reference is not the finished task, the task has been replaced or deleted.
+	if taskRef.task != task {
+		taskRef.lock.Unlock()
+		return
+	}
+
+	// 如果是已经触发的周期任务，并且时间表还有下一次执行，使用下一次执行时间重新启动任务，任务的 ID 保持不变。被取消的周期任务不会继续执行。
+	// If it is a recurring task that has fired and the timetable has a next run, restart the task with the next execution time, the ID of the task stays the same. A canceled recurring task does not continue.
+	if cause := context.Cause(task.ctx); taskRef.schedule != nil && (cause == context.DeadlineExceeded || cause == ErrorTaskEarlyReturn) {
+		if next := nextSlot(taskRef.schedule, taskRef.execAt, time.Now(), taskRef.realign); !next.IsZero() {
+			taskRef.parentRef.cancel()
+			taskRef.execAt = next
+			taskRef.realign = false
+			s.arm(taskRef)
+			if taskRef.persisted {
+				s.persist(taskRef)
+			}
+			id, name, labels := taskRef.id, taskRef.name, taskRef.labels
+			taskRef.lock.Unlock()
+
+			// 调用回调函数，通知任务已经被添加。
+			// Call the callback function to notify that the task has been added.
+			s.notifyAdded(id, name, labels, next)
+			return
+		}
+	}
+
+	// 从调度器中移除任务引用。
+	// Remove the task reference from the scheduler.
+	id, name, labels := taskRef.id, taskRef.name, taskRef.labels
+	s.detach(taskRef)
+	taskRef.lock.Unlock()
+
+	// 释放父引用的上下文。
+	// Release the context of the parent reference.
+	s.release(taskRef, id, name, labels)
+}
+
+// detach 是一个方法，将任务引用从缓存中移除，调用者需要持有任务引用的锁。
+// detach is a method that removes the task reference from the caches, the caller must hold the lock of the task reference.
+func (s *Scheduler) detach(taskRef *TaskRef) {
+	// 从任务缓存中删除这个任务。
+	// Delete this task from the task cache.
+	s.taskCache.Delete(taskRef.id)
+	s.unlink(taskRef)
+}
+
+// unlink 是一个方法，将已经从任务缓存中删除的任务引用从其他的缓存、组和容量统计中移除，调用者需要持有任务引用的锁。
+// unlink is a method that removes a task reference already deleted from the task cache from the other caches, the group and the capacity accounting, the caller must hold the lock of the task reference.
+func (s *Scheduler) unlink(taskRef *TaskRef) {
+	// 如果任务的名称需要唯一
+	// If the name of the task needs to be unique
+	if taskRef.uniqKey != "" {
+		// 只有在名称仍然指向这个任务时才从 uniqCache 中删除，避免删除同名的新任务
+		// Delete from uniqCache only if the name still points to this task, to avoid deleting a new task with the same name
+		s.uniqCache.CompareAndDelete(taskRef.uniqKey, taskRef.id)
+	}
+
+	// 如果任务属于一个组，将任务从组中移除。
+	// If the task belongs to a group, remove the task from the group.
+	if taskRef.group != nil {
+		taskRef.group.leave(taskRef.id)
+	}
+
+	// 如果任务占用了容量，释放它。
+	// If the task takes capacity, release it.
+	if taskRef.admitted {
+		s.admission.release(taskRef.name, taskRef.group, taskRef.tenant)
+		taskRef.admitted = false
+	}
+
+	// 如果绑定了调用者上下文，停止监视它。
+	// If a caller context is bound, stop watching it.
+	if taskRef.detached != nil {
+		close(taskRef.detached)
+		taskRef.detached = nil
+	}
+
+	// 减少租户的任务数量。
+	// Decrease the number of tasks of the tenant.
+	if taskRef.tenant != nil {
+		if taskRef.paused {
+			taskRef.tenant.paused.Add(-1)
+		}
+		taskRef.tenant.release()
+	}
+
+	// 清除任务引用中的任务和暂停标记，之后结束的任务和其他操作不会再处理这个任务引用。
+	// Clear the task and the paused mark in the task reference, tasks finishing afterwards and other operations will not handle this task reference again.
+	taskRef.task = nil
+	taskRef.paused = false
+}
+
+// watch 是一个方法，在调用者上下文结束时使用 ErrorTaskContextCanceled 取消任务，任务被移除或者调度器停止时返回。
+// watch is a method that cancels the task with ErrorTaskContextCanceled when the caller context ends, it returns when the task is removed or the scheduler stops.
+func (s *Scheduler) watch(ctx context.Context, detached <-chan struct{}, id string) {
+	select {
+	case <-detached:
+		return
+	case <-s.ctx.Done():
+		return
+	case <-ctx.Done():
+	}
+
+	// 获取并锁定任务引用，任务可能已经被移除。
+	// Get and lock the task reference, the task may have been removed already.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return
+	}
+
+	// 如果任务没有被暂停，停止任务，任务会报告取消的原因并在结束时被移除，已经开始执行的任务不受影响。
+	// If the task is not paused, stop the task, the task reports the reason of the cancellation and is removed when it finishes, a task that has already fired is not affected.
+	if taskRef.task != nil {
+		taskRef.task.stop(ErrorTaskContextCanceled)
+		taskRef.lock.Unlock()
+		return
+	}
+
+	// 被暂停的任务没有正在运行的任务，直接移除它。
+	// A paused task has no running task, remove it directly.
+	name, labels := taskRef.name, taskRef.labels
+	s.detach(taskRef)
+	taskRef.lock.Unlock()
+	s.release(taskRef, id, name, labels)
+}
+
+// release 是一个方法，在任务引用被移除之后重置它，并通知任务已经被删除。
+// release is a method that resets the task reference after it has been removed, and notifies that the task has been deleted.
+func (s *Scheduler) release(taskRef *TaskRef, id, name string, labels Labels) {
+	// 重置任务引用。
+	// Reset the task reference.
+	taskRef.lock.Lock()
+	if taskRef.parentRef.cancel != nil {
+		taskRef.parentRef.cancel()
+	}
+	persisted, business := taskRef.persisted, taskRef.business
+	taskRef.Reset()
+	taskRef.lock.Unlock()
+
+	// 如果任务使用工作时间延迟，停止监视日历。
+	// If the task uses a business delay, stop watching the calendar.
+	if business != nil {
+		business.unwatch()
+	}
+
+	// 如果任务被持久化，从 Store 中删除它。
+	// If the task is persisted, delete it from the Store.
+	if persisted {
+		s.unpersist(id)
+	}
+
+	// 将任务引用放回任务引用池。
+	// Put the task reference back into the task reference pool.
+	taskRefPool.Put(taskRef)
+
+	// 增加移除任务的计数。
+	// Increase the count of removed tasks.
+	s.counters.removed.Add(1)
+
+	// 调用回调函数，通知任务已经被删除。
+	// Call the callback function to notify that the task has been deleted.
+	s.cfg.callback.OnTaskRemoved(id, name)
+	if callback, ok := s.cfg.callback.(LabeledCallback); ok {
+		callback.OnLabeledTaskRemoved(id, name, labels.clone())
+	}
+	s.events.publish(&Event{Type: EventTaskRemoved, ID: id, Name: name, Labels: labels.clone(), Time: time.Now()})
+}
+
+// lookup 是一个方法，获取指定 ID 的任务引用并锁定它，调用者需要在使用完之后解锁。
+// lookup is a method that gets and locks the task reference with the specified ID, the caller must unlock it after use.
+func (s *Scheduler) lookup(id string) (*TaskRef, error) {
+	// 如果调度器没有运行
+	// If the scheduler is not running
+	if !s.running.Load() {
+		// 返回一个表示调度器没有运行的错误
+		// Return an error indicating that the scheduler is not running
+		return nil, ErrorSchedulerNotRunning
+	}
+
+	// 从 taskCache 中获取任务引用。
+	// Get the task reference from taskCache.
+	taskRef, ok := s.taskCache.Get(id)
+	if !ok {
+		return nil, ErrorTaskNotFound
+	}
+
+	// 锁定任务引用。
+	// Lock the task reference.
+	taskRef.lock.Lock()
+
+	// 任务引用可能在获取和锁定之间被删除或者被复用，此时它没有任务也没有被暂停，或者 ID 不再相同。
+	// The task reference may be deleted or reused between getting and locking it, in which case it has neither a task nor the paused mark, or the ID is no longer the same.
+	if taskRef.id != id || (taskRef.task == nil && !taskRef.paused) {
+		taskRef.lock.Unlock()
+		return nil, ErrorTaskNotFound
+	}
+
+	// 返回任务引用。
+	// Return the task reference.
+	return taskRef, nil
 }
 
 // SetAt 是一个方法，用于在指定时间执行任务。
 // SetAt is a method used to execute tasks at a specified time.
 func (s *Scheduler) SetAt(name string, handleFunc TaskHandleFunc, execAt time.Time) (string, error) {
+	// 调用 SetAtWithOptions 方法，使用默认的任务选项。
+	// Call the SetAtWithOptions method with the default task options.
+	return s.SetAtWithOptions(name, handleFunc, execAt, nil)
+}
+
+// SetAtWithOptions 是一个方法，用于在指定时间执行任务，并使用给定的任务选项，例如标签。
+// SetAtWithOptions is a method used to execute tasks at a specified time with the given task options, such as labels.
+func (s *Scheduler) SetAtWithOptions(name string, handleFunc TaskHandleFunc, execAt time.Time, opts *TaskOptions) (string, error) {
 	// 如果调度器没有运行
 	// If the scheduler is not running
 	if !s.running.Load() {
@@ -232,119 +944,648 @@ func (s *Scheduler) SetAt(name string, handleFunc TaskHandleFunc, execAt time.Ti
 		return "", ErrorSchedulerNotRunning
 	}
 
-	// 创建一个新的上下文，该上下文将在指定时间被取消。
-	// Create a new context that will be cancelled at the specified time.
-	ctx, cancel := context.WithDeadline(s.ctx, execAt)
+	// 添加一个新的任务到调度器，并获取任务的 ID。
+	// Add a new task to the scheduler and get the ID of the task.
+	opts = isTaskOptionsValid(opts)
+	taskID, err := s.add(name, "", handleFunc, execAt, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// 调用回调函数，通知任务已被添加。
+	// Call the callback function to notify that the task has been added.
+	s.notifyAdded(taskID, name, opts.labels, execAt)
+
+	// 返回任务的 ID。
+	// Return the ID of the task.
+	return taskID, nil
+}
+
+// SetAtWithContext 是一个方法，用于在指定时间执行任务，任务的生命周期绑定到调用者上下文。
+// 调用者上下文结束时，等待中的任务被取消，回调函数收到的原因是 ErrorTaskContextCanceled；处理函数收到的上下文携带调用者上下文中的值。
+// 如果调用者上下文已经结束，不会添加任务，并返回 ErrorTaskContextCanceled。
+// SetAtWithContext is a method used to execute tasks at a specified time, the lifetime of the task is bound to the caller context.
+// When the caller context ends, the pending task is canceled and the callback functions receive ErrorTaskContextCanceled as the reason; the context passed to the handling function carries the values of the caller context.
+// If the caller context has already ended, no task is added and ErrorTaskContextCanceled is returned.
+func (s *Scheduler) SetAtWithContext(ctx context.Context, name string, handleFunc ContextHandleFunc, execAt time.Time) (string, error) {
+	return s.setAtWithContext(ctx, name, handleFunc, execAt, nil)
+}
+
+// SetWithContext 是一个方法，用于在指定的延迟之后执行任务，任务的生命周期绑定到调用者上下文，参见 SetAtWithContext。
+// SetWithContext is a method used to execute tasks after a specified delay, the lifetime of the task is bound to the caller context, see SetAtWithContext.
+func (s *Scheduler) SetWithContext(ctx context.Context, name string, handleFunc ContextHandleFunc, delay time.Duration) (string, error) {
+	return s.setAtWithContext(ctx, name, handleFunc, time.Now().Add(delay), relativeOptions(nil))
+}
+
+// setAtWithContext 是一个方法，使用给定的任务选项添加一个绑定到调用者上下文的任务。
+// setAtWithContext is a method that adds a task bound to the caller context with the given task options.
+func (s *Scheduler) setAtWithContext(ctx context.Context, name string, handleFunc ContextHandleFunc, execAt time.Time, opts *TaskOptions) (string, error) {
+	// 如果没有传入调用者上下文，使用默认的上下文。
+	// If no caller context is passed, use the default context.
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 如果调用者上下文已经结束，不添加任务。
+	// If the caller context has already ended, do not add the task.
+	if ctx.Err() != nil {
+		return "", ErrorTaskContextCanceled
+	}
+
+	// 绑定调用者上下文，并将处理函数包装为普通的任务处理函数。
+	// Bind the caller context, and wrap the handling function into an ordinary task handling function.
+	opts = isTaskOptionsValid(opts)
+	opts.ctx = ctx
+	return s.SetAtWithOptions(name, s.bindContext(ctx, handleFunc), execAt, opts)
+}
+
+// bindContext 是一个方法，将接收上下文的处理函数包装为普通的任务处理函数，处理函数收到的上下文派生自调用者上下文，并在调度器停止时被取消。
+// bindContext is a method that wraps a handling function receiving a context into an ordinary task handling function, the context passed to it is derived from the caller context and is canceled when the scheduler stops.
+func (s *Scheduler) bindContext(ctx context.Context, handleFunc ContextHandleFunc) TaskHandleFunc {
+	// 如果没有处理函数，使用默认的任务处理函数。
+	// If there is no handling function, use the default task handling function.
+	if handleFunc == nil {
+		return DefaultTaskHandleFunc
+	}
+
+	return func(_ WaitForContextDone) (any, error) {
+		// 创建处理函数的上下文，它携带调用者上下文中的值。
+		// Create the context of the handling function, it carries the values of the caller context.
+		handlerCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// 调度器停止时取消处理函数的上下文。
+		// Cancel the context of the handling function when the scheduler stops.
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-s.ctx.Done():
+				cancel()
+			case <-finished:
+			}
+		}()
+
+		return handleFunc(handlerCtx)
+	}
+}
+
+// SetAtRegistered 是一个方法，用于在指定时间执行注册表中指定名称的处理函数。
+// SetAtRegistered is a method used to execute the handling function with the specified name in the registry at a specified time.
+func (s *Scheduler) SetAtRegistered(name, handler string, execAt time.Time) (string, error) {
+	// 调用 SetAtRegisteredWithOptions 方法，使用默认的任务选项。
+	// Call the SetAtRegisteredWithOptions method with the default task options.
+	return s.SetAtRegisteredWithOptions(name, handler, execAt, nil)
+}
+
+// SetAtRegisteredWithOptions 是一个方法，用于在指定时间执行注册表中指定名称的处理函数，并使用给定的任务选项。
+// SetAtRegisteredWithOptions is a method used to execute the handling function with the specified name in the registry at a specified time with the given task options.
+func (s *Scheduler) SetAtRegisteredWithOptions(name, handler string, execAt time.Time, opts *TaskOptions) (string, error) {
+	// 如果调度器没有运行
+	// If the scheduler is not running
+	if !s.running.Load() {
+		// 返回空字符串和一个表示调度器没有运行的错误
+		// Return an empty string and an error indicating that the scheduler is not running
+		return "", ErrorSchedulerNotRunning
+	}
+
+	// 从注册表中查找处理函数。
+	// Look up the handling function in the registry.
+	handleFunc, ok := s.cfg.registry.Lookup(handler)
+	if !ok {
+		return "", ErrorHandlerNotFound
+	}
 
 	// 添加一个新的任务到调度器，并获取任务的 ID。
 	// Add a new task to the scheduler and get the ID of the task.
-	taskID := s.add(ctx, cancel, name, handleFunc)
+	opts = isTaskOptionsValid(opts)
+	taskID, err := s.add(name, handler, handleFunc, execAt, opts)
+	if err != nil {
+		return "", err
+	}
 
 	// 调用回调函数，通知任务已被添加。
 	// Call the callback function to notify that the task has been added.
-	s.cfg.callback.OnTaskAdded(taskID, name, execAt)
+	s.notifyAdded(taskID, name, opts.labels, execAt)
 
 	// 返回任务的 ID。
 	// Return the ID of the task.
 	return taskID, nil
 }
 
+// SetRegistered 是一个方法，用于在指定的延迟后执行注册表中指定名称的处理函数。
+// SetRegistered is a method used to execute the handling function with the specified name in the registry after a specified delay.
+func (s *Scheduler) SetRegistered(name, handler string, delay time.Duration) (string, error) {
+	// 调用 SetAtRegistered 方法，将当前时间加上指定的延迟作为执行时间。
+	// Call the SetAtRegistered method, adding the specified delay to the current time as the execution time.
+	return s.SetAtRegisteredWithOptions(name, handler, time.Now().Add(delay), relativeOptions(nil))
+}
+
+// notifyAdded 是一个方法，用于通知任务已被添加。
+// notifyAdded is a method used to notify that a task has been added.
+func (s *Scheduler) notifyAdded(id, name string, labels Labels, execAt time.Time) {
+	s.cfg.callback.OnTaskAdded(id, name, execAt)
+	if callback, ok := s.cfg.callback.(LabeledCallback); ok {
+		callback.OnLabeledTaskAdded(id, name, labels.clone(), execAt)
+	}
+	s.events.publish(&Event{Type: EventTaskAdded, ID: id, Name: name, Labels: labels.clone(), Time: time.Now(), ExecAt: execAt})
+}
+
 // Set 是一个方法，用于在指定的延迟后执行任务。
 // Set is a method used to execute tasks after a specified delay.
 func (s *Scheduler) Set(name string, handleFunc TaskHandleFunc, delay time.Duration) (string, error) {
-	// 调用 SetAt 方法，将当前时间加上指定的延迟作为执行时间。
-	// Call the SetAt method, adding the specified delay to the current time as the execution time.
-	return s.SetAt(name, handleFunc, time.Now().Add(delay))
+	// 调用 SetWithOptions 方法，使用默认的任务选项。
+	// Call the SetWithOptions method with the default task options.
+	return s.SetWithOptions(name, handleFunc, delay, nil)
 }
 
-// Get 是一个方法，用于获取指定 ID 的任务。
-// Get is a method used to get the task with the specified ID.
+// SetWithOptions 是一个方法，用于在指定的延迟后执行任务，并使用给定的任务选项，例如标签。
+// SetWithOptions is a method used to execute tasks after a specified delay with the given task options, such as labels.
+func (s *Scheduler) SetWithOptions(name string, handleFunc TaskHandleFunc, delay time.Duration, opts *TaskOptions) (string, error) {
+	// 调用 SetAtWithOptions 方法，将当前时间加上指定的延迟作为执行时间。
+	// Call the SetAtWithOptions method, adding the specified delay to the current time as the execution time.
+	return s.SetAtWithOptions(name, handleFunc, time.Now().Add(delay), relativeOptions(opts))
+}
+
+// Get 是一个方法，用于获取指定 ID 的任务。被暂停的任务没有正在运行的任务，会返回 ErrorTaskNotPending。
+// Get is a method used to get the task with the specified ID. A paused task has no running task, ErrorTaskNotPending is returned for it.
 func (s *Scheduler) Get(id string) (*Task, error) {
-	// 如果调度器没有运行
-	// If the scheduler is not running
-	if !s.running.Load() {
-		// 返回 nil 和一个表示调度器没有运行的错误
-		// Return nil and an error indicating that the scheduler is not running
-		return nil, ErrorSchedulerNotRunning
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return nil, err
 	}
+	defer taskRef.lock.Unlock()
 
-	// 从 taskCache 中获取任务。
-	// Get the data from taskCache.
-	if data, ok := s.taskCache.Get(id); ok {
-		// 如果任务存在，返回任务。
-		// If the task exists, return the task.
-		return data.(*TaskRef).task, nil
+	// 如果任务被暂停，返回错误。
+	// If the task is paused, return an error.
+	if taskRef.task == nil {
+		return nil, ErrorTaskNotPending
 	}
 
-	// 如果任务不存在，返回 nil。
-	// If the task does not exist, return nil.
-	return nil, ErrorTaskNotFound
+	// 如果任务存在，返回任务。
+	// If the task exists, return the task.
+	return taskRef.task, nil
 }
 
 // Delete 是一个方法，用于删除指定 ID 的任务。
 // Delete is a method used to delete the task with the specified ID.
 func (s *Scheduler) Delete(id string) {
-	// 如果调度器没有运行
-	// If the scheduler is not running
-	if !s.running.Load() {
-		// 直接返回，不执行后续的代码
-		// Return directly, do not execute the following code
-		return
+	s.remove(id)
+}
+
+// remove 是一个方法，用于删除指定 ID 的任务，返回任务是否被删除。
+// remove is a method used to delete the task with the specified ID, it returns whether the task was deleted.
+func (s *Scheduler) remove(id string) bool {
+	// 获取并锁定任务引用，如果任务不存在或者调度器没有运行，直接返回。
+	// Get and lock the task reference, return directly if the task does not exist or the scheduler is not running.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return false
 	}
 
-	// 从 taskCache 中获取任务。
-	// Get the task from taskCache.
-	if data, ok := s.taskCache.Get(id); ok {
-		// 如果获取成功，将数据转换为 Task 类型。
-		// If the retrieval is successful, convert the data to the Task type.
-		taskRef := data.(*TaskRef)
+	// 获取任务的名称、标签和任务，并将任务引用从调度器中移除。
+	// Get the name, the labels of the task and the task, and remove the task reference from the scheduler.
+	taskName, labels, task := taskRef.name, taskRef.labels, taskRef.task
+	s.detach(taskRef)
+	taskRef.lock.Unlock()
 
+	// 如果任务没有被暂停
+	// If the task is not paused
+	if task != nil {
 		// 调用任务的 Cancel 方法来取消任务。
 		// Call the Cancel method of the task to cancel the task.
-		taskRef.task.Cancel()
+		task.Cancel()
 
-		// 调用父引用的 cancel 函数来取消上下文。
-		// Call the cancel function of the parent reference to cancel the context.
-		taskRef.parentRef.cancel()
+		// 调用任务的 Wait 方法来等待任务完成。
+		// Call the Wait method of the task to wait for the task to complete.
+		task.Wait()
+	}
 
-		// 获取任务的名称。
-		// Get the name of the task.
-		taskName := taskRef.task.GetMetadata().GetName()
+	// 重置任务引用，并通知任务已经被删除。
+	// Reset the task reference, and notify that the task has been deleted.
+	s.release(taskRef, id, taskName, labels)
 
-		// 从任务缓存中删除这个任务。
-		// Delete this task from the task cache.
-		s.taskCache.Delete(id)
+	return true
+}
 
-		// 调用任务的 Wait 方法来等待任务完成。
-		// Call the Wait method of the task to wait for the task to complete.
-		taskRef.task.Wait()
-
-		// 重置任务引用。
-		// Reset the task reference.
-		taskRef.Reset()
-
-		// 将任务引用放回任务引用池。
-		// Put the task reference back into the task reference pool.
-		taskRefPool.Put(taskRef)
-
-		// 调用回调函数，通知任务已经被删除。
-		// Call the callback function to notify that the task has been deleted.
-		s.cfg.callback.OnTaskRemoved(id, taskName)
-
-		// 如果调度器的配置中 uniqued 为 true
-		// If uniqued in the scheduler's configuration is true
-		if s.cfg.uniqued {
-			// 从 uniqCache 中删除指定名称的任务
-			// Delete the task with the specified name from uniqCache
-			s.uniqCache.Delete(taskName)
+// EarlyReturn 是一个方法，用于让指定 ID 的任务提前执行。
+// EarlyReturn is a method used to make the task with the specified ID execute early.
+func (s *Scheduler) EarlyReturn(id string) error {
+	// 获取任务。
+	// Get the task.
+	task, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	// 让任务提前返回。
+	// Make the task return early.
+	task.EarlyReturn()
+
+	return nil
+}
+
+// Reschedule 是一个方法，用于修改指定 ID 的任务的执行时间，任务的 ID 保持不变。
+// Reschedule is a method used to change the execution time of the task with the specified ID, the ID of the task stays the same.
+func (s *Scheduler) Reschedule(id string, execAt time.Time) error {
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	defer taskRef.lock.Unlock()
+
+	// 显式的执行时间覆盖工作时间的延迟，任务不再跟随日历的修改。
+	// An explicit execution time overrides the business delay, the task no longer follows modifications of the calendar.
+	if taskRef.business != nil {
+		taskRef.business.unwatch()
+		taskRef.business = nil
+	}
+
+	return s.reschedule(taskRef, execAt)
+}
+
+// reschedule 是一个方法，使用新的执行时间重新启动任务，调用者需要持有任务引用的锁。
+// reschedule is a method restarting the task with a new execution time, the caller must hold the lock of the task reference.
+func (s *Scheduler) reschedule(taskRef *TaskRef, execAt time.Time) error {
+	// 如果任务被暂停，只修改执行时间，任务会在恢复时使用新的执行时间。
+	// If the task is paused, only change the execution time, the task uses the new execution time when it is resumed.
+	if taskRef.paused {
+		taskRef.execAt = execAt
+		return nil
+	}
+
+	// 函数返回时，如果任务被持久化，保存新的执行时间。
+	// When the function returns, if the task is persisted, save the new execution time.
+	defer func() {
+		if taskRef.persisted {
+			s.persist(taskRef)
 		}
+	}()
+
+	// 停止当前的任务，如果任务已经开始执行，则不能重新调度。
+	// Stop the current task, the task can not be rescheduled if it has already fired.
+	if !taskRef.task.stop(errTaskReplaced) {
+		return ErrorTaskNotPending
 	}
+
+	// 释放当前任务的父引用的上下文。
+	// Release the context of the parent reference of the current task.
+	taskRef.parentRef.cancel()
+
+	// 使用新的执行时间创建并启动一个新的任务。
+	// Create and start a new task with the new execution time.
+	taskRef.execAt = execAt
+	s.arm(taskRef)
+
+	// 调用回调函数，通知任务已经被添加。
+	// Call the callback function to notify that the task has been added.
+	s.notifyAdded(taskRef.id, taskRef.name, taskRef.labels, execAt)
+
+	return nil
+}
+
+// Pause 是一个方法，用于暂停指定 ID 的任务，被暂停的任务不会执行，直到它被恢复。
+// Pause is a method used to pause the task with the specified ID, a paused task is not executed until it is resumed.
+func (s *Scheduler) Pause(id string) error {
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	defer taskRef.lock.Unlock()
+
+	// 如果任务已经被暂停，直接返回。
+	// If the task is already paused, return directly.
+	if taskRef.paused {
+		return nil
+	}
+
+	// 停止当前的任务，如果任务已经开始执行，则不能暂停。
+	// Stop the current task, the task can not be paused if it has already fired.
+	if !taskRef.task.stop(errTaskPaused) {
+		return ErrorTaskNotPending
+	}
+
+	// 释放当前任务的父引用的上下文，并标记任务被暂停。
+	// Release the context of the parent reference of the current task, and mark the task as paused.
+	taskRef.parentRef.cancel()
+	taskRef.task = nil
+	taskRef.paused = true
+	if taskRef.tenant != nil {
+		taskRef.tenant.paused.Add(1)
+	}
+
+	// 被暂停的任务只存在于这个节点，从 Store 中删除它，其他节点不会接管它。
+	// A paused task only exists on this node, delete it from the Store so other nodes do not take it over.
+	if taskRef.persisted {
+		s.unpersist(id)
+	}
+
+	return nil
+}
+
+// Resume 是一个方法，用于恢复指定 ID 的被暂停的任务，如果执行时间已经过去，任务会立即执行。
+// Resume is a method used to resume the paused task with the specified ID, the task is executed immediately if its execution time has passed.
+func (s *Scheduler) Resume(id string) error {
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return err
+	}
+	defer taskRef.lock.Unlock()
+
+	// 如果任务没有被暂停，返回错误。
+	// If the task is not paused, return an error.
+	if !taskRef.paused {
+		return ErrorTaskNotPaused
+	}
+
+	// 清除暂停标记，并创建和启动一个新的任务。
+	// Clear the paused mark, and create and start a new task.
+	taskRef.paused = false
+	if taskRef.tenant != nil {
+		taskRef.tenant.paused.Add(-1)
+	}
+	s.arm(taskRef)
+
+	// 如果任务被持久化，重新保存它。
+	// If the task is persisted, save it again.
+	if taskRef.persisted {
+		s.persist(taskRef)
+	}
+
+	return nil
+}
+
+// GetInfo 是一个方法，用于获取指定 ID 的任务的快照。
+// GetInfo is a method used to get the snapshot of the task with the specified ID.
+func (s *Scheduler) GetInfo(id string) (*TaskInfo, error) {
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	defer taskRef.lock.Unlock()
+
+	// 返回任务的快照。
+	// Return the snapshot of the task.
+	return newTaskInfo(taskRef), nil
+}
+
+// Upcoming 是一个方法，用于获取指定 ID 的任务接下来最多 n 次的执行时间，第一次是当前的执行时间，之后的执行时间由周期任务的时间表计算。
+// 一次性任务只返回当前的执行时间。
+// Upcoming is a method used to get at most n upcoming execution times of the task with the specified ID, the first one is the current execution time, the following ones are calculated by the timetable of the recurring task.
+// Only the current execution time is returned for a one-off task.
+func (s *Scheduler) Upcoming(id string, n int) ([]time.Time, error) {
+	// 获取并锁定任务引用。
+	// Get and lock the task reference.
+	taskRef, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+	execAt, schedule := taskRef.execAt, taskRef.schedule
+	taskRef.lock.Unlock()
+
+	// 在释放锁之后计算执行时间，时间表的计算可能比较耗时。
+	// Calculate the execution times after releasing the lock, the calculation of the timetable may take a while.
+	upcoming := make([]time.Time, 0)
+	for next := execAt; len(upcoming) < n && !next.IsZero(); {
+		upcoming = append(upcoming, next)
+		if schedule == nil {
+			break
+		}
+		next = schedule.Next(next)
+	}
+	return upcoming, nil
+}
+
+// List 是一个方法，用于获取调度器中所有任务的快照，快照按照执行时间排序。
+// List is a method used to get the snapshots of all tasks in the scheduler, the snapshots are sorted by the execution time.
+func (s *Scheduler) List() []*TaskInfo {
+	// 如果调度器没有运行，返回空列表。
+	// If the scheduler is not running, return an empty list.
+	if !s.running.Load() {
+		return nil
+	}
+
+	// 先获取所有任务 ID 的快照，再逐个锁定任务引用。
+	// Take a snapshot of all task IDs first, then lock the task references one by one.
+	ids := s.taskCache.Keys()
+
+	// 获取每个任务的快照，已经被删除的任务会被跳过。
+	// Get the snapshot of each task, tasks which have been deleted are skipped.
+	infos := make([]*TaskInfo, 0, len(ids))
+	for _, id := range ids {
+		if info, err := s.GetInfo(id); err == nil {
+			infos = append(infos, info)
+		}
+	}
+
+	// 按照执行时间排序。
+	// Sort by the execution time.
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ExecAt.Before(infos[j].ExecAt)
+	})
+
+	return infos
+}
+
+// selectIDs 是一个方法，用于获取标签匹配选择器的所有任务的 ID。
+// selectIDs is a method used to get the IDs of all tasks whose labels match the selector.
+func (s *Scheduler) selectIDs(selector *Selector) []string {
+	// 先获取所有任务 ID 的快照，再逐个锁定任务引用。
+	// Take a snapshot of all task IDs first, then lock the task references one by one.
+	ids := s.taskCache.Keys()
+
+	// 过滤出标签匹配选择器的任务，已经被删除的任务会被跳过。
+	// Filter the tasks whose labels match the selector, tasks which have been deleted are skipped.
+	matched := ids[:0]
+	for _, id := range ids {
+		taskRef, err := s.lookup(id)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(taskRef.labels) {
+			matched = append(matched, id)
+		}
+		taskRef.lock.Unlock()
+	}
+
+	return matched
+}
+
+// CountWhere 是一个方法，用于获取标签匹配选择器的任务数量，nil 选择器匹配所有任务。
+// CountWhere is a method used to get the number of tasks whose labels match the selector, a nil selector matches all tasks.
+func (s *Scheduler) CountWhere(selector *Selector) int {
+	// 如果调度器没有运行，返回 0。
+	// If the scheduler is not running, return 0.
+	if !s.running.Load() {
+		return 0
+	}
+
+	return len(s.selectIDs(selector))
+}
+
+// DeleteWhere 是一个方法，用于删除标签匹配选择器的所有任务，返回被删除的任务数量，nil 选择器匹配所有任务。
+// DeleteWhere is a method used to delete all tasks whose labels match the selector, it returns the number of deleted tasks, a nil selector matches all tasks.
+func (s *Scheduler) DeleteWhere(selector *Selector) int {
+	// 如果调度器没有运行，返回 0。
+	// If the scheduler is not running, return 0.
+	if !s.running.Load() {
+		return 0
+	}
+
+	// 依次删除每个匹配的任务，在此期间结束的任务不会被计数。
+	// Delete each matched task in turn, tasks finishing in the meantime are not counted.
+	deleted := 0
+	for _, id := range s.selectIDs(selector) {
+		if s.remove(id) {
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+// EarlyReturnWhere 是一个方法，用于让标签匹配选择器的所有任务提前执行，返回提前执行的任务数量，被暂停的任务会被跳过，nil 选择器匹配所有任务。
+// EarlyReturnWhere is a method used to make all tasks whose labels match the selector execute early, it returns the number of affected tasks, paused tasks are skipped, a nil selector matches all tasks.
+func (s *Scheduler) EarlyReturnWhere(selector *Selector) int {
+	// 如果调度器没有运行，返回 0。
+	// If the scheduler is not running, return 0.
+	if !s.running.Load() {
+		return 0
+	}
+
+	// 依次让每个匹配的任务提前执行。
+	// Make each matched task execute early in turn.
+	returned := 0
+	for _, id := range s.selectIDs(selector) {
+		if s.EarlyReturn(id) == nil {
+			returned++
+		}
+	}
+
+	return returned
+}
+
+// Stats 是一个方法，用于获取调度器的统计信息。
+// Stats is a method used to get the statistics of the scheduler.
+func (s *Scheduler) Stats() *Stats {
+	// 获取累计计数器的值。
+	// Get the values of the cumulative counters.
+	stats := &Stats{
+		Added:      s.counters.added.Load(),
+		Executed:   s.counters.executed.Load(),
+		Canceled:   s.counters.canceled.Load(),
+		Removed:    s.counters.removed.Load(),
+		Duplicated: s.counters.duplicated.Load(),
+		Skipped:    s.counters.skipped.Load(),
+		Rejected:   s.counters.rejected.Load(),
+
+		Throttled:     s.counters.throttled.Load(),
+		ThrottledTime: time.Duration(s.counters.throttledTime.Load()),
+	}
+
+	// 如果限制了并发数量，获取正在排队的处理函数数量。
+	// If the concurrency is limited, get the number of queued handling functions.
+	if s.dispatcher != nil {
+		stats.Waiting = s.dispatcher.waiting()
+	}
+
+	// 根据任务的状态统计任务数量。
+	// Count the tasks according to their states.
+	for _, info := range s.List() {
+		switch info.State {
+		case TaskStatePending:
+			stats.Pending++
+		case TaskStateRunning:
+			stats.Running++
+		case TaskStatePaused:
+			stats.Paused++
+		}
+	}
+
+	return stats
+}
+
+// Group 是一个方法，返回指定名称的任务组，组不存在时使用默认配置创建它。
+// Group is a method that returns the task group with the specified name, the group is created with the default configuration if it does not exist.
+func (s *Scheduler) Group(name string) *Group {
+	return s.GroupWithConfig(name, nil)
+}
+
+// GroupWithConfig 是一个方法，返回指定名称的任务组，组不存在时使用给定的配置创建它，组已经存在时配置会被忽略。
+// GroupWithConfig is a method that returns the task group with the specified name, the group is created with the given configuration if it does not exist, the configuration is ignored if the group already exists.
+func (s *Scheduler) GroupWithConfig(name string, conf *GroupConfig) *Group {
+	s.groupsLock.Lock()
+	defer s.groupsLock.Unlock()
+
+	// 如果组已经存在，直接返回。
+	// If the group already exists, return it directly.
+	if group, ok := s.groups[name]; ok {
+		return group
+	}
+
+	// 创建一个新的组。
+	// Create a new group.
+	group := newGroup(s, name, isGroupConfigValid(conf))
+	s.groups[name] = group
+	return group
+}
+
+// RemoveGroup 是一个方法，用于删除指定名称的任务组，返回被删除的任务数量，组不存在时返回 0。
+// 组内所有的任务和 CancelAll 一样被取消并删除，组的上下文被取消，组的分派器和限速器被释放。之后在旧的 Group 上添加任务返回 ErrorGroupRemoved，
+// 再次调用 Group 会使用新的配置创建一个新的同名组。
+// RemoveGroup is a method used to delete the task group with the specified name, it returns the number of deleted tasks, 0 is returned if the group does not exist.
+// Like CancelAll, all tasks of the group are canceled and deleted, the context of the group is canceled, and the dispatcher and the rate limiter of the group are released. Adding tasks to the old Group afterwards returns ErrorGroupRemoved,
+// calling Group again creates a new group with the same name and a new configuration.
+func (s *Scheduler) RemoveGroup(name string) int {
+	s.groupsLock.Lock()
+	group, ok := s.groups[name]
+	delete(s.groups, name)
+	s.groupsLock.Unlock()
+
+	if !ok {
+		return 0
+	}
+	return group.remove()
 }
 
 // Count 是一个方法，用于获取调度器中的任务数量。
 // Count is a method used to get the number of tasks in the scheduler.
 func (s *Scheduler) Count() int {
-	// 返回 taskCache 中的元素数量。
-	// Return the number of elements in taskCache.
-	return s.taskCache.Count()
+	// 返回 taskCache 中的元素数量，不需要锁定所有的分片。
+	// Return the number of elements in taskCache, without locking all shards.
+	return s.taskCache.Len()
+}
+
+// History 是一个方法，用于获取调度器的执行历史，如果没有配置历史记录，则返回 nil。
+// History is a method used to get the execution history of the scheduler, it returns nil if the history is not configured.
+func (s *Scheduler) History() *History {
+	// 返回配置中的历史记录。
+	// Return the history in the configuration.
+	return s.cfg.history
+}
+
+// Registry 是一个方法，用于获取调度器的处理函数注册表。
+// Registry is a method used to get the registry of handling functions of the scheduler.
+func (s *Scheduler) Registry() *Registry {
+	// 返回配置中的注册表。
+	// Return the registry in the configuration.
+	return s.cfg.registry
+}
+
+// Subscribe 是一个方法，用于订阅调度器的事件，返回接收事件的通道和取消订阅的函数。
+// 事件不会阻塞调度器，如果通道的缓冲区已满，事件会被丢弃。调度器停止时通道会被关闭。
+// Subscribe is a method used to subscribe to the events of the scheduler, it returns the channel receiving events and the function to unsubscribe.
+// Events never block the scheduler, they are dropped if the buffer of the channel is full. The channel is closed when the scheduler stops.
+func (s *Scheduler) Subscribe(buffer int) (<-chan *Event, func()) {
+	return s.events.subscribe(buffer)
 }
@@ -1,7 +1,10 @@
 package kairos
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -276,3 +279,431 @@ func TestScheduler_TaskWithoutDuplicated(t *testing.T) {
 	// Assert that all tasks have been executed and removed from the scheduler
 	assert.Equal(t, 0, scheduler.Count())
 }
+
+// TestScheduler_Reschedule is a test function for the Reschedule method of the Scheduler
+func TestScheduler_Reschedule(t *testing.T) {
+	scheduler := New(NewConfig().WithCallback(&testSchedCallback{}))
+	defer scheduler.Stop()
+
+	executed := make(chan time.Time, 1)
+	taskID, err := scheduler.Set("test", func(_ WaitForContextDone) (result any, err error) {
+		executed <- time.Now()
+		return nil, nil
+	}, time.Hour)
+	assert.Nil(t, err)
+
+	// Move the task forward, the ID stays the same
+	start := time.Now()
+	assert.Nil(t, scheduler.Reschedule(taskID, start.Add(time.Millisecond*100)))
+
+	info, err := scheduler.GetInfo(taskID)
+	assert.Nil(t, err)
+	assert.Equal(t, taskID, info.ID)
+
+	select {
+	case at := <-executed:
+		assert.True(t, at.Sub(start) >= time.Millisecond*100)
+	case <-time.After(time.Second):
+		t.Fatal("task should be executed")
+	}
+
+	// The task is removed after it is executed
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, 0, scheduler.Count())
+	assert.ErrorIs(t, scheduler.Reschedule(taskID, time.Now()), ErrorTaskNotFound)
+}
+
+// TestScheduler_PauseResume is a test function for the Pause and Resume methods of the Scheduler
+func TestScheduler_PauseResume(t *testing.T) {
+	scheduler := New(NewConfig().WithCallback(&testSchedCallback{}))
+	defer scheduler.Stop()
+
+	executed := make(chan struct{}, 1)
+	taskID, err := scheduler.Set("test", func(_ WaitForContextDone) (result any, err error) {
+		executed <- struct{}{}
+		return nil, nil
+	}, time.Millisecond*100)
+	assert.Nil(t, err)
+
+	// A paused task is not executed
+	assert.Nil(t, scheduler.Pause(taskID))
+	assert.Nil(t, scheduler.Pause(taskID))
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, 0, len(executed))
+
+	info, err := scheduler.GetInfo(taskID)
+	assert.Nil(t, err)
+	assert.Equal(t, TaskStatePaused, info.State)
+	assert.Equal(t, 1, scheduler.Stats().Paused)
+
+	_, err = scheduler.Get(taskID)
+	assert.ErrorIs(t, err, ErrorTaskNotPending)
+
+	// The task is executed immediately after it is resumed because its execution time has passed
+	assert.Nil(t, scheduler.Resume(taskID))
+	assert.ErrorIs(t, scheduler.Resume(taskID), ErrorTaskNotPaused)
+
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("task should be executed")
+	}
+}
+
+// TestScheduler_List is a test function for the List and Stats methods of the Scheduler
+func TestScheduler_List(t *testing.T) {
+	scheduler := New(nil)
+
+	id2, _ := scheduler.Set("second", nil, time.Hour*2)
+	id1, _ := scheduler.Set("first", nil, time.Hour)
+	id3, _ := scheduler.Set("paused", nil, time.Hour*3)
+	assert.Nil(t, scheduler.Pause(id3))
+
+	// The snapshots are sorted by the execution time
+	infos := scheduler.List()
+	assert.Equal(t, 3, len(infos))
+	assert.Equal(t, []string{id1, id2, id3}, []string{infos[0].ID, infos[1].ID, infos[2].ID})
+
+	// Deleting a paused task works
+	scheduler.Delete(id3)
+
+	stats := scheduler.Stats()
+	assert.Equal(t, 2, stats.Pending)
+	assert.Equal(t, 0, stats.Paused)
+	assert.Equal(t, uint64(3), stats.Added)
+	assert.Equal(t, uint64(1), stats.Removed)
+
+	scheduler.Stop()
+	assert.Nil(t, scheduler.List())
+}
+
+// Define a callback recording the labels passed to the labeled callbacks
+type testLabeledCallback struct {
+	EmptyCallback
+	lock    sync.Mutex
+	added   []Labels
+	removed []Labels
+}
+
+func (tc *testLabeledCallback) OnLabeledTaskAdded(id, name string, labels Labels, execAt time.Time) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	tc.added = append(tc.added, labels)
+}
+
+func (tc *testLabeledCallback) OnLabeledTaskExecuted(id, name string, labels Labels, result any, reason, err error) {
+}
+
+func (tc *testLabeledCallback) OnLabeledTaskRemoved(id, name string, labels Labels) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	tc.removed = append(tc.removed, labels)
+}
+
+func TestScheduler_Labels(t *testing.T) {
+	callback := &testLabeledCallback{}
+	s := New(NewConfig().WithCallback(callback))
+	defer s.Stop()
+
+	labels := Labels{"env": "prod"}
+	id, err := s.SetWithOptions("test", nil, time.Hour, NewTaskOptions().WithLabels(labels))
+	assert.Nil(t, err)
+
+	// The labels are copied when the task is added
+	labels["env"] = "dev"
+
+	info, err := s.GetInfo(id)
+	assert.Nil(t, err)
+	assert.Equal(t, Labels{"env": "prod"}, info.Labels)
+
+	task, err := s.Get(id)
+	assert.Nil(t, err)
+	assert.Equal(t, Labels{"env": "prod"}, task.GetMetadata().GetLabels())
+
+	// The labels are passed to the labeled callbacks
+	s.Delete(id)
+	callback.lock.Lock()
+	assert.Equal(t, []Labels{{"env": "prod"}}, callback.added)
+	assert.Equal(t, []Labels{{"env": "prod"}}, callback.removed)
+	callback.lock.Unlock()
+}
+
+func TestScheduler_Where(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	for i := 0; i < 3; i++ {
+		_, _ = s.SetWithOptions("web", nil, time.Hour, NewTaskOptions().WithLabels(Labels{"env": "prod", "tier": "web"}))
+		_, _ = s.SetWithOptions("api", nil, time.Hour, NewTaskOptions().WithLabels(Labels{"env": "staging", "tier": "api"}))
+	}
+	_, _ = s.Set("plain", nil, time.Hour)
+
+	prod, _ := ParseSelector("env=prod")
+	staging, _ := ParseSelector("env in (staging)")
+	unlabeled, _ := ParseSelector("!env")
+
+	assert.Equal(t, 7, s.CountWhere(nil))
+	assert.Equal(t, 3, s.CountWhere(prod))
+	assert.Equal(t, 1, s.CountWhere(unlabeled))
+
+	// Delete the matched tasks
+	assert.Equal(t, 3, s.DeleteWhere(prod))
+	assert.Equal(t, 0, s.CountWhere(prod))
+	assert.Equal(t, 4, s.Count())
+
+	// Make the matched tasks execute early, paused tasks are skipped
+	ids := make([]string, 0)
+	for _, info := range s.List() {
+		if staging.Matches(info.Labels) {
+			ids = append(ids, info.ID)
+		}
+	}
+	assert.Nil(t, s.Pause(ids[0]))
+	assert.Equal(t, 2, s.EarlyReturnWhere(staging))
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 2, s.Count())
+
+	// The bulk operations do nothing after the scheduler stops
+	s.Stop()
+	assert.Equal(t, 0, s.CountWhere(nil))
+	assert.Equal(t, 0, s.DeleteWhere(nil))
+	assert.Equal(t, 0, s.EarlyReturnWhere(nil))
+}
+
+func TestScheduler_UniquedConcurrent(t *testing.T) {
+	s := New(NewConfig().WithUniqued(true))
+	defer s.Stop()
+
+	const names, workers = 8, 64
+
+	// Add tasks with the same names concurrently, only one task per name must be created
+	var wg sync.WaitGroup
+	ids := make([][]string, names)
+	for n := 0; n < names; n++ {
+		ids[n] = make([]string, workers)
+	}
+	start := make(chan struct{})
+	for n := 0; n < names; n++ {
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(n, w int) {
+				defer wg.Done()
+				<-start
+				ids[n][w], _ = s.Set(fmt.Sprintf("task-%d", n), nil, time.Hour)
+			}(n, w)
+		}
+	}
+	close(start)
+	wg.Wait()
+
+	// Every addition with the same name returned the same task ID
+	for n := 0; n < names; n++ {
+		for w := 1; w < workers; w++ {
+			assert.Equal(t, ids[n][0], ids[n][w])
+		}
+	}
+	assert.Equal(t, names, s.Count())
+	assert.Equal(t, uint64(names), s.Stats().Added)
+	assert.Equal(t, uint64(names*(workers-1)), s.Stats().Duplicated)
+}
+
+func TestScheduler_UniquedChurn(t *testing.T) {
+	s := New(NewConfig().WithUniqued(true))
+	defer s.Stop()
+
+	// Add short-lived tasks with the same name while earlier ones are finishing
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				_, _ = s.Set("churn", nil, time.Microsecond*time.Duration(i%5))
+			}
+		}()
+	}
+	wg.Wait()
+	time.Sleep(time.Millisecond * 100)
+
+	// The uniqueness keys are removed with their tasks, so the name can be used again
+	assert.Equal(t, 0, s.Count())
+	assert.Equal(t, 0, s.uniqCache.Count())
+	stats := s.Stats()
+	assert.Equal(t, uint64(16*200), stats.Added+stats.Duplicated)
+	assert.Equal(t, stats.Added, stats.Removed)
+}
+
+type testDispatchCallback struct {
+	EmptyCallback
+	lock       sync.Mutex
+	priorities []int
+}
+
+func (tc *testDispatchCallback) OnTaskDispatched(id, name string, priority int, waited time.Duration) {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	tc.priorities = append(tc.priorities, priority)
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	callback := &testDispatchCallback{}
+	s := New(NewConfig().WithCallback(callback).WithMaxConcurrency(1).WithPriorityAging(0))
+	defer s.Stop()
+
+	// Occupy the only execution slot with a blocking handler
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_, err := s.Set("blocker", func(done WaitForContextDone) (any, error) {
+		close(started)
+		<-block
+		return nil, nil
+	}, 0)
+	assert.Nil(t, err)
+	<-started
+
+	// Several tasks with different priorities expire while the slot is occupied
+	var lock sync.Mutex
+	order := make([]string, 0)
+	record := func(name string) TaskHandleFunc {
+		return func(done WaitForContextDone) (any, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			order = append(order, name)
+			return nil, nil
+		}
+	}
+	for name, priority := range map[string]int{"low": -1, "normal": 0, "high": 10, "medium": 5} {
+		id, err := s.SetWithOptions(name, record(name), 0, NewTaskOptions().WithPriority(priority))
+		assert.Nil(t, err)
+		if info, err := s.GetInfo(id); err == nil {
+			assert.Equal(t, priority, info.Priority)
+		}
+	}
+	assert.Eventually(t, func() bool { return s.Stats().Waiting == 4 }, time.Second, time.Millisecond)
+
+	// Free the slot, the queued handlers start from the highest priority to the lowest
+	close(block)
+	assert.Eventually(t, func() bool { return s.Count() == 0 }, time.Second, time.Millisecond*10)
+	assert.Equal(t, []string{"high", "medium", "normal", "low"}, order)
+
+	callback.lock.Lock()
+	assert.Equal(t, []int{0, 10, 5, 0, -1}, callback.priorities)
+	callback.lock.Unlock()
+	assert.Equal(t, 0, s.Stats().Waiting)
+}
+
+func TestScheduler_PriorityStop(t *testing.T) {
+	s := New(NewConfig().WithMaxConcurrency(1))
+
+	// A handler occupies the only execution slot, another one is queued behind it
+	block := make(chan struct{})
+	started := make(chan struct{})
+	_, _ = s.Set("blocker", func(done WaitForContextDone) (any, error) {
+		close(started)
+		<-block
+		return nil, nil
+	}, 0)
+	<-started
+	executed := make(chan struct{})
+	_, _ = s.Set("queued", func(done WaitForContextDone) (any, error) {
+		close(executed)
+		return nil, nil
+	}, 0)
+	assert.Eventually(t, func() bool { return s.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+
+	// Stopping the scheduler releases the queued handler instead of keeping it behind the blocked one
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-executed:
+	case <-time.After(time.Second):
+		t.Fatal("the queued handler was not released")
+	}
+
+	close(block)
+	<-stopped
+}
+
+type ctxKey struct{}
+
+func TestScheduler_SetAtWithContext(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	reasons := make(chan error, 4)
+	sub, unsubscribe := s.Subscribe(16)
+	defer unsubscribe()
+	go func() {
+		for event := range sub {
+			if event.Type == EventTaskExecuted {
+				if event.Reason == ErrorTaskContextCanceled.Error() {
+					reasons <- ErrorTaskContextCanceled
+				} else {
+					reasons <- nil
+				}
+			}
+		}
+	}()
+
+	// Canceling the caller context cancels the pending task with a distinct reason
+	ctx, cancel := context.WithCancel(context.Background())
+	executed := make(chan struct{}, 1)
+	_, err := s.SetWithContext(ctx, "request", func(ctx context.Context) (any, error) {
+		executed <- struct{}{}
+		return nil, nil
+	}, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.Count())
+	cancel()
+	assert.Eventually(t, func() bool { return s.Count() == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, ErrorTaskContextCanceled, <-reasons)
+	assert.True(t, errors.Is(ErrorTaskContextCanceled, ErrorTaskCanceled))
+	assert.Empty(t, executed)
+	assert.Equal(t, uint64(1), s.Stats().Canceled)
+
+	// A paused task is removed when the caller context ends
+	ctx, cancel = context.WithCancel(context.Background())
+	id, _ := s.SetWithContext(ctx, "paused", nil, time.Hour)
+	assert.Nil(t, s.Pause(id))
+	cancel()
+	assert.Eventually(t, func() bool { return s.Count() == 0 }, time.Second, time.Millisecond)
+
+	// An already ended caller context is rejected
+	_, err = s.SetWithContext(ctx, "late", nil, time.Hour)
+	assert.Equal(t, ErrorTaskContextCanceled, err)
+
+	// The values of the caller context are passed to the handler, and its context is alive while it runs
+	ctx = context.WithValue(context.Background(), ctxKey{}, "trace-1")
+	values := make(chan any, 1)
+	_, err = s.SetWithContext(ctx, "values", func(ctx context.Context) (any, error) {
+		values <- ctx.Value(ctxKey{})
+		return nil, ctx.Err()
+	}, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "trace-1", <-values)
+	assert.Nil(t, <-reasons)
+}
+
+func TestScheduler_SetAtWithContextFinished(t *testing.T) {
+	s := New(nil)
+	defer s.Stop()
+
+	// A task which finishes normally stops watching the caller context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	group := s.Group("requests")
+	for i := 0; i < 10; i++ {
+		_, err := group.SetWithContext(ctx, "done", nil, 0)
+		assert.Nil(t, err)
+	}
+	group.Wait()
+	assert.Equal(t, 0, s.Count())
+
+	// Canceling afterwards affects nothing
+	cancel()
+	time.Sleep(time.Millisecond * 10)
+	assert.Equal(t, uint64(0), s.Stats().Canceled)
+}
@@ -0,0 +1,209 @@
+package kairos
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store 是一个接口，用于持久化使用注册的处理函数添加的任务。配置了 Store 的调度器在添加、重新调度任务时保存任务的定义，
+// 在任务执行、取消或者删除时删除它，调度器停止时保留它，使重新启动的调度器或者其他节点可以接管这些任务
+// Store is an interface used to persist tasks added with registered handling functions. A scheduler configured with a Store saves the definition of a task when it is added or rescheduled,
+// and deletes it when the task is executed, canceled or deleted, it is kept when the scheduler stops, so a restarted scheduler or another node can take over these tasks
+type Store interface {
+	// Save 保存任务的定义，相同 ID 的定义会被覆盖
+	// Save saves the definition of a task, the definition with the same ID is overwritten
+	Save(def *TaskDefinition) error
+
+	// Get 获取指定 ID 的任务的定义，不存在时返回 ErrorTaskNotFound
+	// Get gets the definition of the task with the specified ID, ErrorTaskNotFound is returned if it does not exist
+	Get(id string) (*TaskDefinition, error)
+
+	// Delete 删除指定 ID 的任务的定义，不存在时不做任何操作
+	// Delete deletes the definition of the task with the specified ID, it does nothing if it does not exist
+	Delete(id string) error
+
+	// Load 返回所有保存的任务的定义
+	// Load returns the definitions of all saved tasks
+	Load() ([]*TaskDefinition, error)
+}
+
+// cloneDefinition 函数复制任务的定义，避免调用者修改保存的定义
+// The cloneDefinition function copies the definition of a task, so the caller can not modify the saved definition
+func cloneDefinition(def *TaskDefinition) *TaskDefinition {
+	clone := *def
+	clone.Labels = def.Labels.clone()
+	return &clone
+}
+
+// MemoryStore 结构体是进程内的 Store 实现，多个调度器可以共享同一个实例，适合测试
+// The MemoryStore struct is an in-process Store implementation, several schedulers can share the same instance, it is suitable for tests
+type MemoryStore struct {
+	// lock 用于保护 defs
+	// lock is used to protect defs
+	lock sync.Mutex
+
+	// defs 是保存的任务的定义，键是任务的 ID
+	// defs are the saved definitions of tasks, the key is the ID of the task
+	defs map[string]*TaskDefinition
+}
+
+// NewMemoryStore 函数创建一个新的 MemoryStore 实例
+// The NewMemoryStore function creates a new MemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{defs: make(map[string]*TaskDefinition)}
+}
+
+// Save 方法保存任务的定义
+// The Save method saves the definition of a task
+func (m *MemoryStore) Save(def *TaskDefinition) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.defs[def.ID] = cloneDefinition(def)
+	return nil
+}
+
+// Get 方法获取指定 ID 的任务的定义
+// The Get method gets the definition of the task with the specified ID
+func (m *MemoryStore) Get(id string) (*TaskDefinition, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	def, ok := m.defs[id]
+	if !ok {
+		return nil, ErrorTaskNotFound
+	}
+	return cloneDefinition(def), nil
+}
+
+// Delete 方法删除指定 ID 的任务的定义
+// The Delete method deletes the definition of the task with the specified ID
+func (m *MemoryStore) Delete(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.defs, id)
+	return nil
+}
+
+// Load 方法返回所有保存的任务的定义，按照执行时间排序
+// The Load method returns the definitions of all saved tasks, sorted by the execution time
+func (m *MemoryStore) Load() ([]*TaskDefinition, error) {
+	m.lock.Lock()
+	defs := make([]*TaskDefinition, 0, len(m.defs))
+	for _, def := range m.defs {
+		defs = append(defs, cloneDefinition(def))
+	}
+	m.lock.Unlock()
+
+	sortDefinitions(defs)
+	return defs, nil
+}
+
+// FileStore 结构体是基于文件系统的 Store 实现，每个任务保存为目录中的一个 JSON 文件，目录可以位于多个节点共享的文件系统上
+// The FileStore struct is a Store implementation over the filesystem, every task is saved as a JSON file in the directory, the directory can be on a filesystem shared by several nodes
+type FileStore struct {
+	// dir 是保存任务的目录
+	// dir is the directory where the tasks are saved
+	dir string
+}
+
+// NewFileStore 函数创建一个新的 FileStore 实例，目录不存在时会被创建
+// The NewFileStore function creates a new FileStore instance, the directory is created if it does not exist
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path 方法返回任务定义文件的路径，ID 经过编码，可以包含任意字符
+// The path method returns the path of the definition file of a task, the ID is encoded so it can contain any character
+func (f *FileStore) path(id string) string {
+	return filepath.Join(f.dir, hex.EncodeToString([]byte(id))+".json")
+}
+
+// Save 方法保存任务的定义，先写入临时文件再重命名，其他节点不会读到不完整的内容
+// The Save method saves the definition of a task, it writes a temporary file and renames it, so other nodes never read incomplete content
+func (f *FileStore) Save(def *TaskDefinition) error {
+	data, err := json.Marshal(def)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.path(def.ID), data)
+}
+
+// Get 方法获取指定 ID 的任务的定义
+// The Get method gets the definition of the task with the specified ID
+func (f *FileStore) Get(id string) (*TaskDefinition, error) {
+	data, err := os.ReadFile(f.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrorTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	def := &TaskDefinition{}
+	if err := json.Unmarshal(data, def); err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Delete 方法删除指定 ID 的任务的定义
+// The Delete method deletes the definition of the task with the specified ID
+func (f *FileStore) Delete(id string) error {
+	if err := os.Remove(f.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Load 方法返回所有保存的任务的定义，按照执行时间排序。在读取期间被删除的文件会被跳过
+// The Load method returns the definitions of all saved tasks, sorted by the execution time. Files deleted while reading are skipped
+func (f *FileStore) Load() ([]*TaskDefinition, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]*TaskDefinition, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		id, err := hex.DecodeString(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		def, err := f.Get(string(id))
+		if errors.Is(err, ErrorTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+
+	sortDefinitions(defs)
+	return defs, nil
+}
+
+// sortDefinitions 函数按照执行时间排序任务的定义，执行时间相同时按照 ID 排序
+// The sortDefinitions function sorts the definitions of tasks by the execution time, by the ID when the execution times are equal
+func sortDefinitions(defs []*TaskDefinition) {
+	sort.Slice(defs, func(i, j int) bool {
+		if !defs[i].ExecAt.Equal(defs[j].ExecAt) {
+			return defs[i].ExecAt.Before(defs[j].ExecAt)
+		}
+		return defs[i].ID < defs[j].ID
+	})
+}
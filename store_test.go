@@ -0,0 +1,58 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testStore(t *testing.T, store Store) {
+	now := time.Now().Truncate(time.Millisecond)
+	labels := Labels{"tenant": "a"}
+
+	assert.Nil(t, store.Save(&TaskDefinition{ID: "2", Name: "second", Handler: "h", ExecAt: now.Add(time.Second)}))
+	assert.Nil(t, store.Save(&TaskDefinition{ID: "1/x", Name: "first", Handler: "h", ExecAt: now, Labels: labels, Priority: 3}))
+
+	// Modifying the saved labels does not affect the store
+	labels["tenant"] = "b"
+
+	def, err := store.Get("1/x")
+	assert.Nil(t, err)
+	assert.Equal(t, "first", def.Name)
+	assert.Equal(t, "a", def.Labels["tenant"])
+	assert.Equal(t, 3, def.Priority)
+	assert.True(t, def.ExecAt.Equal(now))
+
+	_, err = store.Get("unknown")
+	assert.ErrorIs(t, err, ErrorTaskNotFound)
+
+	// Definitions are loaded in the order of the execution time
+	defs, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(defs))
+	assert.Equal(t, "1/x", defs[0].ID)
+	assert.Equal(t, "2", defs[1].ID)
+
+	// Saving the same ID overwrites the definition
+	assert.Nil(t, store.Save(&TaskDefinition{ID: "2", Name: "second", Handler: "h", ExecAt: now.Add(-time.Second)}))
+	defs, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "2", defs[0].ID)
+
+	assert.Nil(t, store.Delete("2"))
+	assert.Nil(t, store.Delete("2"))
+	defs, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(defs))
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	testStore(t, store)
+}
@@ -3,8 +3,10 @@ package kairos
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +23,10 @@ var (
 	// ErrorTaskCanceled represents the task is canceled
 	ErrorTaskCanceled = errors.New("task canceled")
 
+	// ErrorTaskContextCanceled 表示任务因为绑定的调用者上下文结束而被取消，errors.Is(err, ErrorTaskCanceled) 同样成立
+	// ErrorTaskContextCanceled represents the task is canceled because the bound caller context ended, errors.Is(err, ErrorTaskCanceled) also holds
+	ErrorTaskContextCanceled = fmt.Errorf("%w: caller context done", ErrorTaskCanceled)
+
 	// ErrorTaskTimeout 表示任务超时
 	// ErrorTaskTimeout represents the task is timeout
 	ErrorTaskTimeout = errors.New("task timeout")
@@ -28,6 +34,42 @@ var (
 	// ErrorTaskEarlyReturn 表示任务提前返回
 	// ErrorTaskEarlyReturn represents the task returns early
 	ErrorTaskEarlyReturn = errors.New("task early return")
+
+	// ErrorTaskSkipped 表示任务到期了，但是处理函数没有被执行，例如这次触发已经被其他节点认领
+	// ErrorTaskSkipped represents the task expired but its handling function was not executed, for example the firing has been claimed by another node
+	ErrorTaskSkipped = errors.New("task skipped")
+
+	// ErrorTaskClaimedElsewhere 表示这次触发由其他节点执行，或者任务已经在其他节点上被修改或删除，errors.Is(err, ErrorTaskSkipped) 同样成立
+	// ErrorTaskClaimedElsewhere represents the firing is executed by another node, or the task has been changed or deleted on another node, errors.Is(err, ErrorTaskSkipped) also holds
+	ErrorTaskClaimedElsewhere = fmt.Errorf("%w: claimed by another node", ErrorTaskSkipped)
+
+	// ErrorTaskLocked 表示任务的锁被其他实例持有，处理函数被跳过，errors.Is(err, ErrorTaskSkipped) 同样成立
+	// ErrorTaskLocked represents the lock of the task is held by another instance so the handling function is skipped, errors.Is(err, ErrorTaskSkipped) also holds
+	ErrorTaskLocked = fmt.Errorf("%w: locked by another instance", ErrorTaskSkipped)
+
+	// ErrorTaskMisfired 表示任务的延迟超过了阈值，根据错过执行时间的处理策略跳过了处理函数，errors.Is(err, ErrorTaskSkipped) 同样成立
+	// ErrorTaskMisfired represents the lag of the task exceeded the threshold and the handling function is skipped by the misfire policy, errors.Is(err, ErrorTaskSkipped) also holds
+	ErrorTaskMisfired = fmt.Errorf("%w: misfired", ErrorTaskSkipped)
+
+	// ErrorTaskNotPending 表示任务已经开始执行或者已经暂停，不能再被修改
+	// ErrorTaskNotPending represents the task has already fired or is paused, so it can not be changed anymore
+	ErrorTaskNotPending = errors.New("task not pending")
+
+	// ErrorTaskNotPaused 表示任务没有被暂停
+	// ErrorTaskNotPaused represents the task is not paused
+	ErrorTaskNotPaused = errors.New("task not paused")
+)
+
+// 定义一些内部使用的任务停止原因，这些原因不会触发 onExecFunc 回调函数
+// Define some internal stop reasons of the task, these reasons do not trigger the onExecFunc callback function
+var (
+	// errTaskPaused 表示任务被暂停
+	// errTaskPaused represents the task is paused
+	errTaskPaused = errors.New("task paused")
+
+	// errTaskReplaced 表示任务被一个新的任务替换，例如重新调度
+	// errTaskReplaced represents the task is replaced by a new task, for example when it is rescheduled
+	errTaskReplaced = errors.New("task replaced")
 )
 
 // onFinishedHandleFunc 是一个函数类型，它接受一个 TaskMetadata 指针
@@ -38,6 +80,14 @@ type onFinishedHandleFunc = func(metadata *TaskMetadata)
 // onExecutedHandleFunc is a function type that accepts task id, name, data, reason and err
 type onExecutedHandleFunc = func(id, name string, result any, reason, err error)
 
+// onDispatchHandleFunc 是一个函数类型，它在处理函数执行之前被调用，阻塞直到处理函数可以执行，返回处理函数结束后需要调用的释放函数
+// onDispatchHandleFunc is a function type called before the handling function runs, it blocks until the handling function may run and returns the release function to call after the handling function finishes
+type onDispatchHandleFunc = func(metadata *TaskMetadata) (release func())
+
+// onFireHandleFunc 是一个函数类型，它在任务到期、处理函数执行之前被调用，返回非 nil 的原因表示跳过处理函数
+// onFireHandleFunc is a function type called when the task expires and before the handling function runs, a non-nil reason returned means the handling function is skipped
+type onFireHandleFunc = func(metadata *TaskMetadata) (skipped error)
+
 // DefaultTaskHandleFunc 是默认的任务处理函数，它返回 nil 数据和 nil 错误
 // DefaultTaskHandleFunc is the default task handling function, it returns nil data and nil error
 var DefaultTaskHandleFunc TaskHandleFunc = func(done WaitForContextDone) (data any, err error) { return nil, nil }
@@ -70,20 +120,108 @@ type ParentRef struct {
 	cancel context.CancelFunc
 }
 
-// TaskRef 结构体包含一个父引用和一个任务。
-// The TaskRef struct contains a parent reference and a task.
+// TaskRef 结构体包含一个父引用、一个任务以及重新创建任务所需的定义。
+// The TaskRef struct contains a parent reference, a task and the definition needed to recreate the task.
 type TaskRef struct {
+	// lock 用于保护任务引用，任务在暂停、恢复和重新调度时会被替换
+	// lock is used to protect the task reference, the task is replaced when it is paused, resumed and rescheduled
+	lock sync.Mutex
+
 	// parentRef 是一个指向 ParentRef 的指针，它表示任务的父引用
 	// parentRef is a pointer to ParentRef, which represents the parent reference of the task
 	parentRef *ParentRef
 
-	// task 是一个指向 Task 的指针，它表示任务本身
-	// task is a pointer to Task, which represents the task itself
+	// task 是一个指向 Task 的指针，它表示任务本身，任务被暂停或者删除时为 nil
+	// task is a pointer to Task, which represents the task itself, it is nil when the task is paused or deleted
 	task *Task
+
+	// id 是任务的唯一标识符，在重新调度时保持不变
+	// id is the unique identifier of the task, it stays the same when the task is rescheduled
+	id string
+
+	// name 是任务的名称
+	// name is the name of the task
+	name string
+
+	// handleFunc 是任务的处理函数
+	// handleFunc is the handling function of the task
+	handleFunc TaskHandleFunc
+
+	// handler 是处理函数在注册表中的名称，直接传入处理函数时为空
+	// handler is the name of the handling function in the registry, empty when the handling function is passed directly
+	handler string
+
+	// execAt 是任务计划执行的时间
+	// execAt is the scheduled execution time of the task
+	execAt time.Time
+
+	// labels 是任务的标签
+	// labels are the labels of the task
+	labels Labels
+
+	// priority 是任务的优先级
+	// priority is the priority of the task
+	priority int
+
+	// group 是任务所属的组，不属于任何组时为 nil
+	// group is the group the task belongs to, nil if it does not belong to any group
+	group *Group
+
+	// detached 在任务引用被移除时关闭，用于停止监视调用者上下文的 goroutine，没有绑定调用者上下文时为 nil
+	// detached is closed when the task reference is removed, to stop the goroutine watching the caller context, nil if no caller context is bound
+	detached chan struct{}
+
+	// uniqKey 是任务在 uniqCache 中的键，任务的名称不需要唯一时为空
+	// uniqKey is the key of the task in uniqCache, empty if the name of the task does not need to be unique
+	uniqKey string
+
+	// persisted 表示任务是否保存在 Store 中，任务被移除时需要从 Store 中删除
+	// persisted indicates whether the task is saved in the Store, it must be deleted from the Store when the task is removed
+	persisted bool
+
+	// schedule 是周期任务的时间表，一次性任务为 nil
+	// schedule is the timetable of a recurring task, nil for a one-off task
+	schedule Schedule
+
+	// misfireThreshold 是任务允许的最大延迟，不大于 0 表示不检查延迟
+	// misfireThreshold is the maximum lag allowed for the task, not greater than 0 means the lag is not checked
+	misfireThreshold time.Duration
+
+	// misfirePolicy 是延迟超过阈值时的处理策略
+	// misfirePolicy is the policy applied when the lag exceeds the threshold
+	misfirePolicy MisfirePolicy
+
+	// realign 表示周期任务的下一次执行从当前时间之后的第一个时间点开始，而不是从这次执行时间之后
+	// realign indicates the next run of a recurring task starts from the first slot after the current time, instead of after this execution time
+	realign bool
+
+	// clockMode 是任务使用的时钟
+	// clockMode is the clock used by the task
+	clockMode ClockMode
+
+	// payload 是任务的数据
+	// payload is the data of the task
+	payload any
+
+	// business 是使用工作时间延迟添加的任务的延迟，日历被修改时用于重新计算执行时间
+	// business is the delay of a task added with a business delay, it is used to recalculate the execution time when the calendar is modified
+	business *business
+
+	// paused 表示任务是否被暂停
+	// paused indicates whether the task is paused
+	paused bool
+
+	// tenant 是任务所属的租户，不属于任何租户时为 nil
+	// tenant is the tenant the task belongs to, nil if it does not belong to any tenant
+	tenant *tenant
+
+	// admitted 表示任务占用了准入控制的容量，移除时需要释放
+	// admitted indicates the task takes capacity of the admission control, it is released when the task is removed
+	admitted bool
 }
 
-// Reset 方法重置任务引用的父引用和任务。
-// The Reset method resets the parent reference and task of the task reference.
+// Reset 方法重置任务引用的父引用、任务和定义。
+// The Reset method resets the parent reference, task and definition of the task reference.
 func (ref *TaskRef) Reset() {
 	// 重置父引用的上下文
 	// Reset the context of the parent reference
@@ -96,6 +234,30 @@ func (ref *TaskRef) Reset() {
 	// 重置任务
 	// Reset the task
 	ref.task = nil
+
+	// 重置任务的定义
+	// Reset the definition of the task
+	ref.id = ""
+	ref.name = ""
+	ref.handleFunc = nil
+	ref.handler = ""
+	ref.execAt = time.Time{}
+	ref.labels = nil
+	ref.priority = 0
+	ref.group = nil
+	ref.uniqKey = ""
+	ref.detached = nil
+	ref.persisted = false
+	ref.schedule = nil
+	ref.payload = nil
+	ref.misfireThreshold = 0
+	ref.misfirePolicy = MisfireFire
+	ref.realign = false
+	ref.clockMode = ClockMonotonic
+	ref.business = nil
+	ref.paused = false
+	ref.tenant = nil
+	ref.admitted = false
 }
 
 // TaskMetadata 结构体包含任务的 id、name 和 handleFunc
@@ -112,6 +274,38 @@ type TaskMetadata struct {
 	// handleFunc 是任务的处理函数，它定义了任务的具体执行逻辑
 	// handleFunc is the handling function of the task, which defines the specific execution logic of the task
 	handleFunc TaskHandleFunc
+
+	// execAt 是任务计划执行的时间，来自父级上下文的截止时间
+	// execAt is the scheduled execution time of the task, taken from the deadline of the parent context
+	execAt time.Time
+
+	// startedAt 是任务处理函数开始执行的时间
+	// startedAt is the time when the handling function of the task started
+	startedAt time.Time
+
+	// finishedAt 是任务处理函数结束执行的时间
+	// finishedAt is the time when the handling function of the task finished
+	finishedAt time.Time
+
+	// labels 是任务的标签，通过 NewTask 创建的任务没有标签
+	// labels are the labels of the task, tasks created by NewTask have no labels
+	labels Labels
+
+	// priority 是任务的优先级，通过 NewTask 创建的任务优先级为 0
+	// priority is the priority of the task, tasks created by NewTask have priority 0
+	priority int
+
+	// lag 是任务到期时距离计划执行时间的延迟，提前执行的任务为 0
+	// lag is the delay from the scheduled execution time when the task expired, 0 for tasks executed early
+	lag time.Duration
+
+	// throttled 是任务到期之后因为速率限制被推迟的时间
+	// throttled is the time the task was delayed by the rate limit after it expired
+	throttled time.Duration
+
+	// tenant 是任务所属的租户，不属于任何租户时为空
+	// tenant is the tenant the task belongs to, empty if it does not belong to any tenant
+	tenant string
 }
 
 // GetID 方法返回任务的 id
@@ -132,6 +326,54 @@ func (stm *TaskMetadata) GetHandleFunc() TaskHandleFunc {
 	return stm.handleFunc
 }
 
+// GetExecAt 方法返回任务计划执行的时间，如果父级上下文没有截止时间，则返回零值
+// The GetExecAt method returns the scheduled execution time of the task, or the zero value if the parent context has no deadline
+func (stm *TaskMetadata) GetExecAt() time.Time {
+	return stm.execAt
+}
+
+// GetStartedAt 方法返回任务处理函数开始执行的时间，如果处理函数没有执行，则返回零值
+// The GetStartedAt method returns the time when the handling function started, or the zero value if it was not executed
+func (stm *TaskMetadata) GetStartedAt() time.Time {
+	return stm.startedAt
+}
+
+// GetFinishedAt 方法返回任务处理函数结束执行的时间，如果处理函数没有执行，则返回零值
+// The GetFinishedAt method returns the time when the handling function finished, or the zero value if it was not executed
+func (stm *TaskMetadata) GetFinishedAt() time.Time {
+	return stm.finishedAt
+}
+
+// GetLabels 方法返回任务标签的副本，如果任务没有标签，则返回 nil
+// The GetLabels method returns a copy of the labels of the task, or nil if the task has no labels
+func (stm *TaskMetadata) GetLabels() Labels {
+	return stm.labels.clone()
+}
+
+// GetPriority 方法返回任务的优先级，数值越大越先执行
+// The GetPriority method returns the priority of the task, a larger value starts first
+func (stm *TaskMetadata) GetPriority() int {
+	return stm.priority
+}
+
+// GetLag 方法返回任务到期时距离计划执行时间的延迟，提前执行的任务为 0
+// The GetLag method returns the delay from the scheduled execution time when the task expired, 0 for tasks executed early
+func (stm *TaskMetadata) GetLag() time.Duration {
+	return stm.lag
+}
+
+// GetThrottled 方法返回任务到期之后因为速率限制被推迟的时间，没有被推迟的任务为 0
+// The GetThrottled method returns the time the task was delayed by the rate limit after it expired, 0 for tasks that were not delayed
+func (stm *TaskMetadata) GetThrottled() time.Duration {
+	return stm.throttled
+}
+
+// GetTenant 方法返回任务所属的租户，不属于任何租户时为空
+// The GetTenant method returns the tenant the task belongs to, empty if it does not belong to any tenant
+func (stm *TaskMetadata) GetTenant() string {
+	return stm.tenant
+}
+
 // Task 结构体定义
 // Definition of Task struct
 type Task struct {
@@ -166,11 +408,39 @@ type Task struct {
 	// onExecFunc 是任务执行时的回调函数
 	// onExecFunc is the callback function when the task is executed
 	onExecFunc onExecutedHandleFunc
+
+	// onDispatchFunc 是处理函数执行之前的回调函数，为 nil 表示处理函数立即执行
+	// onDispatchFunc is the callback function before the handling function runs, nil means the handling function runs immediately
+	onDispatchFunc onDispatchHandleFunc
+
+	// onFireFunc 是任务到期时的回调函数，它可以跳过处理函数，为 nil 表示处理函数总是执行
+	// onFireFunc is the callback function when the task expires, it can skip the handling function, nil means the handling function is always executed
+	onFireFunc onFireHandleFunc
+
+	// running 表示任务的处理函数是否正在执行
+	// running indicates whether the handling function of the task is running
+	running atomic.Bool
 }
 
 // NewTask 函数用于创建一个新的任务
 // The NewTask function is used to create a new task
 func NewTask(parentCtx context.Context, name string, handleFunc TaskHandleFunc) *Task {
+	// 创建一个新的任务，并为任务生成一个新的 id
+	// Create a new task and generate a new id for the task
+	task := newTask(parentCtx, uuid.NewString(), name, handleFunc)
+
+	// 启动任务
+	// Start the task
+	task.start()
+
+	// 返回任务
+	// Return the task
+	return task
+}
+
+// newTask 函数用于创建一个尚未启动的任务，调用者需要在设置好回调函数后调用 start 方法
+// The newTask function is used to create a task that has not been started, the caller needs to call the start method after setting the callback functions
+func newTask(parentCtx context.Context, id, name string, handleFunc TaskHandleFunc) *Task {
 	// 如果 handleFunc 为 nil，则使用默认的任务处理函数
 	// If handleFunc is nil, use the default task handling function
 	if handleFunc == nil {
@@ -187,9 +457,9 @@ func NewTask(parentCtx context.Context, name string, handleFunc TaskHandleFunc)
 	// Get a task from the task pool
 	task := taskPool.Get().(*Task)
 
-	// 为任务生成一个新的 id
-	// Generate a new id for the task
-	task.metadata.id = uuid.NewString()
+	// 设置任务的 id
+	// Set the id of the task
+	task.metadata.id = id
 
 	// 设置任务的名称
 	// Set the name of the task
@@ -199,6 +469,23 @@ func NewTask(parentCtx context.Context, name string, handleFunc TaskHandleFunc)
 	// Set the handling function of the task
 	task.metadata.handleFunc = handleFunc
 
+	// 使用父级上下文的截止时间作为任务计划执行的时间
+	// Use the deadline of the parent context as the scheduled execution time of the task
+	task.metadata.execAt, _ = parentCtx.Deadline()
+
+	// 重置任务处理函数的开始和结束时间
+	// Reset the start and finish time of the handling function
+	task.metadata.startedAt = time.Time{}
+	task.metadata.finishedAt = time.Time{}
+
+	// 重置任务的标签、优先级、延迟和租户
+	// Reset the labels, the priority, the delays and the tenant of the task
+	task.metadata.labels = nil
+	task.metadata.priority = 0
+	task.metadata.lag = 0
+	task.metadata.throttled = 0
+	task.metadata.tenant = ""
+
 	// 设置任务的父级上下文
 	// Set the parent context of the task
 	task.parentCtx = parentCtx
@@ -215,19 +502,34 @@ func NewTask(parentCtx context.Context, name string, handleFunc TaskHandleFunc)
 	// Create a new Once
 	task.once = &sync.Once{}
 
-	// 增加 WaitGroup 的计数
-	// Increase the count of WaitGroup
-	task.wg.Add(1)
+	// 重置任务的执行状态
+	// Reset the running state of the task
+	task.running.Store(false)
 
-	// 在一个新的 goroutine 中执行任务
-	// Execute the task in a new goroutine
-	go task.executor()
+	// 设置默认的回调函数，避免复用的任务保留上一次的回调函数
+	// Set the default callback functions to prevent a reused task from keeping the previous ones
+	task.onFinFunc = defaultFinishedHandleFunc
+	task.onExecFunc = defaultExecutedHandleFunc
+	task.onDispatchFunc = nil
+	task.onFireFunc = nil
 
 	// 返回任务
 	// Return the task
 	return task
 }
 
+// start 方法用于启动任务
+// The start method is used to start the task
+func (t *Task) start() {
+	// 增加 WaitGroup 的计数
+	// Increase the count of WaitGroup
+	t.wg.Add(1)
+
+	// 在一个新的 goroutine 中执行任务
+	// Execute the task in a new goroutine
+	go t.executor()
+}
+
 // executor 方法用于执行任务
 // The executor method is used to execute the task
 func (t *Task) executor() {
@@ -246,13 +548,13 @@ func (t *Task) executor() {
 		// Decrease the count of WaitGroup
 		t.wg.Done()
 
-		// 将任务放回任务池
-		// Put the task back into the task pool
-		taskPool.Put(t)
-
 		// 调用 onFinFunc 回调函数，传入任务的元数据
 		// Call the onFinFunc callback function, passing in the metadata of the task
 		t.onFinFunc(t.metadata)
+
+		// 将任务放回任务池，必须在 onFinFunc 之后，否则元数据可能被其他任务复用
+		// Put the task back into the task pool, this must happen after onFinFunc, otherwise the metadata may be reused by another task
+		taskPool.Put(t)
 	}()
 
 	// 使用 for 循环和 select 语句来监听和处理事件
@@ -276,27 +578,30 @@ func (t *Task) executor() {
 				// Call the onExecFunc callback function, passing in the task id, task name, nil result, task cancellation error, and nil error
 				t.onExecFunc(t.metadata.id, t.metadata.name, nil, ErrorTaskCanceled, nil)
 
+			// 如果任务因为绑定的调用者上下文结束而被取消
+			// If the task is canceled because the bound caller context ended
+			case ErrorTaskContextCanceled:
+				// 调用 onExecFunc 回调函数，传入任务 id、任务名称、nil 结果、调用者上下文取消错误和 nil 错误
+				// Call the onExecFunc callback function, passing in the task id, task name, nil result, caller context cancellation error, and nil error
+				t.onExecFunc(t.metadata.id, t.metadata.name, nil, ErrorTaskContextCanceled, nil)
+
 			// 如果任务超时
 			// If the task is timeout
 			case context.DeadlineExceeded:
-				// 调用任务的处理函数，获取结果和错误
-				// Call the task's handling function to get the result and error
-				result, err := t.metadata.handleFunc(t.ctx.Done())
-
-				// 调用 onExecFunc 回调函数，传入任务 id、任务名称、结果、任务超时错误和错误
-				// Call the onExecFunc callback function, passing in the task id, task name, result, task timeout error, and error
-				t.onExecFunc(t.metadata.id, t.metadata.name, result, ErrorTaskTimeout, err)
+				// 执行任务，并传入任务超时错误作为原因
+				// Fire the task, passing in the task timeout error as the reason
+				t.fire(ErrorTaskTimeout)
 
 			// 如果任务提前返回
 			// If the task returns early
 			case ErrorTaskEarlyReturn:
-				// 调用任务的处理函数，获取结果和错误
-				// Call the task's handling function to get the result and error
-				result, err := t.metadata.handleFunc(t.ctx.Done())
+				// 执行任务，并传入任务提前返回错误作为原因
+				// Fire the task, passing in the task early return error as the reason
+				t.fire(ErrorTaskEarlyReturn)
 
-				// 调用 onExecFunc 回调函数，传入任务 id、任务名称、结果、任务提前返回错误和错误
-				// Call the onExecFunc callback function, passing in the task id, task name, result, task early return error, and error
-				t.onExecFunc(t.metadata.id, t.metadata.name, result, ErrorTaskEarlyReturn, err)
+			// 如果任务被暂停或者被替换，不调用回调函数，调度器会负责后续的处理
+			// If the task is paused or replaced, do not call the callback function, the scheduler takes care of the rest
+			case errTaskPaused, errTaskReplaced:
 			}
 
 			// 取消任务
@@ -317,6 +622,64 @@ func (t *Task) executor() {
 	}
 }
 
+// fire 方法在任务到期时调用处理函数，并使用给定的原因调用 onExecFunc 回调函数。如果 onFireFunc 跳过了处理函数，使用它返回的原因
+// The fire method calls the handling function when the task expires, and calls the onExecFunc callback function with the given reason. If onFireFunc skips the handling function, the reason it returns is used
+func (t *Task) fire(reason error) {
+	// 记录到期时距离计划执行时间的延迟，提前执行的任务没有延迟
+	// Record the delay from the scheduled execution time when the task expired, tasks executed early have no lag
+	if reason == ErrorTaskTimeout && !t.metadata.execAt.IsZero() {
+		if lag := time.Since(t.metadata.execAt); lag > 0 {
+			t.metadata.lag = lag
+		}
+	}
+
+	// 如果设置了触发回调函数，并且它跳过了处理函数，直接报告跳过的原因
+	// If the fire callback function is set and it skips the handling function, report the reason of skipping directly
+	if t.onFireFunc != nil {
+		if skipped := t.onFireFunc(t.metadata); skipped != nil {
+			t.onExecFunc(t.metadata.id, t.metadata.name, nil, skipped, nil)
+			return
+		}
+	}
+
+	// 调用任务的处理函数，获取结果和错误
+	// Call the task's handling function to get the result and error
+	result, err := t.execute()
+
+	// 调用 onExecFunc 回调函数，传入任务 id、任务名称、结果、原因和错误
+	// Call the onExecFunc callback function, passing in the task id, task name, result, reason, and error
+	t.onExecFunc(t.metadata.id, t.metadata.name, result, reason, err)
+}
+
+// execute 方法调用任务的处理函数，并记录处理函数的开始和结束时间
+// The execute method calls the handling function of the task and records its start and finish time
+func (t *Task) execute() (result any, err error) {
+	// 如果设置了分派回调函数，等待处理函数可以执行，并在处理函数结束后释放
+	// If the dispatch callback function is set, wait until the handling function may run, and release after it finishes
+	if t.onDispatchFunc != nil {
+		release := t.onDispatchFunc(t.metadata)
+		defer release()
+	}
+
+	// 记录处理函数开始执行的时间，并标记任务正在执行
+	// Record the time when the handling function starts, and mark the task as running
+	t.metadata.startedAt = time.Now()
+	t.running.Store(true)
+
+	// 调用任务的处理函数
+	// Call the handling function of the task
+	result, err = t.metadata.handleFunc(t.ctx.Done())
+
+	// 记录处理函数结束执行的时间，并清除正在执行的标记
+	// Record the time when the handling function finishes, and clear the running mark
+	t.metadata.finishedAt = time.Now()
+	t.running.Store(false)
+
+	// 返回结果和错误
+	// Return the result and error
+	return result, err
+}
+
 // EarlyReturn 方法用于提前返回任务
 // The EarlyReturn method is used to return the task early
 func (t *Task) EarlyReturn() {
@@ -349,6 +712,26 @@ func (t *Task) Cancel() {
 	}
 }
 
+// stop 方法使用给定的原因停止任务，如果任务在此之前已经因为其他原因结束，则返回 false
+// The stop method stops the task with the given reason, it returns false if the task has already finished for another reason
+func (t *Task) stop(reason error) bool {
+	// 使用 once.Do 方法确保 cancel 方法只被调用一次
+	// Use the once.Do method to ensure that the cancel method is called only once
+	t.once.Do(func() {
+		t.cancel(reason)
+	})
+
+	// 检查任务是否因为给定的原因而结束
+	// Check whether the task finished for the given reason
+	return errors.Is(context.Cause(t.ctx), reason)
+}
+
+// IsRunning 方法用于判断任务的处理函数是否正在执行
+// The IsRunning method is used to check whether the handling function of the task is running
+func (t *Task) IsRunning() bool {
+	return t.running.Load()
+}
+
 // GetMetadata 方法用于获取任务的元数据
 // The GetMetadata method is used to get the metadata of the task
 func (t *Task) GetMetadata() *TaskMetadata {
@@ -0,0 +1,277 @@
+package kairos
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultTenantWeight 是租户默认的权重
+// DefaultTenantWeight is the default weight of a tenant
+const DefaultTenantWeight = 1
+
+// TenantConfig 是一个结构体，包含租户的可选设置。
+// TenantConfig is a struct that contains the optional settings of a tenant.
+type TenantConfig struct {
+	// weight 是租户在加权公平队列中的权重。
+	// weight is the weight of the tenant in the weighted fair queuing.
+	weight float64
+
+	// maxPending 是租户的任务的最大数量，为 0 表示不限制。
+	// maxPending is the maximum number of tasks of the tenant, 0 means unlimited.
+	maxPending int
+
+	// maxConcurrency 是租户同时执行的处理函数的最大数量，为 0 表示不限制。
+	// maxConcurrency is the maximum number of handling functions of the tenant running at the same time, 0 means unlimited.
+	maxConcurrency int
+}
+
+// NewTenantConfig 是一个函数，用于创建一个新的 TenantConfig 实例
+// NewTenantConfig is a function used to create a new instance of TenantConfig
+func NewTenantConfig() *TenantConfig {
+	return &TenantConfig{weight: DefaultTenantWeight}
+}
+
+// WithWeight 是一个方法，用于设置租户的权重，默认为 DefaultTenantWeight，不大于 0 时使用默认值。
+// 调度器的执行槽位不足时，租户按照权重的比例获得槽位，例如权重为 2 的租户获得的槽位是权重为 1 的租户的两倍。
+// WithWeight is a method used to set the weight of the tenant, the default is DefaultTenantWeight, the default is used if it is not greater than 0.
+// When there are not enough execution slots in the scheduler, tenants get slots in proportion to their weights, for example a tenant with weight 2 gets twice as many slots as a tenant with weight 1.
+func (c *TenantConfig) WithWeight(weight float64) *TenantConfig {
+	c.weight = weight
+	return c
+}
+
+// WithMaxPendingTasks 是一个方法，用于设置租户的任务的最大数量，不大于 0 表示不限制。达到限制时添加租户的任务返回 ErrorTenantQuota，它包装了 ErrorSchedulerFull。
+// WithMaxPendingTasks is a method used to set the maximum number of tasks of the tenant, not greater than 0 means unlimited. When the limit is reached, adding a task of the tenant returns ErrorTenantQuota, which wraps ErrorSchedulerFull.
+func (c *TenantConfig) WithMaxPendingTasks(max int) *TenantConfig {
+	c.maxPending = max
+	return c
+}
+
+// WithMaxConcurrency 是一个方法，用于设置租户同时执行的处理函数的最大数量，不大于 0 表示不限制。
+// 租户的限制和组、调度器的 WithMaxConcurrency 同时生效，处理函数需要先获得租户的执行槽位。
+// WithMaxConcurrency is a method used to set the maximum number of handling functions of the tenant running at the same time, not greater than 0 means unlimited.
+// The limit of the tenant applies together with WithMaxConcurrency of the group and of the scheduler, a handling function gets the execution slot of the tenant first.
+func (c *TenantConfig) WithMaxConcurrency(max int) *TenantConfig {
+	c.maxConcurrency = max
+	return c
+}
+
+// isTenantConfigValid 是一个函数，用于检查 TenantConfig 实例是否有效
+// isTenantConfigValid is a function used to check if the instance of TenantConfig is valid
+func isTenantConfigValid(conf *TenantConfig) *TenantConfig {
+	if conf == nil {
+		return NewTenantConfig()
+	}
+	if conf.weight <= 0 {
+		conf.weight = DefaultTenantWeight
+	}
+	if conf.maxPending < 0 {
+		conf.maxPending = 0
+	}
+	if conf.maxConcurrency < 0 {
+		conf.maxConcurrency = 0
+	}
+	return conf
+}
+
+// TenantStats 结构体包含一个租户的统计信息
+// The TenantStats struct contains the statistics of a tenant
+type TenantStats struct {
+	// Tenant 是租户的名称
+	// Tenant is the name of the tenant
+	Tenant string `json:"tenant"`
+
+	// Pending 是租户正在等待执行的任务数量
+	// Pending is the number of tasks of the tenant waiting to be executed
+	Pending int `json:"pending"`
+
+	// Running 是租户处理函数正在执行的任务数量
+	// Running is the number of tasks of the tenant whose handling function is running
+	Running int `json:"running"`
+
+	// Paused 是租户被暂停的任务数量
+	// Paused is the number of paused tasks of the tenant
+	Paused int `json:"paused"`
+
+	// Waiting 是租户已经到期、正在排队等待执行槽位的处理函数数量
+	// Waiting is the number of expired handling functions of the tenant queued for an execution slot
+	Waiting int `json:"waiting"`
+
+	// Added 是租户添加的任务总数
+	// Added is the total number of tasks added by the tenant
+	Added uint64 `json:"added"`
+
+	// Executed 是租户处理函数被执行的任务总数
+	// Executed is the total number of tasks of the tenant whose handling function was executed
+	Executed uint64 `json:"executed"`
+
+	// Canceled 是租户被取消的任务总数
+	// Canceled is the total number of canceled tasks of the tenant
+	Canceled uint64 `json:"canceled"`
+
+	// Skipped 是租户到期了但是处理函数被跳过的任务总数
+	// Skipped is the total number of expired tasks of the tenant whose handling function was skipped
+	Skipped uint64 `json:"skipped"`
+
+	// Rejected 是租户因为容量限制被拒绝的任务总数
+	// Rejected is the total number of tasks of the tenant rejected by a capacity limit
+	Rejected uint64 `json:"rejected"`
+}
+
+// tenant 结构体是调度器中一个租户的状态
+// The tenant struct is the state of a tenant in the scheduler
+type tenant struct {
+	// name 是租户的名称
+	// name is the name of the tenant
+	name string
+
+	// cfg 是租户的配置
+	// cfg is the configuration of the tenant
+	cfg *TenantConfig
+
+	// dispatcher 用于限制租户同时执行的处理函数数量，没有限制时为 nil
+	// dispatcher is used to limit the number of handling functions of the tenant running at the same time, nil if unlimited
+	dispatcher *dispatcher
+
+	// added、executed、canceled、skipped 和 rejected 是租户的累计计数器
+	// added, executed, canceled, skipped and rejected are the cumulative counters of the tenant
+	added    atomic.Uint64
+	executed atomic.Uint64
+	canceled atomic.Uint64
+	skipped  atomic.Uint64
+	rejected atomic.Uint64
+
+	// tasks 是租户在调度器中的任务数量，paused 是其中被暂停的任务数量，running 是其中处理函数正在执行的任务数量
+	// tasks is the number of tasks of the tenant in the scheduler, paused is the number of them which are paused, running is the number of them whose handling function is running
+	tasks   atomic.Int64
+	paused  atomic.Int64
+	running atomic.Int64
+}
+
+// acquireTenant 是一个方法，为一个新的任务获取指定名称的租户并增加租户的任务数量，名称为空时返回 nil。
+// 租户第一次使用时使用它的配置或者默认的租户配置创建它，没有单独设置的租户达到 WithMaxTenants 的限制时返回 ErrorTenantLimit
+// acquireTenant is a method getting the tenant with the specified name for a new task and increasing the number of tasks of the tenant, nil is returned if the name is empty.
+// The tenant is created with its configuration or the default tenant configuration on first use, ErrorTenantLimit is returned when the tenants without their own settings reached the limit of WithMaxTenants
+func (s *Scheduler) acquireTenant(name string) (*tenant, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	s.tenantsLock.Lock()
+	defer s.tenantsLock.Unlock()
+
+	// 任务数量在租户锁内增加，RemoveTenant 不会删除正在添加任务的租户
+	// The number of tasks is increased under the tenants lock, so RemoveTenant does not delete a tenant a task is being added to
+	if t, ok := s.tenants[name]; ok {
+		t.tasks.Add(1)
+		return t, nil
+	}
+
+	conf, ok := s.cfg.tenants[name]
+	if !ok {
+		if s.cfg.maxTenants > 0 && s.defaultTenants >= s.cfg.maxTenants {
+			return nil, ErrorTenantLimit
+		}
+		s.defaultTenants++
+		conf = s.cfg.defaultTenant
+	}
+	t := &tenant{name: name, cfg: conf}
+	if conf.maxConcurrency > 0 {
+		t.dispatcher = newDispatcher(conf.maxConcurrency, s.cfg.priorityAging, nil)
+	}
+	t.tasks.Add(1)
+	s.tenants[name] = t
+	return t, nil
+}
+
+// release 方法减少租户的任务数量，租户为 nil 时什么都不做
+// The release method decreases the number of tasks of the tenant, nothing is done if the tenant is nil
+func (t *tenant) release() {
+	if t != nil {
+		t.tasks.Add(-1)
+	}
+}
+
+// tenantWeight 是一个方法，返回租户在加权公平队列中的权重，不存在的租户和不属于任何租户的任务使用默认的权重
+// tenantWeight is a method returning the weight of the tenant in the weighted fair queuing, missing tenants and tasks not belonging to any tenant use the default weight
+func (s *Scheduler) tenantWeight(name string) float64 {
+	s.tenantsLock.Lock()
+	defer s.tenantsLock.Unlock()
+
+	if t, ok := s.tenants[name]; ok {
+		return t.cfg.weight
+	}
+	return DefaultTenantWeight
+}
+
+// RemoveTenant 是一个方法，删除没有任务的租户以及它的统计信息，返回租户是否被删除，租户不存在或者仍然有任务时返回 false。
+// 之后再次使用这个名称会创建一个新的租户，统计信息从 0 开始
+// RemoveTenant is a method deleting a tenant without tasks together with its statistics, it returns whether the tenant was deleted, false is returned if the tenant does not exist or still has tasks.
+// Using the name again afterwards creates a new tenant, its statistics start from 0
+func (s *Scheduler) RemoveTenant(name string) bool {
+	s.tenantsLock.Lock()
+	defer s.tenantsLock.Unlock()
+
+	t, ok := s.tenants[name]
+	if !ok || t.tasks.Load() > 0 {
+		return false
+	}
+	delete(s.tenants, name)
+	if _, ok := s.cfg.tenants[name]; !ok {
+		s.defaultTenants--
+	}
+	return true
+}
+
+// Tenants 是一个方法，返回调度器中已经使用过的所有租户的名称，按照名称排序
+// Tenants is a method returning the names of all tenants used in the scheduler, sorted by name
+func (s *Scheduler) Tenants() []string {
+	s.tenantsLock.Lock()
+	defer s.tenantsLock.Unlock()
+
+	names := make([]string, 0, len(s.tenants))
+	for name := range s.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TenantStats 是一个方法，用于获取指定租户的统计信息，没有使用过的租户的统计信息都为 0
+// TenantStats is a method used to get the statistics of the specified tenant, the statistics of a tenant that has not been used are all 0
+func (s *Scheduler) TenantStats(name string) *TenantStats {
+	stats := &TenantStats{Tenant: name}
+
+	// 获取租户的累计计数器的值，不创建没有使用过的租户
+	// Get the values of the cumulative counters of the tenant, a tenant that has not been used is not created
+	s.tenantsLock.Lock()
+	t, ok := s.tenants[name]
+	s.tenantsLock.Unlock()
+	if !ok {
+		return stats
+	}
+	stats.Added = t.added.Load()
+	stats.Executed = t.executed.Load()
+	stats.Canceled = t.canceled.Load()
+	stats.Skipped = t.skipped.Load()
+	stats.Rejected = t.rejected.Load()
+
+	// 获取租户和调度器中正在排队的处理函数数量
+	// Get the number of queued handling functions in the tenant and in the scheduler
+	if t.dispatcher != nil {
+		stats.Waiting += t.dispatcher.waiting()
+	}
+	if s.dispatcher != nil {
+		stats.Waiting += s.dispatcher.waitingOf(name)
+	}
+
+	// 根据租户的任务数量计算各个状态的任务数量，计数器分别更新，等待执行的任务数量不小于 0
+	// Calculate the numbers of tasks in each state from the gauges of the tenant, the gauges are updated separately so the number of pending tasks is kept not less than 0
+	stats.Paused = int(t.paused.Load())
+	stats.Running = int(t.running.Load())
+	if stats.Pending = int(t.tasks.Load()) - stats.Paused - stats.Running; stats.Pending < 0 {
+		stats.Pending = 0
+	}
+
+	return stats
+}
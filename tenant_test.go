@@ -0,0 +1,190 @@
+package kairos
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantConfig_Valid(t *testing.T) {
+	conf := isTenantConfigValid(NewTenantConfig().WithWeight(-1).WithMaxPendingTasks(-1).WithMaxConcurrency(-1))
+	assert.Equal(t, float64(DefaultTenantWeight), conf.weight)
+	assert.Equal(t, 0, conf.maxPending)
+	assert.Equal(t, 0, conf.maxConcurrency)
+	assert.NotNil(t, isTenantConfigValid(nil))
+
+	// A nil configuration removes the settings of the tenant
+	c := NewConfig().WithTenant("a", NewTenantConfig()).WithTenant("b", NewTenantConfig())
+	c.WithTenant("a", nil)
+	assert.Equal(t, 1, len(c.tenants))
+}
+
+func TestScheduler_TenantQuota(t *testing.T) {
+	callback := &testRejectCallback{}
+	scheduler := New(NewConfig().
+		WithCallback(callback).
+		WithTenant("a", NewTenantConfig().WithMaxPendingTasks(1)).
+		WithDefaultTenant(NewTenantConfig().WithMaxPendingTasks(2)))
+	defer scheduler.Stop()
+
+	id, err := scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("a"))
+	assert.Nil(t, err)
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("a"))
+	assert.True(t, errors.Is(err, ErrorTenantQuota))
+	assert.True(t, errors.Is(err, ErrorSchedulerFull))
+	assert.Equal(t, []error{ErrorTenantQuota}, callback.get())
+
+	// Other tenants use the default settings, tasks without a tenant are not limited
+	for i := 0; i < 2; i++ {
+		_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("b"))
+		assert.Nil(t, err)
+	}
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("b"))
+	assert.True(t, errors.Is(err, ErrorTenantQuota))
+	for i := 0; i < 3; i++ {
+		_, err = scheduler.Set("task", nil, time.Hour)
+		assert.Nil(t, err)
+	}
+
+	// The tenant is part of the snapshot of the task
+	info, err := scheduler.GetInfo(id)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", info.Tenant)
+
+	// Statistics are kept per tenant
+	assert.Equal(t, []string{"a", "b"}, scheduler.Tenants())
+	assert.Equal(t, &TenantStats{Tenant: "a", Pending: 1, Added: 1, Rejected: 1}, scheduler.TenantStats("a"))
+	assert.Equal(t, &TenantStats{Tenant: "b", Pending: 2, Added: 2, Rejected: 1}, scheduler.TenantStats("b"))
+	assert.Equal(t, &TenantStats{Tenant: "c"}, scheduler.TenantStats("c"))
+	assert.Equal(t, uint64(2), scheduler.Stats().Rejected)
+
+	// Removing a task frees the quota of its tenant
+	scheduler.Delete(id)
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("a"))
+	assert.Nil(t, err)
+}
+
+func TestScheduler_TenantLimit(t *testing.T) {
+	callback := &testRejectCallback{}
+	scheduler := New(NewConfig().WithCallback(callback).WithMaxTenants(2).WithTenant("vip", NewTenantConfig()))
+	defer scheduler.Stop()
+
+	// Tenants without their own settings are limited, configured tenants are not
+	a, err := scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("a"))
+	assert.Nil(t, err)
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("b"))
+	assert.Nil(t, err)
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("c"))
+	assert.True(t, errors.Is(err, ErrorTenantLimit))
+	assert.True(t, errors.Is(err, ErrorSchedulerFull))
+	assert.Equal(t, []error{ErrorTenantLimit}, callback.get())
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("vip"))
+	assert.Nil(t, err)
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "vip"}, scheduler.Tenants())
+
+	// A tenant with tasks is kept, an empty tenant is removed and frees its place
+	assert.False(t, scheduler.RemoveTenant("b"))
+	assert.False(t, scheduler.RemoveTenant("missing"))
+	for _, info := range scheduler.List() {
+		if info.Tenant == "b" {
+			scheduler.Delete(info.ID)
+		}
+	}
+	assert.True(t, scheduler.RemoveTenant("b"))
+	assert.Equal(t, &TenantStats{Tenant: "b"}, scheduler.TenantStats("b"))
+	_, err = scheduler.SetWithOptions("task", nil, time.Hour, NewTaskOptions().WithTenant("c"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "c", "vip"}, scheduler.Tenants())
+
+	// The gauges follow paused and running tasks
+	assert.Nil(t, scheduler.Pause(a))
+	assert.Equal(t, &TenantStats{Tenant: "a", Pending: 1, Paused: 1, Added: 2}, scheduler.TenantStats("a"))
+	assert.Nil(t, scheduler.Resume(a))
+	release := make(chan struct{})
+	_, err = scheduler.SetWithOptions("task", func(WaitForContextDone) (any, error) {
+		<-release
+		return nil, nil
+	}, 0, NewTaskOptions().WithTenant("a"))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return scheduler.TenantStats("a").Running == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 2, scheduler.TenantStats("a").Pending)
+	close(release)
+	assert.Eventually(t, func() bool {
+		stats := scheduler.TenantStats("a")
+		return stats.Running == 0 && stats.Pending == 2 && stats.Executed == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestScheduler_TenantConcurrency(t *testing.T) {
+	scheduler := New(NewConfig().WithTenant("limited", NewTenantConfig().WithMaxConcurrency(1)))
+	defer scheduler.Stop()
+
+	var running, peak atomic.Int32
+	handleFunc := func(done WaitForContextDone) (interface{}, error) {
+		if n := running.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return nil, nil
+	}
+
+	// The handling functions of the limited tenant run one at a time
+	for i := 0; i < 3; i++ {
+		_, err := scheduler.SetWithOptions("task", handleFunc, 10*time.Millisecond, NewTaskOptions().WithTenant("limited"))
+		assert.Nil(t, err)
+	}
+	assert.Eventually(t, func() bool { return scheduler.TenantStats("limited").Waiting == 2 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return scheduler.Count() == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), peak.Load())
+
+	stats := scheduler.TenantStats("limited")
+	assert.Equal(t, uint64(3), stats.Executed)
+	assert.Equal(t, 0, stats.Waiting)
+}
+
+func TestScheduler_TenantFairness(t *testing.T) {
+	scheduler := New(NewConfig().WithMaxConcurrency(1))
+	defer scheduler.Stop()
+
+	var lock sync.Mutex
+	order := make([]string, 0)
+	handleFunc := func(tenant string) TaskHandleFunc {
+		return func(done WaitForContextDone) (interface{}, error) {
+			lock.Lock()
+			order = append(order, tenant)
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			return nil, nil
+		}
+	}
+
+	// A noisy tenant floods the scheduler just before a quiet tenant
+	execAt := time.Now().Add(20 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		_, err := scheduler.SetAtWithOptions("noisy", handleFunc("noisy"), execAt, NewTaskOptions().WithTenant("noisy"))
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := scheduler.SetAtWithOptions("quiet", handleFunc("quiet"), execAt.Add(time.Millisecond), NewTaskOptions().WithTenant("quiet"))
+		assert.Nil(t, err)
+	}
+	assert.Eventually(t, func() bool { return scheduler.Count() == 0 }, 2*time.Second, time.Millisecond)
+
+	// The quiet tenant does not wait behind all tasks of the noisy tenant
+	lock.Lock()
+	defer lock.Unlock()
+	quiet := 0
+	for _, tenant := range order[:6] {
+		if tenant == "quiet" {
+			quiet++
+		}
+	}
+	assert.Equal(t, 2, quiet, order)
+}